@@ -0,0 +1,546 @@
+package storage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// 归档格式
+const (
+	ArchiveZip   = "zip"
+	ArchiveTar   = "tar"
+	ArchiveTarGz = "tar.gz"
+)
+
+// archiveReadAhead 从其他存储读取归档时每次请求的最小字节数
+const archiveReadAhead = 1 << 20
+
+// errArchiveReadOnly 归档存储的写操作返回的错误
+var errArchiveReadOnly = fmt.Errorf("归档存储是只读的: %w", ErrNotSupported)
+
+// ArchiveStorageConfig 归档存储配置，归档来源按 ReaderAt、Source、Path 的顺序选择
+type ArchiveStorageConfig struct {
+	Path       string      `json:"path"`        // 本地归档文件路径
+	Format     string      `json:"format"`      // 归档格式：zip、tar、tar.gz，为空时根据扩展名或文件头识别
+	TempDir    string      `json:"temp_dir"`    // tar.gz 解压使用的临时目录，默认为系统临时目录
+	ReaderAt   io.ReaderAt `json:"-"`           // 归档内容
+	Size       int64       `json:"-"`           // ReaderAt 的大小，ReaderAt 实现 Size() 时可以不设置
+	Source     Storage     `json:"-"`           // 保存归档的存储
+	SourcePath string      `json:"source_path"` // 归档在 Source 中的路径
+}
+
+// ArchiveStorage 只读的归档存储实现，以目录树的形式浏览 zip 和 tar 归档中的文件。
+// 创建时读取一次归档建立索引：tar 文件和未压缩的 zip 文件记录数据所在的偏移量，范围下载直接定位；
+// 压缩的 zip 文件在读取时解压，范围下载需要跳过偏移量之前的内容。
+// tar.gz 不支持随机访问，创建时解压到临时文件，Close 时删除。所有写操作返回 ErrNotSupported。
+type ArchiveStorage struct {
+	config   ArchiveStorageConfig
+	format   string
+	entries  map[string]*archiveEntry // 按路径索引的文件和目录，根目录为空字符串
+	children map[string][]string      // 目录下按名称排序的子项路径
+	closers  []io.Closer
+	tempPath string // tar.gz 解压后的临时文件
+}
+
+// archiveEntry 归档中的文件或目录
+type archiveEntry struct {
+	isDir   bool
+	size    int64
+	modTime time.Time
+	data    *io.SectionReader // 未压缩的数据，可直接定位
+	zipFile *zip.File         // 压缩的 zip 文件
+}
+
+// NewArchiveStorage 打开归档并建立索引，创建新的归档存储实例
+func NewArchiveStorage(config ArchiveStorageConfig) Storage {
+	s := &ArchiveStorage{
+		config:   config,
+		entries:  map[string]*archiveEntry{"": {isDir: true}},
+		children: map[string][]string{},
+	}
+	if err := s.open(context.Background()); err != nil {
+		hlog.Errorf("打开归档失败: %v", err)
+		s.Close()
+		return nil
+	}
+	hlog.Infof("归档索引完成: 格式 %s, 共 %d 个条目", s.format, len(s.entries)-1)
+	return s
+}
+
+// open 根据配置取得归档内容，识别格式并建立索引
+func (s *ArchiveStorage) open(ctx context.Context) error {
+	var r io.ReaderAt
+	var size int64
+	name := s.config.Path
+	switch {
+	case s.config.ReaderAt != nil:
+		r, size = s.config.ReaderAt, s.config.Size
+		if sizer, ok := r.(interface{ Size() int64 }); ok && size == 0 {
+			size = sizer.Size()
+		}
+		name = ""
+	case s.config.Source != nil:
+		metadata, err := s.config.Source.GetMetadata(ctx, s.config.SourcePath)
+		if err != nil {
+			return err
+		}
+		r = &storageReaderAt{ctx: ctx, storage: s.config.Source, path: s.config.SourcePath, size: metadata.Size}
+		size, name = metadata.Size, s.config.SourcePath
+	case s.config.Path != "":
+		file, err := os.Open(s.config.Path)
+		if err != nil {
+			return err
+		}
+		s.closers = append(s.closers, file)
+		info, err := file.Stat()
+		if err != nil {
+			return err
+		}
+		r, size = file, info.Size()
+	default:
+		return errors.New("未指定归档来源")
+	}
+
+	format, err := detectArchiveFormat(s.config.Format, name, r, size)
+	if err != nil {
+		return err
+	}
+	s.format = format
+
+	switch format {
+	case ArchiveZip:
+		return s.indexZip(r, size)
+	case ArchiveTarGz:
+		r, size, err = s.decompress(r, size)
+		if err != nil {
+			return err
+		}
+	}
+	return s.indexTar(r, size)
+}
+
+// detectArchiveFormat 识别归档格式：优先使用配置，其次根据扩展名，最后根据文件头
+func detectArchiveFormat(format, name string, r io.ReaderAt, size int64) (string, error) {
+	switch format {
+	case ArchiveZip, ArchiveTar, ArchiveTarGz:
+		return format, nil
+	case "tgz":
+		return ArchiveTarGz, nil
+	case "":
+	default:
+		return "", fmt.Errorf("不支持的归档格式: %s", format)
+	}
+
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, nil
+	}
+
+	head := make([]byte, min(size, 512))
+	if _, err := r.ReadAt(head, 0); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	switch {
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")), bytes.HasPrefix(head, []byte("PK\x05\x06")):
+		return ArchiveZip, nil
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return ArchiveTarGz, nil
+	case len(head) >= 262 && string(head[257:262]) == "ustar":
+		return ArchiveTar, nil
+	}
+	return "", errors.New("无法识别归档格式")
+}
+
+// decompress 将 tar.gz 解压到临时文件，返回解压后的内容
+func (s *ArchiveStorage) decompress(r io.ReaderAt, size int64) (io.ReaderAt, int64, error) {
+	gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer gz.Close()
+
+	temp, err := os.CreateTemp(s.config.TempDir, "storage-archive-*.tar")
+	if err != nil {
+		return nil, 0, err
+	}
+	s.closers = append(s.closers, temp)
+	s.tempPath = temp.Name()
+
+	n, err := io.Copy(temp, gz)
+	if err != nil {
+		return nil, 0, fmt.Errorf("解压归档失败: %w", err)
+	}
+	return temp, n, nil
+}
+
+// indexZip 读取 zip 的中央目录建立索引
+func (s *ArchiveStorage) indexZip(r io.ReaderAt, size int64) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		key := archiveKey(f.Name)
+		if strings.HasSuffix(f.Name, "/") {
+			s.addDir(key, f.Modified)
+			continue
+		}
+		entry := &archiveEntry{size: int64(f.UncompressedSize64), modTime: f.Modified, zipFile: f}
+		// 未压缩且未加密的文件直接定位数据
+		if f.Method == zip.Store && f.Flags&0x1 == 0 {
+			if offset, err := f.DataOffset(); err == nil {
+				entry.data = io.NewSectionReader(r, offset, entry.size)
+			}
+		}
+		s.addFile(key, entry)
+	}
+	s.sortChildren()
+	return nil
+}
+
+// indexTar 顺序读取 tar 的文件头建立索引，记录每个文件数据的偏移量
+func (s *ArchiveStorage) indexTar(r io.ReaderAt, size int64) error {
+	sr := io.NewSectionReader(r, 0, size)
+	tr := tar.NewReader(sr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		key := archiveKey(hdr.Name)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			s.addDir(key, hdr.ModTime)
+		case tar.TypeReg:
+			if isSparseTar(hdr) {
+				hlog.Warnf("跳过归档中的稀疏文件: %s", hdr.Name)
+				continue
+			}
+			// Next 返回后读取位置正好是文件数据的开始
+			offset, err := sr.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return err
+			}
+			s.addFile(key, &archiveEntry{size: hdr.Size, modTime: hdr.ModTime, data: io.NewSectionReader(r, offset, hdr.Size)})
+		}
+	}
+	s.sortChildren()
+	return nil
+}
+
+// isSparseTar 判断是否为 GNU 稀疏文件（数据在归档中不连续）
+func isSparseTar(hdr *tar.Header) bool {
+	if hdr.Typeflag == tar.TypeGNUSparse {
+		return true
+	}
+	for key := range hdr.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// archiveKey 规范化归档中的路径：统一使用正斜杠并去掉首尾斜杠，不会超出根目录
+func archiveKey(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	return strings.Trim(path.Clean("/"+name), "/")
+}
+
+// addDir 添加目录及其上级目录，与已有文件同名时忽略
+func (s *ArchiveStorage) addDir(key string, modTime time.Time) {
+	for ; key != ""; key = archiveParent(key) {
+		if entry, ok := s.entries[key]; ok {
+			if entry.isDir && entry.modTime.IsZero() {
+				entry.modTime = modTime
+			}
+			return
+		}
+		s.entries[key] = &archiveEntry{isDir: true, modTime: modTime}
+		parent := archiveParent(key)
+		s.children[parent] = append(s.children[parent], key)
+	}
+}
+
+// addFile 添加文件并补全上级目录。同名文件以后出现的为准，与目录同名时忽略
+func (s *ArchiveStorage) addFile(key string, entry *archiveEntry) {
+	if key == "" {
+		return
+	}
+	parent := archiveParent(key)
+	if dir, ok := s.entries[parent]; ok && !dir.isDir {
+		return
+	}
+	s.addDir(parent, time.Time{})
+	if old, ok := s.entries[key]; ok {
+		if !old.isDir {
+			s.entries[key] = entry
+		}
+		return
+	}
+	s.entries[key] = entry
+	s.children[parent] = append(s.children[parent], key)
+}
+
+// sortChildren 按名称排序各目录的子项
+func (s *ArchiveStorage) sortChildren() {
+	for _, keys := range s.children {
+		sort.Strings(keys)
+	}
+}
+
+// archiveParent 返回上级目录的键
+func archiveParent(key string) string {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+// Close 关闭归档文件并删除解压的临时文件
+func (s *ArchiveStorage) Close() error {
+	var errs []error
+	for _, closer := range s.closers {
+		errs = append(errs, closer.Close())
+	}
+	s.closers = nil
+	if s.tempPath != "" {
+		errs = append(errs, os.Remove(s.tempPath))
+		s.tempPath = ""
+	}
+	return errors.Join(errs...)
+}
+
+// lookup 查找文件或目录，不存在时返回 ErrNotExist
+func (s *ArchiveStorage) lookup(filePath string) (*archiveEntry, error) {
+	entry, ok := s.entries[archiveKey(filePath)]
+	if !ok {
+		return nil, &notExistError{err: fmt.Errorf("归档中不存在: %s", filePath)}
+	}
+	return entry, nil
+}
+
+// lookupFile 查找文件，目录视为不存在
+func (s *ArchiveStorage) lookupFile(filePath string) (*archiveEntry, error) {
+	entry, err := s.lookup(filePath)
+	if err == nil && entry.isDir {
+		return nil, &notExistError{err: fmt.Errorf("%s 是目录", filePath)}
+	}
+	return entry, err
+}
+
+// metadata 将条目转换为 FileMetadata
+func (e *archiveEntry) metadata(name string) FileMetadata {
+	metadata := FileMetadata{
+		Name:    name,
+		Size:    e.size,
+		ModTime: e.modTime,
+		IsDir:   e.isDir,
+	}
+	if !e.isDir {
+		metadata.MIMEType = mimeTypeByName(name)
+	}
+	return metadata
+}
+
+// Download 实现从归档读取文件
+func (s *ArchiveStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从归档读取文件: %s", filePath)
+	return s.DownloadRange(ctx, filePath, 0, -1)
+}
+
+// DownloadRange 实现从归档读取文件范围（未压缩的数据直接定位，压缩的 zip 文件跳过偏移量之前的内容）
+func (s *ArchiveStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从归档读取文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	entry, err := s.lookupFile(filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "归档中获取文件失败: %v", err)
+		return nil, err
+	}
+	offset = min(offset, entry.size)
+	if size < 0 || size > entry.size-offset {
+		size = entry.size - offset
+	}
+
+	if entry.data != nil {
+		return io.NewSectionReader(entry.data, offset, size), nil
+	}
+	rc, err := entry.zipFile.Open()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "打开归档中的文件失败: %v", err)
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, rc, offset); err != nil {
+		rc.Close()
+		hlog.CtxErrorf(ctx, "跳过归档文件内容失败: %v", err)
+		return nil, err
+	}
+	return &limitedReadCloser{Reader: io.LimitReader(rc, size), Closer: rc}, nil
+}
+
+// Exists 实现检查归档中的文件或目录是否存在
+func (s *ArchiveStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	_, ok := s.entries[archiveKey(filePath)]
+	return ok, nil
+}
+
+// ListDir 实现列出归档中的目录内容（仅列出当前层级）
+func (s *ArchiveStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出归档目录内容: %s", dirPath)
+
+	dir, err := s.lookup(dirPath)
+	if err == nil && !dir.isDir {
+		err = fmt.Errorf("%s 不是目录", dirPath)
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出归档目录内容失败: %v", err)
+		return nil, err
+	}
+
+	keys := s.children[archiveKey(dirPath)]
+	files := make([]FileMetadata, 0, len(keys))
+	for _, key := range keys {
+		files = append(files, s.entries[key].metadata(path.Base(key)))
+	}
+
+	hlog.CtxInfof(ctx, "成功列出归档目录内容: %s, 共找到 %d 个条目", dirPath, len(files))
+	return files, nil
+}
+
+// GetMetadata 实现获取归档中文件的元数据
+func (s *ArchiveStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	entry, err := s.lookup(filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取归档文件信息失败: %v", err)
+		return nil, err
+	}
+	metadata := entry.metadata(filePath)
+	return &metadata, nil
+}
+
+// Upload 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	return errArchiveReadOnly
+}
+
+// Delete 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) Delete(ctx context.Context, filePath string) error {
+	return errArchiveReadOnly
+}
+
+// Rename 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	return errArchiveReadOnly
+}
+
+// Move 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return errArchiveReadOnly
+}
+
+// Copy 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	return errArchiveReadOnly
+}
+
+// CreateDir 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) CreateDir(ctx context.Context, dirPath string) error {
+	return errArchiveReadOnly
+}
+
+// DeleteDir 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	return errArchiveReadOnly
+}
+
+// UpdateMetadata 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	return errArchiveReadOnly
+}
+
+// BatchUpload 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	return errArchiveReadOnly
+}
+
+// BatchDownload 实现从归档批量读取文件
+func (s *ArchiveStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从归档批量读取 %d 个文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 归档存储是只读的，返回 ErrNotSupported
+func (s *ArchiveStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	return errArchiveReadOnly
+}
+
+// storageReaderAt 通过范围下载以 io.ReaderAt 的方式读取其他存储中的文件，
+// 每次至少读取 archiveReadAhead 字节并缓存，避免解析归档时的小块读取各自发出请求
+type storageReaderAt struct {
+	ctx     context.Context
+	storage Storage
+	path    string
+	size    int64
+
+	mu     sync.Mutex
+	buf    []byte
+	bufOff int64
+}
+
+func (r *storageReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	end := min(off+int64(len(p)), r.size)
+	if off < r.bufOff || end > r.bufOff+int64(len(r.buf)) {
+		n := min(max(end-off, archiveReadAhead), r.size-off)
+		reader, err := r.storage.DownloadRange(r.ctx, r.path, off, n)
+		if err != nil {
+			return 0, err
+		}
+		data, err := io.ReadAll(reader)
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.buf, r.bufOff = data, off
+	}
+
+	n := 0
+	if start := off - r.bufOff; start < int64(len(r.buf)) {
+		n = copy(p, r.buf[start:])
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
@@ -0,0 +1,165 @@
+package storage_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+)
+
+// archiveTestFiles 测试归档中的文件，data/ 下的目录没有单独的目录条目
+var archiveTestFiles = []struct {
+	name    string
+	content string
+}{
+	{"readme.txt", "archive readme"},
+	{"data/2024/values.csv", strings.Repeat("1,2,3\n", 100)},
+	{"data/2024/empty.bin", ""},
+	{"./data/notes.md", "# notes"},
+}
+
+// newTestZip 生成 zip 归档，stored 为 true 的文件不压缩
+func newTestZip(t *testing.T, stored bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	method := zip.Deflate
+	if stored {
+		method = zip.Store
+	}
+	for _, file := range archiveTestFiles {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: strings.TrimPrefix(file.name, "./"), Method: method, Modified: time.Unix(1700000000, 0)})
+		if err != nil {
+			t.Fatalf("Failed to create zip entry: %v", err)
+		}
+		w.Write([]byte(file.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to close zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newTestTar 生成 tar 归档，gz 为 true 时用 gzip 压缩
+func newTestTar(t *testing.T, gz bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var zw *gzip.Writer
+	if gz {
+		zw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(zw)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+	tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: "data/", Mode: 0o755, ModTime: time.Unix(1700000000, 0)})
+	for _, file := range archiveTestFiles {
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: file.name, Mode: 0o644, Size: int64(len(file.content)), ModTime: time.Unix(1700000000, 0)}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		tw.Write([]byte(file.content))
+	}
+	tw.WriteHeader(&tar.Header{Typeflag: tar.TypeSymlink, Name: "link", Linkname: "readme.txt"})
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if zw != nil {
+		zw.Close()
+	}
+	return buf.Bytes()
+}
+
+func TestArchiveStorage(t *testing.T) {
+	ctx := context.Background()
+	zipData, storedZip, tarData, tgzData := newTestZip(t, false), newTestZip(t, true), newTestTar(t, false), newTestTar(t, true)
+
+	// 归档来自另一个存储时文件名决定格式
+	source := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	if err := source.Upload(ctx, "bundles/dataset.tgz", bytes.NewReader(tgzData)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	localZip := filepath.Join(t.TempDir(), "dataset.zip")
+	if err := os.WriteFile(localZip, zipData, 0o644); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	configs := map[string]storage.ArchiveStorageConfig{
+		"zip":        {Path: localZip},
+		"stored-zip": {ReaderAt: bytes.NewReader(storedZip)},
+		"tar":        {ReaderAt: bytes.NewReader(tarData), Size: int64(len(tarData))},
+		"tar.gz":     {Source: source, SourcePath: "bundles/dataset.tgz", TempDir: t.TempDir()},
+	}
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			s := storage.NewArchiveStorage(config)
+			if s == nil {
+				t.Fatal("NewArchiveStorage returned nil")
+			}
+			defer s.(*storage.ArchiveStorage).Close()
+
+			for _, file := range archiveTestFiles {
+				if got := readString(t)(s.Download(ctx, file.name)); got != file.content {
+					t.Fatalf("Unexpected content of %s: %q", file.name, got)
+				}
+			}
+			if got := readString(t)(s.DownloadRange(ctx, "data/2024/values.csv", 2, 7)); got != "2,3\n1,2" {
+				t.Fatalf("Unexpected range content: %q", got)
+			}
+			if got := readString(t)(s.DownloadRange(ctx, "readme.txt", 8, -1)); got != "readme" {
+				t.Fatalf("Unexpected range content: %q", got)
+			}
+
+			// 缺少目录条目的上级目录同样可以列出
+			files, err := s.ListDir(ctx, "data")
+			if err != nil {
+				t.Fatalf("ListDir failed: %v", err)
+			}
+			if len(files) != 2 || files[0].Name != "2024" || !files[0].IsDir || files[1].Name != "notes.md" || files[1].Size != 7 {
+				t.Fatalf("Unexpected entries: %+v", files)
+			}
+			files, err = s.ListDir(ctx, "/")
+			if err != nil || len(files) != 2 {
+				t.Fatalf("Unexpected root entries: %+v, %v", files, err)
+			}
+			if _, err := s.ListDir(ctx, "missing"); !errors.Is(err, storage.ErrNotExist) {
+				t.Fatalf("Expected ErrNotExist, got %v", err)
+			}
+
+			metadata, err := s.GetMetadata(ctx, "data/2024/values.csv")
+			if err != nil {
+				t.Fatalf("GetMetadata failed: %v", err)
+			}
+			if metadata.Size != 600 || metadata.IsDir || metadata.MIMEType != storage.MIMETypeByExtension("values.csv") || !metadata.ModTime.Equal(time.Unix(1700000000, 0)) {
+				t.Fatalf("Unexpected metadata: %+v", metadata)
+			}
+			if ok, _ := s.Exists(ctx, "data/2024"); !ok {
+				t.Fatal("Expected directory to exist")
+			}
+			if _, err := s.Download(ctx, "link"); !errors.Is(err, storage.ErrNotExist) {
+				t.Fatalf("Expected ErrNotExist for unsupported entry, got %v", err)
+			}
+
+			// 写操作不被支持
+			if err := s.Upload(ctx, "new.txt", strings.NewReader("x")); !errors.Is(err, storage.ErrNotSupported) {
+				t.Fatalf("Expected ErrNotSupported, got %v", err)
+			}
+			if err := s.Delete(ctx, "readme.txt"); !errors.Is(err, storage.ErrNotSupported) {
+				t.Fatalf("Expected ErrNotSupported, got %v", err)
+			}
+		})
+	}
+
+	if s := storage.NewArchiveStorage(storage.ArchiveStorageConfig{ReaderAt: strings.NewReader("not an archive")}); s != nil {
+		t.Fatal("Expected NewArchiveStorage to reject unknown format")
+	}
+}
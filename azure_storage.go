@@ -0,0 +1,543 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	// azureDefaultBlockSize 默认的分块上传块大小
+	azureDefaultBlockSize = 4 << 20
+	// azureDefaultConcurrency 默认的分块并发上传数，每个并发占用一个块大小的内存
+	azureDefaultConcurrency = 4
+	// azureCopyPollInterval 轮询异步复制状态的间隔
+	azureCopyPollInterval = 200 * time.Millisecond
+	// azureExpiresAtMetaKey 记录删除截止时间的元数据键。
+	// Azure 元数据键必须是合法的 C# 标识符，不能使用 ExpiresAtMetaKey 中的连字符。
+	azureExpiresAtMetaKey = "expires_at"
+)
+
+// AzureBlobStorageConfig Azure Blob 存储配置。
+// 使用 ConnectionString，或同时提供 AccountName 和 AccountKey。
+type AzureBlobStorageConfig struct {
+	AccountName      string `json:"account_name"`      // 存储账户名称
+	AccountKey       string `json:"account_key"`       // 存储账户密钥
+	ConnectionString string `json:"connection_string"` // 连接字符串，设置后忽略 AccountName、AccountKey 和 Endpoint
+	Endpoint         string `json:"endpoint"`          // Blob 服务地址，为空时使用 https://<account>.blob.core.windows.net/
+	Container        string `json:"container"`         // 容器名称
+	BaseDir          string `json:"base_dir"`          // 存储基础目录
+	BlockSize        int64  `json:"block_size"`        // 分块上传的块大小（字节），默认 4MiB
+	Concurrency      int    `json:"concurrency"`       // 分块并发上传数，默认 4
+}
+
+// AzureBlobStorage Azure Blob 存储实现，文件以块 Blob 存储
+type AzureBlobStorage struct {
+	config    AzureBlobStorageConfig
+	container *container.Client
+}
+
+// NewAzureBlobStorage 创建新的Azure Blob存储实例
+func NewAzureBlobStorage(config AzureBlobStorageConfig) Storage {
+	client, err := newAzureClient(config)
+	if err != nil {
+		hlog.Errorf("创建Azure Blob客户端失败: %v", err)
+		return nil
+	}
+
+	// 创建容器，已存在时忽略
+	containerClient := client.ServiceClient().NewContainerClient(config.Container)
+	if _, err := containerClient.Create(context.Background(), nil); err != nil {
+		if !bloberror.HasCode(err, bloberror.ContainerAlreadyExists) {
+			hlog.Errorf("创建Azure容器失败: %v", err)
+			return nil
+		}
+	} else {
+		hlog.Infof("成功创建新Azure容器: %s", config.Container)
+	}
+
+	if config.BlockSize <= 0 {
+		config.BlockSize = azureDefaultBlockSize
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = azureDefaultConcurrency
+	}
+
+	return &AzureBlobStorage{
+		config:    config,
+		container: containerClient,
+	}
+}
+
+// newAzureClient 根据连接字符串或账户密钥创建客户端
+func newAzureClient(config AzureBlobStorageConfig) (*azblob.Client, error) {
+	if config.ConnectionString != "" {
+		return azblob.NewClientFromConnectionString(config.ConnectionString, nil)
+	}
+
+	cred, err := azblob.NewSharedKeyCredential(config.AccountName, config.AccountKey)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.blob.core.windows.net/", config.AccountName)
+	}
+	return azblob.NewClientWithSharedKeyCredential(endpoint, cred, nil)
+}
+
+// Upload 实现Azure Blob文件上传，支持设置有效期
+func (s *AzureBlobStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	return s.upload(ctx, filePath, reader, nil, opts...)
+}
+
+// UploadIf 实现条件写入，前置条件不满足时返回 ErrPreconditionFailed
+func (s *AzureBlobStorage) UploadIf(ctx context.Context, filePath string, reader io.Reader, cond UploadCondition, opts ...UploadOption) error {
+	conditions := &blob.ModifiedAccessConditions{}
+	if cond.IfNoneMatch {
+		conditions.IfNoneMatch = to.Ptr(azcore.ETagAny)
+	}
+	if cond.IfMatch != "" {
+		// Azure 的 ETag 带引号，FileMetadata.ETag 中的值已去掉引号
+		conditions.IfMatch = to.Ptr(azcore.ETag(`"` + strings.Trim(cond.IfMatch, `"`) + `"`))
+	}
+
+	err := s.upload(ctx, filePath, reader, &blob.AccessConditions{ModifiedAccessConditions: conditions}, opts...)
+	if bloberror.HasCode(err, bloberror.ConditionNotMet, bloberror.BlobAlreadyExists) {
+		return fmt.Errorf("%s: %w", filePath, ErrPreconditionFailed)
+	}
+	return err
+}
+
+// upload 以块 Blob 流式上传文件：内容按块暂存（StageBlock）后提交块列表，
+// 不超过一个块的小文件直接上传
+func (s *AzureBlobStorage) upload(ctx context.Context, filePath string, reader io.Reader, conditions *blob.AccessConditions, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到Azure Blob: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	uploadOpts := &blockblob.UploadStreamOptions{
+		BlockSize:        s.config.BlockSize,
+		Concurrency:      s.config.Concurrency,
+		HTTPHeaders:      &blob.HTTPHeaders{BlobContentType: to.Ptr(contentType)},
+		AccessConditions: conditions,
+	}
+
+	// 如果设置了有效期，记录删除截止时间并打上索引标签（供生命周期管理策略匹配）
+	if options.Expiration > 0 {
+		expiration := expirationDeadline(options.Expiration)
+		uploadOpts.Metadata = map[string]*string{azureExpiresAtMetaKey: to.Ptr(formatExpiresAt(expiration))}
+		uploadOpts.Tags = map[string]string{ExpireDaysTagKey: expireDaysTagValue(options.Expiration)}
+		hlog.CtxDebugf(ctx, "设置Azure Blob文件过期时间: %v", expiration)
+	}
+
+	_, err = s.container.NewBlockBlobClient(fullKey).UploadStream(ctx, reader, uploadOpts)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "Azure Blob文件上传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从Azure Blob下载文件（流式下载）
+func (s *AzureBlobStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从Azure Blob下载文件: %s", filePath)
+
+	body, err := s.download(ctx, filePath, blob.HTTPRange{})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "Azure Blob文件下载已启动: %s", filePath)
+	return body, nil // 返回原始的Reader，由调用方负责关闭
+}
+
+// DownloadRange 实现从Azure Blob下载文件（支持断点续传）
+func (s *AzureBlobStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从Azure Blob下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	body, err := s.download(ctx, filePath, blob.HTTPRange{Offset: offset, Count: size})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "Azure Blob文件断点续传下载已启动: %s", filePath)
+	return body, nil
+}
+
+// download 下载指定范围的内容，已过期的文件返回 ErrNotExist
+func (s *AzureBlobStorage) download(ctx context.Context, filePath string, httpRange blob.HTTPRange) (io.ReadCloser, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	resp, err := s.container.NewBlobClient(fullKey).DownloadStream(ctx, &blob.DownloadStreamOptions{Range: httpRange})
+	if err != nil {
+		return nil, azureError(err)
+	}
+	if isExpired(azureExpiresAt(resp.Metadata)) {
+		resp.Body.Close()
+		return nil, errExpired(filePath)
+	}
+	return resp.Body, nil
+}
+
+// Delete 实现Azure Blob文件删除（删除不存在的文件不报错）
+func (s *AzureBlobStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从Azure Blob删除文件: %s", filePath)
+
+	if err := s.deleteBlob(ctx, filepath.Join(s.config.BaseDir, filePath)); err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "Azure Blob文件删除成功: %s", filePath)
+	return nil
+}
+
+// deleteBlob 删除完整路径的 Blob 及其快照
+func (s *AzureBlobStorage) deleteBlob(ctx context.Context, fullKey string) error {
+	_, err := s.container.NewBlobClient(fullKey).Delete(ctx, &blob.DeleteOptions{
+		DeleteSnapshots: to.Ptr(blob.DeleteSnapshotsOptionTypeInclude),
+	})
+	if err != nil && !bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Rename 实现Azure Blob文件重命名（复制+删除）
+func (s *AzureBlobStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在Azure Blob中重命名文件: %s -> %s", oldPath, newPath)
+
+	if err := s.copyBlob(ctx, oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob复制文件失败: %v", err)
+		return err
+	}
+
+	// 删除旧文件
+	if err := s.Delete(ctx, oldPath); err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob删除旧文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "Azure Blob文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现Azure Blob文件移动（与重命名相同的操作）
+func (s *AzureBlobStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现Azure Blob文件复制（服务端复制）
+func (s *AzureBlobStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在Azure Blob中复制文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.copyBlob(ctx, srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "Azure Blob复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "Azure Blob文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// copyBlob 使用服务端复制，复制是异步的，等待复制完成后返回
+func (s *AzureBlobStorage) copyBlob(ctx context.Context, srcPath, dstPath string) error {
+	src := s.container.NewBlobClient(filepath.Join(s.config.BaseDir, srcPath))
+	dst := s.container.NewBlobClient(filepath.Join(s.config.BaseDir, dstPath))
+
+	resp, err := dst.StartCopyFromURL(ctx, src.URL(), nil)
+	if err != nil {
+		return azureError(err)
+	}
+
+	status := resp.CopyStatus
+	for status != nil && *status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(azureCopyPollInterval):
+		}
+		props, err := dst.GetProperties(ctx, nil)
+		if err != nil {
+			return azureError(err)
+		}
+		status = props.CopyStatus
+	}
+	if status != nil && *status != blob.CopyStatusTypeSuccess {
+		return fmt.Errorf("Azure Blob复制 %s 失败: %s", srcPath, *status)
+	}
+	return nil
+}
+
+// Exists 实现检查Azure Blob文件是否存在（已过期的文件视为不存在）
+func (s *AzureBlobStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+	props, err := s.container.NewBlobClient(fullKey).GetProperties(ctx, nil)
+	if err != nil {
+		if errors.Is(azureError(err), ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !isExpired(azureExpiresAt(props.Metadata)), nil
+}
+
+// CreateDir 实现Azure Blob目录创建。
+// Blob 存储中目录是隐式的，无需显式创建占位对象。
+func (s *AzureBlobStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxDebugf(ctx, "Azure Blob 目录无需显式创建: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现Azure Blob目录删除（递归删除目录下所有Blob）
+func (s *AzureBlobStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始从Azure Blob中删除目录及其所有内容: %s", dirPath)
+
+	dirPath = ensureOSSDirPath(dirPath)
+	fullKey := joinStorageKey(s.config.BaseDir, dirPath)
+
+	pager := s.container.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: to.Ptr(fullKey)})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "列出Azure Blob目录内容失败: %v", err)
+			return err
+		}
+		for _, item := range page.Segment.BlobItems {
+			// item.Name 已经是完整路径
+			if err := s.deleteBlob(ctx, *item.Name); err != nil {
+				hlog.CtxErrorf(ctx, "删除Azure Blob对象失败: %v", err)
+				return err
+			}
+		}
+	}
+
+	hlog.CtxInfof(ctx, "成功从Azure Blob中删除目录及其所有内容: %s", fullKey)
+	return nil
+}
+
+// ListDir 实现Azure Blob目录列表（按 / 分层，仅当前层级，子目录名以 / 结尾）
+func (s *AzureBlobStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出Azure Blob目录内容: %s", dirPath)
+
+	// 必须保证 prefix 以 / 结尾，否则分层列举会把当前目录自身也作为 BlobPrefix 返回。
+	dirPath = ensureOSSDirPath(dirPath)
+	fullKey := joinStorageKey(s.config.BaseDir, dirPath)
+
+	var fileMetas []FileMetadata
+	pager := s.container.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{Prefix: to.Ptr(fullKey)})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			hlog.CtxErrorf(ctx, "获取Azure Blob目录内容失败: %v", err)
+			return nil, err
+		}
+
+		// 处理普通Blob
+		for _, item := range page.Segment.BlobItems {
+			name := strings.TrimPrefix(*item.Name, fullKey)
+			if name == "" {
+				continue
+			}
+			fileMetas = append(fileMetas, FileMetadata{
+				Name:     name,
+				Size:     deref(item.Properties.ContentLength),
+				ModTime:  deref(item.Properties.LastModified),
+				IsDir:    false,
+				MIMEType: contentTypeOrDetect(deref(item.Properties.ContentType), name),
+				ETag:     azureETag(item.Properties.ETag),
+			})
+		}
+
+		// 处理子目录（BlobPrefixes）
+		for _, prefix := range page.Segment.BlobPrefixes {
+			if name := strings.TrimPrefix(*prefix.Name, fullKey); name != "" {
+				fileMetas = append(fileMetas, FileMetadata{Name: name, IsDir: true})
+			}
+		}
+	}
+
+	hlog.CtxInfof(ctx, "成功列出Azure Blob目录内容: %s", dirPath)
+	return fileMetas, nil
+}
+
+// GetMetadata 实现获取Azure Blob文件元数据
+func (s *AzureBlobStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取Azure Blob文件元数据: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	props, err := s.container.NewBlobClient(fullKey).GetProperties(ctx, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取Azure Blob文件信息失败: %v", err)
+		return nil, azureError(err)
+	}
+	if isExpired(azureExpiresAt(props.Metadata)) {
+		return nil, errExpired(filePath)
+	}
+
+	fileMeta := &FileMetadata{
+		Name:     filePath,
+		Size:     deref(props.ContentLength),
+		ModTime:  deref(props.LastModified),
+		IsDir:    false,
+		MIMEType: contentTypeOrDetect(deref(props.ContentType), filePath),
+		ETag:     azureETag(props.ETag),
+	}
+
+	hlog.CtxInfof(ctx, "成功获取Azure Blob文件元数据: %s", filePath)
+	return fileMeta, nil
+}
+
+// UpdateMetadata 更新Azure Blob文件元数据，仅支持修改 MIME 类型（修改时间由服务端维护，指定时返回 ErrNotSupported）
+func (s *AzureBlobStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新Azure Blob文件元数据: %s", filePath)
+
+	if !metadata.ModTime.IsZero() {
+		return fmt.Errorf("Azure Blob不支持修改文件修改时间: %w", ErrNotSupported)
+	}
+	if metadata.MIMEType == "" {
+		return nil
+	}
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+	blobClient := s.container.NewBlobClient(fullKey)
+
+	// SetHTTPHeaders 会替换全部 HTTP 头，未传入的 Cache-Control、Content-Encoding 等会被清空，
+	// 因此先读取当前的 HTTP 头再修改；以 ETag 为条件，避免覆盖期间被替换的 Blob
+	props, err := blobClient.GetProperties(ctx, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取Azure Blob文件信息失败: %v", err)
+		return azureError(err)
+	}
+	headers := blob.ParseHTTPHeaders(props)
+	headers.BlobContentType = to.Ptr(metadata.MIMEType)
+	_, err = blobClient.SetHTTPHeaders(ctx, headers, &blob.SetHTTPHeadersOptions{
+		AccessConditions: &blob.AccessConditions{ModifiedAccessConditions: &blob.ModifiedAccessConditions{IfMatch: props.ETag}},
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "更新Azure Blob文件元数据失败: %v", err)
+		return azureError(err)
+	}
+
+	hlog.CtxInfof(ctx, "成功更新Azure Blob文件元数据: %s", filePath)
+	return nil
+}
+
+// GetExpiration 读取Azure Blob文件的删除截止时间（不检查是否已过期）
+func (s *AzureBlobStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	props, err := s.container.NewBlobClient(fullKey).GetProperties(ctx, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取Azure Blob文件信息失败: %v", err)
+		return time.Time{}, azureError(err)
+	}
+	return azureExpiresAt(props.Metadata), nil
+}
+
+// Presign 生成Blob级别的SAS URL（需要账户密钥），GET 授予读权限，PUT 授予创建和写权限
+func (s *AzureBlobStorage) Presign(ctx context.Context, method string, filePath string, expires time.Duration) (string, error) {
+	var permissions sas.BlobPermissions
+	switch method {
+	case http.MethodGet:
+		permissions.Read = true
+	case http.MethodPut:
+		permissions.Create = true
+		permissions.Write = true
+	default:
+		return "", fmt.Errorf("Azure Blob预签名不支持 %s 方法: %w", method, ErrNotSupported)
+	}
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	sasURL, err := s.container.NewBlobClient(fullKey).GetSASURL(permissions, time.Now().Add(expires), nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "生成Azure Blob SAS URL失败: %v", err)
+		return "", err
+	}
+	return sasURL, nil
+}
+
+// BatchUpload 实现Azure Blob批量上传
+func (s *AzureBlobStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到Azure Blob", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现Azure Blob批量下载（流式下载）
+func (s *AzureBlobStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个Azure Blob文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现Azure Blob批量删除
+func (s *AzureBlobStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个Azure Blob文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
+
+// azureError 将对象不存在的响应（404）转换为 ErrNotExist
+func azureError(err error) error {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+		return &notExistError{err: err}
+	}
+	return err
+}
+
+// azureExpiresAt 从Blob元数据中解析删除截止时间
+func azureExpiresAt(metadata map[string]*string) time.Time {
+	for key, value := range metadata {
+		if strings.EqualFold(key, azureExpiresAtMetaKey) && value != nil {
+			return parseExpiresAt(*value)
+		}
+	}
+	return time.Time{}
+}
+
+// azureETag 返回去掉引号的 ETag
+func azureETag(etag *azcore.ETag) string {
+	if etag == nil {
+		return ""
+	}
+	return trimETag(string(*etag))
+}
+
+// deref 返回指针指向的值，指针为空时返回零值
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
@@ -0,0 +1,259 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+)
+
+// newFakeAzureStorage 创建连接假 Azure Blob 服务的存储，容器为 photos，基础目录为 base
+func newFakeAzureStorage(t *testing.T, server *storagetest.FakeAzureServer, blockSize int64) storage.Storage {
+	t.Helper()
+	s := storage.NewAzureBlobStorage(storage.AzureBlobStorageConfig{
+		ConnectionString: server.ConnectionString(),
+		Container:        "photos",
+		BaseDir:          "base",
+		BlockSize:        blockSize,
+	})
+	if s == nil {
+		t.Fatal("NewAzureBlobStorage returned nil")
+	}
+	return s
+}
+
+// fakeAzureRequest 直接向假服务发送请求（假服务不校验签名），返回响应头
+func fakeAzureRequest(t *testing.T, server *storagetest.FakeAzureServer, method, blobPath string, header http.Header, body string) http.Header {
+	t.Helper()
+	req, err := http.NewRequest(method, server.Endpoint()+"/photos/"+blobPath, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, blobPath, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		t.Fatalf("%s %s: unexpected status %d", method, blobPath, resp.StatusCode)
+	}
+	return resp.Header
+}
+
+func TestAzureBlobStorage_Presign(t *testing.T) {
+	server := storagetest.NewFakeAzureServer()
+	defer server.Close()
+	s := newFakeAzureStorage(t, server, 0)
+	ctx := context.Background()
+
+	presigner, ok := storage.As[storage.Presigner](s)
+	if !ok {
+		t.Fatal("AzureBlobStorage should implement Presigner")
+	}
+	cases := map[string]string{http.MethodGet: "r", http.MethodPut: "cw"}
+	for method, permissions := range cases {
+		rawURL, err := presigner.Presign(ctx, method, "a b.txt", time.Hour)
+		if err != nil {
+			t.Fatalf("Presign(%s) failed: %v", method, err)
+		}
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			t.Fatalf("Invalid SAS URL %q: %v", rawURL, err)
+		}
+		if !strings.HasSuffix(u.Path, "/devstoreaccount1/photos/base/a b.txt") {
+			t.Fatalf("Unexpected SAS path: %s", u.Path)
+		}
+		if u.Query().Get("sp") != permissions || u.Query().Get("sig") == "" || u.Query().Get("se") == "" {
+			t.Fatalf("Unexpected SAS query for %s: %s", method, u.RawQuery)
+		}
+	}
+
+	if _, err := presigner.Presign(ctx, http.MethodDelete, "a.txt", time.Hour); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestAzureBlobStorage_ExpiresAtMetadata(t *testing.T) {
+	server := storagetest.NewFakeAzureServer()
+	defer server.Close()
+	s := newFakeAzureStorage(t, server, 0)
+	ctx := context.Background()
+	expiration, _ := storage.As[storage.ExpirationReader](s)
+
+	// 服务端返回的元数据键大小写可能变化（假服务返回 X-Ms-Meta-Expires_at）
+	if err := s.Upload(ctx, "temp.txt", strings.NewReader("t"), storage.WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	deadline, err := expiration.GetExpiration(ctx, "temp.txt")
+	if err != nil || time.Until(deadline) < 59*time.Minute || time.Until(deadline) > time.Hour {
+		t.Fatalf("Unexpected deadline: %v, %v", deadline, err)
+	}
+
+	if err := s.Upload(ctx, "keep.txt", strings.NewReader("k")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if deadline, err := expiration.GetExpiration(ctx, "keep.txt"); err != nil || !deadline.IsZero() {
+		t.Fatalf("Expected zero deadline, got %v, %v", deadline, err)
+	}
+}
+
+func TestAzureBlobStorage_BlockUpload(t *testing.T) {
+	server := storagetest.NewFakeAzureServer()
+	defer server.Close()
+	s := newFakeAzureStorage(t, server, 1<<20)
+	ctx := context.Background()
+
+	// 超过一个块的内容按块暂存后提交块列表（SDK 的最小块大小为 1MiB）
+	data := bytes.Repeat([]byte("0123456789"), 250000)
+	if err := s.Upload(ctx, "big.bin", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	stored, blocks, ok := server.Blob("photos", "base/big.bin")
+	if !ok || !bytes.Equal(stored, data) || blocks != 3 {
+		t.Fatalf("Unexpected blob: %d bytes, %d blocks, %v", len(stored), blocks, ok)
+	}
+	// 范围跨越块边界
+	reader, err := s.DownloadRange(ctx, "big.bin", 1<<20-6, 10)
+	if got := readString(t)(reader, err); got != "0123456789" {
+		t.Fatalf("Unexpected range content: %q", got)
+	}
+
+	// 不超过一个块的小文件直接上传
+	if err := s.Upload(ctx, "small.txt", strings.NewReader("small")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if _, blocks, ok := server.Blob("photos", "base/small.txt"); !ok || blocks != 0 {
+		t.Fatalf("Small file should be uploaded with Put Blob, got %d blocks", blocks)
+	}
+
+	// 条件写入在提交块列表时检查
+	cu, ok := storage.As[storage.ConditionalUploader](s)
+	if !ok {
+		t.Fatal("AzureBlobStorage should implement ConditionalUploader")
+	}
+	err = cu.UploadIf(ctx, "big.bin", bytes.NewReader(data), storage.UploadCondition{IfNoneMatch: true})
+	if !errors.Is(err, storage.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	err = cu.UploadIf(ctx, "big.bin", bytes.NewReader(data[:2<<20]), storage.UploadCondition{IfMatch: "0x8D0000000000000"})
+	if !errors.Is(err, storage.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	metadata, err := s.GetMetadata(ctx, "big.bin")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if err := cu.UploadIf(ctx, "big.bin", bytes.NewReader(data[:2<<20]), storage.UploadCondition{IfMatch: metadata.ETag}); err != nil {
+		t.Fatalf("UploadIf failed: %v", err)
+	}
+	if stored, blocks, _ := server.Blob("photos", "base/big.bin"); len(stored) != 2<<20 || blocks != 2 {
+		t.Fatalf("Unexpected blob after UploadIf: %d bytes, %d blocks", len(stored), blocks)
+	}
+}
+
+func TestAzureBlobStorage_CopyPolling(t *testing.T) {
+	server := storagetest.NewFakeAzureServer()
+	defer server.Close()
+	server.PendingCopyPolls = 2
+	s := newFakeAzureStorage(t, server, 0)
+	ctx := context.Background()
+
+	if err := s.Upload(ctx, "src.txt", strings.NewReader("copy me")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Copy(ctx, "src.txt", "dst.txt"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	// Copy 应轮询到复制完成才返回，此时服务端已不再报告 pending
+	if status := fakeAzureRequest(t, server, http.MethodHead, "base/dst.txt", nil, "").Get("x-ms-copy-status"); status != "success" {
+		t.Fatalf("Copy returned before completion, copy status %q", status)
+	}
+	if got := readString(t)(s.Download(ctx, "dst.txt")); got != "copy me" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+
+	// Move 同样等待复制完成后删除源文件
+	if err := s.Move(ctx, "dst.txt", "moved/dst.txt"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if exists, _ := s.Exists(ctx, "dst.txt"); exists {
+		t.Fatal("Source should be deleted after Move")
+	}
+	if err := s.Copy(ctx, "missing.txt", "dst.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestAzureBlobStorage_ListDir(t *testing.T) {
+	server := storagetest.NewFakeAzureServer()
+	defer server.Close()
+	server.PageSize = 2
+	s := newFakeAzureStorage(t, server, 0)
+	ctx := context.Background()
+
+	for _, filePath := range []string{"dir/a.txt", "dir/b.txt", "dir/c.txt", "dir/sub1/x.txt", "dir/sub1/y.txt", "dir/sub2/z.txt", "other.txt"} {
+		if err := s.Upload(ctx, filePath, strings.NewReader("x")); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	// 分层列举跨越多页，子目录以 BlobPrefix 返回，名称带结尾的 "/"
+	files, err := s.ListDir(ctx, "dir")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	var got []string
+	for _, file := range files {
+		got = append(got, fmt.Sprintf("%s:%v", file.Name, file.IsDir))
+	}
+	if want := "a.txt:false b.txt:false c.txt:false sub1/:true sub2/:true"; strings.Join(got, " ") != want {
+		t.Fatalf("Unexpected listing: %v", got)
+	}
+
+	if err := s.DeleteDir(ctx, "dir"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if files, err := s.ListDir(ctx, ""); err != nil || len(files) != 1 || files[0].Name != "other.txt" {
+		t.Fatalf("Unexpected listing after DeleteDir: %+v, %v", files, err)
+	}
+}
+
+func TestAzureBlobStorage_UpdateMetadataKeepsHeaders(t *testing.T) {
+	server := storagetest.NewFakeAzureServer()
+	defer server.Close()
+	s := newFakeAzureStorage(t, server, 0)
+	ctx := context.Background()
+
+	fakeAzureRequest(t, server, http.MethodPut, "base/page.html", http.Header{
+		"X-Ms-Blob-Type":                {"BlockBlob"},
+		"X-Ms-Blob-Content-Type":        {"text/plain"},
+		"X-Ms-Blob-Cache-Control":       {"max-age=60"},
+		"X-Ms-Blob-Content-Disposition": {"inline"},
+	}, "<html></html>")
+	before := fakeAzureRequest(t, server, http.MethodHead, "base/page.html", nil, "")
+
+	// 设置 Content-Type 时保留其他 HTTP 头
+	if err := s.UpdateMetadata(ctx, "page.html", &storage.FileMetadata{MIMEType: "text/html"}); err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+	after := fakeAzureRequest(t, server, http.MethodHead, "base/page.html", nil, "")
+	if after.Get("Content-Type") != "text/html" {
+		t.Fatalf("Unexpected content type: %q", after.Get("Content-Type"))
+	}
+	for _, name := range []string{"Cache-Control", "Content-Disposition", "Content-Md5"} {
+		if after.Get(name) == "" || after.Get(name) != before.Get(name) {
+			t.Fatalf("%s not kept: %q -> %q", name, before.Get(name), after.Get(name))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// 本文件定义 Storage 之外的可选能力接口，后端按自身能力选择实现。
+// 使用前应通过 As 判断（装饰器不会转发这些接口）：
+//
+//	if p, ok := storage.As[storage.Presigner](s); ok { ... }
+
+// FileVersion 文件的一个历史版本
+type FileVersion struct {
+	VersionID    string    `json:"version_id"`    // 版本ID
+	Size         int64     `json:"size"`          // 文件大小
+	ModTime      time.Time `json:"mod_time"`      // 修改时间
+	ETag         string    `json:"etag"`          // 内容标识
+	IsLatest     bool      `json:"is_latest"`     // 是否为当前版本
+	DeleteMarker bool      `json:"delete_marker"` // 是否为删除标记
+}
+
+// Versioner 由支持多版本的存储实现
+type Versioner interface {
+	// ListVersions 按从新到旧的顺序返回文件的所有版本（包括删除标记）
+	ListVersions(ctx context.Context, filePath string) ([]FileVersion, error)
+	// DownloadVersion 下载指定版本的内容
+	DownloadVersion(ctx context.Context, filePath string, versionID string) (io.Reader, error)
+}
+
+// Tagger 由支持对象标签的存储实现
+type Tagger interface {
+	GetTags(ctx context.Context, filePath string) (map[string]string, error)
+	// SetTags 替换文件的全部标签
+	SetTags(ctx context.Context, filePath string, tags map[string]string) error
+}
+
+// Presigner 由支持生成预签名URL的存储实现
+type Presigner interface {
+	// Presign 生成在 expires 内有效的预签名URL，method 为 http.MethodGet 或 http.MethodPut
+	Presign(ctx context.Context, method string, filePath string, expires time.Duration) (string, error)
+}
+
+// UploadCondition 条件写入的前置条件
+type UploadCondition struct {
+	IfNoneMatch bool   // 仅当文件不存在时写入
+	IfMatch     string // 仅当文件当前的 ETag（或版本标识）等于该值时写入
+}
+
+// ConditionalUploader 由支持条件写入的存储实现，前置条件不满足时返回 ErrPreconditionFailed
+type ConditionalUploader interface {
+	UploadIf(ctx context.Context, filePath string, reader io.Reader, cond UploadCondition, opts ...UploadOption) error
+}
+
+// ResumableUploader 由支持从指定位置续传的存储实现
+type ResumableUploader interface {
+	// UploadAt 保留文件前 offset 个字节，丢弃其后的内容并从 offset 处写入 reader 的内容。
+	// offset 为 0 时文件可以不存在；offset 超过当前文件大小时返回错误。
+	// 续传期间文件内容不完整，读取方可能看到部分内容。
+	UploadAt(ctx context.Context, filePath string, reader io.Reader, offset int64) error
+}
+
+// ServerCopier 由能在服务端跨实例复制的存储实现（如同一服务、相同凭证下的不同存储桶或基础目录），
+// CopyBetween 优先使用它，避免数据经过本机
+type ServerCopier interface {
+	// CopyFrom 在服务端将 src 中的 srcPath 复制到本存储的 dstPath，保留内容类型、用户元数据和标签。
+	// src 与本存储不属于同一服务或凭证不同时返回 ErrNotSupported，调用方应改为流式复制。
+	CopyFrom(ctx context.Context, src Storage, srcPath string, dstPath string) error
+}
@@ -2,18 +2,25 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/v-mars/storage"
+	"go.yaml.in/yaml/v3"
+	"golang.org/x/net/webdav"
+	_ "modernc.org/sqlite"
 )
 
 var (
-	storageType = flag.String("type", "local", "Storage type: local, oss, minio, s3")
-	action      = flag.String("action", "", "Action to perform: upload, download, delete, list, mkdir, rmdir")
+	storageType = flag.String("type", "local", "Storage type: local, oss, minio, s3, cos, azure, gcs, sftp, webdav, ftp, sql, archive, http, remote, grpc")
+	action      = flag.String("action", "", "Action to perform: upload, download, delete, list, mkdir, rmdir, rename, quota, serve, sync")
 	src         = flag.String("src", "", "Source file path")
 	dst         = flag.String("dst", "", "Destination file path")
 	dir         = flag.String("dir", "", "Directory path")
@@ -45,6 +52,106 @@ var (
 	s3UseSSL          = flag.Bool("s3.usessl", true, "S3 use SSL")
 	s3Bucket          = flag.String("s3.bucket", "", "S3 bucket name")
 	s3BaseDir         = flag.String("s3.basedir", "", "S3 base directory")
+
+	// COS storage options
+	cosSecretID  = flag.String("cos.secretid", "", "COS secret ID")
+	cosSecretKey = flag.String("cos.secretkey", "", "COS secret key")
+	cosRegion    = flag.String("cos.region", "", "COS region, e.g. ap-guangzhou")
+	cosBucket    = flag.String("cos.bucket", "", "COS bucket name")
+	cosAppID     = flag.String("cos.appid", "", "COS AppID, appended to bucket name if missing")
+	cosBaseDir   = flag.String("cos.basedir", "", "COS base directory")
+	cosEndpoint  = flag.String("cos.endpoint", "", "COS custom bucket URL")
+
+	// Azure Blob storage options
+	azureAccountName      = flag.String("azure.accountname", "", "Azure storage account name")
+	azureAccountKey       = flag.String("azure.accountkey", "", "Azure storage account key")
+	azureConnectionString = flag.String("azure.connectionstring", "", "Azure storage connection string (overrides account name and key)")
+	azureEndpoint         = flag.String("azure.endpoint", "", "Azure Blob service URL")
+	azureContainer        = flag.String("azure.container", "", "Azure Blob container name")
+	azureBaseDir          = flag.String("azure.basedir", "", "Azure Blob base directory")
+
+	// GCS storage options
+	gcsProjectID       = flag.String("gcs.projectid", "", "GCS project ID, used to create the bucket if missing")
+	gcsBucket          = flag.String("gcs.bucket", "", "GCS bucket name")
+	gcsBaseDir         = flag.String("gcs.basedir", "", "GCS base directory")
+	gcsCredentialsFile = flag.String("gcs.credentialsfile", "", "GCS service account key file (defaults to application default credentials)")
+	gcsEmulatorHost    = flag.String("gcs.emulatorhost", "", "GCS emulator host, e.g. http://localhost:4443")
+
+	// SFTP storage options
+	sftpHost           = flag.String("sftp.host", "", "SFTP server host")
+	sftpPort           = flag.Int("sftp.port", 22, "SFTP server port")
+	sftpUser           = flag.String("sftp.user", "", "SFTP user name")
+	sftpPassword       = flag.String("sftp.password", "", "SFTP password")
+	sftpPrivateKeyFile = flag.String("sftp.keyfile", "", "SFTP private key file")
+	sftpPassphrase     = flag.String("sftp.passphrase", "", "SFTP private key passphrase")
+	sftpUseAgent       = flag.Bool("sftp.useagent", false, "SFTP authenticate with keys from ssh-agent (SSH_AUTH_SOCK)")
+	sftpKnownHosts     = flag.String("sftp.knownhosts", "", "SFTP known_hosts file (defaults to ~/.ssh/known_hosts)")
+	sftpInsecure       = flag.Bool("sftp.insecure", false, "SFTP skip host key verification")
+	sftpBaseDir        = flag.String("sftp.basedir", "", "SFTP base directory on the server")
+
+	// WebDAV storage options
+	webdavEndpoint = flag.String("webdav.endpoint", "", "WebDAV root URL, e.g. https://cloud.example.com/remote.php/dav/files/user/")
+	webdavUsername = flag.String("webdav.username", "", "WebDAV user name")
+	webdavPassword = flag.String("webdav.password", "", "WebDAV password")
+	webdavAuthType = flag.String("webdav.authtype", "", "WebDAV auth type: basic, digest (detected from the server challenge if empty)")
+	webdavBaseDir  = flag.String("webdav.basedir", "", "WebDAV base directory relative to the endpoint")
+
+	// FTP storage options
+	ftpHost     = flag.String("ftp.host", "", "FTP server host")
+	ftpPort     = flag.Int("ftp.port", 0, "FTP server port (defaults to 21, or 990 for implicit TLS)")
+	ftpUser     = flag.String("ftp.user", "", "FTP user name (anonymous if empty)")
+	ftpPassword = flag.String("ftp.password", "", "FTP password")
+	ftpTLS      = flag.String("ftp.tls", "", "FTP TLS mode: explicit, implicit (plain FTP if empty)")
+	ftpInsecure = flag.Bool("ftp.insecure", false, "FTP skip TLS certificate verification")
+	ftpBaseDir  = flag.String("ftp.basedir", "", "FTP base directory on the server")
+
+	// SQL storage options
+	sqlDriver      = flag.String("sql.driver", "sqlite", "SQL database/sql driver name (only sqlite is built into the CLI)")
+	sqlDSN         = flag.String("sql.dsn", "", "SQL data source name, e.g. file:storage.db")
+	sqlTablePrefix = flag.String("sql.tableprefix", "", "SQL table name prefix (defaults to storage_)")
+
+	// Archive storage options
+	archivePath   = flag.String("archive.path", "", "Archive file path (read-only zip, tar or tar.gz)")
+	archiveFormat = flag.String("archive.format", "", "Archive format: zip, tar, tar.gz (detected if empty)")
+
+	// HTTP storage options
+	httpURL      = flag.String("http.url", "", "HTTP base URL (read-only)")
+	httpUsername = flag.String("http.username", "", "HTTP basic auth user name")
+	httpPassword = flag.String("http.password", "", "HTTP basic auth password")
+	httpToken    = flag.String("http.token", "", "HTTP bearer token")
+	httpListing  = flag.String("http.listing", "", "HTTP directory listing format: auto, html, json (list disabled if empty)")
+
+	// Remote storage options
+	remoteURL     = flag.String("remote.url", "", "Storage gateway URL including the base path, e.g. http://localhost:8080/api/v1")
+	remoteToken   = flag.String("remote.token", "", "Storage gateway access token")
+	remoteBaseDir = flag.String("remote.basedir", "", "Base directory in the gateway storage")
+
+	// gRPC storage options
+	grpcTarget  = flag.String("grpc.target", "", "gRPC storage service address, e.g. localhost:9090")
+	grpcToken   = flag.String("grpc.token", "", "gRPC storage service access token")
+	grpcBaseDir = flag.String("grpc.basedir", "", "Base directory in the gRPC service storage")
+	grpcTLS     = flag.Bool("grpc.tls", false, "Use TLS for the gRPC connection")
+
+	// Quota options
+	quotaPrefix    = flag.String("quota.prefix", "", "Quota prefix to recalculate usage for")
+	quotaPerTenant = flag.Bool("quota.pertenant", false, "Report usage for each first-level directory under quota prefix")
+
+	// WebDAV server options
+	serveAddr     = flag.String("serve.addr", ":8080", "WebDAV server listen address")
+	serveUsername = flag.String("serve.username", "", "WebDAV server basic auth user name (no auth if empty)")
+	servePassword = flag.String("serve.password", os.Getenv("STORAGE_SERVE_PASSWORD"), "WebDAV server basic auth password (defaults to $STORAGE_SERVE_PASSWORD)")
+	serveReadOnly = flag.Bool("serve.readonly", false, "WebDAV server read-only mode")
+
+	// Sync options
+	syncDstConfig   = flag.String("sync.dstconfig", "", "Destination storage configuration file (YAML or JSON, same format as config.example.yaml), defaults to the source storage")
+	syncCompare     = flag.String("sync.compare", "size_modtime", "Sync compare mode: size_modtime, checksum")
+	syncInclude     = flag.String("sync.include", "", "Comma-separated glob patterns of files to sync")
+	syncExclude     = flag.String("sync.exclude", "", "Comma-separated glob patterns of files to skip")
+	syncDryRun      = flag.Bool("sync.dryrun", false, "Print the sync plan without copying or deleting")
+	syncDelete      = flag.Bool("sync.delete", false, "Delete destination files that do not exist in the source")
+	syncBandwidth   = flag.Int64("sync.bwlimit", 0, "Sync bandwidth limit in bytes per second (0 for unlimited)")
+	syncConcurrency = flag.Int("sync.concurrency", 4, "Number of files to sync concurrently")
+	syncState       = flag.String("sync.state", "", "Local state file for resuming an interrupted sync")
 )
 
 func main() {
@@ -92,6 +199,96 @@ func main() {
 			Bucket:          *s3Bucket,
 			BaseDir:         *s3BaseDir,
 		}
+	case storage.COS:
+		storageConfig.Cos = storage.COSStorageConfig{
+			SecretID:  *cosSecretID,
+			SecretKey: *cosSecretKey,
+			Region:    *cosRegion,
+			Bucket:    *cosBucket,
+			AppID:     *cosAppID,
+			BaseDir:   *cosBaseDir,
+			Endpoint:  *cosEndpoint,
+		}
+	case storage.Azure:
+		storageConfig.Azure = storage.AzureBlobStorageConfig{
+			AccountName:      *azureAccountName,
+			AccountKey:       *azureAccountKey,
+			ConnectionString: *azureConnectionString,
+			Endpoint:         *azureEndpoint,
+			Container:        *azureContainer,
+			BaseDir:          *azureBaseDir,
+		}
+	case storage.GCS:
+		storageConfig.Gcs = storage.GCSStorageConfig{
+			ProjectID:       *gcsProjectID,
+			Bucket:          *gcsBucket,
+			BaseDir:         *gcsBaseDir,
+			CredentialsFile: *gcsCredentialsFile,
+			EmulatorHost:    *gcsEmulatorHost,
+		}
+	case storage.SFTP:
+		storageConfig.Sftp = storage.SFTPStorageConfig{
+			Host:                  *sftpHost,
+			Port:                  *sftpPort,
+			User:                  *sftpUser,
+			Password:              *sftpPassword,
+			PrivateKeyFile:        *sftpPrivateKeyFile,
+			Passphrase:            *sftpPassphrase,
+			UseAgent:              *sftpUseAgent,
+			KnownHostsFile:        *sftpKnownHosts,
+			InsecureIgnoreHostKey: *sftpInsecure,
+			BaseDir:               *sftpBaseDir,
+		}
+	case storage.WebDAV:
+		storageConfig.Webdav = storage.WebDAVStorageConfig{
+			Endpoint: *webdavEndpoint,
+			Username: *webdavUsername,
+			Password: *webdavPassword,
+			AuthType: *webdavAuthType,
+			BaseDir:  *webdavBaseDir,
+		}
+	case storage.FTP:
+		storageConfig.Ftp = storage.FTPStorageConfig{
+			Host:               *ftpHost,
+			Port:               *ftpPort,
+			User:               *ftpUser,
+			Password:           *ftpPassword,
+			TLSMode:            *ftpTLS,
+			InsecureSkipVerify: *ftpInsecure,
+			BaseDir:            *ftpBaseDir,
+		}
+	case storage.SQL:
+		storageConfig.Sql = storage.SQLStorageConfig{
+			Driver:      *sqlDriver,
+			DSN:         *sqlDSN,
+			TablePrefix: *sqlTablePrefix,
+		}
+	case storage.Archive:
+		storageConfig.Archive = storage.ArchiveStorageConfig{
+			Path:   *archivePath,
+			Format: *archiveFormat,
+		}
+	case storage.HTTP:
+		storageConfig.Http = storage.HTTPStorageConfig{
+			BaseURL:     *httpURL,
+			Username:    *httpUsername,
+			Password:    *httpPassword,
+			BearerToken: *httpToken,
+			Listing:     *httpListing,
+		}
+	case storage.Remote:
+		storageConfig.Remote = storage.RemoteStorageConfig{
+			Endpoint: *remoteURL,
+			Token:    *remoteToken,
+			BaseDir:  *remoteBaseDir,
+		}
+	case storage.GRPC:
+		storageConfig.Grpc = storage.GRPCStorageConfig{
+			Target:  *grpcTarget,
+			Token:   *grpcToken,
+			BaseDir: *grpcBaseDir,
+			TLS:     *grpcTLS,
+		}
 	default:
 		storageConfig.Local = storage.LocalStorageConfig{
 			BasePath: *localBasePath,
@@ -152,6 +349,12 @@ func main() {
 			os.Exit(1)
 		}
 		renameFile(ctx, storageInstance, *src, *dst)
+	case "quota":
+		recalculateQuota(ctx, storageInstance, *quotaPrefix, *quotaPerTenant)
+	case "serve":
+		serveWebDAV(storageInstance, *serveAddr, *serveUsername, *servePassword, *serveReadOnly)
+	case "sync":
+		syncDirs(ctx, storageInstance, *src, *dst)
 	default:
 		fmt.Printf("Error: unsupported action: %s\n", *action)
 		os.Exit(1)
@@ -263,3 +466,124 @@ func renameFile(ctx context.Context, s storage.Storage, srcPath, dstPath string)
 
 	fmt.Printf("Successfully renamed %s to %s\n", srcPath, dstPath)
 }
+
+func recalculateQuota(ctx context.Context, s storage.Storage, prefix string, perTenant bool) {
+	quota := storage.NewQuotaStorage(s, storage.QuotaConfig{
+		Rules: []storage.QuotaRule{{Prefix: prefix, PerTenant: perTenant}},
+	})
+
+	usages, err := quota.Recalculate(ctx)
+	if err != nil {
+		fmt.Printf("Failed to recalculate quota usage: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Quota usage under %q:\n", prefix)
+	for _, usage := range usages {
+		fmt.Printf("%s\t%d bytes\t%d objects\n", usage.Prefix, usage.Bytes, usage.Objects)
+	}
+}
+
+func serveWebDAV(s storage.Storage, addr, username, password string, readOnly bool) {
+	handler := &webdav.Handler{
+		FileSystem: storage.NewWebDAVFS(s, storage.WebDAVFSConfig{ReadOnly: readOnly}),
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				fmt.Printf("%s %s: %v\n", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+
+	fmt.Printf("Serving WebDAV on %s (read-only: %v)\n", addr, readOnly)
+	err := http.ListenAndServe(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username != "" {
+			user, pass, ok := r.BasicAuth()
+			if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(username)) != 1 ||
+				subtle.ConstantTimeCompare([]byte(pass), []byte(password)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Basic realm="storage"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		// 只读模式下直接拒绝写方法，避免客户端误以为资源不存在
+		if readOnly {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND":
+			default:
+				http.Error(w, "Read-only", http.StatusForbidden)
+				return
+			}
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	if err != nil {
+		fmt.Printf("Failed to serve WebDAV: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func syncDirs(ctx context.Context, s storage.Storage, srcDir, dstDir string) {
+	dstStorage := s
+	if *syncDstConfig != "" {
+		dstConfig := &storage.Types{}
+		if err := loadConfig(*syncDstConfig, dstConfig); err != nil {
+			fmt.Printf("Failed to load destination configuration: %v\n", err)
+			os.Exit(1)
+		}
+		if _, dstStorage = dstConfig.GetStorage(ctx); dstStorage == nil {
+			fmt.Printf("Failed to initialize destination storage for type: %s\n", dstConfig.Mode)
+			os.Exit(1)
+		}
+	}
+
+	report, err := storage.Sync(ctx, s, srcDir, dstStorage, dstDir, storage.SyncConfig{
+		Compare:          storage.SyncCompareMode(*syncCompare),
+		Include:          splitList(*syncInclude),
+		Exclude:          splitList(*syncExclude),
+		DryRun:           *syncDryRun,
+		DeleteExtraneous: *syncDelete,
+		BandwidthLimit:   *syncBandwidth,
+		Concurrency:      *syncConcurrency,
+		StatePath:        *syncState,
+	})
+	// 失败时同样输出报告，便于查看失败的文件
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+	if err != nil {
+		fmt.Printf("Failed to sync: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadConfig 读取配置文件，字段名与 storage.Types 的 json 标签一致
+func loadConfig(configPath string, config *storage.Types) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(configPath), ".json") {
+		return json.Unmarshal(data, config)
+	}
+
+	// YAML 先转换为 JSON，以便复用 json 标签
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(raw); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+// splitList 拆分逗号分隔的列表
+func splitList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/v-mars/storage"
+	"go.yaml.in/yaml/v3"
+	"google.golang.org/grpc"
+	_ "modernc.org/sqlite"
+)
+
+var (
+	addr          = flag.String("addr", ":8080", "Listen address")
+	configFile    = flag.String("config", "", "Storage configuration file (YAML or JSON, same format as config.example.yaml)")
+	tokens        = flag.String("token", "", "Comma-separated access tokens (defaults to $STORAGE_GATEWAY_TOKEN, authentication disabled if empty)")
+	routePath     = flag.String("base", "/api/v1", "Route base path")
+	pageSize      = flag.Int("pagesize", 1000, "Maximum directory entries per page")
+	localBasePath = flag.String("local.basepath", "./data", "Local storage base path, used when no configuration file is given")
+
+	s3Addr      = flag.String("s3.addr", "", "Listen address of the S3-compatible API, disabled if empty")
+	s3Bucket    = flag.String("s3.bucket", "storage", "Bucket name exposed by the S3-compatible API")
+	s3Region    = flag.String("s3.region", "us-east-1", "Region reported by the S3-compatible API")
+	s3AccessKey = flag.String("s3.accesskey", "", "S3 access key ID (defaults to $STORAGE_S3_ACCESS_KEY, signature verification disabled if empty)")
+	s3SecretKey = flag.String("s3.secretkey", "", "S3 secret access key (defaults to $STORAGE_S3_SECRET_KEY)")
+
+	grpcAddr = flag.String("grpc.addr", "", "Listen address of the gRPC storage service (same access tokens), disabled if empty")
+)
+
+func main() {
+	flag.Parse()
+	ctx := context.Background()
+
+	storageConfig := &storage.Types{}
+	if *configFile != "" {
+		if err := loadConfig(*configFile, storageConfig); err != nil {
+			fmt.Printf("Failed to load configuration: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		storageConfig.Mode = storage.Local
+		storageConfig.Local = storage.LocalStorageConfig{BasePath: *localBasePath}
+	}
+
+	basePath, storageInstance := storageConfig.GetStorage(ctx)
+	if storageInstance == nil {
+		fmt.Printf("Failed to initialize storage instance for type: %s\n", storageConfig.Mode)
+		os.Exit(1)
+	}
+	fmt.Printf("Using storage type: %s, base path: %s\n", storageConfig.AssignMode, basePath)
+
+	tokenList := splitTokens(*tokens)
+	if len(tokenList) == 0 {
+		tokenList = splitTokens(os.Getenv("STORAGE_GATEWAY_TOKEN"))
+	}
+	if len(tokenList) == 0 {
+		fmt.Println("Warning: no access token configured, authentication is disabled")
+	}
+
+	if *s3Addr != "" {
+		go serveS3(ctx, storageInstance)
+	}
+	if *grpcAddr != "" {
+		go serveGRPC(storageInstance, tokenList)
+	}
+
+	// 开启流式请求体，上传不在内存中缓冲
+	h := server.Default(server.WithHostPorts(*addr), server.WithStreamBody(true))
+	storage.NewGateway(storage.GatewayConfig{
+		Storage:  storageInstance,
+		Tokens:   tokenList,
+		BasePath: *routePath,
+		PageSize: *pageSize,
+	}).Register(h)
+	h.Spin()
+}
+
+// serveS3 在单独的端口提供 S3 兼容接口，并定期清理过期的分片上传
+func serveS3(ctx context.Context, storageInstance storage.Storage) {
+	credentials := map[string]string{}
+	accessKey, secretKey := *s3AccessKey, *s3SecretKey
+	if accessKey == "" {
+		accessKey, secretKey = os.Getenv("STORAGE_S3_ACCESS_KEY"), os.Getenv("STORAGE_S3_SECRET_KEY")
+	}
+	if accessKey != "" {
+		credentials[accessKey] = secretKey
+	} else {
+		fmt.Println("Warning: no S3 access key configured, signature verification is disabled")
+	}
+
+	gateway := storage.NewS3Gateway(storage.S3GatewayConfig{
+		Buckets:     map[string]storage.Storage{*s3Bucket: storageInstance},
+		Credentials: credentials,
+		Region:      *s3Region,
+	})
+	go func() {
+		for range time.Tick(time.Hour) {
+			_, _ = gateway.Cleanup(ctx)
+		}
+	}()
+
+	h := server.Default(server.WithHostPorts(*s3Addr), server.WithStreamBody(true))
+	gateway.Register(h)
+	h.Spin()
+}
+
+// serveGRPC 在单独的端口提供 gRPC 存储服务
+func serveGRPC(storageInstance storage.Storage, tokenList []string) {
+	lis, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		fmt.Printf("Failed to listen for gRPC: %v\n", err)
+		os.Exit(1)
+	}
+	srv := grpc.NewServer()
+	storage.NewGRPCServer(storage.GRPCServerConfig{
+		Storage:  storageInstance,
+		Tokens:   tokenList,
+		PageSize: *pageSize,
+	}).Register(srv)
+	if err := srv.Serve(lis); err != nil {
+		fmt.Printf("Failed to serve gRPC: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadConfig 读取配置文件，字段名与 storage.Types 的 json 标签一致
+func loadConfig(configPath string, config *storage.Types) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(filepath.Ext(configPath), ".json") {
+		return json.Unmarshal(data, config)
+	}
+
+	// YAML 先转换为 JSON，以便复用 json 标签
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if data, err = json.Marshal(raw); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, config)
+}
+
+// splitTokens 拆分逗号分隔的令牌列表
+func splitTokens(value string) []string {
+	var result []string
+	for _, token := range strings.Split(value, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			result = append(result, token)
+		}
+	}
+	return result
+}
@@ -0,0 +1,249 @@
+package storage_test
+
+import (
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+	"golang.org/x/net/webdav"
+)
+
+func TestLocalStorage_Conformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: t.TempDir()})
+	})
+}
+
+func TestMemoryStorage_Conformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	})
+}
+
+func TestMemoryStorage_VersioningConformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewMemoryStorage(storage.MemoryStorageConfig{Versioning: true})
+	})
+}
+
+func TestS3Storage_Conformance(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewS3Storage(storage.S3StorageConfig{
+			Endpoint:        server.URL,
+			AccessKeyID:     "test",
+			AccessKeySecret: "test",
+			Region:          "us-east-1",
+			Bucket:          "s3-test",
+			BaseDir:         t.Name(),
+		})
+	})
+}
+
+func TestMinIOStorage_Conformance(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewMinIOStorage(storage.MinIOStorageConfig{
+			Endpoint:        server.Endpoint(),
+			AccessKeyID:     "test",
+			AccessKeySecret: "test",
+			Bucket:          "minio-test",
+			BaseDir:         t.Name(),
+		})
+	})
+}
+
+func TestOSSStorage_Conformance(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+	server.CreateBucket("oss-test")
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewOSSStorage(storage.OSSStorageConfig{
+			Endpoint:        server.URL,
+			AccessKeyID:     "test",
+			AccessKeySecret: "test",
+			Bucket:          "oss-test",
+			BaseDir:         t.Name(),
+		})
+	})
+}
+
+func TestCOSStorage_Conformance(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewCOSStorage(storage.COSStorageConfig{
+			SecretID:  "test",
+			SecretKey: "test",
+			Region:    "ap-guangzhou",
+			Bucket:    "cos-test",
+			AppID:     "1250000000",
+			BaseDir:   t.Name(),
+			Endpoint:  "http://cos-test-1250000000.cos.ap-guangzhou.myqcloud.com",
+			Transport: server.Transport(),
+		})
+	})
+}
+
+// azuriteConnectionString 使用 Azurite 的默认开发账户，Blob 服务地址由 AZURITE_BLOB_ENDPOINT 指定
+// （如 http://127.0.0.1:10000/devstoreaccount1），未设置时使用进程内的假 Azure Blob 服务
+func azuriteConnectionString(t *testing.T) string {
+	endpoint := os.Getenv("AZURITE_BLOB_ENDPOINT")
+	if endpoint == "" {
+		server := storagetest.NewFakeAzureServer()
+		t.Cleanup(server.Close)
+		return server.ConnectionString()
+	}
+	return "DefaultEndpointsProtocol=http;AccountName=" + storagetest.FakeAzureAccount + ";" +
+		"AccountKey=" + storagetest.FakeAzureAccountKey + ";" +
+		"BlobEndpoint=" + endpoint + ";"
+}
+
+func TestAzureBlobStorage_Conformance(t *testing.T) {
+	connectionString := azuriteConnectionString(t)
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewAzureBlobStorage(storage.AzureBlobStorageConfig{
+			ConnectionString: connectionString,
+			Container:        "conformance",
+			BaseDir:          t.Name(),
+			BlockSize:        1 << 20,
+		})
+	})
+}
+
+func TestSFTPStorage_Conformance(t *testing.T) {
+	server := newSFTPTestServer(t)
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		s := storage.NewSFTPStorage(server.config(t.TempDir()))
+		if s != nil {
+			t.Cleanup(func() { s.(*storage.SFTPStorage).Close() })
+		}
+		return s
+	})
+}
+
+func TestWebDAVStorage_Conformance(t *testing.T) {
+	server := newWebDAVTestServer(t, "digest")
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewWebDAVStorage(server.config(t.Name()))
+	})
+}
+
+func TestWebDAVFS_Conformance(t *testing.T) {
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: storage.NewWebDAVFS(storage.NewMemoryStorage(storage.MemoryStorageConfig{}), storage.WebDAVFSConfig{}),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	// 使用 WebDAV 客户端存储访问挂载在内存存储上的 WebDAV 服务
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewWebDAVStorage(storage.WebDAVStorageConfig{Endpoint: server.URL + "/dav/", BaseDir: t.Name()})
+	})
+}
+
+func TestFTPStorage_Conformance(t *testing.T) {
+	server := newFTPTestServer(t, storage.FTPTLSExplicit, false)
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		s := storage.NewFTPStorage(server.config(t.Name(), storage.FTPTLSExplicit))
+		if s != nil {
+			t.Cleanup(func() { s.(*storage.FTPStorage).Close() })
+		}
+		return s
+	})
+}
+
+func TestFTPStorage_ListConformance(t *testing.T) {
+	server := newFTPTestServer(t, "", true)
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		s := storage.NewFTPStorage(server.config(t.Name(), ""))
+		if s != nil {
+			t.Cleanup(func() { s.(*storage.FTPStorage).Close() })
+		}
+		return s
+	})
+}
+
+func TestSQLStorage_Conformance(t *testing.T) {
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return newSQLTestStorage(t, 1024)
+	})
+}
+
+func TestRemoteStorage_Conformance(t *testing.T) {
+	endpoint := startGateway(t, storage.GatewayConfig{
+		Storage: storage.NewMemoryStorage(storage.MemoryStorageConfig{}),
+		Tokens:  []string{"test"},
+	})
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewRemoteStorage(storage.RemoteStorageConfig{
+			Endpoint: endpoint,
+			Token:    "test",
+			BaseDir:  t.Name(),
+		})
+	})
+}
+
+func TestGRPCStorage_Conformance(t *testing.T) {
+	target := startGRPCServer(t, storage.GRPCServerConfig{
+		Storage: storage.NewMemoryStorage(storage.MemoryStorageConfig{}),
+		Tokens:  []string{"test"},
+	})
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return newGRPCStorage(t, storage.GRPCStorageConfig{
+			Target:  target,
+			Token:   "test",
+			BaseDir: t.Name(),
+		})
+	})
+}
+
+func TestS3Gateway_Conformance(t *testing.T) {
+	endpoint := startS3Gateway(t, storage.S3GatewayConfig{
+		Buckets:     map[string]storage.Storage{"data": storage.NewMemoryStorage(storage.MemoryStorageConfig{})},
+		Credentials: map[string]string{"access": "secret"},
+	})
+
+	// 使用 S3 和 MinIO 两种 SDK 访问网关
+	t.Run("S3", func(t *testing.T) {
+		storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+			return storage.NewS3Storage(storage.S3StorageConfig{
+				Endpoint:        endpoint,
+				AccessKeyID:     "access",
+				AccessKeySecret: "secret",
+				Region:          "us-east-1",
+				Bucket:          "data",
+				BaseDir:         t.Name(),
+			})
+		})
+	})
+	t.Run("MinIO", func(t *testing.T) {
+		storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+			return storage.NewMinIOStorage(storage.MinIOStorageConfig{
+				Endpoint:        strings.TrimPrefix(endpoint, "http://"),
+				AccessKeyID:     "access",
+				AccessKeySecret: "secret",
+				Bucket:          "data",
+				BaseDir:         t.Name(),
+			})
+		})
+	})
+}
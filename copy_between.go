@@ -0,0 +1,421 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultCopyConcurrency 复制目录时默认同时复制的文件数
+	defaultCopyConcurrency = 4
+	// maxRateBurst 带宽限制的最大突发字节数，也是限速时单次读取的上限
+	maxRateBurst = 256 << 10
+)
+
+// VerifyMode 跨存储复制后的校验方式
+type VerifyMode int
+
+const (
+	// VerifyChecksum 比较复制的字节数和 MD5 与源、目标文件的大小和 ETag（后端声明 ETag 为 MD5 时，见 FileMetadata.ETagMD5），默认方式
+	VerifyChecksum VerifyMode = iota
+	// VerifyReadBack 在 VerifyChecksum 的基础上重新下载目标文件计算 MD5，适用于 ETag 不是 MD5 的后端
+	VerifyReadBack
+	// VerifyNone 不校验
+	VerifyNone
+)
+
+// CopyOption 定义跨存储复制选项函数类型
+type CopyOption func(*CopyOptions)
+
+// CopyOptions 跨存储复制选项配置
+type CopyOptions struct {
+	Concurrency int        // 复制目录时同时复制的文件数，默认 4
+	Verify      VerifyMode // 校验方式，默认 VerifyChecksum
+	ServerCopy  bool       // 是否优先在服务端复制（同一存储实例或 ServerCopier），默认 true
+
+	limiter *rate.Limiter // 流式复制的带宽限制，同一次调用中的所有文件共享
+}
+
+// WithCopyConcurrency 设置复制目录时同时复制的文件数
+func WithCopyConcurrency(concurrency int) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.Concurrency = concurrency
+	}
+}
+
+// WithCopyVerify 设置复制后的校验方式
+func WithCopyVerify(mode VerifyMode) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.Verify = mode
+	}
+}
+
+// WithServerCopy 设置是否优先在服务端复制，关闭后总是经本机流式复制并校验
+func WithServerCopy(enabled bool) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.ServerCopy = enabled
+	}
+}
+
+// WithCopyBandwidthLimit 限制流式复制的总带宽（字节/秒），同一次调用中并发复制的文件共享该限制，服务端复制不受限制
+func WithCopyBandwidthLimit(bytesPerSecond int64) CopyOption {
+	return func(opts *CopyOptions) {
+		opts.limiter = nil
+		if bytesPerSecond > 0 {
+			opts.limiter = rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxRateBurst)))
+		}
+	}
+}
+
+// applyCopyOptions 应用跨存储复制选项
+func applyCopyOptions(opts ...CopyOption) *CopyOptions {
+	options := &CopyOptions{Concurrency: defaultCopyConcurrency, ServerCopy: true}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	return options
+}
+
+// CopyBetween 将 src 中的文件或目录复制到 dst，src 与 dst 可以是不同类型的存储。
+//
+// src 与 dst 是同一实例时使用 Copy；dst 实现 ServerCopier 且与 src 属于同一服务时在服务端复制；
+// 其他情况下边下载边上传，不在内存或磁盘中缓冲整个文件，并保留内容类型、有效期、标签和修改时间（目标存储支持时）。
+// 流式复制后按 WithCopyVerify 校验，大小或 MD5 不一致时删除目标文件并返回 ErrChecksumMismatch。
+//
+// srcPath 是目录时递归复制其中的所有文件（空目录不复制），由 WithCopyConcurrency 控制并发数，
+// 任一文件失败时取消其余复制并返回该错误，已复制的文件不会回滚。
+func CopyBetween(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, opts ...CopyOption) error {
+	hlog.CtxInfof(ctx, "开始跨存储复制: %s -> %s", srcPath, dstPath)
+
+	if _, err := copyBetween(ctx, src, srcPath, dst, dstPath, applyCopyOptions(opts...)); err != nil {
+		hlog.CtxErrorf(ctx, "跨存储复制失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "跨存储复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// MoveBetween 将 src 中的文件或目录移动到 dst，复制方式与 CopyBetween 相同，全部复制成功后才删除源文件。
+// src 与 dst 是同一实例时，文件直接使用 Move。
+func MoveBetween(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, opts ...CopyOption) error {
+	hlog.CtxInfof(ctx, "开始跨存储移动: %s -> %s", srcPath, dstPath)
+
+	if err := moveBetween(ctx, src, srcPath, dst, dstPath, applyCopyOptions(opts...)); err != nil {
+		hlog.CtxErrorf(ctx, "跨存储移动失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "跨存储移动成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+func moveBetween(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, options *CopyOptions) error {
+	if options.ServerCopy && sameStorage(src, dst) {
+		metadata, err := src.GetMetadata(ctx, srcPath)
+		if err == nil && !metadata.IsDir {
+			return src.Move(ctx, srcPath, dstPath)
+		}
+	}
+
+	isDir, err := copyBetween(ctx, src, srcPath, dst, dstPath, options)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		return src.DeleteDir(ctx, srcPath)
+	}
+	return src.Delete(ctx, srcPath)
+}
+
+// copyBetween 复制文件或目录，返回 srcPath 是否为目录
+func copyBetween(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, options *CopyOptions) (bool, error) {
+	root := strings.Trim(srcPath, "/")
+	var statErr error
+	if root != "" {
+		metadata, err := src.GetMetadata(ctx, srcPath)
+		if err == nil && !metadata.IsDir {
+			return false, copyFileBetween(ctx, src, srcPath, dst, dstPath, metadata, options)
+		}
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			return false, err
+		}
+		// 对象存储中目录没有元数据，按前缀列出；列不出文件时仍返回不存在
+		statErr = err
+	}
+
+	var files []string
+	err := Walk(ctx, src, root, func(filePath string, _ FileMetadata) error {
+		files = append(files, filePath)
+		return nil
+	})
+	if err != nil && !(statErr != nil && errors.Is(err, ErrNotExist)) {
+		return true, err
+	}
+	if len(files) == 0 {
+		if statErr != nil {
+			return false, statErr
+		}
+		return true, dst.CreateDir(ctx, dstPath)
+	}
+	return true, copyFilesBetween(ctx, src, root, files, dst, dstPath, options)
+}
+
+// copyFilesBetween 并发复制目录中的文件，第一个错误取消其余复制
+func copyFilesBetween(ctx context.Context, src Storage, root string, files []string, dst Storage, dstDir string, options *CopyOptions) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, options.Concurrency)
+	for _, filePath := range files {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			rel := strings.TrimPrefix(strings.TrimPrefix(filePath, root), "/")
+			if err := copyFileBetween(ctx, src, filePath, dst, path.Join(dstDir, rel), nil, options); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// copyFileBetween 复制单个文件，metadata 为 nil 时读取源文件元数据
+func copyFileBetween(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, metadata *FileMetadata, options *CopyOptions) error {
+	if options.ServerCopy {
+		if sameStorage(src, dst) {
+			return dst.Copy(ctx, srcPath, dstPath)
+		}
+		// 装饰器（大小限制、配额）不转发 ServerCopier，只对未包装的目标存储在服务端复制
+		if copier, ok := dst.(ServerCopier); ok {
+			err := copier.CopyFrom(ctx, src, srcPath, dstPath)
+			if !errors.Is(err, ErrNotSupported) {
+				return err
+			}
+		}
+	}
+
+	if metadata == nil {
+		var err error
+		if metadata, err = src.GetMetadata(ctx, srcPath); err != nil {
+			return err
+		}
+	}
+	return streamCopy(ctx, src, srcPath, dst, dstPath, metadata, options)
+}
+
+// streamCopy 边下载边上传，并计算复制内容的 MD5 用于校验
+func streamCopy(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, metadata *FileMetadata, options *CopyOptions) error {
+	var opts []UploadOption
+	if metadata.MIMEType != "" {
+		opts = append(opts, WithContentType(metadata.MIMEType))
+	}
+	if reader, ok := As[ExpirationReader](src); ok {
+		deadline, err := reader.GetExpiration(ctx, srcPath)
+		if err != nil {
+			return err
+		}
+		if !deadline.IsZero() {
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return errExpired(srcPath)
+			}
+			opts = append(opts, WithExpiration(remaining))
+		}
+	}
+
+	reader, err := src.Download(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	counter := &checksumReader{reader: rateLimit(ctx, reader, options.limiter), hash: md5.New()}
+	if err := dst.Upload(ctx, dstPath, counter, opts...); err != nil {
+		return err
+	}
+
+	if err := copyAttributes(ctx, src, srcPath, dst, dstPath, metadata); err != nil {
+		return err
+	}
+	if err := verifyCopy(ctx, dst, dstPath, metadata, counter, options.Verify); err != nil {
+		// 删除校验失败的目标文件，避免留下内容错误的副本
+		if deleteErr := dst.Delete(ctx, dstPath); deleteErr != nil {
+			hlog.CtxErrorf(ctx, "删除校验失败的目标文件失败: %v", deleteErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// copyAttributes 复制标签和修改时间，目标存储不支持时忽略
+func copyAttributes(ctx context.Context, src Storage, srcPath string, dst Storage, dstPath string, metadata *FileMetadata) error {
+	srcTagger, srcOK := As[Tagger](src)
+	dstTagger, dstOK := As[Tagger](dst)
+	if srcOK && dstOK {
+		tags, err := srcTagger.GetTags(ctx, srcPath)
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			return err
+		}
+		if len(tags) > 0 {
+			if err := dstTagger.SetTags(ctx, dstPath, tags); err != nil && !errors.Is(err, ErrNotSupported) {
+				return err
+			}
+		}
+	}
+
+	if !metadata.ModTime.IsZero() {
+		err := dst.UpdateMetadata(ctx, dstPath, &FileMetadata{ModTime: metadata.ModTime})
+		if err != nil && !errors.Is(err, ErrNotSupported) {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyCopy 校验复制的内容与源文件和目标文件一致
+func verifyCopy(ctx context.Context, dst Storage, dstPath string, metadata *FileMetadata, copied *checksumReader, mode VerifyMode) error {
+	if mode == VerifyNone {
+		return nil
+	}
+	sum := hex.EncodeToString(copied.hash.Sum(nil))
+	// 源文件的大小和 MD5 可以发现复制期间源文件被修改或读取不完整
+	if metadata.Size > 0 && copied.n != metadata.Size {
+		return fmt.Errorf("%w: %s, 源文件 %d 字节，复制了 %d 字节", ErrChecksumMismatch, dstPath, metadata.Size, copied.n)
+	}
+	if etag := md5ETag(metadata); etag != "" && etag != sum {
+		return fmt.Errorf("%w: %s, 源文件 MD5 %s，复制内容 MD5 %s", ErrChecksumMismatch, dstPath, etag, sum)
+	}
+
+	written, err := dst.GetMetadata(ctx, dstPath)
+	if err != nil {
+		return err
+	}
+	if written.Size != copied.n {
+		return fmt.Errorf("%w: %s, 目标文件 %d 字节，复制了 %d 字节", ErrChecksumMismatch, dstPath, written.Size, copied.n)
+	}
+	if etag := md5ETag(written); etag != "" && etag != sum {
+		return fmt.Errorf("%w: %s, 目标文件 MD5 %s，复制内容 MD5 %s", ErrChecksumMismatch, dstPath, etag, sum)
+	}
+	if mode != VerifyReadBack {
+		return nil
+	}
+
+	reader, err := dst.Download(ctx, dstPath)
+	if err != nil {
+		return err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	h := md5.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return err
+	}
+	if readBack := hex.EncodeToString(h.Sum(nil)); readBack != sum {
+		return fmt.Errorf("%w: %s, 目标文件 MD5 %s，复制内容 MD5 %s", ErrChecksumMismatch, dstPath, readBack, sum)
+	}
+	return nil
+}
+
+// checksumReader 统计读取的字节数并计算校验和
+type checksumReader struct {
+	reader io.Reader
+	hash   hash.Hash
+	n      int64
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	r.n += int64(n)
+	return n, err
+}
+
+// rateLimitedReader 按带宽限制读取
+type rateLimitedReader struct {
+	ctx     context.Context
+	reader  io.Reader
+	limiter *rate.Limiter
+}
+
+// rateLimit 返回受 limiter 限速的 reader，limiter 为 nil 时原样返回
+func rateLimit(ctx context.Context, reader io.Reader, limiter *rate.Limiter) io.Reader {
+	if limiter == nil {
+		return reader
+	}
+	return &rateLimitedReader{ctx: ctx, reader: reader, limiter: limiter}
+}
+
+func (r *rateLimitedReader) Read(p []byte) (int, error) {
+	if burst := r.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// md5ETag 返回 ETag 表示的 MD5（小写十六进制）。后端没有确认 ETag 为 MD5（如加密对象、WebDAV 服务器），
+// 或 ETag 不是 MD5 格式（如分片上传）时返回空字符串
+func md5ETag(metadata *FileMetadata) string {
+	if !metadata.ETagMD5 {
+		return ""
+	}
+	etag := strings.ToLower(trimETag(strings.TrimPrefix(metadata.ETag, "W/")))
+	if len(etag) != 2*md5.Size {
+		return ""
+	}
+	if _, err := hex.DecodeString(etag); err != nil {
+		return ""
+	}
+	return etag
+}
+
+// sameStorage 判断两个存储是否为同一实例
+func sameStorage(a, b Storage) bool {
+	t := reflect.TypeOf(a)
+	return t == reflect.TypeOf(b) && t.Comparable() && a == b
+}
@@ -0,0 +1,204 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+)
+
+// corruptETagStorage 的 GetMetadata 和 ListDir 返回错误的 MD5 ETag，模拟复制期间源文件被修改。
+// notMD5 为 true 时不声明 ETag 为 MD5，模拟加密对象等 ETag 形似 MD5 但不是 MD5 的情况
+type corruptETagStorage struct {
+	storage.Storage
+	notMD5 bool
+}
+
+func (s *corruptETagStorage) GetMetadata(ctx context.Context, filePath string) (*storage.FileMetadata, error) {
+	metadata, err := s.Storage.GetMetadata(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	s.corrupt(metadata)
+	return metadata, nil
+}
+
+func (s *corruptETagStorage) ListDir(ctx context.Context, dirPath string) ([]storage.FileMetadata, error) {
+	files, err := s.Storage.ListDir(ctx, dirPath)
+	for i := range files {
+		s.corrupt(&files[i])
+	}
+	return files, err
+}
+
+func (s *corruptETagStorage) corrupt(metadata *storage.FileMetadata) {
+	metadata.ETag = strings.Repeat("0", 32)
+	if s.notMD5 {
+		metadata.ETagMD5 = false
+	}
+}
+
+func TestCopyBetween_File(t *testing.T) {
+	src := storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: t.TempDir()})
+	dst := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+
+	modTime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := src.Upload(ctx, "reports/q1.dat", strings.NewReader("a,b\n1,2\n"), storage.WithContentType("text/csv"), storage.WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := src.UpdateMetadata(ctx, "reports/q1.dat", &storage.FileMetadata{ModTime: modTime}); err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+
+	if err := storage.CopyBetween(ctx, src, "reports/q1.dat", dst, "archive/q1.dat"); err != nil {
+		t.Fatalf("CopyBetween failed: %v", err)
+	}
+	if got := readString(t)(dst.Download(ctx, "archive/q1.dat")); got != "a,b\n1,2\n" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	metadata, err := dst.GetMetadata(ctx, "archive/q1.dat")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.MIMEType != "text/csv" || !metadata.ModTime.Equal(modTime) {
+		t.Fatalf("Metadata not preserved: %+v", metadata)
+	}
+	deadline, err := dst.(storage.ExpirationReader).GetExpiration(ctx, "archive/q1.dat")
+	if err != nil || deadline.IsZero() || time.Until(deadline) > time.Hour {
+		t.Fatalf("Expiration not preserved: %v, %v", deadline, err)
+	}
+
+	// 移动后删除源文件
+	if err := storage.MoveBetween(ctx, src, "reports/q1.dat", dst, "moved/q1.dat"); err != nil {
+		t.Fatalf("MoveBetween failed: %v", err)
+	}
+	if exists, _ := src.Exists(ctx, "reports/q1.dat"); exists {
+		t.Fatal("Source file should be deleted after move")
+	}
+	if err := storage.CopyBetween(ctx, src, "reports/missing.dat", dst, "missing.dat"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestCopyBetween_Dir(t *testing.T) {
+	src := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	dst := storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: t.TempDir()})
+	ctx := context.Background()
+
+	files := map[string]string{}
+	for i := 0; i < 10; i++ {
+		files[fmt.Sprintf("site/assets/%d.txt", i)] = strings.Repeat("x", i)
+	}
+	files["site/index.html"] = "<html></html>"
+	for name, content := range files {
+		if err := src.Upload(ctx, name, strings.NewReader(content)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	if err := storage.MoveBetween(ctx, src, "site", dst, "public", storage.WithCopyConcurrency(3), storage.WithCopyVerify(storage.VerifyReadBack)); err != nil {
+		t.Fatalf("MoveBetween failed: %v", err)
+	}
+	for name, content := range files {
+		dstPath := "public" + strings.TrimPrefix(name, "site")
+		if got := readString(t)(dst.Download(ctx, dstPath)); got != content {
+			t.Fatalf("Unexpected content of %s: %q", dstPath, got)
+		}
+	}
+	if entries, _ := src.ListDir(ctx, "site"); len(entries) != 0 {
+		t.Fatalf("Source directory should be deleted, got %+v", entries)
+	}
+}
+
+func TestCopyBetween_Verify(t *testing.T) {
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	dst := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "a.txt", strings.NewReader("content")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := backend.(storage.Tagger).SetTags(ctx, "a.txt", map[string]string{"team": "infra"}); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+
+	src := &corruptETagStorage{Storage: backend}
+	if err := storage.CopyBetween(ctx, src, "a.txt", dst, "a.txt"); !errors.Is(err, storage.ErrChecksumMismatch) {
+		t.Fatalf("Expected ErrChecksumMismatch, got %v", err)
+	}
+	if exists, _ := dst.Exists(ctx, "a.txt"); exists {
+		t.Fatal("Mismatched copy should be deleted")
+	}
+	if err := storage.CopyBetween(ctx, src, "a.txt", dst, "a.txt", storage.WithCopyVerify(storage.VerifyNone)); err != nil {
+		t.Fatalf("CopyBetween without verification failed: %v", err)
+	}
+	// 后端没有声明 ETag 为 MD5 时不比较 ETag
+	if err := storage.CopyBetween(ctx, &corruptETagStorage{Storage: backend, notMD5: true}, "a.txt", dst, "a.txt"); err != nil {
+		t.Fatalf("CopyBetween with non-MD5 ETag failed: %v", err)
+	}
+
+	// 保留标签
+	if err := storage.CopyBetween(ctx, backend, "a.txt", dst, "a.txt"); err != nil {
+		t.Fatalf("CopyBetween failed: %v", err)
+	}
+	if tags, _ := dst.(storage.Tagger).GetTags(ctx, "a.txt"); tags["team"] != "infra" {
+		t.Fatalf("Tags not preserved: %v", tags)
+	}
+}
+
+func TestCopyBetween_ServerSide(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+	server.CreateBucket("source")
+	server.CreateBucket("backup")
+	newS3 := func(bucket string) storage.Storage {
+		return storage.NewS3Storage(storage.S3StorageConfig{
+			Endpoint:        server.URL,
+			AccessKeyID:     "test",
+			AccessKeySecret: "test",
+			Region:          "us-east-1",
+			Bucket:          bucket,
+		})
+	}
+	src, dst := newS3("source"), newS3("backup")
+	ctx := context.Background()
+	if err := src.Upload(ctx, "data/a.json", strings.NewReader(`{"a":1}`), storage.WithContentType("application/json")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 源对象无法下载时，同一服务内仍可以在服务端复制
+	server.InjectFault(storagetest.Fault{Method: http.MethodGet, Path: "/source/data/a.json", Status: http.StatusForbidden})
+	if err := storage.CopyBetween(ctx, src, "data", dst, "data"); err != nil {
+		t.Fatalf("CopyBetween failed: %v", err)
+	}
+	if err := storage.CopyBetween(ctx, src, "data/a.json", dst, "b.json", storage.WithServerCopy(false)); err == nil {
+		t.Fatal("Expected streaming copy to fail")
+	}
+	server.ClearFaults()
+
+	metadata, err := dst.GetMetadata(ctx, "data/a.json")
+	if err != nil || metadata.MIMEType != "application/json" {
+		t.Fatalf("Unexpected metadata: %+v, %v", metadata, err)
+	}
+
+	// 不同凭证的存储之间改为流式复制
+	other := storage.NewS3Storage(storage.S3StorageConfig{
+		Endpoint:        server.URL,
+		AccessKeyID:     "other",
+		AccessKeySecret: "other",
+		Region:          "us-east-1",
+		Bucket:          "backup",
+	})
+	if err := storage.CopyBetween(ctx, src, "data/a.json", other, "b.json"); err != nil {
+		t.Fatalf("Streaming CopyBetween failed: %v", err)
+	}
+	if metadata, err := dst.GetMetadata(ctx, "b.json"); err != nil || metadata.MIMEType != "application/json" {
+		t.Fatalf("Unexpected metadata: %+v, %v", metadata, err)
+	}
+}
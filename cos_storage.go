@@ -0,0 +1,532 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// cosDeleteBatchSize COS 单次批量删除最多支持的对象数
+const cosDeleteBatchSize = 1000
+
+// COSStorageConfig 腾讯云 COS 存储配置
+type COSStorageConfig struct {
+	SecretID  string `json:"secret_id"`  // SecretId
+	SecretKey string `json:"secret_key"` // SecretKey
+	Region    string `json:"region"`     // 地域，如 ap-guangzhou
+	Bucket    string `json:"bucket"`     // 存储桶名称，可以带或不带 -<AppID> 后缀
+	AppID     string `json:"app_id"`     // 账号 AppID，Bucket 不带后缀时自动拼接
+	BaseDir   string `json:"base_dir"`   // 存储基础目录
+	Endpoint  string `json:"endpoint"`   // 自定义存储桶访问地址（如 CDN 或全球加速域名），为空时根据 Bucket 和 Region 生成
+
+	// Transport 自定义底层 HTTP 传输（如代理或测试），为空时使用 http.DefaultTransport
+	Transport http.RoundTripper `json:"-"`
+}
+
+// bucketName 返回带 AppID 后缀的完整存储桶名称
+func (c COSStorageConfig) bucketName() string {
+	if c.AppID == "" || strings.HasSuffix(c.Bucket, "-"+c.AppID) {
+		return c.Bucket
+	}
+	return c.Bucket + "-" + c.AppID
+}
+
+// COSStorage 腾讯云 COS 存储实现
+type COSStorage struct {
+	config COSStorageConfig
+	client *cos.Client
+}
+
+// NewCOSStorage 创建新的COS存储实例
+func NewCOSStorage(config COSStorageConfig) Storage {
+	var bucketURL *url.URL
+	var err error
+	if config.Endpoint != "" {
+		bucketURL, err = url.Parse(config.Endpoint)
+	} else {
+		bucketURL, err = cos.NewBucketURL(config.bucketName(), config.Region, true)
+	}
+	if err != nil {
+		hlog.Errorf("解析COS存储桶地址失败: %v", err)
+		return nil
+	}
+
+	client := cos.NewClient(&cos.BaseURL{BucketURL: bucketURL}, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:  config.SecretID,
+			SecretKey: config.SecretKey,
+			Transport: config.Transport,
+		},
+	})
+
+	// 检查Bucket是否存在，如果不存在则创建
+	exists, err := client.Bucket.IsExist(context.Background())
+	if err != nil {
+		hlog.Errorf("检查COS Bucket存在性失败: %v", err)
+		return nil
+	}
+	if !exists {
+		if _, err := client.Bucket.Put(context.Background(), nil); err != nil {
+			hlog.Errorf("创建COS Bucket失败: %v", err)
+			return nil
+		}
+		hlog.Infof("成功创建新COS Bucket: %s", config.bucketName())
+	}
+
+	return &COSStorage{
+		config: config,
+		client: client,
+	}
+}
+
+// Upload 实现COS文件上传，支持设置有效期
+func (s *COSStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到COS: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	headerOpts := &cos.ObjectPutHeaderOptions{ContentType: contentType}
+
+	// 如果设置了有效期，记录删除截止时间并打上生命周期标签（Expires 头仅影响缓存）
+	if options.Expiration > 0 {
+		expiration := expirationDeadline(options.Expiration)
+		headerOpts.Expires = expiration.UTC().Format(http.TimeFormat)
+		headerOpts.XCosMetaXXX = &http.Header{}
+		headerOpts.XCosMetaXXX.Set("x-cos-meta-"+ExpiresAtMetaKey, formatExpiresAt(expiration))
+		headerOpts.XOptionHeader = &http.Header{}
+		headerOpts.XOptionHeader.Set("x-cos-tagging", url.Values{ExpireDaysTagKey: {expireDaysTagValue(options.Expiration)}}.Encode())
+		hlog.CtxDebugf(ctx, "设置COS文件过期时间: %v", expiration)
+	}
+
+	// 无法获取长度的流由 SDK 使用分块传输编码上传
+	_, err = s.client.Object.Put(ctx, fullKey, reader, &cos.ObjectPutOptions{ObjectPutHeaderOptions: headerOpts})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "COS上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "COS文件上传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从COS下载文件（流式下载）
+func (s *COSStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从COS下载文件: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	body, err := s.getObject(ctx, fullKey, filePath, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "COS获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "COS文件下载已启动: %s", filePath)
+	return body, nil // 返回原始的Reader，由调用方负责关闭
+}
+
+// DownloadRange 实现从COS下载文件（支持断点续传）
+func (s *COSStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从COS下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	body, err := s.getObject(ctx, fullKey, filePath, &cos.ObjectGetOptions{
+		Range: fmt.Sprintf("bytes=%d-%d", offset, offset+size-1),
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "COS获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "COS文件断点续传下载已启动: %s", filePath)
+	return body, nil
+}
+
+// Delete 实现COS文件删除
+func (s *COSStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从COS删除文件: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	if _, err := s.client.Object.Delete(ctx, fullKey); err != nil {
+		hlog.CtxErrorf(ctx, "COS删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "COS文件删除成功: %s", filePath)
+	return nil
+}
+
+// Rename 实现COS文件重命名（复制+删除）
+func (s *COSStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在COS中重命名文件: %s -> %s", oldPath, newPath)
+
+	if err := s.copyObject(ctx, oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "COS复制文件失败: %v", err)
+		return err
+	}
+
+	// 删除旧文件
+	if err := s.Delete(ctx, oldPath); err != nil {
+		hlog.CtxErrorf(ctx, "COS删除旧文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "COS文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现COS文件移动（与重命名相同的操作）
+func (s *COSStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现COS文件复制（服务端复制）
+func (s *COSStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在COS中复制文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.copyObject(ctx, srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "COS复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "COS文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Exists 实现检查COS文件是否存在（已过期的文件视为不存在）
+func (s *COSStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+	resp, err := s.client.Object.Head(ctx, fullKey, nil)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !isExpired(cosExpiresAt(resp.Header)), nil
+}
+
+// CreateDir 实现COS目录创建。
+// 对象存储中目录是隐式的，无需显式创建占位对象。
+func (s *COSStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxDebugf(ctx, "COS 目录无需显式创建: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 从COS中删除目录（递归删除目录下所有对象）
+func (s *COSStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始从COS中删除目录及其所有内容: %s", dirPath)
+
+	dirPath = ensureOSSDirPath(dirPath)
+	fullKey := joinStorageKey(s.config.BaseDir, dirPath)
+
+	var marker string
+	for {
+		result, _, err := s.client.Bucket.Get(ctx, &cos.BucketGetOptions{
+			Prefix:  fullKey,
+			Marker:  marker,
+			MaxKeys: cosDeleteBatchSize,
+		})
+		if err != nil {
+			hlog.CtxErrorf(ctx, "列出COS目录内容失败: %v", err)
+			return err
+		}
+
+		// object.Key 已经是完整路径
+		keys := make([]string, 0, len(result.Contents))
+		for _, object := range result.Contents {
+			keys = append(keys, object.Key)
+		}
+		if err := s.deleteKeys(ctx, keys); err != nil {
+			hlog.CtxErrorf(ctx, "删除COS对象失败: %v", err)
+			return err
+		}
+
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	hlog.CtxInfof(ctx, "成功从COS中删除目录及其所有内容: %s", fullKey)
+	return nil
+}
+
+// ListDir 列出COS目录内容（仅当前层级，子目录名以 / 结尾）
+func (s *COSStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出COS目录内容: %s", dirPath)
+
+	// 必须保证 prefix 以 / 结尾，否则 Delimiter 分组会把当前目录自身也作为 CommonPrefix 返回。
+	dirPath = ensureOSSDirPath(dirPath)
+	fullKey := joinStorageKey(s.config.BaseDir, dirPath)
+
+	var fileMetas []FileMetadata
+	var marker string
+	for {
+		result, _, err := s.client.Bucket.Get(ctx, &cos.BucketGetOptions{
+			Prefix:    fullKey,
+			Delimiter: "/",
+			Marker:    marker,
+		})
+		if err != nil {
+			hlog.CtxErrorf(ctx, "获取COS目录内容失败: %v", err)
+			return nil, err
+		}
+
+		// 处理普通对象
+		for _, object := range result.Contents {
+			name := strings.TrimPrefix(object.Key, fullKey)
+			if name == "" {
+				continue
+			}
+			modTime, _ := time.Parse(time.RFC3339, object.LastModified)
+			fileMetas = append(fileMetas, FileMetadata{
+				Name:     name,
+				Size:     object.Size,
+				ModTime:  modTime,
+				IsDir:    false,
+				MIMEType: mimeTypeByName(name),
+				ETag:     trimETag(object.ETag),
+			})
+		}
+
+		// 处理子目录（CommonPrefixes）
+		for _, prefix := range result.CommonPrefixes {
+			if name := strings.TrimPrefix(prefix, fullKey); name != "" {
+				fileMetas = append(fileMetas, FileMetadata{Name: name, IsDir: true})
+			}
+		}
+
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	hlog.CtxInfof(ctx, "成功列出COS目录内容: %s", dirPath)
+	return fileMetas, nil
+}
+
+// GetMetadata 获取COS文件元数据
+func (s *COSStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取COS文件元数据: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	resp, err := s.client.Object.Head(ctx, fullKey, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取COS文件信息失败: %v", err)
+		return nil, cosError(err)
+	}
+	if isExpired(cosExpiresAt(resp.Header)) {
+		return nil, errExpired(filePath)
+	}
+
+	size, _ := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	fileMeta := &FileMetadata{
+		Name:     filePath,
+		Size:     size,
+		ModTime:  modTime,
+		IsDir:    false,
+		MIMEType: contentTypeOrDetect(resp.Header.Get("Content-Type"), filePath),
+		ETag:     trimETag(resp.Header.Get("ETag")),
+	}
+
+	hlog.CtxInfof(ctx, "成功获取COS文件元数据: %s", filePath)
+	return fileMeta, nil
+}
+
+// UpdateMetadata 更新COS文件元数据（COS不支持直接更新元数据，除非重新上传文件）
+func (s *COSStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新COS文件元数据: %s", filePath)
+	hlog.CtxErrorf(ctx, "COS不支持直接更新元数据")
+	return fmt.Errorf("COS不支持直接更新元数据: %w", ErrNotSupported)
+}
+
+// GetExpiration 读取COS文件的删除截止时间（不检查是否已过期）
+func (s *COSStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	resp, err := s.client.Object.Head(ctx, fullKey, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取COS文件信息失败: %v", err)
+		return time.Time{}, cosError(err)
+	}
+	return cosExpiresAt(resp.Header), nil
+}
+
+// EnsureExpirationLifecycle 在COS存储桶上安装按有效期标签删除对象的生命周期规则
+func (s *COSStorage) EnsureExpirationLifecycle(ctx context.Context, days ...int) error {
+	hlog.CtxInfof(ctx, "开始安装COS生命周期规则: %v", days)
+
+	days, err := normalizeExpireDays(days)
+	if err != nil {
+		return err
+	}
+
+	// PutLifecycle 会覆盖整个配置，需要保留非本包安装的规则
+	var rules []cos.BucketLifecycleRule
+	current, _, err := s.client.Bucket.GetLifecycle(ctx)
+	if err != nil {
+		if !cos.IsNotFoundError(err) {
+			hlog.CtxErrorf(ctx, "获取COS生命周期规则失败: %v", err)
+			return err
+		}
+	} else {
+		for _, rule := range current.Rules {
+			if !isExpirationRuleID(rule.ID) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	for _, d := range days {
+		rules = append(rules, cos.BucketLifecycleRule{
+			ID:     expirationRuleID(d),
+			Status: "Enabled",
+			Filter: &cos.BucketLifecycleFilter{
+				Tag: &cos.BucketTaggingTag{Key: ExpireDaysTagKey, Value: strconv.Itoa(d)},
+			},
+			Expiration: &cos.BucketLifecycleExpiration{Days: d},
+		})
+	}
+
+	if _, err := s.client.Bucket.PutLifecycle(ctx, &cos.BucketPutLifecycleOptions{Rules: rules}); err != nil {
+		hlog.CtxErrorf(ctx, "安装COS生命周期规则失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "COS生命周期规则安装成功: %v", days)
+	return nil
+}
+
+// Presign 生成COS预签名URL，仅支持 GET 和 PUT
+func (s *COSStorage) Presign(ctx context.Context, method string, filePath string, expires time.Duration) (string, error) {
+	if method != http.MethodGet && method != http.MethodPut {
+		return "", fmt.Errorf("COS预签名不支持 %s 方法: %w", method, ErrNotSupported)
+	}
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	presignedURL, err := s.client.Object.GetPresignedURL(ctx, method, fullKey, s.config.SecretID, s.config.SecretKey, expires, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "生成COS预签名URL失败: %v", err)
+		return "", err
+	}
+	return presignedURL.String(), nil
+}
+
+// BatchUpload 实现COS批量上传
+func (s *COSStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到COS", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现COS批量下载（流式下载）
+func (s *COSStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个COS文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现COS批量删除，使用批量删除接口每次最多删除1000个对象
+func (s *COSStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个COS文件", len(filePaths))
+
+	keys := make([]string, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		keys = append(keys, filepath.Join(s.config.BaseDir, filePath))
+	}
+	if err := s.deleteKeys(ctx, keys); err != nil {
+		hlog.CtxErrorf(ctx, "COS批量删除文件失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// deleteKeys 按批调用批量删除接口删除完整路径的对象，不存在的对象视为删除成功
+func (s *COSStorage) deleteKeys(ctx context.Context, keys []string) error {
+	for start := 0; start < len(keys); start += cosDeleteBatchSize {
+		end := min(start+cosDeleteBatchSize, len(keys))
+		objects := make([]cos.Object, 0, end-start)
+		for _, key := range keys[start:end] {
+			objects = append(objects, cos.Object{Key: key})
+		}
+
+		result, _, err := s.client.Object.DeleteMulti(ctx, &cos.ObjectDeleteMultiOptions{Quiet: true, Objects: objects})
+		if err != nil {
+			return err
+		}
+		if len(result.Errors) > 0 {
+			failed := result.Errors[0]
+			return fmt.Errorf("删除COS对象 %s 失败: %s %s", failed.Key, failed.Code, failed.Message)
+		}
+	}
+	return nil
+}
+
+// copyObject 在存储桶内服务端复制对象
+func (s *COSStorage) copyObject(ctx context.Context, srcPath, dstPath string) error {
+	srcKey := filepath.Join(s.config.BaseDir, srcPath)
+	dstKey := filepath.Join(s.config.BaseDir, dstPath)
+
+	// 复制源以 URL 形式传递，键中的 "?"、"#"、"%"、空格等需要逐段转义。
+	// SDK 的 Object.Copy 会把键中的 "?" 当作版本号分隔符，因此直接发送带复制源的 PUT 请求
+	segments := strings.Split(srcKey, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	header := http.Header{}
+	header.Set("x-cos-copy-source", s.client.BaseURL.BucketURL.Host+"/"+strings.Join(segments, "/"))
+	_, err := s.client.Object.Put(ctx, dstKey, strings.NewReader(""), &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{XOptionHeader: &header},
+	})
+	return cosError(err)
+}
+
+// getObject 获取对象内容，已过期的对象返回 ErrNotExist
+func (s *COSStorage) getObject(ctx context.Context, fullKey, filePath string, opt *cos.ObjectGetOptions) (io.ReadCloser, error) {
+	resp, err := s.client.Object.Get(ctx, fullKey, opt)
+	if err != nil {
+		return nil, cosError(err)
+	}
+	if isExpired(cosExpiresAt(resp.Header)) {
+		resp.Body.Close()
+		return nil, errExpired(filePath)
+	}
+	return resp.Body, nil
+}
+
+// cosError 将对象不存在的响应（404）转换为 ErrNotExist
+func cosError(err error) error {
+	if cos.IsNotFoundError(err) {
+		return &notExistError{err: err}
+	}
+	return err
+}
+
+// cosExpiresAt 从COS响应头中解析删除截止时间
+func cosExpiresAt(header http.Header) time.Time {
+	return parseExpiresAt(header.Get("x-cos-meta-" + ExpiresAtMetaKey))
+}
@@ -0,0 +1,104 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+)
+
+func TestCOSStorage_Backend(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+
+	// Bucket 不带 AppID 后缀时自动拼接，存储桶不存在时自动创建
+	s := storage.NewCOSStorage(storage.COSStorageConfig{
+		SecretID:  "id",
+		SecretKey: "key",
+		Region:    "ap-guangzhou",
+		Bucket:    "photos",
+		AppID:     "1250000000",
+		BaseDir:   "base",
+		Endpoint:  "http://photos-1250000000.cos.ap-guangzhou.myqcloud.com",
+		Transport: server.Transport(),
+	})
+	if s == nil {
+		t.Fatal("NewCOSStorage returned nil")
+	}
+	ctx := context.Background()
+
+	var paths []string
+	for i := 0; i < 5; i++ {
+		filePath := fmt.Sprintf("dir/%d.txt", i)
+		if err := s.Upload(ctx, filePath, strings.NewReader("data")); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+		paths = append(paths, filePath)
+	}
+	if keys := server.Keys("photos-1250000000"); len(keys) != 5 || keys[0] != "base/dir/0.txt" {
+		t.Fatalf("Unexpected keys in bucket: %v", keys)
+	}
+
+	// 批量删除使用 DeleteObjects，不存在的文件不报错
+	if err := s.BatchDelete(ctx, append(paths[:3], "dir/missing.txt")); err != nil {
+		t.Fatalf("BatchDelete failed: %v", err)
+	}
+	if keys := server.Keys("photos-1250000000"); len(keys) != 2 {
+		t.Fatalf("Expected 2 remaining keys, got %v", keys)
+	}
+
+	// 复制源中的特殊字符逐段转义
+	special := "dir/a b?c#d%e 文件.txt"
+	if err := s.Upload(ctx, special, strings.NewReader("special")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Rename(ctx, special, "dir/renamed.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "dir/renamed.txt")); got != "special" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if err := s.Copy(ctx, "dir/renamed.txt", special); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, special)); got != "special" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if err := s.BatchDelete(ctx, []string{special, "dir/renamed.txt"}); err != nil {
+		t.Fatalf("BatchDelete failed: %v", err)
+	}
+
+	// 预签名URL包含COS签名参数
+	presigner, ok := storage.As[storage.Presigner](s)
+	if !ok {
+		t.Fatal("COSStorage should implement Presigner")
+	}
+	url, err := presigner.Presign(ctx, http.MethodGet, "dir/3.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("Presign failed: %v", err)
+	}
+	if !strings.HasPrefix(url, "http://photos-1250000000.cos.ap-guangzhou.myqcloud.com/base/dir/3.txt?") || !strings.Contains(url, "q-signature=") {
+		t.Fatalf("Unexpected presigned URL: %s", url)
+	}
+	if _, err := presigner.Presign(ctx, http.MethodDelete, "dir/3.txt", time.Minute); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+
+	// 生命周期规则
+	lifecycle, ok := storage.As[storage.LifecycleManager](s)
+	if !ok {
+		t.Fatal("COSStorage should implement LifecycleManager")
+	}
+	if err := lifecycle.EnsureExpirationLifecycle(ctx, 7); err != nil {
+		t.Fatalf("EnsureExpirationLifecycle failed: %v", err)
+	}
+	if err := lifecycle.EnsureExpirationLifecycle(ctx, 7, 30); err != nil {
+		t.Fatalf("EnsureExpirationLifecycle failed on existing rules: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+// ErrNotExist 表示文件不存在（或已过期尚未被清理）。
+// 与 fs.ErrNotExist 等价，因此本地存储返回的 os 错误同样可以用 errors.Is 判断。
+var ErrNotExist = fs.ErrNotExist
+
+// errExpired 构造文件已过期的错误，对调用方表现为 ErrNotExist
+func errExpired(filePath string) error {
+	return fmt.Errorf("文件已过期: %s: %w", filePath, ErrNotExist)
+}
+
+// notExistError 包装后端返回的对象不存在错误（如 S3 的 NoSuchKey），
+// 使 errors.Is(err, ErrNotExist) 成立，同时保留原始错误信息
+type notExistError struct {
+	err error
+}
+
+func (e *notExistError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notExistError) Unwrap() error {
+	return e.err
+}
+
+// Is 使 errors.Is(err, ErrNotExist) 成立
+func (e *notExistError) Is(target error) bool {
+	return target == ErrNotExist
+}
+
+// ErrTooLarge 表示上传的文件超过大小限制，可用 errors.Is 判断 *SizeLimitError
+var ErrTooLarge = errors.New("文件大小超过限制")
+
+// ErrQuotaExceeded 表示操作会超出配额硬限制，可用 errors.Is 判断 *QuotaExceededError
+var ErrQuotaExceeded = errors.New("超出存储配额")
+
+// ErrChecksumMismatch 表示复制后目标文件的大小或校验和与源文件不一致
+var ErrChecksumMismatch = errors.New("文件校验和不一致")
+
+// SizeLimitError 上传文件超过 Types.MaxSize 时返回的错误
+type SizeLimitError struct {
+	Path  string // 文件路径
+	Limit int64  // 大小限制（字节）
+}
+
+func (e *SizeLimitError) Error() string {
+	return fmt.Sprintf("文件大小超过限制: %s, 限制 %d 字节", e.Path, e.Limit)
+}
+
+// Is 使 errors.Is(err, ErrTooLarge) 成立
+func (e *SizeLimitError) Is(target error) bool {
+	return target == ErrTooLarge
+}
+
+// QuotaExceededError 操作超出配额硬限制时返回的错误
+type QuotaExceededError struct {
+	Prefix string // 配额前缀
+	Kind   string // 超出的限制类型: bytes 或 objects
+	Limit  int64  // 硬限制
+	Usage  int64  // 操作完成后的用量
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("超出存储配额: 前缀 %s 的 %s 用量 %d 超过限制 %d", e.Prefix, e.Kind, e.Usage, e.Limit)
+}
+
+// Is 使 errors.Is(err, ErrQuotaExceeded) 成立
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// ErrNotSupported 表示存储后端不支持该操作
+var ErrNotSupported = errors.New("存储后端不支持该操作")
+
+// ErrPreconditionFailed 表示条件写入的前置条件不满足
+var ErrPreconditionFailed = errors.New("前置条件不满足")
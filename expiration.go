@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ExpiresAtMetaKey 记录对象删除截止时间（RFC3339）的用户元数据键
+	ExpiresAtMetaKey = "expires-at"
+	// ExpireDaysTagKey 记录对象有效期天数的对象标签键，供存储桶生命周期规则匹配
+	ExpireDaysTagKey = "storage-expire-days"
+
+	// expirationRulePrefix 本包安装的生命周期规则ID前缀，用于与用户自定义规则区分
+	expirationRulePrefix = "storage-expire-"
+)
+
+// ExpirationReader 由能够读取对象删除截止时间的存储实现。
+// 与 GetMetadata 不同，已过期的对象不会返回 ErrNotExist，Janitor 依赖它扫描过期对象。
+type ExpirationReader interface {
+	// GetExpiration 返回对象的删除截止时间，未设置有效期时返回零值
+	GetExpiration(ctx context.Context, filePath string) (time.Time, error)
+}
+
+// LifecycleManager 由支持存储桶生命周期规则的存储实现（S3、MinIO、OSS、COS）。
+// 规则按 ExpireDaysTagKey 标签匹配，由存储服务在到期后自动删除对象，
+// 精确到秒的删除仍由 Janitor 负责。
+type LifecycleManager interface {
+	// EnsureExpirationLifecycle 为每个天数安装一条生命周期规则，保留存储桶中已有的其他规则
+	EnsureExpirationLifecycle(ctx context.Context, days ...int) error
+}
+
+// expirationDeadline 根据有效期计算删除截止时间
+func expirationDeadline(ttl time.Duration) time.Time {
+	return expirationDeadlineAt(time.Now(), ttl)
+}
+
+// expirationDeadlineAt 以 now 为起点计算删除截止时间（精确到秒）
+func expirationDeadlineAt(now time.Time, ttl time.Duration) time.Time {
+	return now.Add(ttl).UTC().Truncate(time.Second)
+}
+
+// formatExpiresAt 格式化删除截止时间用于写入元数据
+func formatExpiresAt(deadline time.Time) string {
+	return deadline.UTC().Format(time.RFC3339)
+}
+
+// parseExpiresAt 解析元数据中的删除截止时间，无法解析时返回零值
+func parseExpiresAt(value string) time.Time {
+	if value == "" {
+		return time.Time{}
+	}
+	deadline, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return deadline
+}
+
+// expireDays 将有效期向上取整为天数，生命周期规则只能精确到天
+func expireDays(ttl time.Duration) int {
+	days := int((ttl + 24*time.Hour - 1) / (24 * time.Hour))
+	if days < 1 {
+		days = 1
+	}
+	return days
+}
+
+// isExpired 判断删除截止时间是否已过
+func isExpired(deadline time.Time) bool {
+	return isExpiredAt(deadline, time.Now())
+}
+
+// isExpiredAt 判断删除截止时间在 now 时是否已过
+func isExpiredAt(deadline time.Time, now time.Time) bool {
+	return !deadline.IsZero() && !now.Before(deadline)
+}
+
+// lookupMetadata 不区分大小写地读取用户元数据（各SDK返回的键大小写不一致）
+func lookupMetadata(metadata map[string]string, key string) string {
+	if v, ok := metadata[key]; ok {
+		return v
+	}
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// expirationRuleID 返回指定天数对应的生命周期规则ID
+func expirationRuleID(days int) string {
+	return fmt.Sprintf("%s%dd", expirationRulePrefix, days)
+}
+
+// isExpirationRuleID 判断生命周期规则是否由本包安装
+func isExpirationRuleID(id string) bool {
+	return strings.HasPrefix(id, expirationRulePrefix)
+}
+
+// normalizeExpireDays 校验并去重生命周期天数
+func normalizeExpireDays(days []int) ([]int, error) {
+	seen := make(map[int]bool, len(days))
+	result := make([]int, 0, len(days))
+	for _, d := range days {
+		if d <= 0 {
+			return nil, fmt.Errorf("生命周期天数必须大于0: %d", d)
+		}
+		if !seen[d] {
+			seen[d] = true
+			result = append(result, d)
+		}
+	}
+	sort.Ints(result)
+	return result, nil
+}
+
+// expireDaysTagValue 返回写入对象标签的天数值
+func expireDaysTagValue(ttl time.Duration) string {
+	return strconv.Itoa(expireDays(ttl))
+}
@@ -8,13 +8,27 @@ import (
 )
 
 type Types struct {
-	Mode       StorageType        `yaml:"mode" json:"mode"`               // local, s3, minio, oss, cos,
-	AssignMode StorageType        `yaml:"assign_mode" json:"assign_mode"` // local, s3, minio, oss, cos,
-	MaxSize    int64              `yaml:"max_size" json:"max_size"`
-	Local      LocalStorageConfig `json:"local"`
-	Minio      MinIOStorageConfig `json:"minio"`
-	Oss        OSSStorageConfig   `json:"oss"`
-	S3         S3StorageConfig    `json:"s3"`
+	Mode       StorageType            `yaml:"mode" json:"mode"`               // local, s3, minio, oss, cos,
+	AssignMode StorageType            `yaml:"assign_mode" json:"assign_mode"` // local, s3, minio, oss, cos,
+	MaxSize    int64                  `yaml:"max_size" json:"max_size"`       // 单个文件大小限制（字节），0 表示不限制
+	MIMETypes  map[string]string      `yaml:"mime_types" json:"mime_types"`   // 自定义扩展名到 MIME 类型的映射，如 ".md": "text/markdown"
+	Local      LocalStorageConfig     `json:"local"`
+	Minio      MinIOStorageConfig     `json:"minio"`
+	Oss        OSSStorageConfig       `json:"oss"`
+	S3         S3StorageConfig        `json:"s3"`
+	Cos        COSStorageConfig       `json:"cos"`
+	Azure      AzureBlobStorageConfig `json:"azure"`
+	Gcs        GCSStorageConfig       `json:"gcs"`
+	Sftp       SFTPStorageConfig      `json:"sftp"`
+	Webdav     WebDAVStorageConfig    `json:"webdav"`
+	Ftp        FTPStorageConfig       `json:"ftp"`
+	Sql        SQLStorageConfig       `json:"sql"`
+	Archive    ArchiveStorageConfig   `json:"archive"`
+	Http       HTTPStorageConfig      `json:"http"`
+	Remote     RemoteStorageConfig    `json:"remote"`
+	Grpc       GRPCStorageConfig      `json:"grpc"`
+	Mem        MemoryStorageConfig    `json:"mem"`
+	Quota      QuotaConfig            `yaml:"quota" json:"quota"` // 按前缀（租户）的配额限制
 }
 
 // StorageOption 定义存储选项函数类型
@@ -52,6 +66,102 @@ func WithS3Config(config S3StorageConfig) StorageOption {
 	}
 }
 
+// WithCOSConfig 设置COS存储配置选项
+func WithCOSConfig(config COSStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Cos = config
+		s.Mode = COS
+	}
+}
+
+// WithAzureConfig 设置Azure Blob存储配置选项
+func WithAzureConfig(config AzureBlobStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Azure = config
+		s.Mode = Azure
+	}
+}
+
+// WithGCSConfig 设置GCS存储配置选项
+func WithGCSConfig(config GCSStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Gcs = config
+		s.Mode = GCS
+	}
+}
+
+// WithSFTPConfig 设置SFTP存储配置选项
+func WithSFTPConfig(config SFTPStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Sftp = config
+		s.Mode = SFTP
+	}
+}
+
+// WithWebDAVConfig 设置WebDAV存储配置选项
+func WithWebDAVConfig(config WebDAVStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Webdav = config
+		s.Mode = WebDAV
+	}
+}
+
+// WithFTPConfig 设置FTP存储配置选项
+func WithFTPConfig(config FTPStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Ftp = config
+		s.Mode = FTP
+	}
+}
+
+// WithSQLConfig 设置数据库存储配置选项
+func WithSQLConfig(config SQLStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Sql = config
+		s.Mode = SQL
+	}
+}
+
+// WithArchiveConfig 设置归档存储配置选项
+func WithArchiveConfig(config ArchiveStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Archive = config
+		s.Mode = Archive
+	}
+}
+
+// WithHTTPConfig 设置HTTP存储配置选项
+func WithHTTPConfig(config HTTPStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Http = config
+		s.Mode = HTTP
+	}
+}
+
+// WithRemoteConfig 设置远程存储配置选项
+func WithRemoteConfig(config RemoteStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Remote = config
+		s.Mode = Remote
+	}
+}
+
+// WithGRPCConfig 设置 gRPC 存储配置选项
+func WithGRPCConfig(config GRPCStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Grpc = config
+		s.Mode = GRPC
+	}
+}
+
+// WithMemConfig 设置内存存储配置选项
+func WithMemConfig(config MemoryStorageConfig) StorageOption {
+	return func(s *Types) {
+		s.Mem = config
+		s.Mode = Mem
+	}
+}
+
 // WithMode 设置存储模式选项
 func WithMode(mode StorageType) StorageOption {
 	return func(s *Types) {
@@ -73,6 +183,20 @@ func WithMaxSize(maxSize int64) StorageOption {
 	}
 }
 
+// WithQuotaConfig 设置配额配置选项
+func WithQuotaConfig(config QuotaConfig) StorageOption {
+	return func(s *Types) {
+		s.Quota = config
+	}
+}
+
+// WithMIMETypes 设置自定义 MIME 类型映射选项
+func WithMIMETypes(types map[string]string) StorageOption {
+	return func(s *Types) {
+		s.MIMETypes = types
+	}
+}
+
 // DefaultStorageOptions 默认存储选项
 func DefaultStorageOptions() []StorageOption {
 	return []StorageOption{
@@ -101,7 +225,28 @@ func (s *Types) GetStorage(ctx context.Context, opts ...StorageOption) (string,
 		s.AssignMode = s.Mode
 	}
 
-	// 根据模式返回相应的存储实例
+	// 注册自定义 MIME 类型映射
+	RegisterMIMETypes(s.MIMETypes)
+
+	basePath, storage := s.createStorage(ctx)
+	if storage == nil {
+		return basePath, nil
+	}
+
+	// 配额在内层统计实际写入的文件，大小限制在最外层尽早拒绝超限的上传流
+	if len(s.Quota.Rules) > 0 {
+		hlog.CtxInfof(ctx, "启用存储配额: %d 条规则", len(s.Quota.Rules))
+		storage = NewQuotaStorage(storage, s.Quota)
+	}
+	if s.MaxSize > 0 {
+		hlog.CtxInfof(ctx, "启用文件大小限制: %d 字节", s.MaxSize)
+		storage = NewSizeLimitedStorage(storage, s.MaxSize)
+	}
+	return basePath, storage
+}
+
+// createStorage 根据模式创建相应的存储实例
+func (s *Types) createStorage(ctx context.Context) (string, Storage) {
 	switch s.AssignMode {
 	case S3:
 		// 验证S3配置
@@ -127,6 +272,97 @@ func (s *Types) GetStorage(ctx context.Context, opts ...StorageOption) (string,
 		}
 		hlog.CtxInfof(ctx, "Using OSS storage")
 		return s.Oss.BaseDir, NewOSSStorage(s.Oss)
+	case COS:
+		// 验证COS配置（未指定自定义访问地址时需要地域）
+		if s.Cos.BaseDir == "" || s.Cos.SecretID == "" || s.Cos.SecretKey == "" || s.Cos.Bucket == "" || (s.Cos.Region == "" && s.Cos.Endpoint == "") {
+			hlog.CtxErrorf(ctx, "COS config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using COS storage")
+		return s.Cos.BaseDir, NewCOSStorage(s.Cos)
+	case Azure:
+		// 验证Azure配置（连接字符串或账户名+密钥二选一）
+		if s.Azure.BaseDir == "" || s.Azure.Container == "" || (s.Azure.ConnectionString == "" && (s.Azure.AccountName == "" || s.Azure.AccountKey == "")) {
+			hlog.CtxErrorf(ctx, "Azure config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using Azure Blob storage")
+		return s.Azure.BaseDir, NewAzureBlobStorage(s.Azure)
+	case GCS:
+		// 验证GCS配置（凭据可以来自运行环境，不强制要求）
+		if s.Gcs.BaseDir == "" || s.Gcs.Bucket == "" {
+			hlog.CtxErrorf(ctx, "GCS config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using GCS storage")
+		return s.Gcs.BaseDir, NewGCSStorage(s.Gcs)
+	case SFTP:
+		// 验证SFTP配置（认证方式在创建实例时校验）
+		if s.Sftp.BaseDir == "" || s.Sftp.Host == "" || s.Sftp.User == "" {
+			hlog.CtxErrorf(ctx, "SFTP config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using SFTP storage")
+		return s.Sftp.BaseDir, NewSFTPStorage(s.Sftp)
+	case WebDAV:
+		// 验证WebDAV配置（基础目录可以为空，直接使用 Endpoint 对应的目录）
+		if s.Webdav.Endpoint == "" {
+			hlog.CtxErrorf(ctx, "WebDAV config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using WebDAV storage")
+		return s.Webdav.BaseDir, NewWebDAVStorage(s.Webdav)
+	case FTP:
+		// 验证FTP配置（用户名为空时匿名登录）
+		if s.Ftp.BaseDir == "" || s.Ftp.Host == "" {
+			hlog.CtxErrorf(ctx, "FTP config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using FTP storage")
+		return s.Ftp.BaseDir, NewFTPStorage(s.Ftp)
+	case SQL:
+		// 验证数据库配置（驱动需要由使用方导入）
+		if s.Sql.DSN == "" && s.Sql.DB == nil {
+			hlog.CtxErrorf(ctx, "SQL config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using SQL storage")
+		return "", NewSQLStorage(s.Sql)
+	case Archive:
+		// 验证归档配置
+		if s.Archive.Path == "" && s.Archive.ReaderAt == nil && s.Archive.Source == nil {
+			hlog.CtxErrorf(ctx, "Archive config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using Archive storage")
+		return s.Archive.Path, NewArchiveStorage(s.Archive)
+	case HTTP:
+		// 验证HTTP配置
+		if s.Http.BaseURL == "" {
+			hlog.CtxErrorf(ctx, "HTTP config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using HTTP storage")
+		return s.Http.BaseURL, NewHTTPStorage(s.Http)
+	case Remote:
+		// 验证远程存储配置
+		if s.Remote.Endpoint == "" {
+			hlog.CtxErrorf(ctx, "Remote config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using Remote storage")
+		return s.Remote.BaseDir, NewRemoteStorage(s.Remote)
+	case GRPC:
+		// 验证 gRPC 存储配置
+		if s.Grpc.Target == "" && s.Grpc.Conn == nil {
+			hlog.CtxErrorf(ctx, "gRPC config error: missing required fields")
+			return "", nil
+		}
+		hlog.CtxInfof(ctx, "Using gRPC storage")
+		return s.Grpc.BaseDir, NewGRPCStorage(s.Grpc)
+	case Mem:
+		hlog.CtxInfof(ctx, "Using Memory storage")
+		return "", NewMemoryStorage(s.Mem)
 	default:
 		// 默认使用本地存储
 		if s.Local.BasePath == "" {
@@ -159,6 +395,11 @@ func CreateStorage(storageType StorageType) Storage {
 //################## 初始化函数 #####################
 
 func init() {
+	// 注册内存存储驱动（无需任何配置，每次创建得到独立的空存储）
+	RegisterStorageDriver(Mem, func() Storage {
+		return NewMemoryStorage(MemoryStorageConfig{})
+	})
+
 	// 注册本地存储驱动
 	//RegisterStorageDriver(Local, func() Storage {
 	//	// 这里可以添加默认配置或从配置中心获取
@@ -223,6 +464,11 @@ func isDirectoryPlaceholder(key, dirPath string) bool {
 	return key == dirPath || key == dirPath+"/"
 }
 
+// trimETag 去掉 ETag 两侧的引号（HTTP 头中的 ETag 带引号）
+func trimETag(etag string) string {
+	return strings.Trim(etag, "\"")
+}
+
 // joinStorageKey 拼接存储基础路径与相对路径，保留相对路径的尾斜杠。
 // 对象存储的 key 使用正斜杠，且目录列表需要 prefix 以 / 结尾才能正确分组，
 // 因此不能用 filepath.Join（会剥掉尾斜杠）。
@@ -0,0 +1,76 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+)
+
+func newFakeS3Storage(server *storagetest.FakeServer) storage.Storage {
+	return storage.NewS3Storage(storage.S3StorageConfig{
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		AccessKeySecret: "test",
+		Region:          "us-east-1",
+		Bucket:          "fault-test",
+	})
+}
+
+func TestS3Storage_MultipartUpload(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+	s := newFakeS3Storage(server)
+	ctx := context.Background()
+
+	// 不可 Seek 且超过一个分片的流走分片上传
+	data := bytes.Repeat([]byte("0123456789"), 1<<20)
+	if err := s.Upload(ctx, "big.bin", io.MultiReader(bytes.NewReader(data))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	stored, ok := server.Object("fault-test", "big.bin")
+	if !ok || !bytes.Equal(stored, data) {
+		t.Fatalf("Unexpected stored object: %d bytes, exists %v", len(stored), ok)
+	}
+}
+
+func TestS3Storage_InjectedFaults(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+	s := newFakeS3Storage(server)
+	ctx := context.Background()
+
+	if err := s.Upload(ctx, "a.txt", bytes.NewReader([]byte("hello"))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 服务端错误不应被当作文件不存在
+	server.InjectFault(storagetest.Fault{Method: http.MethodGet, Path: "/fault-test/a.txt", Status: http.StatusForbidden})
+	if _, err := s.Download(ctx, "a.txt"); err == nil || errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected access error, got %v", err)
+	}
+	if _, err := s.Exists(ctx, "a.txt"); err != nil {
+		t.Fatalf("HEAD should not be affected by GET fault: %v", err)
+	}
+	server.ClearFaults()
+
+	// 一次性的 503 由 SDK 重试恢复
+	server.InjectFault(storagetest.Fault{Method: http.MethodGet, Status: http.StatusServiceUnavailable, Times: 1})
+	if _, err := s.Download(ctx, "a.txt"); err != nil {
+		t.Fatalf("Download should succeed after retry: %v", err)
+	}
+
+	// 延迟超过请求上下文的截止时间
+	server.InjectFault(storagetest.Fault{Method: http.MethodHead, Delay: time.Second})
+	timeoutCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.GetMetadata(timeoutCtx, "a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+}
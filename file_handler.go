@@ -0,0 +1,412 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	// fileHandlerDefaultParam 默认的路由参数名（如 /files/*filepath）
+	fileHandlerDefaultParam = "filepath"
+	// fileHandlerDefaultRedirectExpiry 默认的预签名URL有效期
+	fileHandlerDefaultRedirectExpiry = 15 * time.Minute
+	// fileHandlerDefaultMaxRanges 默认的单次请求最大范围数
+	fileHandlerDefaultMaxRanges = 16
+)
+
+// FileHandlerConfig 文件下载处理器配置
+type FileHandlerConfig struct {
+	Storage        Storage       `json:"-"`               // 文件所在的存储
+	Param          string        `json:"param"`           // 路由参数名，默认 filepath
+	Prefix         string        `json:"prefix"`          // 拼接在请求路径之前的存储目录
+	Attachment     bool          `json:"attachment"`      // 总是以附件形式下载；否则仅在请求带有 download 查询参数时
+	CacheControl   string        `json:"cache_control"`   // Cache-Control 响应头，为空时不设置
+	Redirect       bool          `json:"redirect"`        // 存储支持预签名时重定向到预签名URL
+	RedirectExpiry time.Duration `json:"redirect_expiry"` // 预签名URL有效期，默认 15 分钟
+	MaxRanges      int           `json:"max_ranges"`      // 单次请求的最大范围数，默认 16，超出时返回完整内容
+}
+
+// NewFileHandler 创建从存储读取文件的 Hertz 处理器，支持 GET 和 HEAD：
+//   - 设置 Content-Type、Content-Length、Accept-Ranges、ETag 和 Last-Modified
+//   - 单个范围返回 206，多个范围返回 multipart/byteranges，均通过 DownloadRange 读取
+//   - If-Match、If-Unmodified-Since 不满足时返回 412，If-None-Match、If-Modified-Since 命中时返回 304，支持 If-Range
+//   - 配置 Redirect 且存储实现 Presigner 时重定向到预签名URL
+//
+// 用法：
+//
+//	h.GET("/files/*filepath", storage.NewFileHandler(storage.FileHandlerConfig{Storage: s}))
+func NewFileHandler(config FileHandlerConfig) app.HandlerFunc {
+	if config.Param == "" {
+		config.Param = fileHandlerDefaultParam
+	}
+	if config.RedirectExpiry <= 0 {
+		config.RedirectExpiry = fileHandlerDefaultRedirectExpiry
+	}
+	if config.MaxRanges <= 0 {
+		config.MaxRanges = fileHandlerDefaultMaxRanges
+	}
+	handler := &fileHandler{config: config}
+	return handler.serve
+}
+
+// fileHandler 文件下载处理器
+type fileHandler struct {
+	config FileHandlerConfig
+}
+
+// serve 处理一次下载请求
+func (h *fileHandler) serve(ctx context.Context, c *app.RequestContext) {
+	method := string(c.Method())
+	if method != http.MethodGet && method != http.MethodHead {
+		c.Header("Allow", "GET, HEAD")
+		c.AbortWithStatus(http.StatusMethodNotAllowed)
+		return
+	}
+
+	filePath := strings.Trim(path.Join(h.config.Prefix, path.Clean("/"+c.Param(h.config.Param))), "/")
+	metadata, err := h.config.Storage.GetMetadata(ctx, filePath)
+	if err == nil && metadata.IsDir {
+		err = ErrNotExist
+	}
+	if errors.Is(err, ErrNotExist) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取下载文件信息失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	etag := fileETag(metadata)
+	c.Header("ETag", etag)
+	if !metadata.ModTime.IsZero() {
+		c.Header("Last-Modified", metadata.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if h.config.CacheControl != "" {
+		c.Header("Cache-Control", h.config.CacheControl)
+	}
+
+	// 条件请求
+	if status := checkPreconditions(c, etag, metadata.ModTime); status != 0 {
+		c.AbortWithStatus(status)
+		return
+	}
+
+	// 重定向到预签名URL，失败时由处理器直接返回内容
+	if h.config.Redirect {
+		if presigner, ok := As[Presigner](h.config.Storage); ok {
+			presigned, err := presigner.Presign(ctx, http.MethodGet, filePath, h.config.RedirectExpiry)
+			if err == nil {
+				c.Redirect(http.StatusFound, []byte(presigned))
+				return
+			}
+			hlog.CtxWarnf(ctx, "生成预签名URL失败，直接返回文件内容: %v", err)
+		}
+	}
+
+	contentType := metadata.MIMEType
+	if contentType == "" {
+		contentType = mimeTypeByName(filePath)
+	}
+	c.Header("Accept-Ranges", "bytes")
+	if h.config.Attachment || c.QueryArgs().Has("download") {
+		c.Header("Content-Disposition", contentDisposition(path.Base(filePath)))
+	}
+
+	size := metadata.Size
+	ranges, err := parseRange(rangeHeader(c, etag, metadata.ModTime), size)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		c.AbortWithStatus(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	if len(ranges) > h.config.MaxRanges || sumRangesSize(ranges) > size {
+		// 范围过多或相互重叠时返回完整内容，避免放大请求
+		ranges = nil
+	}
+
+	var body func() (io.Reader, error)
+	switch len(ranges) {
+	case 0:
+		c.SetStatusCode(http.StatusOK)
+		c.Response.Header.SetContentType(contentType)
+		body = func() (io.Reader, error) { return h.config.Storage.Download(ctx, filePath) }
+	case 1:
+		ra := ranges[0]
+		c.SetStatusCode(http.StatusPartialContent)
+		c.Response.Header.SetContentType(contentType)
+		c.Header("Content-Range", ra.contentRange(size))
+		size = ra.length
+		body = func() (io.Reader, error) { return h.config.Storage.DownloadRange(ctx, filePath, ra.start, ra.length) }
+	default:
+		mw := multipart.NewWriter(io.Discard)
+		c.SetStatusCode(http.StatusPartialContent)
+		c.Response.Header.SetContentType("multipart/byteranges; boundary=" + mw.Boundary())
+		size = rangesMIMESize(ranges, contentType, size)
+		body = func() (io.Reader, error) {
+			return h.multipartBody(ctx, filePath, mw.Boundary(), ranges, contentType, metadata.Size), nil
+		}
+	}
+
+	if method == http.MethodHead {
+		c.Response.Header.SetContentLength(int(size))
+		c.Response.SkipBody = true
+		return
+	}
+	if size == 0 {
+		c.Response.Header.SetContentLength(0)
+		return
+	}
+	reader, err := body()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "下载文件失败: %v", err)
+		c.Response.Header.Del("Content-Range")
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.SetBodyStream(reader, int(size))
+}
+
+// multipartBody 以 multipart/byteranges 格式逐个范围读取文件
+func (h *fileHandler) multipartBody(ctx context.Context, filePath, boundary string, ranges []httpRange, contentType string, size int64) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		mw := multipart.NewWriter(pw)
+		mw.SetBoundary(boundary)
+		for _, ra := range ranges {
+			part, err := mw.CreatePart(ra.mimeHeader(contentType, size))
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			reader, err := h.config.Storage.DownloadRange(ctx, filePath, ra.start, ra.length)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			_, err = io.CopyN(part, reader, ra.length)
+			if closer, ok := reader.(io.Closer); ok {
+				closer.Close()
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(mw.Close())
+	}()
+	return pr
+}
+
+// fileETag 返回文件的 ETag，后端未提供时根据大小和修改时间生成弱 ETag
+func fileETag(metadata *FileMetadata) string {
+	if metadata.ETag != "" {
+		return `"` + metadata.ETag + `"`
+	}
+	return fmt.Sprintf(`W/"%x-%x"`, metadata.Size, metadata.ModTime.UnixNano())
+}
+
+// contentDisposition 返回附件下载的 Content-Disposition，非 ASCII 文件名使用 RFC 5987 编码
+func contentDisposition(name string) string {
+	fallback := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return '_'
+		}
+		return r
+	}, name)
+	disposition := fmt.Sprintf(`attachment; filename="%s"`, fallback)
+	if fallback != name {
+		disposition += "; filename*=UTF-8''" + url.PathEscape(name)
+	}
+	return disposition
+}
+
+// checkPreconditions 检查条件请求头，返回需要直接响应的状态码（0 表示继续）
+func checkPreconditions(c *app.RequestContext, etag string, modTime time.Time) int {
+	if ifMatch := string(c.GetHeader("If-Match")); ifMatch != "" {
+		if !etagMatch(ifMatch, etag, false) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(string(c.GetHeader("If-Unmodified-Since"))); err == nil && !modTime.IsZero() {
+		if modTime.Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := string(c.GetHeader("If-None-Match")); ifNoneMatch != "" {
+		if etagMatch(ifNoneMatch, etag, true) {
+			return http.StatusNotModified
+		}
+	} else if since, err := http.ParseTime(string(c.GetHeader("If-Modified-Since"))); err == nil && !modTime.IsZero() {
+		if !modTime.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// rangeHeader 返回需要处理的 Range 请求头；If-Range 与当前文件不一致时忽略范围返回完整内容
+func rangeHeader(c *app.RequestContext, etag string, modTime time.Time) string {
+	rangeValue := string(c.GetHeader("Range"))
+	ifRange := string(c.GetHeader("If-Range"))
+	if rangeValue == "" || ifRange == "" {
+		return rangeValue
+	}
+	if strings.HasPrefix(ifRange, `"`) || strings.HasPrefix(ifRange, "W/") {
+		if etagMatch(ifRange, etag, false) {
+			return rangeValue
+		}
+		return ""
+	}
+	if since, err := http.ParseTime(ifRange); err == nil && !modTime.IsZero() && modTime.Truncate(time.Second).Equal(since) {
+		return rangeValue
+	}
+	return ""
+}
+
+// etagMatch 判断 ETag 列表是否包含 etag。weak 为 true 时使用弱比较，否则弱 ETag 不匹配
+func etagMatch(list, etag string, weak bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if !weak && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if !weak && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// httpRange 一个字节范围
+type httpRange struct {
+	start, length int64
+}
+
+// contentRange 返回 Content-Range 响应头
+func (r httpRange) contentRange(size int64) string {
+	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, size)
+}
+
+// mimeHeader 返回 multipart/byteranges 中每个部分的头
+func (r httpRange) mimeHeader(contentType string, size int64) textproto.MIMEHeader {
+	return textproto.MIMEHeader{
+		"Content-Range": {r.contentRange(size)},
+		"Content-Type":  {contentType},
+	}
+}
+
+// errNoOverlap 所有范围都超出文件末尾
+var errNoOverlap = errors.New("请求的范围超出文件末尾")
+
+// parseRange 解析 Range 请求头（RFC 7233），超出文件末尾的范围被忽略，全部超出时返回错误
+func parseRange(s string, size int64) ([]httpRange, error) {
+	if s == "" {
+		return nil, nil
+	}
+	spec, ok := strings.CutPrefix(s, "bytes=")
+	if !ok {
+		return nil, errors.New("无效的范围")
+	}
+	var ranges []httpRange
+	noOverlap := false
+	for _, ra := range strings.Split(spec, ",") {
+		ra = strings.TrimSpace(ra)
+		if ra == "" {
+			continue
+		}
+		startText, endText, ok := strings.Cut(ra, "-")
+		if !ok {
+			return nil, errors.New("无效的范围")
+		}
+		startText, endText = strings.TrimSpace(startText), strings.TrimSpace(endText)
+
+		var r httpRange
+		if startText == "" {
+			// -N 表示最后 N 个字节
+			n, err := strconv.ParseInt(endText, 10, 64)
+			if err != nil || n < 0 {
+				return nil, errors.New("无效的范围")
+			}
+			if n == 0 {
+				noOverlap = true
+				continue
+			}
+			n = min(n, size)
+			r.start, r.length = size-n, n
+		} else {
+			start, err := strconv.ParseInt(startText, 10, 64)
+			if err != nil || start < 0 {
+				return nil, errors.New("无效的范围")
+			}
+			if start >= size {
+				noOverlap = true
+				continue
+			}
+			r.start = start
+			if endText == "" {
+				r.length = size - start
+			} else {
+				end, err := strconv.ParseInt(endText, 10, 64)
+				if err != nil || start > end {
+					return nil, errors.New("无效的范围")
+				}
+				r.length = min(end, size-1) - start + 1
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	if noOverlap && len(ranges) == 0 {
+		return nil, errNoOverlap
+	}
+	return ranges, nil
+}
+
+// sumRangesSize 返回所有范围的总长度
+func sumRangesSize(ranges []httpRange) (size int64) {
+	for _, ra := range ranges {
+		size += ra.length
+	}
+	return size
+}
+
+// countingWriter 只统计写入的字节数
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// rangesMIMESize 返回 multipart/byteranges 响应体的总长度（边界长度固定，与实际使用的边界无关）
+func rangesMIMESize(ranges []httpRange, contentType string, size int64) int64 {
+	var w countingWriter
+	mw := multipart.NewWriter(&w)
+	total := int64(0)
+	for _, ra := range ranges {
+		mw.CreatePart(ra.mimeHeader(contentType, size))
+		total += ra.length
+	}
+	mw.Close()
+	return total + int64(w)
+}
@@ -0,0 +1,154 @@
+package storage_test
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/v-mars/storage"
+)
+
+// newFileHandlerEngine 注册 /files/*filepath 下载路由
+func newFileHandlerEngine(handlerConfig storage.FileHandlerConfig) *route.Engine {
+	engine := route.NewEngine(config.NewOptions(nil))
+	handler := storage.NewFileHandler(handlerConfig)
+	engine.GET("/files/*filepath", handler)
+	engine.HEAD("/files/*filepath", handler)
+	engine.POST("/files/*filepath", handler)
+	return engine
+}
+
+// serveFile 发送请求，headers 为成对的请求头名称和值
+func serveFile(engine *route.Engine, method, url string, headers ...string) *protocol.Response {
+	var utHeaders []ut.Header
+	for i := 0; i+1 < len(headers); i += 2 {
+		utHeaders = append(utHeaders, ut.Header{Key: headers[i], Value: headers[i+1]})
+	}
+	return ut.PerformRequest(engine, method, url, nil, utHeaders...).Result()
+}
+
+func TestFileHandler(t *testing.T) {
+	s := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	content := "0123456789abcdefghij"
+	if err := s.Upload(ctx, "docs/data.txt", strings.NewReader(content)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Upload(ctx, "docs/报告 2026.pdf", strings.NewReader("%PDF")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	engine := newFileHandlerEngine(storage.FileHandlerConfig{Storage: s, CacheControl: "max-age=60"})
+
+	// 完整内容
+	resp := serveFile(engine, http.MethodGet, "/files/docs/data.txt")
+	if resp.StatusCode() != http.StatusOK || string(resp.Body()) != content {
+		t.Fatalf("Unexpected response: %d %q", resp.StatusCode(), resp.Body())
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if !strings.HasPrefix(string(resp.Header.ContentType()), "text/plain") || resp.Header.Get("Accept-Ranges") != "bytes" ||
+		resp.Header.ContentLength() != len(content) || etag == "" || lastModified == "" || resp.Header.Get("Cache-Control") != "max-age=60" {
+		t.Fatalf("Unexpected headers: %s", resp.Header.Header())
+	}
+
+	// HEAD 只返回头（ut 记录器会按实际响应体重写 Content-Length，这里不做校验）
+	resp = serveFile(engine, http.MethodHead, "/files/docs/data.txt")
+	if resp.StatusCode() != http.StatusOK || len(resp.Body()) != 0 || resp.Header.Get("ETag") != etag {
+		t.Fatalf("Unexpected HEAD response: %d %q %s", resp.StatusCode(), resp.Body(), resp.Header.Header())
+	}
+
+	// 单个范围
+	resp = serveFile(engine, http.MethodGet, "/files/docs/data.txt", "Range", "bytes=5-9")
+	if resp.StatusCode() != http.StatusPartialContent || string(resp.Body()) != "56789" || resp.Header.Get("Content-Range") != "bytes 5-9/20" {
+		t.Fatalf("Unexpected range response: %d %q %s", resp.StatusCode(), resp.Body(), resp.Header.Header())
+	}
+	resp = serveFile(engine, http.MethodGet, "/files/docs/data.txt", "Range", "bytes=-3")
+	if string(resp.Body()) != "hij" || resp.Header.Get("Content-Range") != "bytes 17-19/20" {
+		t.Fatalf("Unexpected suffix range response: %q %s", resp.Body(), resp.Header.Header())
+	}
+
+	// 多个范围
+	resp = serveFile(engine, http.MethodGet, "/files/docs/data.txt", "Range", "bytes=0-1, 10-12")
+	mediaType, params, _ := mime.ParseMediaType(string(resp.Header.ContentType()))
+	if resp.StatusCode() != http.StatusPartialContent || mediaType != "multipart/byteranges" || resp.Header.ContentLength() != len(resp.Body()) {
+		t.Fatalf("Unexpected multi-range response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+	reader := multipart.NewReader(strings.NewReader(string(resp.Body())), params["boundary"])
+	for _, want := range []struct{ contentRange, data string }{{"bytes 0-1/20", "01"}, {"bytes 10-12/20", "abc"}} {
+		part, err := reader.NextPart()
+		if err != nil {
+			t.Fatalf("NextPart failed: %v", err)
+		}
+		data, _ := io.ReadAll(part)
+		if part.Header.Get("Content-Range") != want.contentRange || string(data) != want.data {
+			t.Fatalf("Unexpected part: %v %q", part.Header, data)
+		}
+	}
+
+	// 超出文件末尾的范围
+	resp = serveFile(engine, http.MethodGet, "/files/docs/data.txt", "Range", "bytes=100-")
+	if resp.StatusCode() != http.StatusRequestedRangeNotSatisfiable || resp.Header.Get("Content-Range") != "bytes */20" {
+		t.Fatalf("Unexpected unsatisfiable response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+
+	// 条件请求
+	for _, tc := range []struct {
+		headers []string
+		status  int
+	}{
+		{[]string{"If-None-Match", etag}, http.StatusNotModified},
+		{[]string{"If-None-Match", `"other", ` + etag}, http.StatusNotModified},
+		{[]string{"If-None-Match", `"other"`}, http.StatusOK},
+		{[]string{"If-Modified-Since", lastModified}, http.StatusNotModified},
+		{[]string{"If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}, http.StatusOK},
+		{[]string{"If-Unmodified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}, http.StatusPreconditionFailed},
+		{[]string{"If-Match", `"other"`}, http.StatusPreconditionFailed},
+		{[]string{"Range", "bytes=0-1", "If-Range", `"other"`}, http.StatusOK},
+		{[]string{"Range", "bytes=0-1", "If-Range", lastModified}, http.StatusPartialContent},
+	} {
+		if resp := serveFile(engine, http.MethodGet, "/files/docs/data.txt", tc.headers...); resp.StatusCode() != tc.status {
+			t.Fatalf("Headers %v: expected %d, got %d", tc.headers, tc.status, resp.StatusCode())
+		}
+	}
+
+	// 下载时设置附件文件名
+	resp = serveFile(engine, http.MethodGet, "/files/docs/%E6%8A%A5%E5%91%8A%202026.pdf?download=1")
+	if resp.StatusCode() != http.StatusOK || resp.Header.Get("Content-Disposition") != `attachment; filename="__ 2026.pdf"; filename*=UTF-8''%E6%8A%A5%E5%91%8A%202026.pdf` {
+		t.Fatalf("Unexpected disposition: %d %q", resp.StatusCode(), resp.Header.Get("Content-Disposition"))
+	}
+
+	if resp := serveFile(engine, http.MethodGet, "/files/docs/missing.txt"); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", resp.StatusCode())
+	}
+	if resp := serveFile(engine, http.MethodGet, "/files/docs"); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("Expected 404 for directory, got %d", resp.StatusCode())
+	}
+	if resp := serveFile(engine, http.MethodPost, "/files/docs/data.txt"); resp.StatusCode() != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected 405, got %d", resp.StatusCode())
+	}
+}
+
+func TestFileHandler_Redirect(t *testing.T) {
+	s := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	if err := s.Upload(context.Background(), "a/b.txt", strings.NewReader("b")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 存储支持预签名时重定向，包装后的存储同样可以找到预签名能力
+	engine := newFileHandlerEngine(storage.FileHandlerConfig{Storage: storage.NewSizeLimitedStorage(s, 1024), Prefix: "a", Redirect: true})
+	resp := serveFile(engine, http.MethodGet, "/files/b.txt")
+	if resp.StatusCode() != http.StatusFound || !strings.HasPrefix(resp.Header.Get("Location"), "memory:///a/b.txt?") {
+		t.Fatalf("Unexpected redirect: %d %q", resp.StatusCode(), resp.Header.Get("Location"))
+	}
+	if resp := serveFile(engine, http.MethodGet, "/files/missing.txt"); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", resp.StatusCode())
+	}
+}
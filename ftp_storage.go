@@ -0,0 +1,962 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/jlaffaye/ftp"
+)
+
+const (
+	// ftpDefaultPort 默认的FTP端口
+	ftpDefaultPort = 21
+	// ftpDefaultImplicitTLSPort 隐式TLS（FTPS）的默认端口
+	ftpDefaultImplicitTLSPort = 990
+	// ftpDefaultPoolSize 默认保留的空闲连接数
+	ftpDefaultPoolSize = 4
+	// ftpDefaultTimeout 默认的连接超时时间
+	ftpDefaultTimeout = 10 * time.Second
+)
+
+// FTP TLS 模式
+const (
+	FTPTLSExplicit = "explicit" // 显式TLS：连接后通过 AUTH TLS 升级
+	FTPTLSImplicit = "implicit" // 隐式TLS：连接建立时即使用TLS
+)
+
+// FTPStorageConfig FTP/FTPS 存储配置。数据连接总是使用被动模式（EPSV，不支持时使用 PASV）。
+type FTPStorageConfig struct {
+	Host               string        `json:"host"`                 // 服务器地址
+	Port               int           `json:"port"`                 // 服务器端口，默认 21（隐式TLS为 990）
+	User               string        `json:"user"`                 // 用户名，为空时匿名登录
+	Password           string        `json:"password"`             // 密码
+	TLSMode            string        `json:"tls_mode"`             // TLS 模式：explicit、implicit，为空时不加密
+	InsecureSkipVerify bool          `json:"insecure_skip_verify"` // 不校验服务器证书（仅用于测试环境）
+	TLSConfig          *tls.Config   `json:"-"`                    // 自定义TLS配置，设置后忽略 InsecureSkipVerify
+	DisableEPSV        bool          `json:"disable_epsv"`         // 禁用 EPSV，只使用 PASV
+	DisableMLSD        bool          `json:"disable_mlsd"`         // 禁用 MLSD/MLST，使用 LIST 并解析其输出
+	BaseDir            string        `json:"base_dir"`             // 存储基础目录（相对路径基于登录后的当前目录）
+	PoolSize           int           `json:"pool_size"`            // 保留的空闲连接数，默认 4
+	Timeout            time.Duration `json:"timeout"`              // 连接超时时间，默认 10 秒
+}
+
+// FTPStorage FTP/FTPS 存储实现。
+// FTP 连接同一时间只能执行一个传输，操作时从连接池取出空闲连接（没有时新建），完成后放回；
+// 连接池最多保留 PoolSize 个空闲连接。附加元数据（有效期、MIME 类型）与SFTP存储相同，
+// 保存在 <BaseDir>/.storage-meta/ 下。
+type FTPStorage struct {
+	config    FTPStorageConfig
+	tlsConfig *tls.Config
+	root      string               // 基础目录的绝对路径
+	idle      chan *ftp.ServerConn // 空闲连接
+	dirs      sync.Map             // 已确认存在的目录，避免每次上传都发送 MKD
+}
+
+// NewFTPStorage 创建新的FTP存储实例，创建时建立第一个连接以校验配置
+func NewFTPStorage(config FTPStorageConfig) Storage {
+	if config.Port <= 0 {
+		config.Port = ftpDefaultPort
+		if config.TLSMode == FTPTLSImplicit {
+			config.Port = ftpDefaultImplicitTLSPort
+		}
+	}
+	if config.PoolSize <= 0 {
+		config.PoolSize = ftpDefaultPoolSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = ftpDefaultTimeout
+	}
+	if config.User == "" {
+		config.User = "anonymous"
+	}
+
+	s := &FTPStorage{
+		config: config,
+		idle:   make(chan *ftp.ServerConn, config.PoolSize),
+	}
+	switch config.TLSMode {
+	case "":
+	case FTPTLSExplicit, FTPTLSImplicit:
+		s.tlsConfig = config.TLSConfig
+		if s.tlsConfig == nil {
+			s.tlsConfig = &tls.Config{ServerName: config.Host, InsecureSkipVerify: config.InsecureSkipVerify}
+		}
+	default:
+		hlog.Errorf("不支持的FTP TLS模式: %s", config.TLSMode)
+		return nil
+	}
+
+	// 建立第一个连接，确定基础目录的绝对路径并确保其存在
+	conn, err := s.dial(context.Background())
+	if err != nil {
+		hlog.Errorf("连接FTP服务器失败: %v", err)
+		return nil
+	}
+	s.root = path.Clean("/" + config.BaseDir)
+	if !path.IsAbs(config.BaseDir) {
+		cwd, err := conn.CurrentDir()
+		if err != nil {
+			conn.Quit()
+			hlog.Errorf("获取FTP当前目录失败: %v", err)
+			return nil
+		}
+		s.root = path.Join("/", cwd, config.BaseDir)
+	}
+	err = s.mkdirAll(conn, s.root)
+	s.release(conn, err)
+	if err != nil {
+		hlog.Errorf("创建FTP基础目录失败: %v", err)
+		return nil
+	}
+	return s
+}
+
+// dial 建立新连接并登录
+func (s *FTPStorage) dial(ctx context.Context) (*ftp.ServerConn, error) {
+	options := []ftp.DialOption{
+		ftp.DialWithContext(ctx),
+		ftp.DialWithTimeout(s.config.Timeout),
+		ftp.DialWithDisabledEPSV(s.config.DisableEPSV),
+		ftp.DialWithDisabledMLSD(s.config.DisableMLSD),
+	}
+	switch s.config.TLSMode {
+	case FTPTLSExplicit:
+		options = append(options, ftp.DialWithExplicitTLS(s.tlsConfig))
+	case FTPTLSImplicit:
+		options = append(options, ftp.DialWithTLS(s.tlsConfig))
+	}
+
+	conn, err := ftp.Dial(net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)), options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Login(s.config.User, s.config.Password); err != nil {
+		conn.Quit()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// acquire 取出一个可用的空闲连接，没有时新建连接
+func (s *FTPStorage) acquire(ctx context.Context) (*ftp.ServerConn, error) {
+	for {
+		select {
+		case conn := <-s.idle:
+			// 空闲期间可能已被服务器断开
+			if err := conn.NoOp(); err == nil {
+				return conn, nil
+			}
+			conn.Quit()
+		default:
+			return s.dial(ctx)
+		}
+	}
+}
+
+// release 归还连接。出现网络错误时连接状态不确定，直接关闭
+func (s *FTPStorage) release(conn *ftp.ServerConn, err error) {
+	var netErr net.Error
+	var protocolErr textproto.ProtocolError
+	if errors.As(err, &netErr) || errors.As(err, &protocolErr) || errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		conn.Quit()
+		return
+	}
+	select {
+	case s.idle <- conn:
+	default:
+		conn.Quit()
+	}
+}
+
+// withConn 使用一个连接执行操作，完成后归还连接
+func (s *FTPStorage) withConn(ctx context.Context, fn func(conn *ftp.ServerConn) error) error {
+	conn, err := s.acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("连接FTP服务器失败: %w", err)
+	}
+	err = fn(conn)
+	s.release(conn, err)
+	return err
+}
+
+// Close 关闭所有空闲连接
+func (s *FTPStorage) Close() error {
+	for {
+		select {
+		case conn := <-s.idle:
+			conn.Quit()
+		default:
+			return nil
+		}
+	}
+}
+
+// fullPath 返回服务器上的绝对路径
+func (s *FTPStorage) fullPath(filePath string) string {
+	return path.Join(s.root, filePath)
+}
+
+// mkdirAll 逐级创建目录，已存在的目录会被缓存
+func (s *FTPStorage) mkdirAll(conn *ftp.ServerConn, dir string) error {
+	if dir == "/" || dir == "." {
+		return nil
+	}
+	if _, ok := s.dirs.Load(dir); ok {
+		return nil
+	}
+	if err := s.mkdirAll(conn, path.Dir(dir)); err != nil {
+		return err
+	}
+	// 目录已存在（或无权在上级目录中创建）时服务器返回 550，由后续操作报告真正的错误
+	if err := conn.MakeDir(dir); err != nil && !isFTPCode(err, ftp.StatusFileUnavailable) {
+		return err
+	}
+	s.dirs.Store(dir, struct{}{})
+	return nil
+}
+
+// Upload 实现FTP文件上传。
+// 先上传到同目录下的临时文件再重命名，读取方不会看到不完整的文件；设置有效期时删除截止时间记录在附加元数据中。
+func (s *FTPStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到FTP: %s", filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	err = s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if err := s.writeFile(conn, s.fullPath(filePath), reader); err != nil {
+			return err
+		}
+
+		// 覆盖上传时同时覆盖旧的附加元数据；能由扩展名推断的类型无需记录
+		meta := &localObjectMeta{}
+		if contentType != MIMETypeByExtension(filePath) {
+			meta.ContentType = contentType
+		}
+		if options.Expiration > 0 {
+			deadline := expirationDeadline(options.Expiration)
+			meta.ExpiresAt = &deadline
+			hlog.CtxDebugf(ctx, "设置FTP文件过期时间: %v", deadline)
+		}
+		return s.writeMeta(conn, filePath, meta)
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件上传成功: %s", filePath)
+	return nil
+}
+
+// writeFile 将内容上传到临时文件后重命名为目标文件
+func (s *FTPStorage) writeFile(conn *ftp.ServerConn, fullPath string, reader io.Reader) error {
+	dir := path.Dir(fullPath)
+	if err := s.mkdirAll(conn, dir); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tempPath := path.Join(dir, localUploadTempPrefix+hex.EncodeToString(suffix))
+
+	err := conn.Stor(tempPath, reader)
+	if err == nil {
+		err = s.rename(conn, tempPath, fullPath)
+	}
+	if err != nil {
+		// 上传中断时删除临时文件，已有文件保持不变
+		conn.Delete(tempPath)
+		return err
+	}
+	return nil
+}
+
+// rename 重命名并覆盖已存在的目标文件（部分服务器不允许覆盖，此时先删除目标文件）
+func (s *FTPStorage) rename(conn *ftp.ServerConn, oldPath, newPath string) error {
+	if err := conn.Rename(oldPath, newPath); err == nil {
+		return nil
+	}
+	if err := conn.Delete(newPath); err != nil && !isFTPCode(err, ftp.StatusFileUnavailable) {
+		return err
+	}
+	return conn.Rename(oldPath, newPath)
+}
+
+// UploadAt 实现 ResumableUploader，从 offset 处续传FTP文件。
+// offset 等于当前文件大小时使用 APPE 追加；小于时使用 REST + STOR，服务器未截断其后的内容时返回错误。
+func (s *FTPStorage) UploadAt(ctx context.Context, filePath string, reader io.Reader, offset int64) error {
+	hlog.CtxInfof(ctx, "开始续传文件到FTP: %s, offset: %d", filePath, offset)
+
+	fullPath := s.fullPath(filePath)
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if offset == 0 {
+			if err := s.mkdirAll(conn, path.Dir(fullPath)); err != nil {
+				return err
+			}
+			return conn.Stor(fullPath, reader)
+		}
+
+		size, err := conn.FileSize(fullPath)
+		if err != nil {
+			return ftpError(err)
+		}
+		if offset > size {
+			return fmt.Errorf("续传位置 %d 超过文件 %s 的当前大小 %d", offset, filePath, size)
+		}
+		if offset == size {
+			return conn.Append(fullPath, reader)
+		}
+
+		counter := &countingReader{reader: reader}
+		if err := conn.StorFrom(fullPath, counter, uint64(offset)); err != nil {
+			return err
+		}
+		if size, err = conn.FileSize(fullPath); err != nil {
+			return err
+		}
+		if size != offset+counter.n {
+			return fmt.Errorf("FTP服务器续传时未截断文件 %s，当前大小 %d，期望 %d", filePath, size, offset+counter.n)
+		}
+		return nil
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP续传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件续传成功: %s", filePath)
+	return nil
+}
+
+// countingReader 统计读取的字节数
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// Download 实现从FTP下载文件（流式下载）
+func (s *FTPStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从FTP下载文件: %s", filePath)
+
+	body, err := s.download(ctx, filePath, 0, -1)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件下载已启动: %s", filePath)
+	return body, nil // 返回原始的Reader，由调用方负责关闭（关闭后连接才会归还连接池）
+}
+
+// DownloadRange 实现从FTP下载文件（REST 指定起始位置，支持断点续传）
+func (s *FTPStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从FTP下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	body, err := s.download(ctx, filePath, offset, size)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件断点续传下载已启动: %s", filePath)
+	return body, nil
+}
+
+// download 从 offset 处下载 size 个字节（size 小于 0 表示到文件末尾），已过期的文件返回 ErrNotExist。
+// 下载期间独占一个连接，返回的 Reader 关闭时归还。
+func (s *FTPStorage) download(ctx context.Context, filePath string, offset, size int64) (io.ReadCloser, error) {
+	conn, err := s.acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("连接FTP服务器失败: %w", err)
+	}
+
+	fullPath := s.fullPath(filePath)
+	entry, err := s.stat(conn, fullPath)
+	if err == nil && entry.Type == ftp.EntryTypeFolder {
+		err = fmt.Errorf("%s 是目录", filePath)
+	}
+	if err == nil {
+		err = s.checkExpired(conn, filePath)
+	}
+	if err != nil {
+		s.release(conn, err)
+		return nil, err
+	}
+
+	// 起始位置超出文件末尾或读取长度为 0 时无需建立数据连接
+	fileSize := int64(entry.Size)
+	if size == 0 || offset >= fileSize {
+		s.release(conn, nil)
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+	if size > 0 && offset+size >= fileSize {
+		size = -1
+	}
+
+	resp, err := conn.RetrFrom(fullPath, uint64(offset))
+	if err != nil {
+		s.release(conn, err)
+		return nil, ftpError(err)
+	}
+	body := &ftpDownload{storage: s, conn: conn, resp: resp}
+	if size > 0 {
+		return &limitedReadCloser{Reader: io.LimitReader(body, size), Closer: body}, nil
+	}
+	return body, nil
+}
+
+// ftpDownload 下载中的数据连接，关闭时归还控制连接
+type ftpDownload struct {
+	storage *FTPStorage
+	conn    *ftp.ServerConn
+	resp    *ftp.Response
+	eof     bool
+	once    sync.Once
+}
+
+func (d *ftpDownload) Read(p []byte) (int, error) {
+	n, err := d.resp.Read(p)
+	if err == io.EOF {
+		d.eof = true
+	}
+	return n, err
+}
+
+// Close 关闭数据连接。未读完就关闭时服务器会中止传输，此时控制连接直接关闭而不归还
+func (d *ftpDownload) Close() error {
+	var err error
+	d.once.Do(func() {
+		err = d.resp.Close()
+		if !d.eof {
+			d.conn.Quit()
+			err = nil
+			return
+		}
+		d.storage.release(d.conn, err)
+	})
+	return err
+}
+
+// Delete 实现FTP文件删除（删除不存在的文件不报错）
+func (s *FTPStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从FTP删除文件: %s", filePath)
+
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if err := conn.Delete(s.fullPath(filePath)); err != nil && !isFTPCode(err, ftp.StatusFileUnavailable) {
+			return err
+		}
+		return s.removeMeta(conn, filePath)
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件删除成功: %s", filePath)
+	return nil
+}
+
+// Rename 实现FTP文件重命名（RNFR/RNTO，覆盖已存在的目标文件）
+func (s *FTPStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在FTP中重命名文件: %s -> %s", oldPath, newPath)
+
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		oldFullPath, newFullPath := s.fullPath(oldPath), s.fullPath(newPath)
+		entry, err := s.stat(conn, oldFullPath)
+		if err != nil {
+			return err
+		}
+		// 确保目标目录存在
+		if err := s.mkdirAll(conn, path.Dir(newFullPath)); err != nil {
+			return err
+		}
+		if err := s.rename(conn, oldFullPath, newFullPath); err != nil {
+			return err
+		}
+		if entry.Type == ftp.EntryTypeFolder {
+			s.forgetDirs(oldFullPath)
+			return s.renameMetaDir(conn, oldPath, newPath)
+		}
+		return s.renameMeta(conn, oldPath, newPath)
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP文件重命名失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现FTP文件移动（与重命名相同的操作）
+func (s *FTPStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现FTP文件复制。FTP 协议没有服务端复制，内容经由客户端中转（同时占用两个连接）。
+func (s *FTPStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在FTP中复制文件: %s -> %s", srcPath, dstPath)
+
+	src, err := s.download(ctx, srcPath, 0, -1)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "打开源文件失败: %v", err)
+		return err
+	}
+	defer src.Close()
+
+	err = s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if err := s.writeFile(conn, s.fullPath(dstPath), src); err != nil {
+			return err
+		}
+		meta, err := s.readMeta(conn, srcPath)
+		if err != nil {
+			return err
+		}
+		return s.writeMeta(conn, dstPath, meta)
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Exists 实现检查FTP文件是否存在（已过期的文件视为不存在）
+func (s *FTPStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if _, err := s.stat(conn, s.fullPath(filePath)); err != nil {
+			return err
+		}
+		return s.checkExpired(conn, filePath)
+	})
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+// CreateDir 实现FTP目录创建（递归创建）
+func (s *FTPStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始创建FTP目录: %s", dirPath)
+
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		return s.mkdirAll(conn, s.fullPath(dirPath))
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "创建FTP目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP目录创建成功: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现FTP目录删除（递归删除目录及其附加元数据）
+func (s *FTPStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除FTP目录: %s", dirPath)
+
+	fullPath := s.fullPath(dirPath)
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		for _, dir := range []string{fullPath, s.metaDirPath(dirPath)} {
+			if err := s.removeAll(conn, dir); err != nil && !errors.Is(err, ErrNotExist) {
+				return err
+			}
+		}
+		return nil
+	})
+	s.forgetDirs(fullPath)
+	s.forgetDirs(s.metaDirPath(dirPath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "FTP删除目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "FTP目录删除成功: %s", dirPath)
+	return nil
+}
+
+// forgetDirs 清除目录及其子目录的缓存
+func (s *FTPStorage) forgetDirs(fullPath string) {
+	s.dirs.Range(func(key, _ any) bool {
+		if dir := key.(string); dir == fullPath || strings.HasPrefix(dir, fullPath+"/") {
+			s.dirs.Delete(key)
+		}
+		return true
+	})
+}
+
+// removeAll 递归删除目录（不切换当前目录）
+func (s *FTPStorage) removeAll(conn *ftp.ServerConn, dir string) error {
+	entries, err := conn.List(dir)
+	if err != nil {
+		return ftpError(err)
+	}
+	for _, entry := range entries {
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		entryPath := path.Join(dir, entry.Name)
+		if entry.Type == ftp.EntryTypeFolder {
+			err = s.removeAll(conn, entryPath)
+		} else {
+			err = conn.Delete(entryPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return conn.RemoveDir(dir)
+}
+
+// ListDir 实现FTP目录列表（仅列出当前层级，不递归）。
+// 服务器支持时使用 MLSD，否则使用 LIST 并解析其输出。
+func (s *FTPStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出FTP目录内容: %s", dirPath)
+
+	var files []FileMetadata
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		fullPath := s.fullPath(dirPath)
+		entries, err := conn.List(fullPath)
+		if err != nil {
+			return ftpError(err)
+		}
+
+		// 只读取存在附加元数据的文件，避免为每个文件建立一次数据连接
+		withMeta := map[string]bool{}
+		if metaEntries, err := conn.List(s.metaDirPath(dirPath)); err == nil {
+			for _, entry := range metaEntries {
+				withMeta[strings.TrimSuffix(entry.Name, ".json")] = true
+			}
+		}
+
+		isRoot := fullPath == s.root
+		files = make([]FileMetadata, 0, len(entries))
+		for _, entry := range entries {
+			// 跳过存放附加元数据的隐藏目录和上传中的临时文件
+			if entry.Name == "." || entry.Name == ".." || isRoot && entry.Name == localMetaDir || strings.HasPrefix(entry.Name, localUploadTempPrefix) {
+				continue
+			}
+			metadata := FileMetadata{
+				Name:    entry.Name,
+				Size:    int64(entry.Size),
+				ModTime: entry.Time,
+				IsDir:   entry.Type == ftp.EntryTypeFolder,
+			}
+			if !metadata.IsDir {
+				entryPath := path.Join(dirPath, entry.Name)
+				var meta *localObjectMeta
+				if withMeta[entry.Name] {
+					meta, _ = s.readMeta(conn, entryPath)
+				}
+				metadata.MIMEType = recordedMIMEType(entryPath, meta)
+			}
+			files = append(files, metadata)
+		}
+		return nil
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出FTP目录内容失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "成功列出FTP目录内容: %s, 共找到 %d 个条目", dirPath, len(files))
+	return files, nil
+}
+
+// GetMetadata 实现获取FTP文件元数据
+func (s *FTPStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取FTP文件元数据: %s", filePath)
+
+	var metadata *FileMetadata
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		fullPath := s.fullPath(filePath)
+		entry, err := s.stat(conn, fullPath)
+		if err != nil {
+			return err
+		}
+		metadata = &FileMetadata{
+			Name:    filePath,
+			Size:    int64(entry.Size),
+			ModTime: entry.Time,
+			IsDir:   entry.Type == ftp.EntryTypeFolder,
+		}
+		if metadata.IsDir {
+			return nil
+		}
+
+		// LIST 返回的时间精度不足，服务器支持时使用 MDTM
+		if !conn.IsTimePreciseInList() && conn.IsGetTimeSupported() {
+			if modTime, err := conn.GetTime(fullPath); err == nil {
+				metadata.ModTime = modTime
+			}
+		}
+		meta, err := s.readMeta(conn, filePath)
+		if err != nil {
+			return err
+		}
+		if meta.ExpiresAt != nil && isExpired(*meta.ExpiresAt) {
+			return errExpired(filePath)
+		}
+		metadata.MIMEType = recordedMIMEType(filePath, meta)
+		return nil
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取FTP文件信息失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "成功获取FTP文件元数据: %s", filePath)
+	return metadata, nil
+}
+
+// UpdateMetadata 更新FTP文件元数据。修改时间通过 MFMT 设置，服务器不支持时返回 ErrNotSupported
+func (s *FTPStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新FTP文件元数据: %s", filePath)
+
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if !metadata.ModTime.IsZero() {
+			if !conn.IsSetTimeSupported() {
+				return fmt.Errorf("FTP服务器不支持修改文件修改时间: %w", ErrNotSupported)
+			}
+			if err := conn.SetTime(s.fullPath(filePath), metadata.ModTime); err != nil {
+				return ftpError(err)
+			}
+		}
+		if metadata.MIMEType != "" {
+			meta, err := s.readMeta(conn, filePath)
+			if err != nil {
+				return err
+			}
+			meta.ContentType = metadata.MIMEType
+			return s.writeMeta(conn, filePath, meta)
+		}
+		return nil
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "更新FTP文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "成功更新FTP文件元数据: %s", filePath)
+	return nil
+}
+
+// GetExpiration 读取FTP文件的删除截止时间（不检查是否已过期）
+func (s *FTPStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	var expiration time.Time
+	err := s.withConn(ctx, func(conn *ftp.ServerConn) error {
+		if _, err := s.stat(conn, s.fullPath(filePath)); err != nil {
+			return err
+		}
+		meta, err := s.readMeta(conn, filePath)
+		if err != nil {
+			return err
+		}
+		if meta.ExpiresAt != nil {
+			expiration = *meta.ExpiresAt
+		}
+		return nil
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取文件元数据失败: %v", err)
+		return time.Time{}, err
+	}
+	return expiration, nil
+}
+
+// BatchUpload 实现FTP批量上传
+func (s *FTPStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到FTP", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现FTP批量下载（流式下载）
+func (s *FTPStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个FTP文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现FTP批量删除
+func (s *FTPStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个FTP文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
+
+// stat 获取文件或目录信息。服务器支持时使用 MLST，否则列出上级目录查找
+func (s *FTPStorage) stat(conn *ftp.ServerConn, fullPath string) (*ftp.Entry, error) {
+	entry, err := conn.GetEntry(fullPath)
+	if err == nil {
+		return entry, nil
+	}
+	if !isFTPCode(err, ftp.StatusBadCommand, ftp.StatusBadArguments, ftp.StatusNotImplemented, ftp.StatusNotImplementedParameter) {
+		return nil, ftpError(err)
+	}
+
+	entries, err := conn.List(path.Dir(fullPath))
+	if err != nil {
+		return nil, ftpError(err)
+	}
+	name := path.Base(fullPath)
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry, nil
+		}
+	}
+	return nil, &notExistError{err: fmt.Errorf("FTP文件不存在: %s", fullPath)}
+}
+
+// metaPath 返回文件对应的附加元数据路径
+func (s *FTPStorage) metaPath(filePath string) string {
+	return path.Join(s.root, localMetaDir, filePath) + ".json"
+}
+
+// metaDirPath 返回目录对应的附加元数据目录
+func (s *FTPStorage) metaDirPath(dirPath string) string {
+	return path.Join(s.root, localMetaDir, dirPath)
+}
+
+// readMeta 读取文件的附加元数据，不存在时返回空元数据
+func (s *FTPStorage) readMeta(conn *ftp.ServerConn, filePath string) (*localObjectMeta, error) {
+	meta := &localObjectMeta{}
+	resp, err := conn.Retr(s.metaPath(filePath))
+	if err != nil {
+		if isFTPCode(err, ftp.StatusFileUnavailable) {
+			return meta, nil
+		}
+		return nil, err
+	}
+	err = json.NewDecoder(resp).Decode(meta)
+	io.Copy(io.Discard, resp)
+	if closeErr := resp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// writeMeta 写入文件的附加元数据，元数据为空时删除对应文件
+func (s *FTPStorage) writeMeta(conn *ftp.ServerConn, filePath string, meta *localObjectMeta) error {
+	if meta == nil || meta.isEmpty() {
+		return s.removeMeta(conn, filePath)
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(conn, s.metaPath(filePath), strings.NewReader(string(data)))
+}
+
+// removeMeta 删除文件的附加元数据
+func (s *FTPStorage) removeMeta(conn *ftp.ServerConn, filePath string) error {
+	if err := conn.Delete(s.metaPath(filePath)); err != nil && !isFTPCode(err, ftp.StatusFileUnavailable) {
+		return err
+	}
+	return nil
+}
+
+// renameMeta 随文件一起移动附加元数据
+func (s *FTPStorage) renameMeta(conn *ftp.ServerConn, oldPath, newPath string) error {
+	meta, err := s.readMeta(conn, oldPath)
+	if err != nil {
+		return err
+	}
+	if err := s.writeMeta(conn, newPath, meta); err != nil {
+		return err
+	}
+	return s.removeMeta(conn, oldPath)
+}
+
+// renameMetaDir 随目录一起移动附加元数据目录，先删除目标位置残留的附加元数据
+func (s *FTPStorage) renameMetaDir(conn *ftp.ServerConn, oldPath, newPath string) error {
+	oldMetaDir, newMetaDir := s.metaDirPath(oldPath), s.metaDirPath(newPath)
+	if _, err := s.stat(conn, oldMetaDir); err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := s.removeAll(conn, newMetaDir); err != nil && !errors.Is(err, ErrNotExist) {
+		return err
+	}
+	s.forgetDirs(newMetaDir)
+	if err := s.mkdirAll(conn, path.Dir(newMetaDir)); err != nil {
+		return err
+	}
+	if err := conn.Rename(oldMetaDir, newMetaDir); err != nil {
+		return err
+	}
+	s.forgetDirs(oldMetaDir)
+	return nil
+}
+
+// checkExpired 检查文件是否已过期，过期时返回 ErrNotExist
+func (s *FTPStorage) checkExpired(conn *ftp.ServerConn, filePath string) error {
+	meta, err := s.readMeta(conn, filePath)
+	if err != nil {
+		return err
+	}
+	if meta.ExpiresAt != nil && isExpired(*meta.ExpiresAt) {
+		return errExpired(filePath)
+	}
+	return nil
+}
+
+// isFTPCode 判断错误是否为指定状态码的FTP应答
+func isFTPCode(err error, codes ...int) bool {
+	var reply *textproto.Error
+	if !errors.As(err, &reply) {
+		return false
+	}
+	for _, code := range codes {
+		if reply.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ftpError 将 550 和 450 应答转换为 ErrNotExist（FTP 没有区分“不存在”和“不可用”的状态码，
+// 部分服务器列出不存在的目录时返回 450）
+func ftpError(err error) error {
+	if isFTPCode(err, ftp.StatusFileUnavailable, ftp.StatusFileActionIgnored) {
+		return &notExistError{err: err}
+	}
+	return err
+}
@@ -0,0 +1,271 @@
+package storage_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ftpserver "github.com/fclairamb/ftpserverlib"
+	"github.com/spf13/afero"
+	"github.com/v-mars/storage"
+)
+
+// ftpTestDriver 进程内FTP服务器的驱动，用户 tester/secret 读写 root 目录
+type ftpTestDriver struct {
+	root      string
+	settings  *ftpserver.Settings
+	tlsConfig *tls.Config
+	connected atomic.Int32 // 建立过的控制连接数
+}
+
+func (d *ftpTestDriver) GetSettings() (*ftpserver.Settings, error) { return d.settings, nil }
+
+func (d *ftpTestDriver) ClientConnected(ftpserver.ClientContext) (string, error) {
+	d.connected.Add(1)
+	return "storage test server", nil
+}
+
+func (d *ftpTestDriver) ClientDisconnected(ftpserver.ClientContext) {}
+
+func (d *ftpTestDriver) AuthUser(_ ftpserver.ClientContext, user, pass string) (ftpserver.ClientDriver, error) {
+	if user != "tester" || pass != "secret" {
+		return nil, os.ErrPermission
+	}
+	return afero.NewBasePathFs(afero.NewOsFs(), d.root), nil
+}
+
+func (d *ftpTestDriver) GetTLSConfig() (*tls.Config, error) { return d.tlsConfig, nil }
+
+// ftpTestServer 进程内的FTP服务器
+type ftpTestServer struct {
+	*ftpTestDriver
+	host   string
+	port   int
+	certs  *x509.CertPool // 用于校验服务器证书
+	server *ftpserver.FtpServer
+}
+
+// newFTPTestServer 启动FTP服务器。tlsMode 为 implicit 时使用隐式TLS，否则同时接受明文和显式TLS；
+// listOnly 为 true 时服务器不支持 MLSD/MLST，客户端只能解析 LIST 输出
+func newFTPTestServer(t *testing.T, tlsMode string, listOnly bool) *ftpTestServer {
+	t.Helper()
+	cert, certs := newTestCertificate(t)
+	driver := &ftpTestDriver{
+		root: t.TempDir(),
+		settings: &ftpserver.Settings{
+			ListenAddr:  "127.0.0.1:0",
+			DisableMLSD: listOnly,
+			DisableMLST: listOnly,
+		},
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	if tlsMode == storage.FTPTLSImplicit {
+		driver.settings.TLSRequired = ftpserver.ImplicitEncryption
+	}
+
+	server := ftpserver.NewFtpServer(driver)
+	if err := server.Listen(); err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	go server.Serve()
+	t.Cleanup(func() { server.Stop() })
+
+	host, port, _ := net.SplitHostPort(server.Addr())
+	portNum, _ := strconv.Atoi(port)
+	return &ftpTestServer{ftpTestDriver: driver, host: host, port: portNum, certs: certs, server: server}
+}
+
+// config 返回访问该服务器的配置
+func (s *ftpTestServer) config(baseDir, tlsMode string) storage.FTPStorageConfig {
+	config := storage.FTPStorageConfig{
+		Host:     s.host,
+		Port:     s.port,
+		User:     "tester",
+		Password: "secret",
+		TLSMode:  tlsMode,
+		BaseDir:  baseDir,
+		PoolSize: 2,
+	}
+	if tlsMode != "" {
+		config.TLSConfig = &tls.Config{ServerName: s.host, RootCAs: s.certs}
+	}
+	return config
+}
+
+// newTestCertificate 生成 127.0.0.1 的自签名证书
+func newTestCertificate(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "storage test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	parsed, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(parsed)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+func TestFTPStorage_Backend(t *testing.T) {
+	server := newFTPTestServer(t, "", false)
+	s := storage.NewFTPStorage(server.config("base", ""))
+	if s == nil {
+		t.Fatal("NewFTPStorage returned nil")
+	}
+	defer s.(*storage.FTPStorage).Close()
+	ctx := context.Background()
+
+	// 顺序执行的操作复用同一个连接，读完并关闭的下载会归还连接
+	for i := 0; i < 5; i++ {
+		if err := s.Upload(ctx, "pool.txt", strings.NewReader("pooled")); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+		if got := readString(t)(s.Download(ctx, "pool.txt")); got != "pooled" {
+			t.Fatalf("Unexpected content: %q", got)
+		}
+	}
+	if n := server.connected.Load(); n != 1 {
+		t.Fatalf("Expected 1 pooled connection, got %d", n)
+	}
+
+	// 文件保存在基础目录下，临时文件和附加元数据目录不出现在列表中
+	if data, err := os.ReadFile(filepath.Join(server.root, "base", "pool.txt")); err != nil || string(data) != "pooled" {
+		t.Fatalf("Unexpected file on server: %q, %v", data, err)
+	}
+	if err := s.Upload(ctx, "typed", strings.NewReader("{}"), storage.WithContentType("application/json")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	files, err := s.ListDir(ctx, "")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", files)
+	}
+	for _, file := range files {
+		if file.Name == "typed" && file.MIMEType != "application/json" {
+			t.Fatalf("Recorded content type not used: %q", file.MIMEType)
+		}
+	}
+
+	// 续传：等于文件大小时追加；服务器不截断其后内容时报告错误
+	resumable, ok := storage.As[storage.ResumableUploader](s)
+	if !ok {
+		t.Fatal("FTPStorage should implement ResumableUploader")
+	}
+	if err := resumable.UploadAt(ctx, "pool.txt", strings.NewReader(" more"), 6); err != nil {
+		t.Fatalf("UploadAt failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "pool.txt")); got != "pooled more" {
+		t.Fatalf("Unexpected appended content: %q", got)
+	}
+	if err := resumable.UploadAt(ctx, "pool.txt", strings.NewReader("P"), 1); err == nil {
+		t.Fatal("Expected UploadAt to report untruncated content")
+	}
+
+	// 重命名目录时附加元数据随目录移动，原路径可以重新创建
+	if err := s.Upload(ctx, "old/sub/ttl.txt", strings.NewReader("t"), storage.WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Rename(ctx, "old", "new"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	expiration, _ := storage.As[storage.ExpirationReader](s)
+	if deadline, err := expiration.GetExpiration(ctx, "new/sub/ttl.txt"); err != nil || time.Until(deadline) < 59*time.Minute {
+		t.Fatalf("Expiration not moved: %v, %v", deadline, err)
+	}
+	if err := s.Upload(ctx, "old/sub/ttl.txt", strings.NewReader("t"), storage.WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload after rename failed: %v", err)
+	}
+}
+
+func TestFTPStorage_TLS(t *testing.T) {
+	ctx := context.Background()
+	for _, mode := range []string{storage.FTPTLSExplicit, storage.FTPTLSImplicit} {
+		t.Run(mode, func(t *testing.T) {
+			server := newFTPTestServer(t, mode, false)
+			s := storage.NewFTPStorage(server.config("tls", mode))
+			if s == nil {
+				t.Fatal("NewFTPStorage returned nil")
+			}
+			defer s.(*storage.FTPStorage).Close()
+
+			if err := s.Upload(ctx, "secure.txt", strings.NewReader("encrypted")); err != nil {
+				t.Fatalf("Upload failed: %v", err)
+			}
+			if got := readString(t)(s.DownloadRange(ctx, "secure.txt", 2, 5)); got != "crypt" {
+				t.Fatalf("Unexpected range content: %q", got)
+			}
+
+			// 不信任服务器证书时连接失败
+			config := server.config("tls", mode)
+			config.TLSConfig = nil
+			if s := storage.NewFTPStorage(config); s != nil {
+				t.Fatal("Expected NewFTPStorage to reject untrusted certificate")
+			}
+		})
+	}
+}
+
+func TestFTPStorage_ListFallback(t *testing.T) {
+	server := newFTPTestServer(t, "", true)
+	s := storage.NewFTPStorage(server.config("list", ""))
+	if s == nil {
+		t.Fatal("NewFTPStorage returned nil")
+	}
+	defer s.(*storage.FTPStorage).Close()
+	ctx := context.Background()
+
+	if err := s.Upload(ctx, "dir/file name.txt", strings.NewReader("listed")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	metadata, err := s.GetMetadata(ctx, "dir/file name.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Size != 6 || metadata.IsDir {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+	files, err := s.ListDir(ctx, "dir")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "file name.txt" || files[0].Size != 6 {
+		t.Fatalf("Unexpected entries: %+v", files)
+	}
+	if _, err := s.GetMetadata(ctx, "dir/missing.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,498 @@
+package storage
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+const (
+	// gatewayDefaultBasePath 默认的网关路由前缀
+	gatewayDefaultBasePath = "/api/v1"
+	// gatewayDefaultPageSize 默认的单页目录条目数
+	gatewayDefaultPageSize = 1000
+	// gatewayMaxJSONBody JSON 请求体的最大字节数
+	gatewayMaxJSONBody = 4 << 20
+	// gatewayExpirationHeader 上传有效期（秒）请求头
+	gatewayExpirationHeader = "X-Upload-Expiration"
+	// gatewayContentTypeHeader 批量上传时指定 MIME 类型的请求头
+	gatewayContentTypeHeader = "X-Upload-Content-Type"
+)
+
+// 网关错误响应中的错误码，RemoteStorage 据此还原为对应的错误
+const (
+	gatewayCodeNotExist           = "not_exist"
+	gatewayCodeNotSupported       = "not_supported"
+	gatewayCodePreconditionFailed = "precondition_failed"
+	gatewayCodeTooLarge           = "too_large"
+	gatewayCodeQuotaExceeded      = "quota_exceeded"
+	gatewayCodeBadRequest         = "bad_request"
+	gatewayCodeUnauthorized       = "unauthorized"
+	gatewayCodeInternal           = "internal"
+)
+
+// GatewayConfig 存储网关配置
+type GatewayConfig struct {
+	Storage  Storage  `json:"-"`         // 对外提供服务的存储
+	Tokens   []string `json:"tokens"`    // 允许的访问令牌（Authorization: Bearer <token>），为空时不认证
+	BasePath string   `json:"base_path"` // 路由前缀，默认 /api/v1
+	PageSize int      `json:"page_size"` // 目录列表的单页最大条目数，默认 1000
+}
+
+// Gateway 将 Storage 以 HTTP 接口对外提供，供其他语言的服务使用，RemoteStorage 是对应的 Go 客户端。
+// 路由（均位于 BasePath 下）：
+//
+//	PUT    /files/*path      上传，Content-Type 指定 MIME 类型（通用类型时自动检测），X-Upload-Expiration 指定有效期（秒）
+//	GET    /files/*path      下载，支持 Range 和条件请求
+//	HEAD   /files/*path      文件响应头
+//	DELETE /files/*path      删除文件
+//	GET    /exists/*path     {"exists": true}
+//	GET    /metadata/*path   获取元数据
+//	PATCH  /metadata/*path   更新元数据
+//	GET    /dirs/*path       列出目录，?limit=&marker= 分页，返回 {"entries": [...], "next_marker": "..."}
+//	PUT    /dirs/*path       创建目录
+//	DELETE /dirs/*path       递归删除目录
+//	POST   /rename、/move、/copy          {"src": "...", "dst": "..."}
+//	POST   /batch/upload     multipart/form-data，每个分段的字段名为 URL 编码的目标路径
+//	POST   /batch/download   {"paths": [...]}，返回 multipart/form-data，字段名为 URL 编码的路径
+//	POST   /batch/delete     {"paths": [...]}
+//	GET    /healthz          健康检查，不需要认证
+//
+// 出错时返回 {"error": "...", "code": "not_exist"}，code 为 not_exist、not_supported、precondition_failed、
+// too_large、quota_exceeded、bad_request、unauthorized 或 internal。
+type Gateway struct {
+	config GatewayConfig
+	files  app.HandlerFunc
+}
+
+// gatewayError 网关错误响应
+type gatewayError struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// gatewayPathPair 重命名、移动和复制的请求体
+type gatewayPathPair struct {
+	Src string `json:"src"`
+	Dst string `json:"dst"`
+}
+
+// gatewayPaths 批量下载和删除的请求体
+type gatewayPaths struct {
+	Paths []string `json:"paths"`
+}
+
+// gatewayListing 目录列表响应
+type gatewayListing struct {
+	Entries    []FileMetadata `json:"entries"`
+	NextMarker string         `json:"next_marker,omitempty"`
+}
+
+// NewGateway 创建存储网关，通过 Register 注册路由：
+//
+//	gateway := storage.NewGateway(storage.GatewayConfig{Storage: s, Tokens: []string{token}})
+//	gateway.Register(h)
+func NewGateway(config GatewayConfig) *Gateway {
+	if config.BasePath == "" {
+		config.BasePath = gatewayDefaultBasePath
+	}
+	config.BasePath = strings.TrimSuffix("/"+strings.Trim(config.BasePath, "/"), "/")
+	if config.PageSize <= 0 {
+		config.PageSize = gatewayDefaultPageSize
+	}
+	return &Gateway{
+		config: config,
+		files:  NewFileHandler(FileHandlerConfig{Storage: config.Storage, Param: "path"}),
+	}
+}
+
+// Register 在路由组上注册网关路由
+func (g *Gateway) Register(r route.IRoutes) {
+	base := g.config.BasePath
+	r.GET(base+"/healthz", func(ctx context.Context, c *app.RequestContext) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	r.PUT(base+"/files/*path", g.auth, g.upload)
+	r.GET(base+"/files/*path", g.auth, g.files)
+	r.HEAD(base+"/files/*path", g.auth, g.files)
+	r.DELETE(base+"/files/*path", g.auth, g.delete)
+	r.GET(base+"/exists/*path", g.auth, g.exists)
+	r.GET(base+"/metadata/*path", g.auth, g.getMetadata)
+	r.PATCH(base+"/metadata/*path", g.auth, g.updateMetadata)
+
+	r.GET(base+"/dirs/*path", g.auth, g.listDir)
+	r.PUT(base+"/dirs/*path", g.auth, g.createDir)
+	r.DELETE(base+"/dirs/*path", g.auth, g.deleteDir)
+
+	r.POST(base+"/rename", g.auth, g.transfer(g.config.Storage.Rename))
+	r.POST(base+"/move", g.auth, g.transfer(g.config.Storage.Move))
+	r.POST(base+"/copy", g.auth, g.transfer(g.config.Storage.Copy))
+
+	r.POST(base+"/batch/upload", g.auth, g.batchUpload)
+	r.POST(base+"/batch/download", g.auth, g.batchDownload)
+	r.POST(base+"/batch/delete", g.auth, g.batchDelete)
+}
+
+// auth 校验访问令牌
+func (g *Gateway) auth(ctx context.Context, c *app.RequestContext) {
+	if len(g.config.Tokens) == 0 {
+		return
+	}
+	token, ok := strings.CutPrefix(string(c.GetHeader("Authorization")), "Bearer ")
+	if ok {
+		for _, allowed := range g.config.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+				return
+			}
+		}
+	}
+	c.Header("WWW-Authenticate", `Bearer realm="storage"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gatewayError{Error: "无效的访问令牌", Code: gatewayCodeUnauthorized})
+}
+
+// filePath 返回路由参数中的存储路径
+func (g *Gateway) filePath(c *app.RequestContext) string {
+	return cleanRequestPath(c.Param("path"))
+}
+
+// cleanRequestPath 规范化客户端传入的存储路径：去掉 ".." 等相对路径和首尾斜杠，
+// 使请求无法访问存储根目录之外的文件。路径指向根目录时返回空字符串
+func cleanRequestPath(filePath string) string {
+	return strings.Trim(path.Clean("/"+filePath), "/")
+}
+
+// cleanRequestPaths 规范化请求体中的路径列表，任一路径为空时返回错误
+func cleanRequestPaths(filePaths []string) ([]string, error) {
+	cleaned := make([]string, len(filePaths))
+	for i, filePath := range filePaths {
+		if cleaned[i] = cleanRequestPath(filePath); cleaned[i] == "" {
+			return nil, fmt.Errorf("无效的文件路径: %q", filePath)
+		}
+	}
+	return cleaned, nil
+}
+
+// abort 按错误类型返回状态码和错误码
+func (g *Gateway) abort(ctx context.Context, c *app.RequestContext, err error) {
+	status, code := http.StatusInternalServerError, gatewayCodeInternal
+	switch {
+	case errors.Is(err, ErrNotExist):
+		status, code = http.StatusNotFound, gatewayCodeNotExist
+	case errors.Is(err, ErrNotSupported):
+		status, code = http.StatusNotImplemented, gatewayCodeNotSupported
+	case errors.Is(err, ErrPreconditionFailed):
+		status, code = http.StatusPreconditionFailed, gatewayCodePreconditionFailed
+	case errors.Is(err, ErrTooLarge):
+		status, code = http.StatusRequestEntityTooLarge, gatewayCodeTooLarge
+	case errors.Is(err, ErrQuotaExceeded):
+		status, code = http.StatusInsufficientStorage, gatewayCodeQuotaExceeded
+	default:
+		hlog.CtxErrorf(ctx, "存储网关请求失败: %s %s, %v", c.Method(), c.Path(), err)
+	}
+	c.AbortWithStatusJSON(status, gatewayError{Error: err.Error(), Code: code})
+}
+
+// badRequest 返回 400
+func (g *Gateway) badRequest(c *app.RequestContext, msg string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, gatewayError{Error: msg, Code: gatewayCodeBadRequest})
+}
+
+// uploadOptions 从请求头读取上传选项
+func (g *Gateway) uploadOptions(c *app.RequestContext, contentType string) ([]UploadOption, error) {
+	var opts []UploadOption
+	if contentType != "" {
+		opts = append(opts, WithContentType(contentType))
+	}
+	if value := string(c.GetHeader(gatewayExpirationHeader)); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("无效的 %s: %q", gatewayExpirationHeader, value)
+		}
+		opts = append(opts, WithExpiration(time.Duration(seconds)*time.Second))
+	}
+	return opts, nil
+}
+
+// decodeJSON 解析 JSON 请求体
+func (g *Gateway) decodeJSON(c *app.RequestContext, v interface{}) bool {
+	if err := json.NewDecoder(io.LimitReader(requestBody(c), gatewayMaxJSONBody)).Decode(v); err != nil {
+		g.badRequest(c, "无效的请求体: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// upload 上传请求体
+func (g *Gateway) upload(ctx context.Context, c *app.RequestContext) {
+	filePath := g.filePath(c)
+	if filePath == "" {
+		g.badRequest(c, "缺少文件路径")
+		return
+	}
+	// curl 等客户端默认发送的通用类型不作为文件类型，由后端自动检测
+	contentType := string(c.ContentType())
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" || mediaType == defaultMIMEType {
+		contentType = ""
+	}
+	opts, err := g.uploadOptions(c, contentType)
+	if err != nil {
+		g.badRequest(c, err.Error())
+		return
+	}
+	if err := g.config.Storage.Upload(ctx, filePath, requestBody(c), opts...); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// delete 删除文件
+func (g *Gateway) delete(ctx context.Context, c *app.RequestContext) {
+	if err := g.config.Storage.Delete(ctx, g.filePath(c)); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// exists 检查文件是否存在
+func (g *Gateway) exists(ctx context.Context, c *app.RequestContext) {
+	exists, err := g.config.Storage.Exists(ctx, g.filePath(c))
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.JSON(http.StatusOK, map[string]bool{"exists": exists})
+}
+
+// getMetadata 获取元数据
+func (g *Gateway) getMetadata(ctx context.Context, c *app.RequestContext) {
+	metadata, err := g.config.Storage.GetMetadata(ctx, g.filePath(c))
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.JSON(http.StatusOK, metadata)
+}
+
+// updateMetadata 更新元数据
+func (g *Gateway) updateMetadata(ctx context.Context, c *app.RequestContext) {
+	var metadata FileMetadata
+	if !g.decodeJSON(c, &metadata) {
+		return
+	}
+	if err := g.config.Storage.UpdateMetadata(ctx, g.filePath(c), &metadata); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// listDir 分页列出目录：条目按名称排序，返回名称大于 marker 的最多 limit 个条目
+func (g *Gateway) listDir(ctx context.Context, c *app.RequestContext) {
+	limit := g.config.PageSize
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			g.badRequest(c, "无效的 limit: "+value)
+			return
+		}
+		limit = min(n, g.config.PageSize)
+	}
+	marker := c.Query("marker")
+
+	entries, err := g.config.Storage.ListDir(ctx, g.filePath(c))
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+	start := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Name > marker
+	})
+	listing := gatewayListing{Entries: entries[start:]}
+	if len(listing.Entries) > limit {
+		listing.Entries = listing.Entries[:limit]
+		listing.NextMarker = listing.Entries[limit-1].Name
+	}
+	if listing.Entries == nil {
+		listing.Entries = []FileMetadata{}
+	}
+	c.JSON(http.StatusOK, listing)
+}
+
+// createDir 创建目录
+func (g *Gateway) createDir(ctx context.Context, c *app.RequestContext) {
+	if err := g.config.Storage.CreateDir(ctx, g.filePath(c)); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// deleteDir 递归删除目录
+func (g *Gateway) deleteDir(ctx context.Context, c *app.RequestContext) {
+	// 不允许删除存储根目录
+	dirPath := g.filePath(c)
+	if dirPath == "" {
+		g.badRequest(c, "缺少目录路径")
+		return
+	}
+	if err := g.config.Storage.DeleteDir(ctx, dirPath); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// transfer 返回重命名、移动或复制的处理器
+func (g *Gateway) transfer(op func(ctx context.Context, srcPath, dstPath string) error) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		var pair gatewayPathPair
+		if !g.decodeJSON(c, &pair) {
+			return
+		}
+		srcPath, dstPath := cleanRequestPath(pair.Src), cleanRequestPath(pair.Dst)
+		if srcPath == "" || dstPath == "" {
+			g.badRequest(c, "缺少 src 或 dst")
+			return
+		}
+		if err := op(ctx, srcPath, dstPath); err != nil {
+			g.abort(ctx, c, err)
+			return
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// batchUpload 逐个上传表单中的文件分段，任一文件失败时返回错误，此前的文件保持已上传
+func (g *Gateway) batchUpload(ctx context.Context, c *app.RequestContext) {
+	mediaType, params, err := mime.ParseMediaType(string(c.ContentType()))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		g.badRequest(c, "请求体必须是 multipart/form-data")
+		return
+	}
+	opts, err := g.uploadOptions(c, string(c.GetHeader(gatewayContentTypeHeader)))
+	if err != nil {
+		g.badRequest(c, err.Error())
+		return
+	}
+
+	reader := multipart.NewReader(requestBody(c), params["boundary"])
+	count := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			g.badRequest(c, "读取上传表单失败: "+err.Error())
+			return
+		}
+		filePath, err := url.PathUnescape(part.FormName())
+		if filePath = cleanRequestPath(filePath); err != nil || filePath == "" {
+			part.Close()
+			g.badRequest(c, "无效的文件路径: "+part.FormName())
+			return
+		}
+		err = g.config.Storage.Upload(ctx, filePath, part, opts...)
+		part.Close()
+		if err != nil {
+			g.abort(ctx, c, err)
+			return
+		}
+		count++
+	}
+	hlog.CtxInfof(ctx, "存储网关批量上传完成: %d 个文件", count)
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// batchDownload 先通过 BatchDownload 打开全部文件，再以 multipart/form-data 逐个返回
+func (g *Gateway) batchDownload(ctx context.Context, c *app.RequestContext) {
+	var request gatewayPaths
+	if !g.decodeJSON(c, &request) {
+		return
+	}
+	filePaths, err := cleanRequestPaths(request.Paths)
+	if err != nil {
+		g.badRequest(c, err.Error())
+		return
+	}
+	readers, err := g.config.Storage.BatchDownload(ctx, filePaths)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		var err error
+		// 分段名称使用客户端请求的原始路径，便于客户端按请求路径查找
+		for i, filePath := range filePaths {
+			reader, ok := readers[filePath]
+			if !ok {
+				continue
+			}
+			delete(readers, filePath)
+			if err == nil {
+				header := make(textproto.MIMEHeader)
+				header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, url.PathEscape(request.Paths[i])))
+				header.Set("Content-Type", "application/octet-stream")
+				var part io.Writer
+				if part, err = mw.CreatePart(header); err == nil {
+					_, err = io.Copy(part, reader)
+				}
+			}
+			if closer, ok := reader.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	c.SetStatusCode(http.StatusOK)
+	c.Response.Header.SetContentType(mw.FormDataContentType())
+	c.SetBodyStream(pr, -1)
+}
+
+// batchDelete 批量删除文件
+func (g *Gateway) batchDelete(ctx context.Context, c *app.RequestContext) {
+	var request gatewayPaths
+	if !g.decodeJSON(c, &request) {
+		return
+	}
+	filePaths, err := cleanRequestPaths(request.Paths)
+	if err != nil {
+		g.badRequest(c, err.Error())
+		return
+	}
+	if err := g.config.Storage.BatchDelete(ctx, filePaths); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
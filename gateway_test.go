@@ -0,0 +1,258 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/v-mars/storage"
+)
+
+// startGateway 在随机端口启动存储网关，返回包含路由前缀的地址
+func startGateway(t *testing.T, config storage.GatewayConfig) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	h := server.New(
+		server.WithHostPorts(addr),
+		server.WithStreamBody(true),
+		server.WithDisablePrintRoute(true),
+		server.WithExitWaitTime(0),
+	)
+	storage.NewGateway(config).Register(h)
+	go h.Spin()
+	t.Cleanup(func() { h.Shutdown(context.Background()) })
+
+	// 等待服务就绪
+	for i := 0; i < 100; i++ {
+		if resp, err := http.Get("http://" + addr + "/api/v1/healthz"); err == nil {
+			resp.Body.Close()
+			return "http://" + addr + "/api/v1"
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("Gateway did not start")
+	return ""
+}
+
+func TestGateway(t *testing.T) {
+	backend := storage.NewSizeLimitedStorage(storage.NewMemoryStorage(storage.MemoryStorageConfig{}), 8<<20)
+	endpoint := startGateway(t, storage.GatewayConfig{Storage: backend, Tokens: []string{"secret", "other"}, PageSize: 2})
+	s := storage.NewRemoteStorage(storage.RemoteStorageConfig{Endpoint: endpoint, Token: "other"})
+	ctx := context.Background()
+
+	// 令牌认证
+	unauthorized := storage.NewRemoteStorage(storage.RemoteStorageConfig{Endpoint: endpoint, Token: "wrong"})
+	if _, err := unauthorized.Exists(ctx, "a.txt"); err == nil || !strings.Contains(err.Error(), "令牌") {
+		t.Fatalf("Expected authentication error, got %v", err)
+	}
+
+	// 超过 Hertz 默认请求体上限（4MB）的文件流式上传
+	large := bytes.Repeat([]byte("0123456789abcdef"), 5<<16)
+	if err := s.Upload(ctx, "big/large.bin", io.MultiReader(bytes.NewReader(large))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	reader, err := s.DownloadRange(ctx, "big/large.bin", int64(len(large))-16, 16)
+	if got := readString(t)(reader, err); got != "0123456789abcdef" {
+		t.Fatalf("Unexpected range content: %q", got)
+	}
+
+	// 后端错误通过错误码还原
+	err = s.Upload(ctx, "big/too-large.bin", bytes.NewReader(make([]byte, 9<<20)))
+	if !errors.Is(err, storage.ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+	readOnly := startGateway(t, storage.GatewayConfig{Storage: storage.NewFSStorage(fstest.MapFS{})})
+	err = storage.NewRemoteStorage(storage.RemoteStorageConfig{Endpoint: readOnly}).Upload(ctx, "a.txt", strings.NewReader("a"))
+	if !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+
+	// 分页列表：客户端自动读取所有分页
+	for i := 0; i < 5; i++ {
+		if err := s.Upload(ctx, fmt.Sprintf("page/%d.txt", i), strings.NewReader("x")); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	files, err := s.ListDir(ctx, "page")
+	if err != nil || len(files) != 5 || files[4].Name != "4.txt" {
+		t.Fatalf("Unexpected listing: %+v, %v", files, err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, endpoint+"/dirs/page?limit=10&marker=1.txt", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("List request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	var listing struct {
+		Entries    []storage.FileMetadata `json:"entries"`
+		NextMarker string                 `json:"next_marker"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&listing); err != nil {
+		t.Fatalf("Decode listing failed: %v", err)
+	}
+	if len(listing.Entries) != 2 || listing.Entries[0].Name != "2.txt" || listing.NextMarker != "3.txt" {
+		t.Fatalf("Unexpected page: %+v", listing)
+	}
+
+	// 有效期通过请求头传递
+	if err := s.Upload(ctx, "temp.txt", strings.NewReader("t"), storage.WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	expiration, _ := storage.As[storage.ExpirationReader](backend)
+	deadline, err := expiration.GetExpiration(ctx, "temp.txt")
+	if err != nil || time.Until(deadline) < 59*time.Minute {
+		t.Fatalf("Unexpected expiration: %v, %v", deadline, err)
+	}
+}
+
+func TestGateway_BatchWithBaseDir(t *testing.T) {
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	endpoint := startGateway(t, storage.GatewayConfig{Storage: backend})
+	s := storage.NewRemoteStorage(storage.RemoteStorageConfig{Endpoint: endpoint, BaseDir: "tenant"})
+	ctx := context.Background()
+
+	files := map[string]io.Reader{
+		"a.txt":        strings.NewReader("a"),
+		"dir/b c#?.md": strings.NewReader("b"),
+		"中文/文件.txt":    strings.NewReader("c"),
+	}
+	if err := s.BatchUpload(ctx, files, storage.WithContentType("text/x-test")); err != nil {
+		t.Fatalf("BatchUpload failed: %v", err)
+	}
+	metadata, err := backend.GetMetadata(ctx, "tenant/dir/b c#?.md")
+	if err != nil || metadata.MIMEType != "text/x-test" {
+		t.Fatalf("Unexpected backend metadata: %+v, %v", metadata, err)
+	}
+
+	readers, err := s.BatchDownload(ctx, []string{"a.txt", "dir/b c#?.md", "中文/文件.txt"})
+	if err != nil || len(readers) != 3 {
+		t.Fatalf("BatchDownload failed: %v, %v", readers, err)
+	}
+	if got := readString(t)(readers["中文/文件.txt"], nil); got != "c" {
+		t.Fatalf("Unexpected batch content: %q", got)
+	}
+	if _, err := s.BatchDownload(ctx, []string{"a.txt", "missing.txt"}); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+
+	if err := s.BatchDelete(ctx, []string{"a.txt", "dir/b c#?.md"}); err != nil {
+		t.Fatalf("BatchDelete failed: %v", err)
+	}
+	if exists, _ := backend.Exists(ctx, "tenant/a.txt"); exists {
+		t.Fatal("tenant/a.txt should be deleted")
+	}
+	if exists, _ := backend.Exists(ctx, "tenant/中文/文件.txt"); !exists {
+		t.Fatal("tenant/中文/文件.txt should be kept")
+	}
+}
+
+func TestGateway_PathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	backend := storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: root})
+	endpoint := startGateway(t, storage.GatewayConfig{Storage: backend})
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "a.txt", strings.NewReader("a")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	post := func(route, contentType string, body io.Reader) (int, string) {
+		t.Helper()
+		resp, err := http.Post(endpoint+route, contentType, body)
+		if err != nil {
+			t.Fatalf("Request %s failed: %v", route, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+	assertSecretKept := func() {
+		t.Helper()
+		if data, err := os.ReadFile(secret); err != nil || string(data) != "secret" {
+			t.Fatalf("File outside the storage root was modified: %q, %v", data, err)
+		}
+	}
+
+	// 请求体中的 ../ 被限制在存储根目录内
+	for _, route := range []string{"/copy", "/move", "/rename"} {
+		if status, _ := post(route, "application/json", strings.NewReader(`{"src":"../secret.txt","dst":"stolen.txt"}`)); status != http.StatusNotFound {
+			t.Fatalf("%s: expected 404, got %d", route, status)
+		}
+		if status, _ := post(route, "application/json", strings.NewReader(`{"src":"a.txt","dst":"../../"}`)); status != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400 for empty dst, got %d", route, status)
+		}
+	}
+	if exists, _ := backend.Exists(ctx, "stolen.txt"); exists {
+		t.Fatal("File outside the storage root should not be copied")
+	}
+	assertSecretKept()
+
+	if _, body := post("/batch/download", "application/json", strings.NewReader(`{"paths":["../secret.txt"]}`)); strings.Contains(body, "\r\n\r\nsecret") {
+		t.Fatalf("File outside the storage root was downloaded: %s", body)
+	}
+	if status, _ := post("/batch/delete", "application/json", strings.NewReader(`{"paths":["../secret.txt"]}`)); status == http.StatusBadRequest {
+		t.Fatal("Cleaned path should be accepted")
+	}
+	if status, _ := post("/batch/delete", "application/json", strings.NewReader(`{"paths":["a.txt","/.."]}`)); status != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for empty path, got %d", status)
+	}
+	if exists, _ := backend.Exists(ctx, "a.txt"); !exists {
+		t.Fatal("Rejected batch should not delete any file")
+	}
+	assertSecretKept()
+
+	// 表单分段名称同样被规范化
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile(url.PathEscape("../escaped.txt"), "escaped.txt")
+	part.Write([]byte("escaped"))
+	mw.Close()
+	if status, _ := post("/batch/upload", mw.FormDataContentType(), &body); status != http.StatusNoContent {
+		t.Fatalf("Expected 204, got %d", status)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatalf("Upload escaped the storage root: %v", err)
+	}
+	if got := readString(t)(backend.Download(ctx, "escaped.txt")); got != "escaped" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+
+	// 不允许删除存储根目录（路径中的 ".." 已由路由规范化）
+	req, _ := http.NewRequest(http.MethodDelete, endpoint+"/dirs/", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got %d", resp.StatusCode)
+	}
+	if exists, _ := backend.Exists(ctx, "escaped.txt"); !exists {
+		t.Fatal("Storage root should not be deleted")
+	}
+}
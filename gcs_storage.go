@@ -0,0 +1,678 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const (
+	// gcsDefaultPartSize 默认的并行上传分片大小
+	gcsDefaultPartSize = 16 << 20
+	// gcsDefaultConcurrency 默认的并行上传并发数
+	gcsDefaultConcurrency = 4
+	// gcsMaxComposeSources 单次合并（compose）最多支持的源对象数
+	gcsMaxComposeSources = 32
+	// gcsComposeTempDir 并行上传分片的临时目录（相对 BaseDir），合并完成后删除
+	gcsComposeTempDir = ".compose/"
+)
+
+// GCSStorageConfig Google Cloud Storage 存储配置。
+// 凭据使用 CredentialsFile 或 CredentialsJSON，均为空时使用应用默认凭据（ADC）；
+// 设置 EmulatorHost 时连接模拟器（如 fake-gcs-server）且不进行认证。
+type GCSStorageConfig struct {
+	ProjectID         string `json:"project_id"`         // 项目ID，存储桶不存在时用于创建
+	Bucket            string `json:"bucket"`             // 存储桶名称
+	BaseDir           string `json:"base_dir"`           // 存储基础目录
+	CredentialsFile   string `json:"credentials_file"`   // 服务账号密钥文件路径
+	CredentialsJSON   string `json:"credentials_json"`   // 服务账号密钥内容
+	EmulatorHost      string `json:"emulator_host"`      // 模拟器地址，如 http://localhost:4443
+	ChunkSize         int    `json:"chunk_size"`         // 可续传上传的分块大小（字节），默认 16MiB
+	ParallelThreshold int64  `json:"parallel_threshold"` // 可寻址内容达到该大小时分片并行上传后合并，0 表示不启用
+	PartSize          int64  `json:"part_size"`          // 并行上传的分片大小（字节），默认 16MiB
+	Concurrency       int    `json:"concurrency"`        // 并行上传的并发数，默认 4
+}
+
+// GCSStorage Google Cloud Storage 存储实现
+type GCSStorage struct {
+	config GCSStorageConfig
+	client *gcs.Client
+	bucket *gcs.BucketHandle
+
+	// 用于生成V4签名URL的服务账号，为空时由SDK从凭据中自动探测
+	googleAccessID string
+	privateKey     []byte
+}
+
+// NewGCSStorage 创建新的GCS存储实例
+func NewGCSStorage(config GCSStorageConfig) Storage {
+	credentials, err := gcsCredentials(config)
+	if err != nil {
+		hlog.Errorf("读取GCS凭据失败: %v", err)
+		return nil
+	}
+
+	var opts []option.ClientOption
+	switch {
+	case config.EmulatorHost != "":
+		// 模拟器只提供 JSON/XML API，无需认证；凭据仅用于签名URL
+		opts = append(opts, option.WithEndpoint(gcsEmulatorEndpoint(config.EmulatorHost)), option.WithoutAuthentication())
+	case credentials != nil:
+		opts = append(opts, option.WithAuthCredentialsJSON(option.ServiceAccount, credentials))
+	}
+
+	ctx := context.Background()
+	client, err := gcs.NewClient(ctx, opts...)
+	if err != nil {
+		hlog.Errorf("创建GCS客户端失败: %v", err)
+		return nil
+	}
+
+	// 检查存储桶是否存在，不存在则创建
+	bucket := client.Bucket(config.Bucket)
+	if _, err := bucket.Attrs(ctx); err != nil {
+		if !errors.Is(err, gcs.ErrBucketNotExist) {
+			hlog.Errorf("检查GCS存储桶失败: %v", err)
+			client.Close()
+			return nil
+		}
+		if err := bucket.Create(ctx, config.ProjectID, nil); err != nil {
+			hlog.Errorf("创建GCS存储桶失败: %v", err)
+			client.Close()
+			return nil
+		}
+		hlog.Infof("成功创建新GCS存储桶: %s", config.Bucket)
+	}
+
+	if config.PartSize <= 0 {
+		config.PartSize = gcsDefaultPartSize
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = gcsDefaultConcurrency
+	}
+
+	s := &GCSStorage{
+		config: config,
+		client: client,
+		bucket: bucket,
+	}
+	if credentials != nil {
+		var key struct {
+			ClientEmail string `json:"client_email"`
+			PrivateKey  string `json:"private_key"`
+		}
+		if err := json.Unmarshal(credentials, &key); err == nil {
+			s.googleAccessID = key.ClientEmail
+			s.privateKey = []byte(key.PrivateKey)
+		}
+	}
+	return s
+}
+
+// gcsCredentials 读取服务账号密钥，未配置时返回 nil
+func gcsCredentials(config GCSStorageConfig) ([]byte, error) {
+	if config.CredentialsJSON != "" {
+		return []byte(config.CredentialsJSON), nil
+	}
+	if config.CredentialsFile != "" {
+		return os.ReadFile(config.CredentialsFile)
+	}
+	return nil, nil
+}
+
+// gcsEmulatorEndpoint 返回模拟器的 JSON API 地址，与 STORAGE_EMULATOR_HOST 的处理方式一致
+func gcsEmulatorEndpoint(host string) string {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return strings.TrimSuffix(host, "/") + "/storage/v1/"
+}
+
+// Upload 实现GCS文件上传，支持设置有效期
+func (s *GCSStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	return s.upload(ctx, filePath, reader, gcs.Conditions{}, opts...)
+}
+
+// UploadIf 实现条件写入（基于对象的 generation），前置条件不满足时返回 ErrPreconditionFailed。
+// GCS 的 FileMetadata.ETag 即对象的 generation。
+func (s *GCSStorage) UploadIf(ctx context.Context, filePath string, reader io.Reader, cond UploadCondition, opts ...UploadOption) error {
+	var conds gcs.Conditions
+	if cond.IfNoneMatch {
+		conds.DoesNotExist = true
+	}
+	if cond.IfMatch != "" {
+		generation, err := strconv.ParseInt(cond.IfMatch, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: 无效的 generation %q: %w", filePath, cond.IfMatch, ErrPreconditionFailed)
+		}
+		conds.GenerationMatch = generation
+	}
+
+	err := s.upload(ctx, filePath, reader, conds, opts...)
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) && apiErr.Code == http.StatusPreconditionFailed {
+		return fmt.Errorf("%s: %w", filePath, ErrPreconditionFailed)
+	}
+	return err
+}
+
+// upload 上传文件。可寻址且达到 ParallelThreshold 的内容分片并行上传后合并，
+// 其余内容使用可续传上传（按 ChunkSize 分块）
+func (s *GCSStorage) upload(ctx context.Context, filePath string, reader io.Reader, conds gcs.Conditions, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到GCS: %s", filePath)
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	attrs := gcs.ObjectAttrs{ContentType: contentType}
+
+	// 如果设置了有效期，记录删除截止时间，并写入 CustomTime（供 DaysSinceCustomTime 生命周期规则匹配）
+	if options.Expiration > 0 {
+		expiration := expirationDeadline(options.Expiration)
+		attrs.Metadata = map[string]string{ExpiresAtMetaKey: formatExpiresAt(expiration)}
+		attrs.CustomTime = expiration
+		hlog.CtxDebugf(ctx, "设置GCS文件过期时间: %v", expiration)
+	}
+
+	object := s.bucket.Object(fullKey)
+	if conds != (gcs.Conditions{}) {
+		object = object.If(conds)
+	}
+
+	if src, size, ok := s.parallelSource(reader); ok {
+		err = s.composeUpload(ctx, object, src, size, attrs)
+	} else {
+		err = s.resumableUpload(ctx, object, reader, attrs)
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "GCS上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "GCS文件上传成功: %s", filePath)
+	return nil
+}
+
+// resumableUpload 使用可续传上传写入对象
+func (s *GCSStorage) resumableUpload(ctx context.Context, object *gcs.ObjectHandle, reader io.Reader, attrs gcs.ObjectAttrs) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := object.NewWriter(ctx)
+	writer.ObjectAttrs.ContentType = attrs.ContentType
+	writer.ObjectAttrs.Metadata = attrs.Metadata
+	writer.ObjectAttrs.CustomTime = attrs.CustomTime
+	if s.config.ChunkSize > 0 {
+		writer.ChunkSize = s.config.ChunkSize
+	}
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		// 取消上下文会中止上传，避免留下不完整的对象
+		cancel()
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+// parallelSource 判断内容是否可以分片并行上传，返回内容从当前位置开始的剩余大小
+func (s *GCSStorage) parallelSource(reader io.Reader) (*io.SectionReader, int64, bool) {
+	if s.config.ParallelThreshold <= 0 {
+		return nil, 0, false
+	}
+	src, ok := reader.(interface {
+		io.ReaderAt
+		io.Seeker
+	})
+	if !ok {
+		return nil, 0, false
+	}
+
+	pos, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, false
+	}
+	end, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, false
+	}
+	if _, err := src.Seek(pos, io.SeekStart); err != nil {
+		return nil, 0, false
+	}
+	size := end - pos
+	if size < s.config.ParallelThreshold {
+		return nil, 0, false
+	}
+	return io.NewSectionReader(src, pos, size), size, true
+}
+
+// composeUpload 将内容分片并行上传为临时对象，再合并（compose）为目标对象。
+// 单次合并最多 32 个源对象，分片数超过时增大分片大小。
+func (s *GCSStorage) composeUpload(ctx context.Context, object *gcs.ObjectHandle, src *io.SectionReader, size int64, attrs gcs.ObjectAttrs) error {
+	partSize := s.config.PartSize
+	if minPart := (size + gcsMaxComposeSources - 1) / gcsMaxComposeSources; partSize < minPart {
+		partSize = minPart
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	tempDir := joinStorageKey(s.config.BaseDir, gcsComposeTempDir+hex.EncodeToString(id)+"/")
+
+	var parts []*gcs.ObjectHandle
+	for offset := int64(0); offset < size; offset += partSize {
+		parts = append(parts, s.bucket.Object(fmt.Sprintf("%s%05d", tempDir, len(parts))))
+	}
+	// 无论成功与否都清理临时分片
+	defer func() {
+		for _, part := range parts {
+			if err := part.Delete(context.WithoutCancel(ctx)); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+				hlog.CtxErrorf(ctx, "删除GCS临时分片失败: %v", err)
+			}
+		}
+	}()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, s.config.Concurrency)
+	for i, part := range parts {
+		offset := int64(i) * partSize
+		length := min(partSize, size-offset)
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			writer := part.NewWriter(ctx)
+			writer.ChunkSize = 0 // 分片一次性上传，不使用可续传会话
+			_, err := io.Copy(writer, io.NewSectionReader(src, offset, length))
+			if closeErr := writer.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+
+	composer := object.ComposerFrom(parts...)
+	composer.ContentType = attrs.ContentType
+	composer.Metadata = attrs.Metadata
+	composer.CustomTime = attrs.CustomTime
+	_, err := composer.Run(ctx)
+	return err
+}
+
+// Download 实现从GCS下载文件（流式下载）
+func (s *GCSStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从GCS下载文件: %s", filePath)
+
+	reader, err := s.download(ctx, filePath, 0, -1)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "GCS获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "GCS文件下载已启动: %s", filePath)
+	return reader, nil // 返回原始的Reader，由调用方负责关闭
+}
+
+// DownloadRange 实现从GCS下载文件（支持断点续传）
+func (s *GCSStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从GCS下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	if size <= 0 {
+		size = -1
+	}
+	reader, err := s.download(ctx, filePath, offset, size)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "GCS获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "GCS文件断点续传下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// download 下载指定范围的内容，已过期的文件返回 ErrNotExist。
+// 先读取属性检查有效期，再按属性中的 generation 读取，保证读到的是同一版本。
+func (s *GCSStorage) download(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, error) {
+	object := s.bucket.Object(filepath.Join(s.config.BaseDir, filePath))
+
+	attrs, err := object.Attrs(ctx)
+	if err != nil {
+		return nil, gcsError(err)
+	}
+	if isExpired(gcsExpiresAt(attrs)) {
+		return nil, errExpired(filePath)
+	}
+
+	reader, err := object.Generation(attrs.Generation).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, gcsError(err)
+	}
+	return reader, nil
+}
+
+// Delete 实现GCS文件删除（删除不存在的文件不报错）
+func (s *GCSStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从GCS删除文件: %s", filePath)
+
+	if err := s.deleteObject(ctx, filepath.Join(s.config.BaseDir, filePath)); err != nil {
+		hlog.CtxErrorf(ctx, "GCS删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "GCS文件删除成功: %s", filePath)
+	return nil
+}
+
+// deleteObject 删除完整路径的对象，对象不存在时忽略
+func (s *GCSStorage) deleteObject(ctx context.Context, fullKey string) error {
+	err := s.bucket.Object(fullKey).Delete(ctx)
+	if err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Rename 实现GCS文件重命名（复制+删除）
+func (s *GCSStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在GCS中重命名文件: %s -> %s", oldPath, newPath)
+
+	if err := s.copyObject(ctx, oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "GCS复制文件失败: %v", err)
+		return err
+	}
+
+	// 删除旧文件
+	if err := s.Delete(ctx, oldPath); err != nil {
+		hlog.CtxErrorf(ctx, "GCS删除旧文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "GCS文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现GCS文件移动（与重命名相同的操作）
+func (s *GCSStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现GCS文件复制（服务端复制）
+func (s *GCSStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在GCS中复制文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.copyObject(ctx, srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "GCS复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "GCS文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// copyObject 服务端复制对象，保留内容类型和元数据
+func (s *GCSStorage) copyObject(ctx context.Context, srcPath, dstPath string) error {
+	src := s.bucket.Object(filepath.Join(s.config.BaseDir, srcPath))
+	dst := s.bucket.Object(filepath.Join(s.config.BaseDir, dstPath))
+
+	if _, err := dst.CopierFrom(src).Run(ctx); err != nil {
+		return gcsError(err)
+	}
+	return nil
+}
+
+// Exists 实现检查GCS文件是否存在（已过期的文件视为不存在）
+func (s *GCSStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	attrs, err := s.bucket.Object(filepath.Join(s.config.BaseDir, filePath)).Attrs(ctx)
+	if err != nil {
+		if errors.Is(err, gcs.ErrObjectNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !isExpired(gcsExpiresAt(attrs)), nil
+}
+
+// CreateDir 实现GCS目录创建。
+// GCS 中目录是隐式的，无需显式创建占位对象。
+func (s *GCSStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxDebugf(ctx, "GCS 目录无需显式创建: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现GCS目录删除（递归删除目录下所有对象）
+func (s *GCSStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始从GCS中删除目录及其所有内容: %s", dirPath)
+
+	dirPath = ensureOSSDirPath(dirPath)
+	fullKey := joinStorageKey(s.config.BaseDir, dirPath)
+
+	query := &gcs.Query{Prefix: fullKey}
+	if err := query.SetAttrSelection([]string{"Name"}); err != nil {
+		return err
+	}
+	it := s.bucket.Objects(ctx, query)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			hlog.CtxErrorf(ctx, "列出GCS目录内容失败: %v", err)
+			return err
+		}
+		// attrs.Name 已经是完整路径
+		if err := s.deleteObject(ctx, attrs.Name); err != nil {
+			hlog.CtxErrorf(ctx, "删除GCS对象失败: %v", err)
+			return err
+		}
+	}
+
+	hlog.CtxInfof(ctx, "成功从GCS中删除目录及其所有内容: %s", fullKey)
+	return nil
+}
+
+// ListDir 实现GCS目录列表（按 / 分层，仅当前层级，子目录名以 / 结尾）
+func (s *GCSStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出GCS目录内容: %s", dirPath)
+
+	// 必须保证 prefix 以 / 结尾，否则分层列举会把当前目录自身也作为前缀返回。
+	dirPath = ensureOSSDirPath(dirPath)
+	fullKey := joinStorageKey(s.config.BaseDir, dirPath)
+
+	var fileMetas []FileMetadata
+	it := s.bucket.Objects(ctx, &gcs.Query{Prefix: fullKey, Delimiter: "/"})
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			hlog.CtxErrorf(ctx, "获取GCS目录内容失败: %v", err)
+			return nil, err
+		}
+
+		// 子目录只有 Prefix 字段
+		if attrs.Prefix != "" {
+			if name := strings.TrimPrefix(attrs.Prefix, fullKey); name != "" {
+				fileMetas = append(fileMetas, FileMetadata{Name: name, IsDir: true})
+			}
+			continue
+		}
+
+		name := strings.TrimPrefix(attrs.Name, fullKey)
+		if name == "" {
+			continue
+		}
+		fileMetas = append(fileMetas, FileMetadata{
+			Name:     name,
+			Size:     attrs.Size,
+			ModTime:  attrs.Updated,
+			IsDir:    false,
+			MIMEType: contentTypeOrDetect(attrs.ContentType, name),
+			ETag:     gcsETag(attrs),
+		})
+	}
+
+	hlog.CtxInfof(ctx, "成功列出GCS目录内容: %s", dirPath)
+	return fileMetas, nil
+}
+
+// GetMetadata 实现获取GCS文件元数据
+func (s *GCSStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取GCS文件元数据: %s", filePath)
+
+	attrs, err := s.bucket.Object(filepath.Join(s.config.BaseDir, filePath)).Attrs(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取GCS文件信息失败: %v", err)
+		return nil, gcsError(err)
+	}
+	if isExpired(gcsExpiresAt(attrs)) {
+		return nil, errExpired(filePath)
+	}
+
+	fileMeta := &FileMetadata{
+		Name:     filePath,
+		Size:     attrs.Size,
+		ModTime:  attrs.Updated,
+		IsDir:    false,
+		MIMEType: contentTypeOrDetect(attrs.ContentType, filePath),
+		ETag:     gcsETag(attrs),
+	}
+
+	hlog.CtxInfof(ctx, "成功获取GCS文件元数据: %s", filePath)
+	return fileMeta, nil
+}
+
+// UpdateMetadata 更新GCS文件元数据，仅支持修改 MIME 类型（修改时间由服务端维护，指定时返回 ErrNotSupported）
+func (s *GCSStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新GCS文件元数据: %s", filePath)
+
+	if !metadata.ModTime.IsZero() {
+		return fmt.Errorf("GCS不支持修改文件修改时间: %w", ErrNotSupported)
+	}
+	if metadata.MIMEType == "" {
+		return nil
+	}
+
+	object := s.bucket.Object(filepath.Join(s.config.BaseDir, filePath))
+	if _, err := object.Update(ctx, gcs.ObjectAttrsToUpdate{ContentType: metadata.MIMEType}); err != nil {
+		hlog.CtxErrorf(ctx, "更新GCS文件元数据失败: %v", err)
+		return gcsError(err)
+	}
+
+	hlog.CtxInfof(ctx, "成功更新GCS文件元数据: %s", filePath)
+	return nil
+}
+
+// GetExpiration 读取GCS文件的删除截止时间（不检查是否已过期）
+func (s *GCSStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	attrs, err := s.bucket.Object(filepath.Join(s.config.BaseDir, filePath)).Attrs(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取GCS文件信息失败: %v", err)
+		return time.Time{}, gcsError(err)
+	}
+	return gcsExpiresAt(attrs), nil
+}
+
+// Presign 生成V4签名URL，仅支持 GET 和 PUT。
+// 需要服务账号私钥；未配置密钥文件时由SDK从运行环境的凭据中探测签名方式。
+func (s *GCSStorage) Presign(ctx context.Context, method string, filePath string, expires time.Duration) (string, error) {
+	if method != http.MethodGet && method != http.MethodPut {
+		return "", fmt.Errorf("GCS预签名不支持 %s 方法: %w", method, ErrNotSupported)
+	}
+
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	signedURL, err := s.bucket.SignedURL(fullKey, &gcs.SignedURLOptions{
+		Scheme:         gcs.SigningSchemeV4,
+		Method:         method,
+		Expires:        time.Now().Add(expires),
+		GoogleAccessID: s.googleAccessID,
+		PrivateKey:     s.privateKey,
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "生成GCS签名URL失败: %v", err)
+		return "", err
+	}
+	return signedURL, nil
+}
+
+// BatchUpload 实现GCS批量上传
+func (s *GCSStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到GCS", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现GCS批量下载（流式下载）
+func (s *GCSStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个GCS文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现GCS批量删除
+func (s *GCSStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个GCS文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
+
+// gcsError 将对象不存在的错误转换为 ErrNotExist
+func gcsError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.Is(err, gcs.ErrObjectNotExist) || (errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound) {
+		return &notExistError{err: err}
+	}
+	return err
+}
+
+// gcsExpiresAt 从对象元数据中解析删除截止时间
+func gcsExpiresAt(attrs *gcs.ObjectAttrs) time.Time {
+	return parseExpiresAt(lookupMetadata(attrs.Metadata, ExpiresAtMetaKey))
+}
+
+// gcsETag 返回对象的 generation，作为条件写入使用的版本标识
+func gcsETag(attrs *gcs.ObjectAttrs) string {
+	return strconv.FormatInt(attrs.Generation, 10)
+}
@@ -0,0 +1,5 @@
+// Package gcstest 使用进程内的 fake-gcs-server 测试 GCS 存储。
+//
+// fake-gcs-server 依赖较多，因此放在独立的测试模块中，不作为 storage 模块的依赖。
+// 在本目录下运行 go test ./... 执行测试。
+package gcstest
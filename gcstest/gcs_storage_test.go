@@ -0,0 +1,152 @@
+package gcstest_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsouza/fake-gcs-server/fakestorage"
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+)
+
+// newFakeGCSServer 启动进程内的 fake-gcs-server。
+// PublicHost 必须与监听地址一致，否则 XML API 的下载请求会把主机名当作存储桶名。
+func newFakeGCSServer(t *testing.T) *fakestorage.Server {
+	t.Helper()
+	server, err := fakestorage.NewServerWithOptions(fakestorage.Options{
+		Scheme:     "http",
+		Host:       "127.0.0.1",
+		PublicHost: "127.0.0.1",
+		Writer:     io.Discard,
+	})
+	if err != nil {
+		t.Fatalf("Failed to start fake GCS server: %v", err)
+	}
+	t.Cleanup(server.Stop)
+	return server
+}
+
+// gcsTestCredentials 生成一个仅用于签名URL的服务账号密钥
+func gcsTestCredentials(t *testing.T) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	credentials, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "tester@storage-test.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal credentials: %v", err)
+	}
+	return string(credentials)
+}
+
+func TestGCSStorage_Backend(t *testing.T) {
+	server := newFakeGCSServer(t)
+
+	s := storage.NewGCSStorage(storage.GCSStorageConfig{
+		ProjectID:         "test",
+		Bucket:            "gcs-backend",
+		BaseDir:           "base",
+		CredentialsJSON:   gcsTestCredentials(t),
+		EmulatorHost:      server.URL(),
+		ChunkSize:         256 << 10,
+		ParallelThreshold: 1 << 20,
+		PartSize:          256 << 10,
+	})
+	if s == nil {
+		t.Fatal("NewGCSStorage returned nil")
+	}
+	ctx := context.Background()
+
+	// 可寻址的大文件分片并行上传后合并，临时分片需要被清理
+	data := bytes.Repeat([]byte("0123456789abcdef"), (1<<20+100)/16)
+	if err := s.Upload(ctx, "big/composed.bin", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Composite upload failed: %v", err)
+	}
+	got, err := io.ReadAll(mustReader(t)(s.Download(ctx, "big/composed.bin")))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Composed content mismatch: %d bytes, %v", len(got), err)
+	}
+	objects, _, err := server.ListObjectsWithOptions("gcs-backend", fakestorage.ListOptions{Prefix: "base/.compose/"})
+	if err != nil || len(objects) != 0 {
+		t.Fatalf("Temporary parts were not cleaned up: %d, %v", len(objects), err)
+	}
+
+	// 不可寻址的内容使用按 ChunkSize 分块的可续传上传
+	if err := s.Upload(ctx, "big/stream.bin", io.MultiReader(bytes.NewReader(data))); err != nil {
+		t.Fatalf("Resumable upload failed: %v", err)
+	}
+	got, err = io.ReadAll(mustReader(t)(s.DownloadRange(ctx, "big/stream.bin", 16, 16)))
+	if err != nil || string(got) != "0123456789abcdef" {
+		t.Fatalf("DownloadRange = %q, %v", got, err)
+	}
+
+	// V4 签名URL由配置的服务账号签名
+	presigner, ok := storage.As[storage.Presigner](s)
+	if !ok {
+		t.Fatal("GCSStorage should implement Presigner")
+	}
+	signed, err := presigner.Presign(ctx, http.MethodPut, "big/composed.bin", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Presign failed: %v", err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("Invalid signed URL %q: %v", signed, err)
+	}
+	query := u.Query()
+	if !strings.HasSuffix(u.Path, "/gcs-backend/base/big/composed.bin") ||
+		query.Get("X-Goog-Algorithm") != "GOOG4-RSA-SHA256" ||
+		!strings.HasPrefix(query.Get("X-Goog-Credential"), "tester@storage-test.iam.gserviceaccount.com/") ||
+		query.Get("X-Goog-Expires") == "" || query.Get("X-Goog-Signature") == "" {
+		t.Fatalf("Unexpected signed URL: %s", signed)
+	}
+	if _, err := presigner.Presign(ctx, http.MethodDelete, "big/composed.bin", time.Minute); err == nil {
+		t.Fatal("Expected error for unsupported presign method")
+	}
+}
+
+func TestGCSStorage_Conformance(t *testing.T) {
+	server := newFakeGCSServer(t)
+	credentials := gcsTestCredentials(t)
+
+	storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+		return storage.NewGCSStorage(storage.GCSStorageConfig{
+			ProjectID:       "test",
+			Bucket:          "gcs-test",
+			BaseDir:         t.Name(),
+			CredentialsJSON: credentials,
+			EmulatorHost:    server.URL(),
+		})
+	})
+}
+
+// mustReader 包装 Download 的返回值，出错时终止测试
+func mustReader(t *testing.T) func(io.Reader, error) io.Reader {
+	return func(reader io.Reader, err error) io.Reader {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Download failed: %v", err)
+		}
+		return reader
+	}
+}
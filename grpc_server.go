@@ -0,0 +1,401 @@
+package storage
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/v-mars/storage/storagepb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// grpcDefaultChunkSize 上传和下载时每条消息的默认最大字节数
+	grpcDefaultChunkSize = 64 << 10
+	// grpcDefaultPageSize ListDir 每条消息的默认最大条目数
+	grpcDefaultPageSize = 1000
+	// grpcErrorDomain 错误详情 ErrorInfo 的 domain，reason 与网关错误码相同
+	grpcErrorDomain = "storage.v-mars.github.com"
+)
+
+// GRPCServerConfig gRPC 存储服务配置
+type GRPCServerConfig struct {
+	Storage   Storage  `json:"-"`          // 对外提供服务的存储
+	Tokens    []string `json:"tokens"`     // 允许的访问令牌（metadata authorization: Bearer <token>），为空时不认证
+	ChunkSize int      `json:"chunk_size"` // 下载时每条消息的最大字节数，默认 64KiB
+	PageSize  int      `json:"page_size"`  // ListDir 每条消息的最大条目数，默认 1000
+}
+
+// GRPCServer 将 Storage 以 gRPC 服务（storagepb.StorageService）对外提供，GRPCStorage 是对应的 Go 客户端。
+// 客户端的截止时间随请求传递到服务端，存储操作使用请求的 ctx，超时或客户端取消时中止。
+// 上传和下载依赖 gRPC 的流量控制实现背压：上传时存储读取多少才接收多少，下载时发送阻塞则暂停读取存储。
+// 存储错误转换为 gRPC 状态码，并在 ErrorInfo 中附带与网关相同的错误码（not_exist、too_large 等）。
+type GRPCServer struct {
+	storagepb.UnimplementedStorageServiceServer
+	config GRPCServerConfig
+}
+
+// NewGRPCServer 创建 gRPC 存储服务，通过 Register 注册到 grpc.Server：
+//
+//	srv := grpc.NewServer()
+//	storage.NewGRPCServer(storage.GRPCServerConfig{Storage: s, Tokens: []string{token}}).Register(srv)
+func NewGRPCServer(config GRPCServerConfig) *GRPCServer {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = grpcDefaultChunkSize
+	}
+	if config.PageSize <= 0 {
+		config.PageSize = grpcDefaultPageSize
+	}
+	return &GRPCServer{config: config}
+}
+
+// Register 在 gRPC 服务器上注册存储服务
+func (s *GRPCServer) Register(r grpc.ServiceRegistrar) {
+	storagepb.RegisterStorageServiceServer(r, s)
+}
+
+// authorize 校验 metadata 中的访问令牌
+func (s *GRPCServer) authorize(ctx context.Context) error {
+	if len(s.config.Tokens) == 0 {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(value, "Bearer ")
+		if !ok {
+			continue
+		}
+		for _, allowed := range s.config.Tokens {
+			if subtle.ConstantTimeCompare([]byte(token), []byte(allowed)) == 1 {
+				return nil
+			}
+		}
+	}
+	return grpcStatusError(codes.Unauthenticated, gatewayCodeUnauthorized, "无效的访问令牌")
+}
+
+// grpcStatusError 构造带 ErrorInfo 详情的状态错误
+func grpcStatusError(code codes.Code, reason, msg string) error {
+	st := status.New(code, msg)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: grpcErrorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// grpcError 将存储错误转换为 gRPC 状态错误，已经是状态错误的（如接收请求失败）原样返回
+func grpcError(ctx context.Context, method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	code, reason := codes.Internal, gatewayCodeInternal
+	switch {
+	case errors.Is(err, ErrNotExist):
+		code, reason = codes.NotFound, gatewayCodeNotExist
+	case errors.Is(err, ErrNotSupported):
+		code, reason = codes.Unimplemented, gatewayCodeNotSupported
+	case errors.Is(err, ErrPreconditionFailed):
+		code, reason = codes.FailedPrecondition, gatewayCodePreconditionFailed
+	case errors.Is(err, ErrTooLarge):
+		code, reason = codes.ResourceExhausted, gatewayCodeTooLarge
+	case errors.Is(err, ErrQuotaExceeded):
+		code, reason = codes.ResourceExhausted, gatewayCodeQuotaExceeded
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		hlog.CtxErrorf(ctx, "gRPC存储服务请求失败: %s, %v", method, err)
+	}
+	return grpcStatusError(code, reason, err.Error())
+}
+
+// Upload 接收流式上传，第一条消息为上传参数
+func (s *GRPCServer) Upload(stream storagepb.StorageService_UploadServer) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	header := req.GetHeader()
+	filePath := cleanRequestPath(header.GetPath())
+	if filePath == "" {
+		return grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "第一条消息必须是包含路径的上传参数")
+	}
+	if header.ExpirationSeconds < 0 {
+		return grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "无效的有效期")
+	}
+
+	var opts []UploadOption
+	if header.ContentType != "" {
+		opts = append(opts, WithContentType(header.ContentType))
+	}
+	if header.ExpirationSeconds > 0 {
+		opts = append(opts, WithExpiration(time.Duration(header.ExpirationSeconds)*time.Second))
+	}
+	if err := s.config.Storage.Upload(ctx, filePath, &grpcUploadReader{stream: stream}, opts...); err != nil {
+		return grpcError(ctx, "Upload", err)
+	}
+	return stream.SendAndClose(&storagepb.UploadResponse{})
+}
+
+// grpcUploadReader 按需从上传流接收数据块，存储读取得慢时不再接收，由流量控制阻塞客户端发送
+type grpcUploadReader struct {
+	stream storagepb.StorageService_UploadServer
+	chunk  []byte
+}
+
+func (r *grpcUploadReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		req, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if req.GetHeader() != nil {
+			return 0, grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "上传参数只能出现在第一条消息中")
+		}
+		r.chunk = req.GetChunk()
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+// Download 流式发送文件或文件范围，size 为 0 时读到文件末尾
+func (s *GRPCServer) Download(req *storagepb.DownloadRequest, stream storagepb.StorageService_DownloadServer) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	if req.Offset < 0 || req.Size < 0 {
+		return grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "无效的下载范围")
+	}
+	filePath := cleanRequestPath(req.Path)
+
+	var reader io.Reader
+	var err error
+	if req.Offset == 0 && req.Size == 0 {
+		reader, err = s.config.Storage.Download(ctx, filePath)
+	} else {
+		size := req.Size
+		if size == 0 {
+			// 部分后端不支持以负数表示读到文件末尾，这里根据文件大小计算剩余长度
+			var metadata *FileMetadata
+			if metadata, err = s.config.Storage.GetMetadata(ctx, filePath); err != nil {
+				return grpcError(ctx, "Download", err)
+			}
+			if size = metadata.Size - req.Offset; size <= 0 {
+				return nil
+			}
+		}
+		reader, err = s.config.Storage.DownloadRange(ctx, filePath, req.Offset, size)
+	}
+	if err != nil {
+		return grpcError(ctx, "Download", err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	// Send 返回前已完成序列化，缓冲区可以复用
+	buf := make([]byte, s.config.ChunkSize)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&storagepb.DownloadResponse{Chunk: buf[:n]}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return grpcError(ctx, "Download", err)
+		}
+	}
+}
+
+// Delete 删除文件
+func (s *GRPCServer) Delete(ctx context.Context, req *storagepb.PathRequest) (*storagepb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.config.Storage.Delete(ctx, cleanRequestPath(req.Path)); err != nil {
+		return nil, grpcError(ctx, "Delete", err)
+	}
+	return &storagepb.Empty{}, nil
+}
+
+// Rename 重命名文件
+func (s *GRPCServer) Rename(ctx context.Context, req *storagepb.TransferRequest) (*storagepb.Empty, error) {
+	return s.transfer(ctx, "Rename", s.config.Storage.Rename, req)
+}
+
+// Move 移动文件
+func (s *GRPCServer) Move(ctx context.Context, req *storagepb.TransferRequest) (*storagepb.Empty, error) {
+	return s.transfer(ctx, "Move", s.config.Storage.Move, req)
+}
+
+// Copy 复制文件
+func (s *GRPCServer) Copy(ctx context.Context, req *storagepb.TransferRequest) (*storagepb.Empty, error) {
+	return s.transfer(ctx, "Copy", s.config.Storage.Copy, req)
+}
+
+// transfer 执行重命名、移动或复制
+func (s *GRPCServer) transfer(ctx context.Context, method string, op func(ctx context.Context, srcPath, dstPath string) error, req *storagepb.TransferRequest) (*storagepb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	srcPath, dstPath := cleanRequestPath(req.Src), cleanRequestPath(req.Dst)
+	if srcPath == "" || dstPath == "" {
+		return nil, grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "src 和 dst 不能为空")
+	}
+	if err := op(ctx, srcPath, dstPath); err != nil {
+		return nil, grpcError(ctx, method, err)
+	}
+	return &storagepb.Empty{}, nil
+}
+
+// Exists 检查文件是否存在
+func (s *GRPCServer) Exists(ctx context.Context, req *storagepb.PathRequest) (*storagepb.ExistsResponse, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	exists, err := s.config.Storage.Exists(ctx, cleanRequestPath(req.Path))
+	if err != nil {
+		return nil, grpcError(ctx, "Exists", err)
+	}
+	return &storagepb.ExistsResponse{Exists: exists}, nil
+}
+
+// CreateDir 创建目录
+func (s *GRPCServer) CreateDir(ctx context.Context, req *storagepb.PathRequest) (*storagepb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if err := s.config.Storage.CreateDir(ctx, cleanRequestPath(req.Path)); err != nil {
+		return nil, grpcError(ctx, "CreateDir", err)
+	}
+	return &storagepb.Empty{}, nil
+}
+
+// DeleteDir 递归删除目录
+func (s *GRPCServer) DeleteDir(ctx context.Context, req *storagepb.PathRequest) (*storagepb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	// 不允许删除存储根目录
+	dirPath := cleanRequestPath(req.Path)
+	if dirPath == "" {
+		return nil, grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "目录路径不能为空")
+	}
+	if err := s.config.Storage.DeleteDir(ctx, dirPath); err != nil {
+		return nil, grpcError(ctx, "DeleteDir", err)
+	}
+	return &storagepb.Empty{}, nil
+}
+
+// ListDir 列出目录，每条消息最多 PageSize 个条目
+func (s *GRPCServer) ListDir(req *storagepb.PathRequest, stream storagepb.StorageService_ListDirServer) error {
+	ctx := stream.Context()
+	if err := s.authorize(ctx); err != nil {
+		return err
+	}
+	entries, err := s.config.Storage.ListDir(ctx, cleanRequestPath(req.Path))
+	if err != nil {
+		return grpcError(ctx, "ListDir", err)
+	}
+	for start := 0; start < len(entries); start += s.config.PageSize {
+		page := entries[start:min(start+s.config.PageSize, len(entries))]
+		resp := &storagepb.ListDirResponse{Entries: make([]*storagepb.FileMetadata, len(page))}
+		for i := range page {
+			resp.Entries[i] = toProtoMetadata(&page[i])
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetMetadata 获取文件元数据
+func (s *GRPCServer) GetMetadata(ctx context.Context, req *storagepb.PathRequest) (*storagepb.FileMetadata, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	metadata, err := s.config.Storage.GetMetadata(ctx, cleanRequestPath(req.Path))
+	if err != nil {
+		return nil, grpcError(ctx, "GetMetadata", err)
+	}
+	return toProtoMetadata(metadata), nil
+}
+
+// UpdateMetadata 更新文件元数据
+func (s *GRPCServer) UpdateMetadata(ctx context.Context, req *storagepb.UpdateMetadataRequest) (*storagepb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.Metadata == nil {
+		return nil, grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, "metadata 不能为空")
+	}
+	if err := s.config.Storage.UpdateMetadata(ctx, cleanRequestPath(req.Path), fromProtoMetadata(req.Metadata)); err != nil {
+		return nil, grpcError(ctx, "UpdateMetadata", err)
+	}
+	return &storagepb.Empty{}, nil
+}
+
+// BatchDelete 批量删除文件
+func (s *GRPCServer) BatchDelete(ctx context.Context, req *storagepb.BatchDeleteRequest) (*storagepb.Empty, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	filePaths, err := cleanRequestPaths(req.Paths)
+	if err != nil {
+		return nil, grpcStatusError(codes.InvalidArgument, gatewayCodeBadRequest, err.Error())
+	}
+	if err := s.config.Storage.BatchDelete(ctx, filePaths); err != nil {
+		return nil, grpcError(ctx, "BatchDelete", err)
+	}
+	return &storagepb.Empty{}, nil
+}
+
+// toProtoMetadata 将 FileMetadata 转换为 protobuf 消息，零值时间转换为 0
+func toProtoMetadata(metadata *FileMetadata) *storagepb.FileMetadata {
+	msg := &storagepb.FileMetadata{
+		Name:     metadata.Name,
+		Size:     metadata.Size,
+		IsDir:    metadata.IsDir,
+		MimeType: metadata.MIMEType,
+		Etag:     metadata.ETag,
+	}
+	if !metadata.ModTime.IsZero() {
+		msg.ModTimeUnixNano = metadata.ModTime.UnixNano()
+	}
+	return msg
+}
+
+// fromProtoMetadata 将 protobuf 消息转换为 FileMetadata
+func fromProtoMetadata(msg *storagepb.FileMetadata) *FileMetadata {
+	metadata := &FileMetadata{
+		Name:     msg.Name,
+		Size:     msg.Size,
+		IsDir:    msg.IsDir,
+		MIMEType: msg.MimeType,
+		ETag:     msg.Etag,
+	}
+	if msg.ModTimeUnixNano != 0 {
+		metadata.ModTime = time.Unix(0, msg.ModTimeUnixNano)
+	}
+	return metadata
+}
@@ -0,0 +1,482 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/v-mars/storage/storagepb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCStorageConfig gRPC 存储客户端配置
+type GRPCStorageConfig struct {
+	Target      string                   `json:"target"`     // 服务地址，如 storage-service:9090 或 dns:///storage-service:9090
+	Token       string                   `json:"token"`      // 访问令牌
+	BaseDir     string                   `json:"base_dir"`   // 服务端存储中的基础目录
+	TLS         bool                     `json:"tls"`        // 使用 TLS 连接（系统根证书），默认不加密
+	Timeout     time.Duration            `json:"timeout"`    // ctx 没有截止时间时单次非流式调用的超时，默认不限制
+	ChunkSize   int                      `json:"chunk_size"` // 上传时每条消息的最大字节数，默认 64KiB
+	DialOptions []grpc.DialOption        `json:"-"`          // 额外的连接选项，可覆盖传输凭证
+	Conn        grpc.ClientConnInterface `json:"-"`          // 已建立的连接，设置时忽略 Target、TLS 和 DialOptions
+}
+
+// GRPCStorage 通过 gRPC 存储服务（GRPCServer）访问远程存储的客户端。
+// ctx 的截止时间随请求传递到服务端；上传和下载为分块流式传输，由 gRPC 流量控制提供背压。
+// 服务端返回的错误还原为 ErrNotExist、ErrNotSupported、ErrPreconditionFailed、ErrTooLarge 和 ErrQuotaExceeded，
+// 超时和取消还原为 context.DeadlineExceeded 和 context.Canceled，可用 errors.Is 判断。
+type GRPCStorage struct {
+	config GRPCStorageConfig
+	conn   *grpc.ClientConn // 由本实例创建的连接，Close 时关闭
+	client storagepb.StorageServiceClient
+}
+
+// NewGRPCStorage 创建新的 gRPC 存储实例（连接在首次调用时建立）
+func NewGRPCStorage(config GRPCStorageConfig) Storage {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = grpcDefaultChunkSize
+	}
+	config.BaseDir = strings.Trim(config.BaseDir, "/")
+
+	s := &GRPCStorage{config: config}
+	cc := config.Conn
+	if cc == nil {
+		if config.Target == "" {
+			hlog.Errorf("gRPC存储服务地址不能为空")
+			return nil
+		}
+		creds := insecure.NewCredentials()
+		if config.TLS {
+			creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+		}
+		opts := append([]grpc.DialOption{grpc.WithTransportCredentials(creds)}, config.DialOptions...)
+		conn, err := grpc.NewClient(config.Target, opts...)
+		if err != nil {
+			hlog.Errorf("创建gRPC存储连接失败: %v", err)
+			return nil
+		}
+		s.conn, cc = conn, conn
+	}
+	s.client = storagepb.NewStorageServiceClient(cc)
+	return s
+}
+
+// Close 关闭由本实例创建的连接，使用 Conn 传入的连接由调用方关闭
+func (s *GRPCStorage) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// fullPath 返回服务端存储中的完整路径
+func (s *GRPCStorage) fullPath(filePath string) string {
+	return strings.Trim(path.Join(s.config.BaseDir, filePath), "/")
+}
+
+// streamContext 返回附带访问令牌的 ctx，用于流式调用（截止时间完全由调用方的 ctx 决定）
+func (s *GRPCStorage) streamContext(ctx context.Context) context.Context {
+	if s.config.Token == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+s.config.Token)
+}
+
+// callContext 返回非流式调用的 ctx，ctx 没有截止时间时应用 Timeout
+func (s *GRPCStorage) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = s.streamContext(ctx)
+	if _, ok := ctx.Deadline(); ok || s.config.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, s.config.Timeout)
+}
+
+// grpcStorageError 将 gRPC 状态错误还原为对应的错误，原始状态仍可通过 status.FromError 获取
+func grpcStorageError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() {
+	case codes.NotFound:
+		return &notExistError{err: err}
+	case codes.Unimplemented:
+		return fmt.Errorf("%w: %w", err, ErrNotSupported)
+	case codes.FailedPrecondition:
+		return fmt.Errorf("%w: %w", err, ErrPreconditionFailed)
+	case codes.ResourceExhausted:
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == grpcErrorDomain {
+				switch info.Reason {
+				case gatewayCodeTooLarge:
+					return fmt.Errorf("%w: %w", err, ErrTooLarge)
+				case gatewayCodeQuotaExceeded:
+					return fmt.Errorf("%w: %w", err, ErrQuotaExceeded)
+				}
+			}
+		}
+	case codes.Canceled:
+		return fmt.Errorf("%w: %w", err, context.Canceled)
+	case codes.DeadlineExceeded:
+		return fmt.Errorf("%w: %w", err, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// Upload 实现上传文件到 gRPC 存储服务（分块流式上传）
+func (s *GRPCStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到gRPC存储: %s", filePath)
+
+	if err := s.upload(ctx, filePath, reader, opts); err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件上传成功: %s", filePath)
+	return nil
+}
+
+// upload 发送上传参数和数据块，读取 reader 失败时取消调用，使服务端放弃本次上传
+func (s *GRPCStorage) upload(ctx context.Context, filePath string, reader io.Reader, opts []UploadOption) error {
+	ctx, cancel := context.WithCancel(s.streamContext(ctx))
+	defer cancel()
+	stream, err := s.client.Upload(ctx)
+	if err != nil {
+		return grpcStorageError(err)
+	}
+
+	options := ApplyUploadOptions(opts...)
+	header := &storagepb.UploadHeader{Path: s.fullPath(filePath), ContentType: options.ContentType}
+	if options.Expiration > 0 {
+		// 服务端以秒为单位，不足一秒的部分向上取整
+		header.ExpirationSeconds = int64((options.Expiration + time.Second - 1) / time.Second)
+	}
+	// Send 返回 io.EOF 表示服务端已结束调用，实际错误由 CloseAndRecv 返回
+	err = stream.Send(&storagepb.UploadRequest{Data: &storagepb.UploadRequest_Header{Header: header}})
+	buf := make([]byte, s.config.ChunkSize)
+	for err == nil {
+		n, readErr := io.ReadFull(reader, buf)
+		if n > 0 {
+			// Send 在流量控制窗口耗尽时阻塞，直到服务端读取
+			err = stream.Send(&storagepb.UploadRequest{Data: &storagepb.UploadRequest_Chunk{Chunk: buf[:n]}})
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	if err != nil && err != io.EOF {
+		return grpcStorageError(err)
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		return grpcStorageError(err)
+	}
+	return nil
+}
+
+// Download 实现从 gRPC 存储服务下载文件（流式下载）
+func (s *GRPCStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从gRPC存储下载文件: %s", filePath)
+
+	reader, err := s.download(ctx, filePath, 0, 0)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储下载文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// DownloadRange 实现从 gRPC 存储服务下载文件范围（size <= 0 表示到文件末尾）
+func (s *GRPCStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从gRPC存储下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	reader, err := s.download(ctx, filePath, max(offset, 0), max(size, 0))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储下载文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件断点续传下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// download 发起下载调用并接收第一条消息，使文件不存在等错误在返回前即可得到
+func (s *GRPCStorage) download(ctx context.Context, filePath string, offset, size int64) (io.ReadCloser, error) {
+	ctx, cancel := context.WithCancel(s.streamContext(ctx))
+	stream, err := s.client.Download(ctx, &storagepb.DownloadRequest{Path: s.fullPath(filePath), Offset: offset, Size: size})
+	if err != nil {
+		cancel()
+		return nil, grpcStorageError(err)
+	}
+	reader := &grpcDownloadReader{stream: stream, cancel: cancel}
+	if reader.next(); reader.err != nil && reader.err != io.EOF {
+		return nil, reader.err
+	}
+	return reader, nil
+}
+
+// grpcDownloadReader 按需接收下载流中的数据块，不读取时由流量控制暂停服务端发送；Close 取消调用
+type grpcDownloadReader struct {
+	stream storagepb.StorageService_DownloadClient
+	cancel context.CancelFunc
+	chunk  []byte
+	err    error
+}
+
+// next 接收下一个数据块，流结束或出错时释放调用
+func (r *grpcDownloadReader) next() {
+	resp, err := r.stream.Recv()
+	if err != nil {
+		r.cancel()
+		if err != io.EOF {
+			err = grpcStorageError(err)
+		}
+		r.err = err
+		return
+	}
+	r.chunk = resp.Chunk
+}
+
+func (r *grpcDownloadReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.next()
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (r *grpcDownloadReader) Close() error {
+	r.cancel()
+	r.chunk = nil
+	if r.err == nil {
+		r.err = fs.ErrClosed
+	}
+	return nil
+}
+
+// Delete 实现删除 gRPC 存储服务中的文件
+func (s *GRPCStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始删除gRPC存储文件: %s", filePath)
+
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	if _, err := s.client.Delete(callCtx, &storagepb.PathRequest{Path: s.fullPath(filePath)}); err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件删除成功: %s", filePath)
+	return nil
+}
+
+// transfer 调用重命名、移动或复制接口
+func (s *GRPCStorage) transfer(ctx context.Context, call func(context.Context, *storagepb.TransferRequest, ...grpc.CallOption) (*storagepb.Empty, error), srcPath, dstPath string) error {
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	_, err := call(callCtx, &storagepb.TransferRequest{Src: s.fullPath(srcPath), Dst: s.fullPath(dstPath)})
+	return grpcStorageError(err)
+}
+
+// Rename 实现重命名 gRPC 存储服务中的文件
+func (s *GRPCStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始重命名gRPC存储文件: %s -> %s", oldPath, newPath)
+
+	if err := s.transfer(ctx, s.client.Rename, oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储重命名文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现移动 gRPC 存储服务中的文件
+func (s *GRPCStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始移动gRPC存储文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.transfer(ctx, s.client.Move, srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储移动文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件移动成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Copy 实现复制 gRPC 存储服务中的文件
+func (s *GRPCStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始复制gRPC存储文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.transfer(ctx, s.client.Copy, srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Exists 实现检查 gRPC 存储服务中的文件是否存在
+func (s *GRPCStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	resp, err := s.client.Exists(callCtx, &storagepb.PathRequest{Path: s.fullPath(filePath)})
+	if err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储检查文件是否存在失败: %v", err)
+		return false, err
+	}
+	return resp.Exists, nil
+}
+
+// CreateDir 实现在 gRPC 存储服务中创建目录
+func (s *GRPCStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始创建gRPC存储目录: %s", dirPath)
+
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	if _, err := s.client.CreateDir(callCtx, &storagepb.PathRequest{Path: s.fullPath(dirPath)}); err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储创建目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储目录创建成功: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现递归删除 gRPC 存储服务中的目录
+func (s *GRPCStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除gRPC存储目录: %s", dirPath)
+
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	if _, err := s.client.DeleteDir(callCtx, &storagepb.PathRequest{Path: s.fullPath(dirPath)}); err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储删除目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储目录删除成功: %s", dirPath)
+	return nil
+}
+
+// ListDir 实现列出 gRPC 存储服务目录内容，接收所有批次
+func (s *GRPCStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出gRPC存储目录: %s", dirPath)
+
+	files, err := s.listDir(ctx, dirPath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "gRPC存储列出目录失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储目录列出成功: %s, 共 %d 项", dirPath, len(files))
+	return files, nil
+}
+
+// listDir 接收目录条目流
+func (s *GRPCStorage) listDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	ctx, cancel := context.WithCancel(s.streamContext(ctx))
+	defer cancel()
+	stream, err := s.client.ListDir(ctx, &storagepb.PathRequest{Path: s.fullPath(dirPath)})
+	if err != nil {
+		return nil, grpcStorageError(err)
+	}
+	var files []FileMetadata
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, grpcStorageError(err)
+		}
+		for _, entry := range resp.Entries {
+			files = append(files, *fromProtoMetadata(entry))
+		}
+	}
+}
+
+// GetMetadata 实现获取 gRPC 存储服务文件元数据
+func (s *GRPCStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	resp, err := s.client.GetMetadata(callCtx, &storagepb.PathRequest{Path: s.fullPath(filePath)})
+	if err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储获取文件元数据失败: %v", err)
+		return nil, err
+	}
+	metadata := fromProtoMetadata(resp)
+	// 与其他后端一致，名称为传入的文件路径
+	metadata.Name = filePath
+	return metadata, nil
+}
+
+// UpdateMetadata 实现更新 gRPC 存储服务文件元数据
+func (s *GRPCStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	_, err := s.client.UpdateMetadata(callCtx, &storagepb.UpdateMetadataRequest{Path: s.fullPath(filePath), Metadata: toProtoMetadata(metadata)})
+	if err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储更新文件元数据失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// BatchUpload 实现批量上传文件（逐个流式上传）
+func (s *GRPCStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传文件到gRPC存储: %d 个", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现批量下载文件（流式下载）
+func (s *GRPCStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从gRPC存储批量下载文件: %d 个", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现批量删除文件
+func (s *GRPCStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除gRPC存储文件: %d 个", len(filePaths))
+
+	paths := make([]string, 0, len(filePaths))
+	for _, filePath := range filePaths {
+		paths = append(paths, s.fullPath(filePath))
+	}
+	callCtx, cancel := s.callContext(ctx)
+	defer cancel()
+	if _, err := s.client.BatchDelete(callCtx, &storagepb.BatchDeleteRequest{Paths: paths}); err != nil {
+		err = grpcStorageError(err)
+		hlog.CtxErrorf(ctx, "gRPC存储批量删除失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "gRPC存储批量删除成功: %d 个", len(filePaths))
+	return nil
+}
@@ -0,0 +1,267 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagepb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// startGRPCServer 在随机端口启动 gRPC 存储服务，返回服务地址
+func startGRPCServer(t *testing.T, config storage.GRPCServerConfig) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	srv := grpc.NewServer()
+	storage.NewGRPCServer(config).Register(srv)
+	go srv.Serve(listener)
+	t.Cleanup(srv.Stop)
+	return listener.Addr().String()
+}
+
+// newGRPCStorage 创建 gRPC 存储客户端，测试结束时关闭连接
+func newGRPCStorage(t *testing.T, config storage.GRPCStorageConfig) storage.Storage {
+	t.Helper()
+	s := storage.NewGRPCStorage(config)
+	if s == nil {
+		t.Fatal("NewGRPCStorage returned nil")
+	}
+	t.Cleanup(func() { s.(*storage.GRPCStorage).Close() })
+	return s
+}
+
+// blockingStorage 的 GetMetadata 一直阻塞到 ctx 结束
+type blockingStorage struct {
+	storage.Storage
+	done chan error
+}
+
+func (s *blockingStorage) GetMetadata(ctx context.Context, filePath string) (*storage.FileMetadata, error) {
+	<-ctx.Done()
+	s.done <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+// endlessStorage 的 Download 返回无限长的内容，并统计已读取的字节数
+type endlessStorage struct {
+	storage.Storage
+	read atomic.Int64
+}
+
+func (s *endlessStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	return countingZeroReader{&s.read}, nil
+}
+
+type countingZeroReader struct {
+	n *atomic.Int64
+}
+
+func (r countingZeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	r.n.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// failingReader 读取一部分数据后返回错误
+type failingReader struct {
+	data io.Reader
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, r.err
+	}
+	return n, err
+}
+
+func TestGRPCStorage_Errors(t *testing.T) {
+	backend := storage.NewSizeLimitedStorage(storage.NewMemoryStorage(storage.MemoryStorageConfig{}), 4)
+	target := startGRPCServer(t, storage.GRPCServerConfig{Storage: backend, Tokens: []string{"secret"}})
+	ctx := context.Background()
+
+	// 令牌错误
+	unauthorized := newGRPCStorage(t, storage.GRPCStorageConfig{Target: target, Token: "wrong"})
+	if _, err := unauthorized.Exists(ctx, "a.txt"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated, got %v", err)
+	}
+
+	s := newGRPCStorage(t, storage.GRPCStorageConfig{Target: target, Token: "secret"})
+	if _, err := s.Download(ctx, "missing.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+	if _, err := s.GetMetadata(ctx, "missing.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+	if err := s.Upload(ctx, "big.txt", strings.NewReader("too large")); !errors.Is(err, storage.ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge, got %v", err)
+	}
+
+	// 读取上传内容失败时中止上传，不留下文件
+	readErr := errors.New("read failed")
+	if err := s.Upload(ctx, "a.txt", &failingReader{data: strings.NewReader("abc"), err: readErr}); !errors.Is(err, readErr) {
+		t.Fatalf("Expected reader error, got %v", err)
+	}
+	if exists, _ := s.Exists(ctx, "a.txt"); exists {
+		t.Fatal("Aborted upload should not create the file")
+	}
+}
+
+func TestGRPCStorage_Deadline(t *testing.T) {
+	backend := &blockingStorage{Storage: storage.NewMemoryStorage(storage.MemoryStorageConfig{}), done: make(chan error, 1)}
+	s := newGRPCStorage(t, storage.GRPCStorageConfig{Target: startGRPCServer(t, storage.GRPCServerConfig{Storage: backend})})
+
+	// 客户端的截止时间传递到服务端存储操作
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := s.GetMetadata(ctx, "a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+	select {
+	case err := <-backend.done:
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+			t.Fatalf("Unexpected server context error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Server storage call was not cancelled")
+	}
+
+	// 未设置截止时间时使用 Timeout
+	s = newGRPCStorage(t, storage.GRPCStorageConfig{
+		Target:  startGRPCServer(t, storage.GRPCServerConfig{Storage: backend}),
+		Timeout: 100 * time.Millisecond,
+	})
+	if _, err := s.GetMetadata(context.Background(), "a.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestGRPCStorage_Streaming(t *testing.T) {
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	s := newGRPCStorage(t, storage.GRPCStorageConfig{
+		Target:    startGRPCServer(t, storage.GRPCServerConfig{Storage: backend, ChunkSize: 1000}),
+		ChunkSize: 1000,
+	})
+	ctx := context.Background()
+
+	// 多个分块的上传和下载
+	data := bytes.Repeat([]byte("0123456789"), 1050)
+	if err := s.Upload(ctx, "data.bin", bytes.NewReader(data)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "data.bin")); got != string(data) {
+		t.Fatalf("Unexpected content length: %d", len(got))
+	}
+	if got := readString(t)(s.DownloadRange(ctx, "data.bin", 10495, 0)); got != "56789" {
+		t.Fatalf("Unexpected range to end: %q", got)
+	}
+	if got := readString(t)(s.DownloadRange(ctx, "data.bin", 998, 5)); got != "89012" {
+		t.Fatalf("Unexpected range: %q", got)
+	}
+}
+
+func TestGRPCStorage_Backpressure(t *testing.T) {
+	backend := &endlessStorage{Storage: storage.NewMemoryStorage(storage.MemoryStorageConfig{})}
+	s := newGRPCStorage(t, storage.GRPCStorageConfig{Target: startGRPCServer(t, storage.GRPCServerConfig{Storage: backend})})
+
+	reader, err := s.Download(context.Background(), "endless.bin")
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if _, err := reader.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	// 客户端不读取时，服务端在流量控制窗口耗尽后停止读取存储
+	time.Sleep(200 * time.Millisecond)
+	if n := backend.read.Load(); n > 64<<20 {
+		t.Fatalf("Server read %d bytes without backpressure", n)
+	}
+	reader.(io.Closer).Close()
+	if _, err := reader.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Expected read after Close to fail")
+	}
+}
+
+func TestGRPCServer_PathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret.txt")
+	if err := os.WriteFile(secret, []byte("secret"), 0o644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	backend := storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: filepath.Join(dir, "root")})
+	conn, err := grpc.NewClient(startGRPCServer(t, storage.GRPCServerConfig{Storage: backend}), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer conn.Close()
+	// 直接使用生成的客户端发送未经处理的路径
+	client := storagepb.NewStorageServiceClient(conn)
+	ctx := context.Background()
+
+	if _, err := client.Copy(ctx, &storagepb.TransferRequest{Src: "../secret.txt", Dst: "stolen.txt"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	if _, err := client.GetMetadata(ctx, &storagepb.PathRequest{Path: "../secret.txt"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	stream, err := client.Download(ctx, &storagepb.DownloadRequest{Path: "../../secret.txt"})
+	if err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.NotFound {
+		t.Fatalf("Expected NotFound, got %v", err)
+	}
+	if _, err := client.Delete(ctx, &storagepb.PathRequest{Path: "../secret.txt"}); err == nil {
+		t.Fatal("Expected Delete to fail inside the storage root")
+	}
+	if _, err := client.BatchDelete(ctx, &storagepb.BatchDeleteRequest{Paths: []string{"../secret.txt", "/.."}}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	if data, err := os.ReadFile(secret); err != nil || string(data) != "secret" {
+		t.Fatalf("File outside the storage root was modified: %q, %v", data, err)
+	}
+
+	// 上传路径同样被限制在存储根目录内
+	upload, err := client.Upload(ctx)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	upload.Send(&storagepb.UploadRequest{Data: &storagepb.UploadRequest_Header{Header: &storagepb.UploadHeader{Path: "../escaped.txt"}}})
+	upload.Send(&storagepb.UploadRequest{Data: &storagepb.UploadRequest_Chunk{Chunk: []byte("escaped")}})
+	if _, err := upload.CloseAndRecv(); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escaped.txt")); !os.IsNotExist(err) {
+		t.Fatalf("Upload escaped the storage root: %v", err)
+	}
+	if got := readString(t)(backend.Download(ctx, "escaped.txt")); got != "escaped" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+
+	// 不允许删除存储根目录
+	for _, dirPath := range []string{"", "/", ".."} {
+		if _, err := client.DeleteDir(ctx, &storagepb.PathRequest{Path: dirPath}); status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("DeleteDir(%q): expected InvalidArgument, got %v", dirPath, err)
+		}
+	}
+	if exists, _ := backend.Exists(ctx, "escaped.txt"); !exists {
+		t.Fatal("Storage root should not be deleted")
+	}
+}
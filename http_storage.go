@@ -0,0 +1,563 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/net/html"
+)
+
+const (
+	// httpDefaultTimeout 默认的等待响应超时时间
+	httpDefaultTimeout = 60 * time.Second
+	// httpMaxListingSize 目录列表页面的最大字节数
+	httpMaxListingSize = 16 << 20
+)
+
+// 目录列表格式
+const (
+	HTTPListingAuto = "auto" // 根据响应的 Content-Type 选择 HTML 或 JSON
+	HTTPListingHTML = "html" // nginx/Apache autoindex 等 HTML 列表页面
+	HTTPListingJSON = "json" // nginx autoindex_format json 或 Caddy browse 的 JSON 列表
+)
+
+// errHTTPReadOnly HTTP 存储的写操作返回的错误
+var errHTTPReadOnly = fmt.Errorf("HTTP存储是只读的: %w", ErrNotSupported)
+
+// HTTPStorageConfig HTTP 存储配置
+type HTTPStorageConfig struct {
+	BaseURL     string            `json:"base_url"`     // 根地址，如 https://cdn.example.com/datasets/
+	Headers     map[string]string `json:"headers"`      // 每个请求附加的请求头
+	Username    string            `json:"username"`     // Basic 认证用户名
+	Password    string            `json:"password"`     // Basic 认证密码
+	BearerToken string            `json:"bearer_token"` // Bearer 认证令牌，设置后忽略用户名和密码
+	Listing     string            `json:"listing"`      // 目录列表格式：auto、html、json，为空时 ListDir 返回 ErrNotSupported
+	Timeout     time.Duration     `json:"timeout"`      // 等待响应头的超时时间（不限制流式读取响应体），默认 60 秒
+	Transport   http.RoundTripper `json:"-"`            // 自定义传输层，为空时使用 http.DefaultTransport
+}
+
+// HTTPStorage 只读的 HTTP 存储实现，从静态文件服务器或 CDN 读取文件。
+// 下载使用 GET（范围下载使用 Range 请求头），元数据使用 HEAD；
+// 配置 Listing 后通过解析目录页面（HTML 或 JSON）支持 ListDir。所有写操作返回 ErrNotSupported。
+type HTTPStorage struct {
+	config  HTTPStorageConfig
+	baseURL *url.URL
+	client  *http.Client
+}
+
+// NewHTTPStorage 创建新的HTTP存储实例
+func NewHTTPStorage(config HTTPStorageConfig) Storage {
+	baseURL, err := url.Parse(config.BaseURL)
+	if err != nil || (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+		hlog.Errorf("HTTP地址无效: %q", config.BaseURL)
+		return nil
+	}
+	switch config.Listing {
+	case "", HTTPListingAuto, HTTPListingHTML, HTTPListingJSON:
+	default:
+		hlog.Errorf("不支持的目录列表格式: %s", config.Listing)
+		return nil
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = httpDefaultTimeout
+	}
+
+	return &HTTPStorage{
+		config:  config,
+		baseURL: baseURL,
+		client:  &http.Client{Transport: config.Transport},
+	}
+}
+
+// url 返回文件的完整地址，isDir 为 true 时以 / 结尾
+func (s *HTTPStorage) url(filePath string, isDir bool) *url.URL {
+	u := *s.baseURL
+	u.Path = path.Join("/", s.baseURL.Path, filePath)
+	if isDir && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	u.RawPath = ""
+	return &u
+}
+
+// do 发送带有自定义请求头和认证信息的请求，404 转换为 ErrNotExist，其他非 2xx 状态码（除 416 外）返回错误。
+// Timeout 只限制等待响应头的时间，大文件下载的响应体可以持续读取，由 ctx 控制
+func (s *HTTPStorage) do(ctx context.Context, method string, target *url.URL, header http.Header) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(s.config.Timeout, cancel)
+	resp, err := s.send(ctx, method, target, header)
+	if !timer.Stop() && err != nil {
+		err = fmt.Errorf("HTTP %s %s 等待响应超时: %w", method, target.Redacted(), context.DeadlineExceeded)
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelReadCloser{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// send 发送请求并检查状态码
+func (s *HTTPStorage) send(ctx context.Context, method string, target *url.URL, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range s.config.Headers {
+		req.Header.Set(key, value)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	switch {
+	case s.config.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+s.config.BearerToken)
+	case s.config.Username != "":
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return resp, nil
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	resp.Body.Close()
+
+	err = fmt.Errorf("HTTP %s %s 失败: %s", method, target.Redacted(), resp.Status)
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusGone:
+		return nil, &notExistError{err: err}
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, fmt.Errorf("%w: %w", err, ErrNotSupported)
+	}
+	return nil, err
+}
+
+// Download 实现从HTTP服务器下载文件
+func (s *HTTPStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从HTTP下载文件: %s", filePath)
+
+	reader, err := s.download(ctx, filePath, 0, -1)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "HTTP获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "HTTP文件下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// DownloadRange 实现从HTTP服务器下载文件范围（Range 请求）
+func (s *HTTPStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从HTTP下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	reader, err := s.download(ctx, filePath, offset, size)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "HTTP获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "HTTP文件断点续传下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// download 返回从 offset 开始最多 size 个字节的内容（size 小于 0 表示到文件末尾）。
+// 服务器忽略 Range 返回完整内容时跳过 offset 之前的部分。
+func (s *HTTPStorage) download(ctx context.Context, filePath string, offset, size int64) (io.ReadCloser, error) {
+	if size == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	header := http.Header{}
+	if offset > 0 || size > 0 {
+		rangeHeader := fmt.Sprintf("bytes=%d-", offset)
+		if size > 0 {
+			rangeHeader += strconv.FormatInt(offset+size-1, 10)
+		}
+		header.Set("Range", rangeHeader)
+	}
+
+	resp, err := s.do(ctx, http.MethodGet, s.url(filePath, false), header)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 起始位置超出文件末尾
+		resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	case http.StatusOK:
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil && !errors.Is(err, io.EOF) {
+				resp.Body.Close()
+				return nil, err
+			}
+		}
+	}
+	if size > 0 {
+		return &limitedReadCloser{Reader: io.LimitReader(resp.Body, size), Closer: resp.Body}, nil
+	}
+	return resp.Body, nil
+}
+
+// cancelReadCloser 关闭响应体时取消请求的 ctx
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (r *cancelReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.cancel()
+	return err
+}
+
+// Exists 实现检查HTTP服务器上的文件是否存在（HEAD 请求）
+func (s *HTTPStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	_, err := s.stat(ctx, filePath)
+	if errors.Is(err, ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// GetMetadata 实现获取HTTP服务器上文件的元数据（HEAD 请求）
+func (s *HTTPStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取HTTP文件元数据: %s", filePath)
+
+	metadata, err := s.stat(ctx, filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取HTTP文件信息失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "成功获取HTTP文件元数据: %s", filePath)
+	return metadata, nil
+}
+
+// stat 通过 HEAD 读取文件信息，服务器不允许 HEAD 时改用只读取第一个字节的 GET 请求
+func (s *HTTPStorage) stat(ctx context.Context, filePath string) (*FileMetadata, error) {
+	resp, err := s.do(ctx, http.MethodHead, s.url(filePath, false), nil)
+	if errors.Is(err, ErrNotSupported) {
+		resp, err = s.do(ctx, http.MethodGet, s.url(filePath, false), http.Header{"Range": {"bytes=0-0"}})
+	}
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	metadata := &FileMetadata{
+		Name:     filePath,
+		Size:     resp.ContentLength,
+		MIMEType: contentTypeOrDetect(resp.Header.Get("Content-Type"), filePath),
+		ETag:     trimETag(resp.Header.Get("ETag")),
+	}
+	if resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// Content-Range: bytes 0-0/1234 或 bytes */0
+		contentRange := resp.Header.Get("Content-Range")
+		if i := strings.LastIndex(contentRange, "/"); i >= 0 {
+			metadata.Size, _ = strconv.ParseInt(contentRange[i+1:], 10, 64)
+		}
+	}
+	if metadata.Size < 0 {
+		metadata.Size = 0
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		metadata.ModTime = modTime
+	}
+	return metadata, nil
+}
+
+// ListDir 实现HTTP目录列表（解析目录页面，仅列出当前层级）
+func (s *HTTPStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出HTTP目录内容: %s", dirPath)
+
+	files, err := s.listDir(ctx, dirPath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出HTTP目录内容失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "成功列出HTTP目录内容: %s, 共找到 %d 个条目", dirPath, len(files))
+	return files, nil
+}
+
+// listDir 请求目录地址并按配置的格式解析
+func (s *HTTPStorage) listDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	if s.config.Listing == "" {
+		return nil, fmt.Errorf("未配置目录列表格式: %w", ErrNotSupported)
+	}
+
+	// 目录页面一次性读取，整个请求受 Timeout 限制
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	dirURL := s.url(dirPath, true)
+	header := http.Header{}
+	switch s.config.Listing {
+	case HTTPListingJSON:
+		header.Set("Accept", "application/json")
+	case HTTPListingHTML:
+		header.Set("Accept", "text/html")
+	default:
+		header.Set("Accept", "application/json, text/html;q=0.9")
+	}
+	resp, err := s.do(ctx, http.MethodGet, dirURL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, httpMaxListingSize)
+
+	format := s.config.Listing
+	if format == HTTPListingAuto {
+		format = HTTPListingHTML
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); strings.HasSuffix(mediaType, "json") {
+			format = HTTPListingJSON
+		}
+	}
+
+	var files []FileMetadata
+	if format == HTTPListingJSON {
+		files, err = parseJSONListing(body)
+	} else {
+		files, err = parseHTMLListing(body, dirURL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析目录列表失败: %w", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+// httpJSONEntry JSON 目录列表的条目，兼容 nginx（name、type、mtime、size）和 Caddy（name、is_dir、mod_time、size）
+type httpJSONEntry struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	IsDir   bool   `json:"is_dir"`
+	Size    int64  `json:"size"`
+	MTime   string `json:"mtime"`
+	ModTime string `json:"mod_time"`
+}
+
+// parseJSONListing 解析 JSON 目录列表
+func parseJSONListing(r io.Reader) ([]FileMetadata, error) {
+	var entries []httpJSONEntry
+	if err := json.NewDecoder(r).Decode(&entries); err != nil {
+		return nil, err
+	}
+
+	files := make([]FileMetadata, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name, "/")
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+			continue
+		}
+		file := FileMetadata{
+			Name:  name,
+			IsDir: entry.IsDir || entry.Type == "directory" || strings.HasSuffix(entry.Name, "/"),
+		}
+		if !file.IsDir {
+			file.Size = entry.Size
+			file.MIMEType = mimeTypeByName(name)
+		}
+		for _, value := range []string{entry.MTime, entry.ModTime} {
+			if modTime, ok := parseListingTime(value); ok {
+				file.ModTime = modTime
+				break
+			}
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+var (
+	// httpListingDate autoindex 页面中的修改时间，如 nginx 的 18-Oct-2026 10:00 和 Apache 的 2026-10-18 10:00
+	httpListingDate = regexp.MustCompile(`\d{2}-[A-Za-z]{3}-\d{4} \d{2}:\d{2}(:\d{2})?|\d{4}-\d{2}-\d{2} \d{2}:\d{2}(:\d{2})?`)
+	// httpListingSize 修改时间之后的精确字节数（nginx 默认 autoindex_exact_size on）
+	httpListingSize = regexp.MustCompile(`^\s*(\d+)(\s|$)`)
+)
+
+// parseListingTime 解析目录列表中的时间
+func parseListingTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{time.RFC3339Nano, "02-Jan-2006 15:04:05", "02-Jan-2006 15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseHTMLListing 解析 HTML 目录页面：只保留指向目录直接子项的链接，以 / 结尾的链接视为目录。
+// 链接之后的文本中能识别出修改时间和精确字节数时一并填充（nginx autoindex），否则大小为 0。
+func parseHTMLListing(r io.Reader, dirURL *url.URL) ([]FileMetadata, error) {
+	var files []FileMetadata
+	seen := map[string]bool{}
+	var current *FileMetadata
+	var text strings.Builder
+
+	// flush 解析上一个链接之后的文本
+	flush := func() {
+		if current == nil {
+			return
+		}
+		rest := text.String()
+		if loc := httpListingDate.FindStringIndex(rest); loc != nil {
+			if modTime, ok := parseListingTime(rest[loc[0]:loc[1]]); ok {
+				current.ModTime = modTime
+			}
+			if m := httpListingSize.FindStringSubmatch(rest[loc[1]:]); m != nil && !current.IsDir {
+				current.Size, _ = strconv.ParseInt(m[1], 10, 64)
+			}
+		}
+		current = nil
+		text.Reset()
+	}
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			if err := tokenizer.Err(); !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			flush()
+			return files, nil
+		case html.TextToken:
+			if current != nil {
+				text.Write(tokenizer.Text())
+			}
+		case html.StartTagToken:
+			name, hasAttr := tokenizer.TagName()
+			if string(name) != "a" {
+				continue
+			}
+			// 每个条目的文本到下一个链接为止
+			flush()
+			if !hasAttr {
+				continue
+			}
+			var href string
+			for {
+				key, value, more := tokenizer.TagAttr()
+				if string(key) == "href" {
+					href = string(value)
+				}
+				if !more {
+					break
+				}
+			}
+			child, isDir, ok := listingChild(dirURL, href)
+			if !ok || seen[child] {
+				continue
+			}
+			seen[child] = true
+			files = append(files, FileMetadata{Name: child, IsDir: isDir})
+			current = &files[len(files)-1]
+			if !isDir {
+				current.MIMEType = mimeTypeByName(child)
+			}
+		}
+	}
+}
+
+// listingChild 判断链接是否指向目录的直接子项，返回子项名称和是否为目录
+func listingChild(dirURL *url.URL, href string) (string, bool, bool) {
+	if href == "" || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+		return "", false, false
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", false, false
+	}
+	target := dirURL.ResolveReference(ref)
+	if target.Host != dirURL.Host || target.RawQuery != "" || !strings.HasPrefix(target.Path, dirURL.Path) {
+		return "", false, false
+	}
+	rest := strings.TrimPrefix(target.Path, dirURL.Path)
+	isDir := strings.HasSuffix(rest, "/")
+	rest = strings.TrimSuffix(rest, "/")
+	if rest == "" || strings.Contains(rest, "/") {
+		return "", false, false
+	}
+	return rest, isDir, true
+}
+
+// Upload HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	return errHTTPReadOnly
+}
+
+// Delete HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) Delete(ctx context.Context, filePath string) error {
+	return errHTTPReadOnly
+}
+
+// Rename HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	return errHTTPReadOnly
+}
+
+// Move HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return errHTTPReadOnly
+}
+
+// Copy HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	return errHTTPReadOnly
+}
+
+// CreateDir HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) CreateDir(ctx context.Context, dirPath string) error {
+	return errHTTPReadOnly
+}
+
+// DeleteDir HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	return errHTTPReadOnly
+}
+
+// UpdateMetadata HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	return errHTTPReadOnly
+}
+
+// BatchUpload HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	return errHTTPReadOnly
+}
+
+// BatchDownload 实现从HTTP服务器批量下载
+func (s *HTTPStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个HTTP文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete HTTP存储是只读的，返回 ErrNotSupported
+func (s *HTTPStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	return errHTTPReadOnly
+}
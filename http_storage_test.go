@@ -0,0 +1,198 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+)
+
+// nginxAutoindex nginx autoindex 生成的目录页面（autoindex_exact_size on）
+const nginxAutoindex = `<html>
+<head><title>Index of /nginx/data/</title></head>
+<body>
+<h1>Index of /nginx/data/</h1><hr><pre><a href="../">../</a>
+<a href="sub%20dir/">sub dir/</a>                                           18-Oct-2026 10:00                   -
+<a href="values.csv">values.csv</a>                                         17-Oct-2026 09:30                1234
+<a href="/elsewhere/file.txt">file.txt</a>                                  17-Oct-2026 09:30                  10
+</pre><hr></body>
+</html>`
+
+// nginxJSONIndex nginx autoindex_format json 生成的目录列表
+const nginxJSONIndex = `[
+{ "name":"sub dir", "type":"directory", "mtime":"Sun, 18 Oct 2026 10:00:00 GMT" },
+{ "name":"values.csv", "type":"file", "mtime":"Sat, 17 Oct 2026 09:30:00 GMT", "size":1234 }
+]`
+
+// newHTTPTestServer 启动静态文件服务器：/files/ 要求 Bearer 令牌和自定义请求头，
+// /nohead/ 不允许 HEAD 请求，/nginx/data/ 返回 nginx 的 HTML 或 JSON 目录列表
+func newHTTPTestServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "dir", "sub"), 0o755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	os.WriteFile(filepath.Join(root, "dir", "file name.txt"), []byte("hello http"), 0o644)
+	os.WriteFile(filepath.Join(root, "dir", "data.json"), []byte("{}"), 0o644)
+
+	files := http.FileServer(http.Dir(root))
+	mux := http.NewServeMux()
+	mux.Handle("/files/", http.StripPrefix("/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("X-Client") != "storage" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})))
+	mux.Handle("/nohead/", http.StripPrefix("/nohead", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})))
+	mux.HandleFunc("/nginx/data/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "json") {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(nginxJSONIndex))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(nginxAutoindex))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, root
+}
+
+func TestHTTPStorage_Backend(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	s := storage.NewHTTPStorage(storage.HTTPStorageConfig{
+		BaseURL:     server.URL + "/files/",
+		Headers:     map[string]string{"X-Client": "storage"},
+		BearerToken: "token",
+		Listing:     storage.HTTPListingAuto,
+	})
+	if s == nil {
+		t.Fatal("NewHTTPStorage returned nil")
+	}
+	ctx := context.Background()
+
+	if got := readString(t)(s.Download(ctx, "dir/file name.txt")); got != "hello http" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if got := readString(t)(s.DownloadRange(ctx, "dir/file name.txt", 6, 3)); got != "htt" {
+		t.Fatalf("Unexpected range content: %q", got)
+	}
+	if got := readString(t)(s.DownloadRange(ctx, "dir/file name.txt", 100, -1)); got != "" {
+		t.Fatalf("Expected empty content past end, got %q", got)
+	}
+	if _, err := s.Download(ctx, "dir/missing.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+
+	metadata, err := s.GetMetadata(ctx, "dir/file name.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Size != 10 || metadata.ModTime.IsZero() || !strings.HasPrefix(metadata.MIMEType, "text/plain") {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+	if ok, err := s.Exists(ctx, "dir/missing.txt"); ok || err != nil {
+		t.Fatalf("Expected missing file, got %v, %v", ok, err)
+	}
+
+	// Go 的文件服务器返回 HTML 目录页面
+	files, err := s.ListDir(ctx, "dir")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != 3 || files[0].Name != "data.json" || files[1].Name != "file name.txt" || files[2].Name != "sub" || !files[2].IsDir {
+		t.Fatalf("Unexpected entries: %+v", files)
+	}
+
+	if err := s.Upload(ctx, "new.txt", strings.NewReader("x")); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+
+	// 缺少认证信息时请求失败
+	anonymous := storage.NewHTTPStorage(storage.HTTPStorageConfig{BaseURL: server.URL + "/files/"})
+	if _, err := anonymous.Download(ctx, "dir/file name.txt"); err == nil {
+		t.Fatal("Expected unauthorized download to fail")
+	}
+	if _, err := anonymous.ListDir(ctx, "dir"); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported without listing format, got %v", err)
+	}
+}
+
+func TestHTTPStorage_HeadFallback(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	s := storage.NewHTTPStorage(storage.HTTPStorageConfig{BaseURL: server.URL + "/nohead"})
+	ctx := context.Background()
+
+	// 不允许 HEAD 时从 Range 响应的 Content-Range 中读取大小
+	metadata, err := s.GetMetadata(ctx, "dir/data.json")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Size != 2 || metadata.MIMEType != "application/json" {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+}
+
+func TestHTTPStorage_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-header.txt" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		// 响应体分两次发送，间隔超过 Timeout
+		w.Write([]byte("first "))
+		w.(http.Flusher).Flush()
+		time.Sleep(300 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer server.Close()
+	s := storage.NewHTTPStorage(storage.HTTPStorageConfig{BaseURL: server.URL, Timeout: 100 * time.Millisecond})
+	ctx := context.Background()
+
+	// Timeout 只限制等待响应头的时间，不中断流式读取的响应体
+	if got := readString(t)(s.Download(ctx, "stream.txt")); got != "first second" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if _, err := s.Download(ctx, "slow-header.txt"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestHTTPStorage_Listing(t *testing.T) {
+	server, _ := newHTTPTestServer(t)
+	ctx := context.Background()
+	modTime := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+
+	for _, listing := range []string{storage.HTTPListingHTML, storage.HTTPListingJSON} {
+		t.Run(listing, func(t *testing.T) {
+			s := storage.NewHTTPStorage(storage.HTTPStorageConfig{BaseURL: server.URL + "/nginx/", Listing: listing})
+			files, err := s.ListDir(ctx, "data")
+			if err != nil {
+				t.Fatalf("ListDir failed: %v", err)
+			}
+			// 上级目录和指向其他目录的链接被忽略，精确大小和修改时间被解析
+			if len(files) != 2 {
+				t.Fatalf("Expected 2 entries, got %+v", files)
+			}
+			if files[0].Name != "sub dir" || !files[0].IsDir {
+				t.Fatalf("Unexpected directory entry: %+v", files[0])
+			}
+			if files[1].Name != "values.csv" || files[1].Size != 1234 || !files[1].ModTime.Equal(modTime) {
+				t.Fatalf("Unexpected file entry: %+v", files[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,522 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// StorageFS 将任意存储适配为只读的 io/fs 文件系统，可直接用于 html/template、http.FileServer（http.FS）和 fs.WalkDir。
+// 返回的文件按需通过 Download/DownloadRange 读取，支持 Seek 和 ReadAt，不会复制到本地。
+// 对象存储没有真正的目录，GetMetadata 找不到且 ListDir 返回非空结果的路径视为目录。
+type StorageFS struct {
+	ctx     context.Context
+	storage Storage
+	root    string // 存储中的根目录，Sub 返回的文件系统不为空
+}
+
+var (
+	_ fs.ReadDirFS  = (*StorageFS)(nil)
+	_ fs.ReadFileFS = (*StorageFS)(nil)
+	_ fs.StatFS     = (*StorageFS)(nil)
+	_ fs.SubFS      = (*StorageFS)(nil)
+)
+
+// NewFS 创建存储的 io/fs 适配器，ctx 用于所有存储操作
+func NewFS(ctx context.Context, s Storage) *StorageFS {
+	return &StorageFS{ctx: ctx, storage: s}
+}
+
+// storagePath 校验 fs 路径并返回存储中的路径
+func (f *StorageFS) storagePath(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return f.root, nil
+	}
+	return path.Join(f.root, name), nil
+}
+
+// pathError 将存储错误转换为 *fs.PathError，不存在的错误统一为 fs.ErrNotExist
+func pathError(op, name string, err error) error {
+	if errors.Is(err, ErrNotExist) {
+		err = fs.ErrNotExist
+	}
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+// Open 打开文件或目录
+func (f *StorageFS) Open(name string) (fs.File, error) {
+	info, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &storageDir{fsys: f, name: name, info: info}, nil
+	}
+	return &storageFile{fsys: f, name: name, info: info}, nil
+}
+
+// Stat 返回文件或目录的信息
+func (f *StorageFS) Stat(name string) (fs.FileInfo, error) {
+	return f.stat("stat", name)
+}
+
+// stat 读取文件信息，找不到文件时检查是否为非空目录
+func (f *StorageFS) stat(op, name string) (*storageFileInfo, error) {
+	storagePath, err := f.storagePath(op, name)
+	if err != nil {
+		return nil, err
+	}
+	if name == "." {
+		return &storageFileInfo{name: ".", metadata: FileMetadata{Name: storagePath, IsDir: true}}, nil
+	}
+
+	metadata, err := f.storage.GetMetadata(f.ctx, storagePath)
+	if err == nil {
+		// 后端返回的 Name 不一定是完整路径，读取时使用请求的路径
+		info := *metadata
+		info.Name = storagePath
+		return &storageFileInfo{name: path.Base(name), metadata: info}, nil
+	}
+	if !errors.Is(err, ErrNotExist) {
+		return nil, pathError(op, name, err)
+	}
+	entries, listErr := f.storage.ListDir(f.ctx, storagePath)
+	if listErr != nil || len(entries) == 0 {
+		return nil, pathError(op, name, err)
+	}
+	return &storageFileInfo{name: path.Base(name), metadata: FileMetadata{Name: storagePath, IsDir: true}}, nil
+}
+
+// ReadDir 读取目录内容，按名称排序
+func (f *StorageFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	storagePath, err := f.storagePath("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return f.readDir(name, storagePath)
+}
+
+// readDir 列出目录并统一各后端的返回形式：子目录名可能以 / 结尾，也可能返回带斜杠的多级路径（取第一级作为子目录）
+func (f *StorageFS) readDir(name, storagePath string) ([]fs.DirEntry, error) {
+	files, err := f.storage.ListDir(f.ctx, storagePath)
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+
+	entries := make([]fs.DirEntry, 0, len(files))
+	seen := make(map[string]bool, len(files))
+	for _, file := range files {
+		entryName := strings.TrimSuffix(file.Name, "/")
+		if i := strings.Index(entryName, "/"); i >= 0 {
+			entryName = entryName[:i]
+			file = FileMetadata{IsDir: true}
+		}
+		if entryName == "" || entryName == "." || seen[entryName] {
+			continue
+		}
+		seen[entryName] = true
+		file.Name = path.Join(storagePath, entryName)
+		entries = append(entries, fs.FileInfoToDirEntry(&storageFileInfo{name: entryName, metadata: file}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// ReadFile 读取整个文件
+func (f *StorageFS) ReadFile(name string) ([]byte, error) {
+	storagePath, err := f.storagePath("read", name)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := f.storage.Download(f.ctx, storagePath)
+	if err != nil {
+		return nil, pathError("read", name, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, pathError("read", name, err)
+	}
+	return data, nil
+}
+
+// Sub 返回以 dir 为根的文件系统（不检查目录是否存在）
+func (f *StorageFS) Sub(dir string) (fs.FS, error) {
+	storagePath, err := f.storagePath("sub", dir)
+	if err != nil {
+		return nil, err
+	}
+	return &StorageFS{ctx: f.ctx, storage: f.storage, root: storagePath}, nil
+}
+
+// storageFileInfo 由 FileMetadata 转换的 fs.FileInfo，Sys 返回 FileMetadata
+type storageFileInfo struct {
+	name     string
+	metadata FileMetadata
+}
+
+func (i *storageFileInfo) Name() string       { return i.name }
+func (i *storageFileInfo) Size() int64        { return i.metadata.Size }
+func (i *storageFileInfo) ModTime() time.Time { return i.metadata.ModTime }
+func (i *storageFileInfo) IsDir() bool        { return i.metadata.IsDir }
+func (i *storageFileInfo) Sys() any           { return i.metadata }
+
+func (i *storageFileInfo) Mode() fs.FileMode {
+	if i.metadata.IsDir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// storageFile 存储中的文件，首次读取时开始下载，Seek 后从新的位置重新下载。
+// 文件大小只用于选择范围下载，读取总是进行到后端返回 EOF，大小未知（为 0）的文件也能读出全部内容
+type storageFile struct {
+	fsys   *StorageFS
+	name   string
+	info   *storageFileInfo
+	offset int64
+	reader io.Reader // 从 offset 开始的下载流
+	closed bool
+}
+
+func (f *storageFile) Stat() (fs.FileInfo, error) {
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: fs.ErrClosed}
+	}
+	return f.info, nil
+}
+
+func (f *storageFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if f.reader == nil {
+		reader, err := f.download(f.offset, f.info.Size()-f.offset)
+		if err != nil {
+			return 0, pathError("read", f.name, err)
+		}
+		f.reader = reader
+	}
+	n, err := f.reader.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *storageFile) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset {
+		f.closeReader()
+		f.offset = offset
+	}
+	return offset, nil
+}
+
+// ReadAt 每次调用发出一个范围下载，不影响 Read 的位置
+func (f *storageFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrClosed}
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: fs.ErrInvalid}
+	}
+	reader, err := f.download(off, int64(len(p)))
+	if err != nil {
+		return 0, pathError("read", f.name, err)
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	n, err := io.ReadFull(reader, p)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *storageFile) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closeReader()
+	f.closed = true
+	return nil
+}
+
+// download 从 offset 开始下载。offset 在已知大小之内时范围下载 size 字节（部分后端不支持以负数表示读到文件末尾），
+// 否则（大小未知或文件已变长）下载整个文件并跳过 offset 之前的内容
+func (f *storageFile) download(offset, size int64) (io.Reader, error) {
+	if offset > 0 && offset < f.info.Size() {
+		return f.fsys.storage.DownloadRange(f.fsys.ctx, f.info.metadata.Name, offset, size)
+	}
+	reader, err := f.fsys.storage.Download(f.fsys.ctx, f.info.metadata.Name)
+	if err != nil || offset == 0 {
+		return reader, err
+	}
+	if _, err := io.CopyN(io.Discard, reader, offset); err != nil && !errors.Is(err, io.EOF) {
+		if closer, ok := reader.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+	return reader, nil
+}
+
+// closeReader 关闭当前的下载流
+func (f *storageFile) closeReader() {
+	if closer, ok := f.reader.(io.Closer); ok {
+		closer.Close()
+	}
+	f.reader = nil
+}
+
+// storageDir 存储中的目录，首次 ReadDir 时列出内容
+type storageDir struct {
+	fsys    *StorageFS
+	name    string
+	info    *storageFileInfo
+	entries []fs.DirEntry
+	loaded  bool
+}
+
+func (d *storageDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *storageDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("是目录")}
+}
+
+func (d *storageDir) Close() error { return nil }
+
+// ReadDir 按 fs.ReadDirFile 的约定分批返回目录项
+func (d *storageDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.fsys.readDir(d.name, d.info.metadata.Name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries, d.loaded = entries, true
+	}
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+// errFSReadOnly io/fs 存储的写操作返回的错误
+var errFSReadOnly = fmt.Errorf("io/fs 存储是只读的: %w", ErrNotSupported)
+
+// FSStorage 将 io/fs 文件系统（如 embed.FS、os.DirFS、fstest.MapFS）适配为只读存储，写操作返回 ErrNotSupported
+type FSStorage struct {
+	fsys fs.FS
+}
+
+// NewFSStorage 创建 io/fs 文件系统的只读存储适配器
+func NewFSStorage(fsys fs.FS) Storage {
+	if fsys == nil {
+		return nil
+	}
+	return &FSStorage{fsys: fsys}
+}
+
+// fsName 将存储路径转换为 fs 路径（去掉首尾斜杠，根目录为 .）
+func fsName(filePath string) string {
+	name := strings.Trim(path.Clean("/"+filePath), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// Download 实现从 io/fs 文件系统读取文件
+func (s *FSStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从io/fs读取文件: %s", filePath)
+	return s.DownloadRange(ctx, filePath, 0, -1)
+}
+
+// DownloadRange 实现从 io/fs 文件系统读取文件范围（文件支持 ReaderAt 或 Seek 时直接定位）
+func (s *FSStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	file, err := s.fsys.Open(fsName(filePath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "io/fs打开文件失败: %v", err)
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = &notExistError{err: fmt.Errorf("%s 是目录", filePath)}
+	}
+	if err != nil {
+		file.Close()
+		hlog.CtxErrorf(ctx, "io/fs获取文件信息失败: %v", err)
+		return nil, err
+	}
+	if offset == 0 && size < 0 {
+		return file, nil
+	}
+
+	if size < 0 {
+		size = max(info.Size()-offset, 0)
+	}
+	var reader io.Reader
+	switch f := file.(type) {
+	case io.ReaderAt:
+		reader = io.NewSectionReader(f, offset, size)
+	case io.Seeker:
+		if _, err = f.Seek(offset, io.SeekStart); err == nil {
+			reader = io.LimitReader(file, size)
+		}
+	default:
+		if _, err = io.CopyN(io.Discard, file, offset); errors.Is(err, io.EOF) {
+			err = nil
+		}
+		reader = io.LimitReader(file, size)
+	}
+	if err != nil {
+		file.Close()
+		hlog.CtxErrorf(ctx, "io/fs定位文件失败: %v", err)
+		return nil, err
+	}
+	return &limitedReadCloser{Reader: reader, Closer: file}, nil
+}
+
+// Exists 实现检查 io/fs 文件系统中的文件或目录是否存在
+func (s *FSStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	_, err := fs.Stat(s.fsys, fsName(filePath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListDir 实现列出 io/fs 文件系统的目录内容（仅列出当前层级）
+func (s *FSStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出io/fs目录内容: %s", dirPath)
+
+	entries, err := fs.ReadDir(s.fsys, fsName(dirPath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出io/fs目录内容失败: %v", err)
+		return nil, err
+	}
+
+	files := make([]FileMetadata, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			hlog.CtxErrorf(ctx, "获取io/fs文件信息失败: %v", err)
+			return nil, err
+		}
+		files = append(files, fsMetadata(entry.Name(), info))
+	}
+	return files, nil
+}
+
+// GetMetadata 实现获取 io/fs 文件系统中文件的元数据
+func (s *FSStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	info, err := fs.Stat(s.fsys, fsName(filePath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取io/fs文件信息失败: %v", err)
+		return nil, err
+	}
+	metadata := fsMetadata(filePath, info)
+	return &metadata, nil
+}
+
+// fsMetadata 将 fs.FileInfo 转换为 FileMetadata
+func fsMetadata(name string, info fs.FileInfo) FileMetadata {
+	metadata := FileMetadata{
+		Name:    name,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+	if !info.IsDir() {
+		metadata.MIMEType = mimeTypeByName(name)
+	}
+	return metadata
+}
+
+// Upload io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	return errFSReadOnly
+}
+
+// Delete io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) Delete(ctx context.Context, filePath string) error {
+	return errFSReadOnly
+}
+
+// Rename io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	return errFSReadOnly
+}
+
+// Move io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return errFSReadOnly
+}
+
+// Copy io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	return errFSReadOnly
+}
+
+// CreateDir io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) CreateDir(ctx context.Context, dirPath string) error {
+	return errFSReadOnly
+}
+
+// DeleteDir io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	return errFSReadOnly
+}
+
+// UpdateMetadata io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	return errFSReadOnly
+}
+
+// BatchUpload io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	return errFSReadOnly
+}
+
+// BatchDownload 实现从 io/fs 文件系统批量读取文件
+func (s *FSStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从io/fs批量读取 %d 个文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete io/fs 存储是只读的，返回 ErrNotSupported
+func (s *FSStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	return errFSReadOnly
+}
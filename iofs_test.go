@@ -0,0 +1,174 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/v-mars/storage"
+)
+
+// newFSTestStorage 创建包含模板和静态资源的内存存储
+func newFSTestStorage(t *testing.T) storage.Storage {
+	t.Helper()
+	s := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	for name, content := range map[string]string{
+		"templates/index.html":        `<h1>{{.}}</h1>`,
+		"templates/partials/nav.html": `<nav></nav>`,
+		"static/app.js":               "console.log('storage')",
+		"static/css/site.css":         "body{}",
+	} {
+		if err := s.Upload(ctx, name, strings.NewReader(content)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	return s
+}
+
+func TestStorageFS(t *testing.T) {
+	fsys := storage.NewFS(context.Background(), newFSTestStorage(t))
+
+	// 标准库的文件系统一致性检查（包含 ReadDir、Stat、Seek、ReadAt 和 Sub）
+	if err := fstest.TestFS(fsys, "templates/index.html", "templates/partials/nav.html", "static/app.js", "static/css/site.css"); err != nil {
+		t.Fatalf("TestFS failed: %v", err)
+	}
+
+	if _, err := fs.Stat(fsys, "missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := fsys.Open("../escape"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Expected fs.ErrInvalid, got %v", err)
+	}
+
+	// 直接用于 html/template
+	tmpl, err := template.ParseFS(fsys, "templates/*.html")
+	if err != nil {
+		t.Fatalf("ParseFS failed: %v", err)
+	}
+	var out strings.Builder
+	if err := tmpl.ExecuteTemplate(&out, "index.html", "hello"); err != nil || out.String() != "<h1>hello</h1>" {
+		t.Fatalf("Unexpected template output: %q, %v", out.String(), err)
+	}
+
+	// 直接用于 http.FileServer，Range 请求通过 Seek 定位
+	static, err := fs.Sub(fsys, "static")
+	if err != nil {
+		t.Fatalf("Sub failed: %v", err)
+	}
+	server := httptest.NewServer(http.FileServer(http.FS(static)))
+	defer server.Close()
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/app.js", nil)
+	req.Header.Set("Range", "bytes=13-19")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent || string(body) != "storage" {
+		t.Fatalf("Unexpected response: %d %q", resp.StatusCode, body)
+	}
+	resp, err = http.Get(server.URL + "/missing.js")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404, got %d", resp.StatusCode)
+	}
+}
+
+// unknownSizeStorage 元数据中只返回文件名、不返回大小的存储
+type unknownSizeStorage struct {
+	storage.Storage
+}
+
+func (s unknownSizeStorage) GetMetadata(ctx context.Context, filePath string) (*storage.FileMetadata, error) {
+	metadata, err := s.Storage.GetMetadata(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return &storage.FileMetadata{Name: metadata.Name[strings.LastIndex(metadata.Name, "/")+1:]}, nil
+}
+
+func TestStorageFS_UnknownSize(t *testing.T) {
+	fsys := storage.NewFS(context.Background(), unknownSizeStorage{newFSTestStorage(t)})
+
+	// 使用请求的路径读取，并读到后端返回 EOF 为止
+	file, err := fsys.Open("static/app.js")
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer file.Close()
+	if data, err := io.ReadAll(file); err != nil || string(data) != "console.log('storage')" {
+		t.Fatalf("Unexpected content: %q, %v", data, err)
+	}
+	p := make([]byte, 7)
+	if n, err := file.(io.ReaderAt).ReadAt(p, 13); n != 7 || string(p) != "storage" {
+		t.Fatalf("Unexpected ReadAt result: %q, %v", p[:n], err)
+	}
+	if _, err := file.(io.Seeker).Seek(8, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if data, err := io.ReadAll(file); err != nil || string(data) != "log('storage')" {
+		t.Fatalf("Unexpected content after Seek: %q, %v", data, err)
+	}
+}
+
+func TestFSStorage(t *testing.T) {
+	modTime := time.Unix(1700000000, 0)
+	s := storage.NewFSStorage(fstest.MapFS{
+		"assets/logo.svg":   {Data: []byte("<svg></svg>"), ModTime: modTime},
+		"assets/js/app.js":  {Data: []byte("console.log(1)"), ModTime: modTime},
+		"templates/a.tmpl":  {Data: []byte("{{.}}"), ModTime: modTime},
+		"templates/b.tmpl":  {Data: []byte("b"), ModTime: modTime},
+		"templates/c/d.txt": {Data: []byte("d"), ModTime: modTime},
+	})
+	ctx := context.Background()
+
+	if got := readString(t)(s.Download(ctx, "/assets/logo.svg")); got != "<svg></svg>" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if got := readString(t)(s.DownloadRange(ctx, "assets/js/app.js", 8, 3)); got != "log" {
+		t.Fatalf("Unexpected range content: %q", got)
+	}
+	if _, err := s.Download(ctx, "assets"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist for directory, got %v", err)
+	}
+
+	metadata, err := s.GetMetadata(ctx, "assets/logo.svg")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Size != 11 || !metadata.ModTime.Equal(modTime) || metadata.MIMEType != "image/svg+xml" {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+
+	// 与其他存储一样可以用 Walk 遍历
+	var walked []string
+	if err := storage.Walk(ctx, s, "templates", func(filePath string, _ storage.FileMetadata) error {
+		walked = append(walked, filePath)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if strings.Join(walked, ",") != "templates/a.tmpl,templates/b.tmpl,templates/c/d.txt" {
+		t.Fatalf("Unexpected walk: %v", walked)
+	}
+
+	if ok, _ := s.Exists(ctx, "missing"); ok {
+		t.Fatal("Expected missing file not to exist")
+	}
+	if err := s.Upload(ctx, "new.txt", strings.NewReader("x")); !errors.Is(err, storage.ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// JanitorConfig 过期清理器配置
+type JanitorConfig struct {
+	Root     string        `yaml:"root" json:"root"`         // 扫描的根目录，默认为存储根目录
+	Interval time.Duration `yaml:"interval" json:"interval"` // 扫描间隔，默认1小时
+	DryRun   bool          `yaml:"dry_run" json:"dry_run"`   // 仅记录将被删除的对象，不实际删除
+}
+
+// JanitorReport 单次扫描结果
+type JanitorReport struct {
+	Scanned      int64         `json:"scanned"`       // 扫描的对象数
+	Expired      int64         `json:"expired"`       // 已过期的对象数
+	Deleted      int64         `json:"deleted"`       // 成功删除的对象数（DryRun时为0）
+	Failed       int64         `json:"failed"`        // 删除或读取失败的对象数
+	ExpiredPaths []string      `json:"expired_paths"` // 已过期对象的路径
+	StartedAt    time.Time     `json:"started_at"`    // 扫描开始时间
+	Duration     time.Duration `json:"duration"`      // 扫描耗时
+}
+
+// JanitorMetrics 清理器累计指标
+type JanitorMetrics struct {
+	Runs      int64     `json:"runs"`        // 扫描次数
+	Scanned   int64     `json:"scanned"`     // 累计扫描对象数
+	Expired   int64     `json:"expired"`     // 累计过期对象数
+	Deleted   int64     `json:"deleted"`     // 累计删除对象数
+	Failed    int64     `json:"failed"`      // 累计失败数
+	LastRunAt time.Time `json:"last_run_at"` // 最近一次扫描开始时间
+}
+
+// Janitor 定期扫描并删除已过期的对象
+type Janitor struct {
+	storage Storage
+	reader  ExpirationReader
+	config  JanitorConfig
+
+	runs    atomic.Int64
+	scanned atomic.Int64
+	expired atomic.Int64
+	deleted atomic.Int64
+	failed  atomic.Int64
+	lastRun atomic.Int64
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewJanitor 创建过期清理器，存储（或其包装的存储）必须实现 ExpirationReader
+func NewJanitor(s Storage, config JanitorConfig) (*Janitor, error) {
+	reader, ok := As[ExpirationReader](s)
+	if !ok {
+		return nil, fmt.Errorf("存储类型 %T 不支持读取过期时间", s)
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+	return &Janitor{
+		storage: s,
+		reader:  reader,
+		config:  config,
+	}, nil
+}
+
+// RunOnce 执行一次完整扫描
+func (j *Janitor) RunOnce(ctx context.Context) (*JanitorReport, error) {
+	report := &JanitorReport{StartedAt: time.Now()}
+	j.runs.Add(1)
+	j.lastRun.Store(report.StartedAt.UnixNano())
+
+	hlog.CtxInfof(ctx, "开始扫描过期文件: root=%s, dryRun=%t", j.config.Root, j.config.DryRun)
+
+	err := Walk(ctx, j.storage, j.config.Root, func(filePath string, _ FileMetadata) error {
+		report.Scanned++
+
+		deadline, err := j.reader.GetExpiration(ctx, filePath)
+		if err != nil {
+			if errors.Is(err, ErrNotExist) {
+				// 扫描期间被并发删除
+				return nil
+			}
+			hlog.CtxErrorf(ctx, "读取文件过期时间失败: %s, %v", filePath, err)
+			report.Failed++
+			return nil
+		}
+		if !isExpired(deadline) {
+			return nil
+		}
+
+		report.Expired++
+		report.ExpiredPaths = append(report.ExpiredPaths, filePath)
+		if j.config.DryRun {
+			hlog.CtxInfof(ctx, "[DryRun] 文件已过期，将被删除: %s, 截止时间=%v", filePath, deadline)
+			return nil
+		}
+
+		if err := j.storage.Delete(ctx, filePath); err != nil {
+			hlog.CtxErrorf(ctx, "删除过期文件失败: %s, %v", filePath, err)
+			report.Failed++
+			return nil
+		}
+		report.Deleted++
+		return nil
+	})
+
+	report.Duration = time.Since(report.StartedAt)
+	j.scanned.Add(report.Scanned)
+	j.expired.Add(report.Expired)
+	j.deleted.Add(report.Deleted)
+	j.failed.Add(report.Failed)
+
+	if err != nil {
+		hlog.CtxErrorf(ctx, "扫描过期文件失败: %v", err)
+		return report, err
+	}
+
+	hlog.CtxInfof(ctx, "过期文件扫描完成: 扫描 %d 个, 过期 %d 个, 删除 %d 个, 失败 %d 个, 耗时 %v",
+		report.Scanned, report.Expired, report.Deleted, report.Failed, report.Duration)
+	return report, nil
+}
+
+// Start 在后台按间隔周期扫描，重复调用无效
+func (j *Janitor) Start(ctx context.Context) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.cancel != nil {
+		return
+	}
+
+	ctx, j.cancel = context.WithCancel(ctx)
+	j.done = make(chan struct{})
+
+	go func(done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(j.config.Interval)
+		defer ticker.Stop()
+
+		for {
+			_, _ = j.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}(j.done)
+}
+
+// Stop 停止后台扫描并等待当前扫描结束
+func (j *Janitor) Stop() {
+	j.mu.Lock()
+	cancel, done := j.cancel, j.done
+	j.cancel, j.done = nil, nil
+	j.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// Metrics 返回累计指标
+func (j *Janitor) Metrics() JanitorMetrics {
+	metrics := JanitorMetrics{
+		Runs:    j.runs.Load(),
+		Scanned: j.scanned.Load(),
+		Expired: j.expired.Load(),
+		Deleted: j.deleted.Load(),
+		Failed:  j.failed.Load(),
+	}
+	if last := j.lastRun.Load(); last != 0 {
+		metrics.LastRunAt = time.Unix(0, last)
+	}
+	return metrics
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLocalStorage_ExpiredReadsNotExist(t *testing.T) {
+	// 创建临时目录用于测试
+	tempDir, err := os.MkdirTemp("", "storage_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	storage := NewLocalStorage(LocalStorageConfig{BasePath: tempDir}).(*LocalStorage)
+	ctx := context.Background()
+
+	err = storage.Upload(ctx, "dir/ttl.txt", bytes.NewReader([]byte("temporary")), WithExpiration(time.Hour))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	deadline, err := storage.GetExpiration(ctx, "dir/ttl.txt")
+	if err != nil {
+		t.Fatalf("GetExpiration failed: %v", err)
+	}
+	if deadline.IsZero() || deadline.Before(time.Now()) {
+		t.Fatalf("Unexpected deadline: %v", deadline)
+	}
+
+	// 将截止时间改到过去，模拟已过期但尚未被清理的文件
+	past := time.Now().Add(-time.Minute)
+	if err := storage.writeMeta("dir/ttl.txt", &localObjectMeta{ExpiresAt: &past}); err != nil {
+		t.Fatalf("writeMeta failed: %v", err)
+	}
+
+	if _, err := storage.Download(ctx, "dir/ttl.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Download expected ErrNotExist, got %v", err)
+	}
+	if _, err := storage.DownloadRange(ctx, "dir/ttl.txt", 0, 4); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("DownloadRange expected ErrNotExist, got %v", err)
+	}
+	if _, err := storage.GetMetadata(ctx, "dir/ttl.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("GetMetadata expected ErrNotExist, got %v", err)
+	}
+	if exists, err := storage.Exists(ctx, "dir/ttl.txt"); err != nil || exists {
+		t.Fatalf("Exists expected false, got %t, %v", exists, err)
+	}
+
+	// 覆盖上传且不设置有效期后文件重新可读
+	err = storage.Upload(ctx, "dir/ttl.txt", bytes.NewReader([]byte("permanent")))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if exists, err := storage.Exists(ctx, "dir/ttl.txt"); err != nil || !exists {
+		t.Fatalf("Exists expected true, got %t, %v", exists, err)
+	}
+
+	// 附加元数据目录不应出现在列表中
+	files, err := storage.ListDir(ctx, "")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	for _, f := range files {
+		if f.Name == localMetaDir {
+			t.Fatalf("ListDir should hide %s", localMetaDir)
+		}
+	}
+}
+
+func TestJanitor_RunOnce(t *testing.T) {
+	// 创建临时目录用于测试
+	tempDir, err := os.MkdirTemp("", "storage_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	storage := NewLocalStorage(LocalStorageConfig{BasePath: tempDir}).(*LocalStorage)
+	ctx := context.Background()
+
+	files := map[string]time.Duration{
+		"keep.txt":         0,
+		"later.txt":        time.Hour,
+		"old.txt":          time.Hour,
+		"nested/old.txt":   time.Hour,
+		"nested/keep.txt":  0,
+		"nested/a/old.txt": time.Hour,
+	}
+	for filePath, ttl := range files {
+		var opts []UploadOption
+		if ttl > 0 {
+			opts = append(opts, WithExpiration(ttl))
+		}
+		if err := storage.Upload(ctx, filePath, bytes.NewReader([]byte(filePath)), opts...); err != nil {
+			t.Fatalf("Upload %s failed: %v", filePath, err)
+		}
+	}
+
+	past := time.Now().Add(-time.Minute)
+	for _, filePath := range []string{"old.txt", "nested/old.txt", "nested/a/old.txt"} {
+		if err := storage.writeMeta(filePath, &localObjectMeta{ExpiresAt: &past}); err != nil {
+			t.Fatalf("writeMeta failed: %v", err)
+		}
+	}
+
+	// DryRun 只报告不删除
+	dryRun, err := NewJanitor(storage, JanitorConfig{DryRun: true})
+	if err != nil {
+		t.Fatalf("NewJanitor failed: %v", err)
+	}
+	report, err := dryRun.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if report.Scanned != int64(len(files)) || report.Expired != 3 || report.Deleted != 0 {
+		t.Fatalf("Unexpected dry-run report: %+v", report)
+	}
+	if _, err := os.Stat(tempDir + "/old.txt"); err != nil {
+		t.Fatalf("DryRun should not delete files: %v", err)
+	}
+
+	janitor, err := NewJanitor(storage, JanitorConfig{})
+	if err != nil {
+		t.Fatalf("NewJanitor failed: %v", err)
+	}
+	report, err = janitor.RunOnce(ctx)
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if report.Expired != 3 || report.Deleted != 3 || report.Failed != 0 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+
+	for filePath, ttl := range files {
+		_, err := os.Stat(tempDir + "/" + filePath)
+		expired := ttl > 0 && filePath != "later.txt"
+		if expired && !os.IsNotExist(err) {
+			t.Fatalf("Expired file %s was not deleted", filePath)
+		}
+		if !expired && err != nil {
+			t.Fatalf("File %s should be kept: %v", filePath, err)
+		}
+	}
+
+	metrics := janitor.Metrics()
+	if metrics.Runs != 1 || metrics.Deleted != 3 || metrics.LastRunAt.IsZero() {
+		t.Fatalf("Unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestLocalStorage_RenameDirKeepsExpiration(t *testing.T) {
+	storage := NewLocalStorage(LocalStorageConfig{BasePath: t.TempDir()}).(*LocalStorage)
+	ctx := context.Background()
+
+	for _, name := range []string{"old/a.txt", "old/sub/b.txt"} {
+		if err := storage.Upload(ctx, name, bytes.NewReader([]byte("temporary")), WithExpiration(time.Hour)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	// 目标位置残留的附加元数据不应作用于移动过来的文件
+	past := time.Now().Add(-time.Minute)
+	if err := storage.writeMeta("new/a.txt", &localObjectMeta{ExpiresAt: &past}); err != nil {
+		t.Fatalf("writeMeta failed: %v", err)
+	}
+
+	if err := storage.Rename(ctx, "old", "new"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	for _, name := range []string{"new/a.txt", "new/sub/b.txt"} {
+		deadline, err := storage.GetExpiration(ctx, name)
+		if err != nil || time.Until(deadline) < 59*time.Minute {
+			t.Fatalf("Expiration of %s not moved: %v, %v", name, deadline, err)
+		}
+	}
+	if _, err := os.Stat(storage.metaDirPath("old")); !os.IsNotExist(err) {
+		t.Fatalf("Old metadata directory should be removed, got %v", err)
+	}
+
+	// 在原路径新建的文件不受原来的有效期影响
+	if err := storage.Upload(ctx, "old/a.txt", bytes.NewReader([]byte("permanent"))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if deadline, err := storage.GetExpiration(ctx, "old/a.txt"); err != nil || !deadline.IsZero() {
+		t.Fatalf("Unexpected expiration: %v, %v", deadline, err)
+	}
+}
@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// localMetaDir 本地存储保存附加元数据的隐藏目录（位于 BasePath 下，ListDir 会跳过）。
+// 本地文件系统没有对象存储的用户元数据，过期时间等信息以 JSON 文件的形式
+// 保存在 <BasePath>/.storage-meta/<filePath>.json 中。
+const localMetaDir = ".storage-meta"
+
+// localObjectMeta 本地文件的附加元数据
+type localObjectMeta struct {
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`   // 删除截止时间
+	ContentType string     `json:"content_type,omitempty"` // MIME 类型（仅在与扩展名推断结果不同时记录）
+}
+
+// isEmpty 判断是否没有任何附加元数据
+func (m *localObjectMeta) isEmpty() bool {
+	return m.ExpiresAt == nil && m.ContentType == ""
+}
+
+// metaPath 返回文件对应的附加元数据路径
+func (s *LocalStorage) metaPath(filePath string) string {
+	return filepath.Join(s.config.BasePath, localMetaDir, filePath) + ".json"
+}
+
+// metaDirPath 返回目录对应的附加元数据目录
+func (s *LocalStorage) metaDirPath(dirPath string) string {
+	return filepath.Join(s.config.BasePath, localMetaDir, dirPath)
+}
+
+// readMeta 读取文件的附加元数据，不存在时返回空元数据
+func (s *LocalStorage) readMeta(filePath string) (*localObjectMeta, error) {
+	meta := &localObjectMeta{}
+	data, err := os.ReadFile(s.metaPath(filePath))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return meta, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// writeMeta 写入文件的附加元数据，元数据为空时删除对应文件
+func (s *LocalStorage) writeMeta(filePath string, meta *localObjectMeta) error {
+	if meta == nil || meta.isEmpty() {
+		return s.removeMeta(filePath)
+	}
+	metaPath := s.metaPath(filePath)
+	if err := os.MkdirAll(filepath.Dir(metaPath), os.ModePerm); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(metaPath, data, 0644)
+}
+
+// removeMeta 删除文件的附加元数据
+func (s *LocalStorage) removeMeta(filePath string) error {
+	err := os.Remove(s.metaPath(filePath))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// renameMeta 随文件一起移动附加元数据
+func (s *LocalStorage) renameMeta(oldPath, newPath string) error {
+	meta, err := s.readMeta(oldPath)
+	if err != nil {
+		return err
+	}
+	if err := s.writeMeta(newPath, meta); err != nil {
+		return err
+	}
+	return s.removeMeta(oldPath)
+}
+
+// renameMetaDir 随目录一起移动附加元数据目录。
+// 目标位置残留的附加元数据不属于移动过来的文件，先删除
+func (s *LocalStorage) renameMetaDir(oldPath, newPath string) error {
+	oldMetaDir, newMetaDir := s.metaDirPath(oldPath), s.metaDirPath(newPath)
+	if _, err := os.Stat(oldMetaDir); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := os.RemoveAll(newMetaDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(newMetaDir), os.ModePerm); err != nil {
+		return err
+	}
+	return os.Rename(oldMetaDir, newMetaDir)
+}
+
+// mimeType 返回文件的 MIME 类型：优先使用上传时记录的类型，其次根据扩展名和文件内容检测
+func (s *LocalStorage) mimeType(filePath string, meta *localObjectMeta) string {
+	if meta != nil && meta.ContentType != "" {
+		return meta.ContentType
+	}
+	return detectFileMIMEType(filepath.Join(s.config.BasePath, filePath))
+}
+
+// checkExpired 检查文件是否已过期，过期时返回 ErrNotExist
+func (s *LocalStorage) checkExpired(filePath string) error {
+	meta, err := s.readMeta(filePath)
+	if err != nil {
+		return err
+	}
+	if meta.ExpiresAt != nil && isExpired(*meta.ExpiresAt) {
+		return errExpired(filePath)
+	}
+	return nil
+}
+
+// recordedMIMEType 返回文件的 MIME 类型：优先使用上传时记录的类型，其次根据扩展名推断（不读取文件内容），
+// 用于远程文件和目录列表
+func recordedMIMEType(filePath string, meta *localObjectMeta) string {
+	if meta != nil && meta.ContentType != "" {
+		return meta.ContentType
+	}
+	return mimeTypeByName(filePath)
+}
@@ -2,9 +2,12 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 )
@@ -14,6 +17,9 @@ type LocalStorageConfig struct {
 	BasePath string `json:"base_path"` // 本地存储基础路径
 }
 
+// localUploadTempPrefix 上传过程中临时文件的名称前缀
+const localUploadTempPrefix = ".storage-upload-"
+
 // LocalStorage 本地存储实现
 type LocalStorage struct {
 	config LocalStorageConfig
@@ -26,10 +32,20 @@ func NewLocalStorage(config LocalStorageConfig) Storage {
 	}
 }
 
-// Upload 实现本地文件上传（本地存储不支持有效期）
+// Upload 实现本地文件上传。
+// 设置有效期时删除截止时间记录在附加元数据中，到期后读取返回 ErrNotExist，由 Janitor 负责删除。
 func (s *LocalStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
 	hlog.CtxInfof(ctx, "开始上传文件到本地存储: %s", filePath)
 
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
 	fullPath := filepath.Join(s.config.BasePath, filePath)
 	dir := filepath.Dir(fullPath)
 
@@ -38,19 +54,47 @@ func (s *LocalStorage) Upload(ctx context.Context, filePath string, reader io.Re
 		return err
 	}
 
-	file, err := os.Create(fullPath)
+	// 先写入同目录下的临时文件再重命名，读取方不会看到不完整的文件，并发覆盖时以最后完成的上传为准
+	file, err := os.CreateTemp(dir, localUploadTempPrefix+"*")
 	if err != nil {
 		hlog.CtxErrorf(ctx, "创建文件失败: %v", err)
 		return err
 	}
-	defer file.Close()
+	defer os.Remove(file.Name())
 
 	_, err = io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
+		// 上传中断（如超过大小限制）时临时文件由 defer 删除，已有文件保持不变
+		hlog.CtxErrorf(ctx, "写入文件失败: %v", err)
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		hlog.CtxErrorf(ctx, "设置文件权限失败: %v", err)
+		return err
+	}
+	if err := os.Rename(file.Name(), fullPath); err != nil {
 		hlog.CtxErrorf(ctx, "写入文件失败: %v", err)
 		return err
 	}
 
+	// 覆盖上传时同时覆盖旧的附加元数据；能由扩展名推断的类型无需记录
+	meta := &localObjectMeta{}
+	if contentType != MIMETypeByExtension(filePath) {
+		meta.ContentType = contentType
+	}
+	if options.Expiration > 0 {
+		deadline := expirationDeadline(options.Expiration)
+		meta.ExpiresAt = &deadline
+		hlog.CtxDebugf(ctx, "设置本地文件过期时间: %v", deadline)
+	}
+	if err := s.writeMeta(filePath, meta); err != nil {
+		hlog.CtxErrorf(ctx, "写入文件元数据失败: %v", err)
+		return err
+	}
+
 	hlog.CtxInfof(ctx, "文件上传成功: %s", filePath)
 	return nil
 }
@@ -60,6 +104,10 @@ func (s *LocalStorage) Download(ctx context.Context, filePath string) (io.Reader
 	hlog.CtxInfof(ctx, "开始下载本地文件: %s", filePath)
 
 	fullPath := filepath.Join(s.config.BasePath, filePath)
+	if err := s.checkExpired(filePath); err != nil {
+		hlog.CtxErrorf(ctx, "打开本地文件失败: %v", err)
+		return nil, err
+	}
 
 	// 创建管道：一端读取文件内容，另一端提供给调用者
 	pr, pw := io.Pipe()
@@ -94,6 +142,10 @@ func (s *LocalStorage) DownloadRange(ctx context.Context, filePath string, offse
 	hlog.CtxInfof(ctx, "开始本地文件断点续传下载: %s, offset=%d, size=%d", filePath, offset, size)
 
 	fullPath := filepath.Join(s.config.BasePath, filePath)
+	if err := s.checkExpired(filePath); err != nil {
+		hlog.CtxErrorf(ctx, "打开本地文件失败: %v", err)
+		return nil, err
+	}
 
 	// 创建管道：一端读取文件内容，另一端提供给调用者
 	pr, pw := io.Pipe()
@@ -145,6 +197,11 @@ func (s *LocalStorage) Delete(ctx context.Context, filePath string) error {
 		return err
 	}
 
+	if err := s.removeMeta(filePath); err != nil {
+		hlog.CtxErrorf(ctx, "删除文件元数据失败: %v", err)
+		return err
+	}
+
 	hlog.CtxInfof(ctx, "文件删除成功: %s", filePath)
 	return nil
 }
@@ -168,6 +225,15 @@ func (s *LocalStorage) Rename(ctx context.Context, oldPath string, newPath strin
 		return err
 	}
 
+	renameMeta := s.renameMeta
+	if info, err := os.Stat(newFullPath); err == nil && info.IsDir() {
+		renameMeta = s.renameMetaDir
+	}
+	if err := renameMeta(oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "移动文件元数据失败: %v", err)
+		return err
+	}
+
 	hlog.CtxInfof(ctx, "文件重命名成功: %s -> %s", oldPath, newPath)
 	return nil
 }
@@ -211,18 +277,30 @@ func (s *LocalStorage) Copy(ctx context.Context, srcPath string, dstPath string)
 		return err
 	}
 
+	meta, err := s.readMeta(srcPath)
+	if err == nil {
+		err = s.writeMeta(dstPath, meta)
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "复制文件元数据失败: %v", err)
+		return err
+	}
+
 	hlog.CtxInfof(ctx, "文件复制成功: %s -> %s", srcPath, dstPath)
 	return nil
 }
 
-// Exists 实现检查本地文件是否存在
+// Exists 实现检查本地文件是否存在（已过期的文件视为不存在）
 func (s *LocalStorage) Exists(ctx context.Context, filePath string) (bool, error) {
 	fullPath := filepath.Join(s.config.BasePath, filePath)
 	_, err := os.Stat(fullPath)
+	if err == nil {
+		err = s.checkExpired(filePath)
+	}
 	if err == nil {
 		return true, nil
 	}
-	if os.IsNotExist(err) {
+	if errors.Is(err, ErrNotExist) {
 		return false, nil
 	}
 	return false, err
@@ -256,6 +334,11 @@ func (s *LocalStorage) DeleteDir(ctx context.Context, dirPath string) error {
 		return err
 	}
 
+	if err := os.RemoveAll(s.metaDirPath(dirPath)); err != nil {
+		hlog.CtxErrorf(ctx, "删除目录元数据失败: %v", err)
+		return err
+	}
+
 	hlog.CtxInfof(ctx, "目录删除成功: %s", dirPath)
 	return nil
 }
@@ -272,18 +355,29 @@ func (s *LocalStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetad
 		return nil, err
 	}
 
+	isRoot := filepath.Clean(fullPath) == filepath.Clean(s.config.BasePath)
+
 	files := make([]FileMetadata, 0, len(entries))
 	for _, entry := range entries {
+		// 跳过存放附加元数据的隐藏目录和上传中的临时文件
+		if isRoot && entry.Name() == localMetaDir || strings.HasPrefix(entry.Name(), localUploadTempPrefix) {
+			continue
+		}
 		info, err := entry.Info()
 		if err != nil {
 			continue
 		}
 		metadata := FileMetadata{
-			Name:     entry.Name(),
-			Size:     info.Size(),
-			ModTime:  info.ModTime(),
-			IsDir:    entry.IsDir(),
-			MIMEType: "application/octet-stream",
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			IsDir:   entry.IsDir(),
+		}
+		if !entry.IsDir() {
+			// 列表中不读取文件内容识别类型，避免大目录逐个打开文件；需要识别内容时使用 GetMetadata
+			entryPath := filepath.Join(dirPath, entry.Name())
+			meta, _ := s.readMeta(entryPath)
+			metadata.MIMEType = recordedMIMEType(entryPath, meta)
 		}
 		files = append(files, metadata)
 	}
@@ -299,17 +393,23 @@ func (s *LocalStorage) GetMetadata(ctx context.Context, filePath string) (*FileM
 	fullPath := filepath.Join(s.config.BasePath, filePath)
 
 	info, err := os.Stat(fullPath)
+	if err == nil && !info.IsDir() {
+		err = s.checkExpired(filePath)
+	}
 	if err != nil {
 		hlog.CtxErrorf(ctx, "获取文件信息失败: %v", err)
 		return nil, err
 	}
 
 	metadata := &FileMetadata{
-		Name:     filePath,
-		Size:     info.Size(),
-		ModTime:  info.ModTime(),
-		IsDir:    info.IsDir(),
-		MIMEType: "application/octet-stream", // 简化处理，实际应根据文件类型判断
+		Name:    filePath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+	if !info.IsDir() {
+		meta, _ := s.readMeta(filePath)
+		metadata.MIMEType = s.mimeType(filePath, meta)
 	}
 
 	hlog.CtxInfof(ctx, "成功获取文件元数据: %s", filePath)
@@ -335,6 +435,24 @@ func (s *LocalStorage) UpdateMetadata(ctx context.Context, filePath string, meta
 	return nil
 }
 
+// GetExpiration 读取本地文件的删除截止时间（不检查是否已过期）
+func (s *LocalStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	fullPath := filepath.Join(s.config.BasePath, filePath)
+	if _, err := os.Stat(fullPath); err != nil {
+		return time.Time{}, err
+	}
+
+	meta, err := s.readMeta(filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取文件元数据失败: %v", err)
+		return time.Time{}, err
+	}
+	if meta.ExpiresAt == nil {
+		return time.Time{}, nil
+	}
+	return *meta.ExpiresAt, nil
+}
+
 // BatchUpload 实现批量上传
 func (s *LocalStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
 	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件", len(files))
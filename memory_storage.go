@@ -0,0 +1,569 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// MemoryStorageConfig 内存存储配置
+type MemoryStorageConfig struct {
+	Versioning bool             `json:"versioning"` // 是否保留历史版本（删除时写入删除标记）
+	Clock      func() time.Time `json:"-"`          // 时钟，用于测试中获得确定的修改时间，默认 time.Now
+}
+
+// MemoryStorage 内存存储实现，主要用于单元测试。
+// 语义与对象存储保持一致：目录是隐式的（CreateDir 为空操作，Exists 只判断文件），
+// 删除不存在的文件不报错，ListDir 中子目录名以 / 结尾。所有方法均可并发调用。
+type MemoryStorage struct {
+	config MemoryStorageConfig
+
+	mu       sync.RWMutex
+	objects  map[string]*memoryObject   // 当前版本
+	versions map[string][]*memoryObject // 历史版本（从新到旧），仅在开启多版本时记录
+	seq      int64                      // 版本号计数器
+}
+
+// memoryObject 内存中的一个对象（或一个版本），写入后不再修改，读取时无需复制
+type memoryObject struct {
+	data         []byte
+	modTime      time.Time
+	contentType  string
+	etag         string
+	expiresAt    time.Time
+	tags         map[string]string
+	versionID    string
+	deleteMarker bool
+}
+
+// NewMemoryStorage 创建新的内存存储实例
+func NewMemoryStorage(config MemoryStorageConfig) Storage {
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+	return &MemoryStorage{
+		config:   config,
+		objects:  make(map[string]*memoryObject),
+		versions: make(map[string][]*memoryObject),
+	}
+}
+
+// memoryKey 规范化文件路径为对象 key（去掉前导斜杠，折叠 . 和 ..）
+func memoryKey(filePath string) string {
+	return strings.TrimPrefix(path.Clean("/"+filePath), "/")
+}
+
+// memoryNotExist 构造文件不存在的错误，可用 errors.Is(err, ErrNotExist) 判断
+func memoryNotExist(op, filePath string) error {
+	return &fs.PathError{Op: op, Path: filePath, Err: ErrNotExist}
+}
+
+// now 返回当前时钟时间
+func (s *MemoryStorage) now() time.Time {
+	return s.config.Clock()
+}
+
+// lookup 返回未过期的当前对象，调用方需持有锁
+func (s *MemoryStorage) lookup(op, filePath string) (*memoryObject, error) {
+	object, ok := s.objects[memoryKey(filePath)]
+	if !ok {
+		return nil, memoryNotExist(op, filePath)
+	}
+	if isExpiredAt(object.expiresAt, s.now()) {
+		return nil, errExpired(filePath)
+	}
+	return object, nil
+}
+
+// store 写入对象的新版本，调用方需持有写锁
+func (s *MemoryStorage) store(key string, object *memoryObject) {
+	s.seq++
+	if s.config.Versioning {
+		object.versionID = strconv.FormatInt(s.seq, 10)
+		s.versions[key] = append([]*memoryObject{object}, s.versions[key]...)
+	} else {
+		object.versionID = "null"
+	}
+	if object.deleteMarker {
+		delete(s.objects, key)
+		return
+	}
+	s.objects[key] = object
+}
+
+// remove 删除当前对象，开启多版本时写入删除标记，调用方需持有写锁
+func (s *MemoryStorage) remove(key string) {
+	if _, ok := s.objects[key]; !ok {
+		return
+	}
+	if s.config.Versioning {
+		s.store(key, &memoryObject{modTime: s.now(), deleteMarker: true})
+		return
+	}
+	delete(s.objects, key)
+}
+
+// replace 原地替换当前版本（不产生新版本），调用方需持有写锁
+func (s *MemoryStorage) replace(key string, old, updated *memoryObject) {
+	s.objects[key] = updated
+	for i, version := range s.versions[key] {
+		if version == old {
+			s.versions[key][i] = updated
+		}
+	}
+}
+
+// Upload 实现内存文件上传，支持设置有效期
+func (s *MemoryStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	return s.put(ctx, filePath, reader, UploadCondition{}, opts...)
+}
+
+// UploadIf 按前置条件上传文件，条件不满足时返回 ErrPreconditionFailed
+func (s *MemoryStorage) UploadIf(ctx context.Context, filePath string, reader io.Reader, cond UploadCondition, opts ...UploadOption) error {
+	return s.put(ctx, filePath, reader, cond, opts...)
+}
+
+// put 读取完整内容后在写锁内检查前置条件并写入
+func (s *MemoryStorage) put(ctx context.Context, filePath string, reader io.Reader, cond UploadCondition, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到内存存储: %s", filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取上传内容失败: %v", err)
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sum := md5.Sum(data)
+	object := &memoryObject{
+		data:        data,
+		contentType: contentType,
+		etag:        hex.EncodeToString(sum[:]),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, _ := s.lookup("upload", filePath)
+	if cond.IfNoneMatch && current != nil {
+		hlog.CtxErrorf(ctx, "内存存储条件写入失败，文件已存在: %s", filePath)
+		return fmt.Errorf("文件已存在: %s: %w", filePath, ErrPreconditionFailed)
+	}
+	if cond.IfMatch != "" && (current == nil || trimETag(cond.IfMatch) != current.etag) {
+		hlog.CtxErrorf(ctx, "内存存储条件写入失败，ETag 不匹配: %s", filePath)
+		return fmt.Errorf("ETag 不匹配: %s: %w", filePath, ErrPreconditionFailed)
+	}
+
+	object.modTime = s.now()
+	if options.Expiration > 0 {
+		object.expiresAt = expirationDeadlineAt(object.modTime, options.Expiration)
+		object.tags = map[string]string{ExpireDaysTagKey: expireDaysTagValue(options.Expiration)}
+	}
+	s.store(memoryKey(filePath), object)
+
+	hlog.CtxInfof(ctx, "内存文件上传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从内存下载文件
+func (s *MemoryStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从内存存储下载文件: %s", filePath)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, err := s.lookup("open", filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "内存存储获取文件失败: %v", err)
+		return nil, err
+	}
+	return bytes.NewReader(object.data), nil
+}
+
+// DownloadRange 实现从内存下载文件的指定范围，超出文件末尾的部分会被截断
+func (s *MemoryStorage) DownloadRange(ctx context.Context, filePath string, offset, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从内存存储断点续传下载: %s, offset=%d, size=%d", filePath, offset, size)
+
+	if offset < 0 {
+		return nil, fmt.Errorf("无效的偏移量: %d", offset)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, err := s.lookup("open", filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "内存存储获取文件失败: %v", err)
+		return nil, err
+	}
+
+	length := int64(len(object.data))
+	start := min(offset, length)
+	end := start
+	if size > 0 {
+		end = min(start+size, length)
+	}
+	return bytes.NewReader(object.data[start:end]), nil
+}
+
+// Delete 实现内存文件删除，文件不存在时不报错
+func (s *MemoryStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从内存存储删除文件: %s", filePath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.remove(memoryKey(filePath))
+	return nil
+}
+
+// Rename 实现内存文件重命名（复制+删除）
+func (s *MemoryStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在内存存储中重命名文件: %s -> %s", oldPath, newPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.copyLocked(oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "内存存储重命名文件失败: %v", err)
+		return err
+	}
+	if memoryKey(oldPath) != memoryKey(newPath) {
+		s.remove(memoryKey(oldPath))
+	}
+	return nil
+}
+
+// Move 实现内存文件移动（与重命名相同的操作）
+func (s *MemoryStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在内存存储中移动文件: %s -> %s", srcPath, dstPath)
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现内存文件复制，内容、类型、标签和有效期一并复制
+func (s *MemoryStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在内存存储中复制文件: %s -> %s", srcPath, dstPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.copyLocked(srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "内存存储复制文件失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// copyLocked 复制当前对象为目标路径的新版本，调用方需持有写锁
+func (s *MemoryStorage) copyLocked(srcPath, dstPath string) error {
+	src, err := s.lookup("copy", srcPath)
+	if err != nil {
+		return err
+	}
+	s.store(memoryKey(dstPath), &memoryObject{
+		data:        src.data,
+		modTime:     s.now(),
+		contentType: src.contentType,
+		etag:        src.etag,
+		expiresAt:   src.expiresAt,
+		tags:        copyTags(src.tags),
+	})
+	return nil
+}
+
+// Exists 实现检查内存文件是否存在（目录前缀和已过期的文件视为不存在）
+func (s *MemoryStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, _ := s.lookup("stat", filePath)
+	return object != nil, nil
+}
+
+// CreateDir 实现内存目录创建。与对象存储一致，目录是隐式的，直接返回成功。
+func (s *MemoryStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxDebugf(ctx, "内存存储目录无需显式创建: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现内存目录删除（递归删除目录下所有文件）
+func (s *MemoryStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除内存存储目录: %s", dirPath)
+
+	prefix := memoryPrefix(dirPath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			s.remove(key)
+		}
+	}
+	return nil
+}
+
+// ListDir 实现列出内存目录内容（仅列出当前层级，子目录名以 / 结尾）
+func (s *MemoryStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出内存存储目录: %s", dirPath)
+
+	prefix := memoryPrefix(dirPath)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var files []FileMetadata
+	seenDirs := make(map[string]bool)
+	for key, object := range s.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(name, "/"); i >= 0 {
+			dir := name[:i+1]
+			if !seenDirs[dir] {
+				seenDirs[dir] = true
+				files = append(files, FileMetadata{Name: dir, IsDir: true})
+			}
+			continue
+		}
+		files = append(files, object.metadata(name))
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
+
+// memoryPrefix 返回目录对应的 key 前缀（根目录为空字符串）
+func memoryPrefix(dirPath string) string {
+	prefix := memoryKey(dirPath)
+	if prefix != "" {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// metadata 构造对象的元数据
+func (o *memoryObject) metadata(name string) FileMetadata {
+	return FileMetadata{
+		Name:     name,
+		Size:     int64(len(o.data)),
+		ModTime:  o.modTime,
+		MIMEType: o.contentType,
+		ETag:     o.etag,
+		ETagMD5:  o.etag != "",
+	}
+}
+
+// GetMetadata 实现获取内存文件元数据
+func (s *MemoryStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取内存文件元数据: %s", filePath)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, err := s.lookup("stat", filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取内存文件元数据失败: %v", err)
+		return nil, err
+	}
+	metadata := object.metadata(filePath)
+	return &metadata, nil
+}
+
+// UpdateMetadata 更新内存文件元数据（支持修改时间和 MIME 类型）
+func (s *MemoryStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新内存文件元数据: %s", filePath)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, err := s.lookup("update", filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "更新内存文件元数据失败: %v", err)
+		return err
+	}
+
+	// 对象写入后不可修改，更新元数据时替换为副本
+	updated := *object
+	if !metadata.ModTime.IsZero() {
+		updated.modTime = metadata.ModTime
+	}
+	if metadata.MIMEType != "" {
+		updated.contentType = metadata.MIMEType
+	}
+	s.replace(memoryKey(filePath), object, &updated)
+	return nil
+}
+
+// GetExpiration 读取内存文件的删除截止时间（不检查是否已过期）
+func (s *MemoryStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, ok := s.objects[memoryKey(filePath)]
+	if !ok {
+		return time.Time{}, memoryNotExist("stat", filePath)
+	}
+	return object.expiresAt, nil
+}
+
+// ListVersions 按从新到旧的顺序返回文件的所有版本。
+// 未开启多版本时只返回当前版本，版本ID为 "null"（与 S3 未开启版本控制的存储桶一致）。
+func (s *MemoryStorage) ListVersions(ctx context.Context, filePath string) ([]FileVersion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := memoryKey(filePath)
+	history := s.versions[key]
+	if !s.config.Versioning {
+		if object, ok := s.objects[key]; ok {
+			history = []*memoryObject{object}
+		}
+	}
+	if len(history) == 0 {
+		return nil, memoryNotExist("versions", filePath)
+	}
+
+	versions := make([]FileVersion, 0, len(history))
+	for i, object := range history {
+		versions = append(versions, FileVersion{
+			VersionID:    object.versionID,
+			Size:         int64(len(object.data)),
+			ModTime:      object.modTime,
+			ETag:         object.etag,
+			IsLatest:     i == 0,
+			DeleteMarker: object.deleteMarker,
+		})
+	}
+	return versions, nil
+}
+
+// DownloadVersion 下载指定版本的内容，版本为删除标记时返回 ErrNotExist
+func (s *MemoryStorage) DownloadVersion(ctx context.Context, filePath string, versionID string) (io.Reader, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key := memoryKey(filePath)
+	history := s.versions[key]
+	if !s.config.Versioning {
+		if object, ok := s.objects[key]; ok {
+			history = []*memoryObject{object}
+		}
+	}
+	for _, object := range history {
+		if object.versionID == versionID && !object.deleteMarker {
+			return bytes.NewReader(object.data), nil
+		}
+	}
+	return nil, memoryNotExist("open", filePath+"?versionId="+versionID)
+}
+
+// GetTags 返回文件的标签
+func (s *MemoryStorage) GetTags(ctx context.Context, filePath string) (map[string]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, err := s.lookup("tags", filePath)
+	if err != nil {
+		return nil, err
+	}
+	tags := copyTags(object.tags)
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	return tags, nil
+}
+
+// SetTags 替换文件的全部标签（不产生新版本）
+func (s *MemoryStorage) SetTags(ctx context.Context, filePath string, tags map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	object, err := s.lookup("tags", filePath)
+	if err != nil {
+		return err
+	}
+	updated := *object
+	updated.tags = copyTags(tags)
+	s.replace(memoryKey(filePath), object, &updated)
+	return nil
+}
+
+// Presign 生成 memory:// 形式的占位URL。内存存储没有网络端点，
+// 该URL只用于测试调用方是否正确传递方法、路径和有效期，不能真正访问。
+func (s *MemoryStorage) Presign(ctx context.Context, method string, filePath string, expires time.Duration) (string, error) {
+	if method != http.MethodGet && method != http.MethodPut {
+		return "", fmt.Errorf("预签名不支持的方法 %s: %w", method, ErrNotSupported)
+	}
+	if expires <= 0 {
+		return "", fmt.Errorf("无效的预签名有效期: %v", expires)
+	}
+
+	query := url.Values{}
+	query.Set("method", method)
+	query.Set("expires", strconv.FormatInt(s.now().Add(expires).Unix(), 10))
+	presigned := url.URL{
+		Scheme:   "memory",
+		Path:     "/" + memoryKey(filePath),
+		RawQuery: query.Encode(),
+	}
+	return presigned.String(), nil
+}
+
+// BatchUpload 实现批量上传
+func (s *MemoryStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到内存存储", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现批量下载
+func (s *MemoryStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从内存存储批量下载 %d 个文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现批量删除
+func (s *MemoryStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始从内存存储批量删除 %d 个文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
+
+// copyTags 复制标签映射，nil 保持为 nil
+func copyTags(tags map[string]string) map[string]string {
+	if tags == nil {
+		return nil
+	}
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	return copied
+}
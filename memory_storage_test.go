@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorage_Basic(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemoryStorage(MemoryStorageConfig{Clock: func() time.Time { return now }})
+	ctx := context.Background()
+
+	if err := s.Upload(ctx, "/docs/readme.txt", strings.NewReader("hello world")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Upload(ctx, "docs/sub/a.json", strings.NewReader("{}")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	reader, err := s.DownloadRange(ctx, "docs/readme.txt", 6, 100)
+	if err != nil {
+		t.Fatalf("DownloadRange failed: %v", err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != "world" {
+		t.Fatalf("Unexpected range content: %q", data)
+	}
+
+	metadata, err := s.GetMetadata(ctx, "docs/readme.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Name != "docs/readme.txt" || metadata.Size != 11 || !metadata.ModTime.Equal(now) || metadata.ETag == "" {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+	if !strings.HasPrefix(metadata.MIMEType, "text/plain") {
+		t.Fatalf("Unexpected MIME type: %s", metadata.MIMEType)
+	}
+
+	// 目录是隐式的：前缀不视为文件，子目录名以 / 结尾
+	if exists, _ := s.Exists(ctx, "docs"); exists {
+		t.Fatal("Directory prefix should not exist as a file")
+	}
+	files, err := s.ListDir(ctx, "docs")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "readme.txt" || files[1].Name != "sub/" || !files[1].IsDir {
+		t.Fatalf("Unexpected listing: %+v", files)
+	}
+
+	// 删除不存在的文件不报错，复制不存在的文件返回 ErrNotExist
+	if err := s.Delete(ctx, "missing.txt"); err != nil {
+		t.Fatalf("Delete missing file failed: %v", err)
+	}
+	if err := s.Copy(ctx, "missing.txt", "copy.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from Copy, got %v", err)
+	}
+
+	if err := s.Rename(ctx, "docs/readme.txt", "docs/renamed.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := s.Download(ctx, "docs/readme.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist after rename, got %v", err)
+	}
+
+	if err := s.DeleteDir(ctx, "docs"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if files, _ := s.ListDir(ctx, ""); len(files) != 0 {
+		t.Fatalf("Expected empty storage, got %+v", files)
+	}
+}
+
+func TestMemoryStorage_Expiration(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var mu sync.Mutex
+	clock := func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	s := NewMemoryStorage(MemoryStorageConfig{Clock: clock})
+	ctx := context.Background()
+
+	if err := s.Upload(ctx, "tmp.txt", strings.NewReader("temp"), WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	reader, ok := As[ExpirationReader](s)
+	if !ok {
+		t.Fatal("MemoryStorage should implement ExpirationReader")
+	}
+	if deadline, _ := reader.GetExpiration(ctx, "tmp.txt"); !deadline.Equal(now.Add(time.Hour)) {
+		t.Fatalf("Unexpected deadline: %v", deadline)
+	}
+
+	mu.Lock()
+	now = now.Add(2 * time.Hour)
+	mu.Unlock()
+
+	if exists, _ := s.Exists(ctx, "tmp.txt"); exists {
+		t.Fatal("Expired file should not exist")
+	}
+	if _, err := s.Download(ctx, "tmp.txt"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist for expired file, got %v", err)
+	}
+}
+
+func TestMemoryStorage_Capabilities(t *testing.T) {
+	s := NewMemoryStorage(MemoryStorageConfig{Versioning: true})
+	ctx := context.Background()
+
+	// 条件写入
+	cu, ok := As[ConditionalUploader](s)
+	if !ok {
+		t.Fatal("MemoryStorage should implement ConditionalUploader")
+	}
+	if err := cu.UploadIf(ctx, "a.txt", strings.NewReader("v1"), UploadCondition{IfNoneMatch: true}); err != nil {
+		t.Fatalf("UploadIf failed: %v", err)
+	}
+	err := cu.UploadIf(ctx, "a.txt", strings.NewReader("v2"), UploadCondition{IfNoneMatch: true})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	metadata, _ := s.GetMetadata(ctx, "a.txt")
+	if err := cu.UploadIf(ctx, "a.txt", strings.NewReader("v2"), UploadCondition{IfMatch: `"` + metadata.ETag + `"`}); err != nil {
+		t.Fatalf("UploadIf with matching ETag failed: %v", err)
+	}
+	err = cu.UploadIf(ctx, "a.txt", strings.NewReader("v3"), UploadCondition{IfMatch: metadata.ETag})
+	if !errors.Is(err, ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed for stale ETag, got %v", err)
+	}
+
+	// 多版本：删除后写入删除标记，旧版本仍可读取
+	if err := s.Delete(ctx, "a.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	versioner, _ := As[Versioner](s)
+	versions, err := versioner.ListVersions(ctx, "a.txt")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	if len(versions) != 3 || !versions[0].DeleteMarker || !versions[0].IsLatest {
+		t.Fatalf("Unexpected versions: %+v", versions)
+	}
+	reader, err := versioner.DownloadVersion(ctx, "a.txt", versions[2].VersionID)
+	if err != nil {
+		t.Fatalf("DownloadVersion failed: %v", err)
+	}
+	if data, _ := io.ReadAll(reader); string(data) != "v1" {
+		t.Fatalf("Unexpected version content: %q", data)
+	}
+
+	// 标签
+	if err := s.Upload(ctx, "b.txt", strings.NewReader("b")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	tagger, _ := As[Tagger](s)
+	if err := tagger.SetTags(ctx, "b.txt", map[string]string{"env": "test"}); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	if err := s.Copy(ctx, "b.txt", "c.txt"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if tags, _ := tagger.GetTags(ctx, "c.txt"); tags["env"] != "test" {
+		t.Fatalf("Tags should be copied, got %v", tags)
+	}
+
+	// 预签名占位URL
+	presigner, _ := As[Presigner](s)
+	url, err := presigner.Presign(ctx, http.MethodGet, "b.txt", time.Minute)
+	if err != nil || !strings.HasPrefix(url, "memory:///b.txt?") {
+		t.Fatalf("Unexpected presigned URL: %s, %v", url, err)
+	}
+	if _, err := presigner.Presign(ctx, http.MethodDelete, "b.txt", time.Minute); !errors.Is(err, ErrNotSupported) {
+		t.Fatalf("Expected ErrNotSupported, got %v", err)
+	}
+}
+
+func TestMemoryStorage_Concurrent(t *testing.T) {
+	s := CreateStorage(Mem)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			filePath := fmt.Sprintf("dir/%d.txt", i)
+			if err := s.Upload(ctx, filePath, strings.NewReader("data")); err != nil {
+				t.Errorf("Upload failed: %v", err)
+				return
+			}
+			if _, err := s.ListDir(ctx, "dir"); err != nil {
+				t.Errorf("ListDir failed: %v", err)
+			}
+			if err := s.Copy(ctx, filePath, filePath+".bak"); err != nil {
+				t.Errorf("Copy failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	files, err := s.ListDir(ctx, "dir")
+	if err != nil || len(files) != 40 {
+		t.Fatalf("Expected 40 files, got %d, %v", len(files), err)
+	}
+}
@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultMIMEType 无法识别时使用的默认 MIME 类型
+	defaultMIMEType = "application/octet-stream"
+	// sniffLen http.DetectContentType 最多使用的字节数
+	sniffLen = 512
+)
+
+var (
+	customMIMETypesMu sync.RWMutex
+	customMIMETypes   = make(map[string]string)
+)
+
+// RegisterMIMEType 注册自定义扩展名到 MIME 类型的映射，优先于系统映射表。
+// 扩展名不区分大小写，可以带或不带前导点，如 ".md" 或 "md"。
+func RegisterMIMEType(ext, mimeType string) {
+	ext = normalizeExt(ext)
+	if ext == "" || mimeType == "" {
+		return
+	}
+	customMIMETypesMu.Lock()
+	defer customMIMETypesMu.Unlock()
+	customMIMETypes[ext] = mimeType
+}
+
+// RegisterMIMETypes 批量注册自定义 MIME 类型映射（对应配置中的 mime_types）
+func RegisterMIMETypes(types map[string]string) {
+	for ext, mimeType := range types {
+		RegisterMIMEType(ext, mimeType)
+	}
+}
+
+// MIMETypeByExtension 根据文件扩展名返回 MIME 类型，无法识别时返回空字符串
+func MIMETypeByExtension(filePath string) string {
+	ext := normalizeExt(filepath.Ext(filePath))
+	if ext == "" {
+		return ""
+	}
+
+	customMIMETypesMu.RLock()
+	mimeType, ok := customMIMETypes[ext]
+	customMIMETypesMu.RUnlock()
+	if ok {
+		return mimeType
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// DetectMIMEType 检测上传内容的 MIME 类型。
+// 优先根据扩展名判断；无法识别时读取前 512 字节进行内容嗅探。
+// 返回的 reader 会重放已读取的字节，调用方必须用它替代原 reader 继续读取；
+// 原 reader 实现 io.Seeker 时会回退到原位置并直接返回原 reader，保持其可重试性。
+func DetectMIMEType(filePath string, reader io.Reader) (string, io.Reader, error) {
+	if mimeType := MIMETypeByExtension(filePath); mimeType != "" {
+		return mimeType, reader, nil
+	}
+
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		pos, err := seeker.Seek(0, io.SeekCurrent)
+		if err == nil {
+			head, err := readHead(seeker)
+			if err != nil {
+				return "", nil, err
+			}
+			if _, err := seeker.Seek(pos, io.SeekStart); err != nil {
+				return "", nil, err
+			}
+			return http.DetectContentType(head), reader, nil
+		}
+	}
+
+	head, err := readHead(reader)
+	if err != nil {
+		return "", nil, err
+	}
+	return http.DetectContentType(head), io.MultiReader(bytes.NewReader(head), reader), nil
+}
+
+// detectFileMIMEType 检测本地文件的 MIME 类型（扩展名优先，其次读取文件头嗅探）
+func detectFileMIMEType(fullPath string) string {
+	if mimeType := MIMETypeByExtension(fullPath); mimeType != "" {
+		return mimeType
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return defaultMIMEType
+	}
+	defer file.Close()
+
+	head, err := readHead(file)
+	if err != nil {
+		return defaultMIMEType
+	}
+	return http.DetectContentType(head)
+}
+
+// mimeTypeByName 根据对象名返回 MIME 类型，用于列表等无法获取内容类型的场景
+func mimeTypeByName(name string) string {
+	if mimeType := MIMETypeByExtension(name); mimeType != "" {
+		return mimeType
+	}
+	return defaultMIMEType
+}
+
+// contentTypeOrDetect 返回存储服务记录的内容类型；未记录或为默认类型时按扩展名推断
+func contentTypeOrDetect(contentType, name string) string {
+	if contentType != "" && contentType != defaultMIMEType {
+		return contentType
+	}
+	return mimeTypeByName(name)
+}
+
+// resolveContentType 确定上传内容的 MIME 类型，上传选项中指定的类型优先
+func resolveContentType(filePath string, reader io.Reader, options *UploadOptions) (string, io.Reader, error) {
+	if options.ContentType != "" {
+		return options.ContentType, reader, nil
+	}
+	return DetectMIMEType(filePath, reader)
+}
+
+// readHead 读取最多 sniffLen 个字节
+func readHead(reader io.Reader) ([]byte, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(reader, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	return head[:n], nil
+}
+
+// normalizeExt 统一扩展名格式为小写并带前导点
+func normalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext == "" || ext == "." {
+		return ""
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
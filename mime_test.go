@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectMIMEType(t *testing.T) {
+	RegisterMIMEType("MDX", "text/mdx")
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("x", 1024)
+	tests := []struct {
+		name     string
+		filePath string
+		content  string
+		expected string
+	}{
+		{"extension", "a/b/index.html", "plain text", "text/html; charset=utf-8"},
+		{"custom mapping", "doc.mdx", "# title", "text/mdx"},
+		{"sniff png", "image", png, "image/png"},
+		{"sniff text", "notes", "hello world", "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 使用不可 Seek 的 reader，验证嗅探后内容不会丢失
+			src := io.MultiReader(strings.NewReader(tt.content))
+			mimeType, reader, err := DetectMIMEType(tt.filePath, src)
+			if err != nil {
+				t.Fatalf("DetectMIMEType failed: %v", err)
+			}
+			if mimeType != tt.expected {
+				t.Fatalf("MIME type mismatch. Expected: %s, Got: %s", tt.expected, mimeType)
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("Failed to read: %v", err)
+			}
+			if string(data) != tt.content {
+				t.Fatalf("Content was consumed by sniffing")
+			}
+		})
+	}
+
+	// 可 Seek 的 reader 应原样返回并回到原位置
+	seeker := bytes.NewReader([]byte(png))
+	mimeType, reader, err := DetectMIMEType("image", seeker)
+	if err != nil || mimeType != "image/png" || reader != io.Reader(seeker) {
+		t.Fatalf("Unexpected result for seeker: %s, %v", mimeType, err)
+	}
+	if seeker.Len() != len(png) {
+		t.Fatalf("Seeker was not rewound")
+	}
+}
+
+func TestLocalStorage_MIMEType(t *testing.T) {
+	// 创建临时目录用于测试
+	tempDir, err := os.MkdirTemp("", "storage_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	storage := NewLocalStorage(LocalStorageConfig{BasePath: tempDir})
+	ctx := context.Background()
+
+	uploads := []struct {
+		filePath string
+		content  string
+		opts     []UploadOption
+		expected string
+	}{
+		{"style.css", "body{}", nil, "text/css; charset=utf-8"},
+		{"blob", "\x89PNG\r\n\x1a\n0000", nil, "image/png"},
+		{"data.bin", "{}", []UploadOption{WithContentType("application/json")}, "application/json"},
+	}
+
+	for _, u := range uploads {
+		if err := storage.Upload(ctx, u.filePath, strings.NewReader(u.content), u.opts...); err != nil {
+			t.Fatalf("Upload %s failed: %v", u.filePath, err)
+		}
+		metadata, err := storage.GetMetadata(ctx, u.filePath)
+		if err != nil {
+			t.Fatalf("GetMetadata %s failed: %v", u.filePath, err)
+		}
+		if metadata.MIMEType != u.expected {
+			t.Fatalf("GetMetadata MIME type mismatch for %s. Expected: %s, Got: %s", u.filePath, u.expected, metadata.MIMEType)
+		}
+	}
+
+	files, err := storage.ListDir(ctx, "")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != len(uploads) {
+		t.Fatalf("Expected %d entries, got %d", len(uploads), len(files))
+	}
+	for _, f := range files {
+		for _, u := range uploads {
+			if f.Name == u.filePath && f.MIMEType != u.expected {
+				t.Fatalf("ListDir MIME type mismatch for %s. Expected: %s, Got: %s", f.Name, u.expected, f.MIMEType)
+			}
+		}
+	}
+
+	// 未经 Upload 写入、没有记录类型的文件：列表只按扩展名推断，GetMetadata 读取内容识别
+	if err := os.MkdirAll(filepath.Join(tempDir, "raw"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "raw", "image"), []byte("\x89PNG\r\n\x1a\n0000"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err = storage.ListDir(ctx, "raw")
+	if err != nil || len(files) != 1 || files[0].MIMEType != defaultMIMEType {
+		t.Fatalf("Unexpected raw listing: %+v, %v", files, err)
+	}
+	if metadata, err := storage.GetMetadata(ctx, "raw/image"); err != nil || metadata.MIMEType != "image/png" {
+		t.Fatalf("Unexpected raw metadata: %+v, %v", metadata, err)
+	}
+}
@@ -2,15 +2,19 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 )
 
 // MinIOStorageConfig MinIO 存储配置
@@ -72,17 +76,25 @@ func (s *MinIOStorage) Upload(ctx context.Context, filePath string, reader io.Re
 	// 应用上传选项
 	options := ApplyUploadOptions(opts...)
 
-	putOpts := minio.PutObjectOptions{}
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
 
-	// 如果设置了有效期，添加过期时间选项
+	putOpts := minio.PutObjectOptions{ContentType: contentType}
+
+	// 如果设置了有效期，记录删除截止时间并打上生命周期标签（Expires 头仅影响缓存）
 	if options.Expiration > 0 {
-		expiration := time.Now().Add(options.Expiration)
+		expiration := expirationDeadline(options.Expiration)
 		putOpts.Expires = expiration
+		putOpts.UserMetadata = map[string]string{ExpiresAtMetaKey: formatExpiresAt(expiration)}
+		putOpts.UserTags = map[string]string{ExpireDaysTagKey: expireDaysTagValue(options.Expiration)}
 		hlog.CtxDebugf(ctx, "设置MinIO文件过期时间: %v", expiration)
 	}
 
 	// 使用流式上传
-	_, err := s.client.PutObject(ctx, s.config.Bucket, fullKey, reader, -1, putOpts)
+	_, err = s.client.PutObject(ctx, s.config.Bucket, fullKey, reader, -1, putOpts)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "MinIO上传文件失败: %v", err)
 		return err
@@ -98,10 +110,14 @@ func (s *MinIOStorage) Download(ctx context.Context, filePath string) (io.Reader
 
 	fullKey := filepath.Join(s.config.BaseDir, filePath)
 
+	if err := s.checkObjectExpired(ctx, fullKey, filePath); err != nil {
+		hlog.CtxErrorf(ctx, "MinIO获取文件失败: %v", err)
+		return nil, err
+	}
 	object, err := s.client.GetObject(ctx, s.config.Bucket, fullKey, minio.GetObjectOptions{})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "MinIO获取文件失败: %v", err)
-		return nil, err
+		return nil, minioError(err)
 	}
 
 	hlog.CtxInfof(ctx, "MinIO文件下载已启动: %s", filePath)
@@ -117,11 +133,15 @@ func (s *MinIOStorage) DownloadRange(ctx context.Context, filePath string, offse
 	if err := opts.SetRange(offset, offset+size-1); err != nil {
 		return nil, err
 	}
-	// 获取对象信息以确定文件大小
+	// 先检查对象是否存在且未过期；在已打开的对象上调用 Stat 会丢失 Range 请求头
+	if err := s.checkObjectExpired(ctx, fullKey, filePath); err != nil {
+		hlog.CtxErrorf(ctx, "MinIO获取文件失败: %v", err)
+		return nil, err
+	}
 	object, err := s.client.GetObject(ctx, s.config.Bucket, fullKey, opts)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "MinIO获取文件失败: %v", err)
-		return nil, err
+		return nil, minioError(err)
 	}
 
 	hlog.CtxInfof(ctx, "MinIO文件断点续传下载已启动: %s", filePath)
@@ -166,7 +186,7 @@ func (s *MinIOStorage) Rename(ctx context.Context, oldPath string, newPath strin
 	_, err := s.client.CopyObject(ctx, dstOpts, srcOpts)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "MinIO复制文件失败: %v", err)
-		return err
+		return minioError(err)
 	}
 
 	// 删除旧文件
@@ -205,25 +225,57 @@ func (s *MinIOStorage) Copy(ctx context.Context, srcPath string, dstPath string)
 	_, err := s.client.CopyObject(ctx, dstOpts, srcOpts)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "MinIO复制文件失败: %v", err)
-		return err
+		return minioError(err)
 	}
 
 	hlog.CtxInfof(ctx, "MinIO文件复制成功: %s -> %s", srcPath, dstPath)
 	return nil
 }
 
-// Exists 实现检查MinIO文件是否存在
+// CopyFrom 实现 ServerCopier，src 是同一服务、相同凭证的 MinIO 存储时在服务端复制（可以跨存储桶）
+func (s *MinIOStorage) CopyFrom(ctx context.Context, src Storage, srcPath string, dstPath string) error {
+	other, ok := As[*MinIOStorage](src)
+	if !ok || !sameMinIOAccount(other.config, s.config) {
+		return fmt.Errorf("MinIO无法在服务端从该存储复制: %w", ErrNotSupported)
+	}
+	hlog.CtxInfof(ctx, "开始在MinIO服务端复制文件: %s/%s -> %s/%s", other.config.Bucket, srcPath, s.config.Bucket, dstPath)
+
+	srcOpts := minio.CopySrcOptions{
+		Bucket: other.config.Bucket,
+		Object: filepath.Join(other.config.BaseDir, srcPath),
+	}
+	dstOpts := minio.CopyDestOptions{
+		Bucket: s.config.Bucket,
+		Object: filepath.Join(s.config.BaseDir, dstPath),
+	}
+
+	if _, err := s.client.CopyObject(ctx, dstOpts, srcOpts); err != nil {
+		hlog.CtxErrorf(ctx, "MinIO服务端复制文件失败: %v", err)
+		return minioError(err)
+	}
+
+	hlog.CtxInfof(ctx, "MinIO服务端复制文件成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// sameMinIOAccount 判断两个配置是否使用同一服务和凭证（存储桶和基础目录可以不同）
+func sameMinIOAccount(a, b MinIOStorageConfig) bool {
+	a.Bucket, a.BaseDir = "", ""
+	b.Bucket, b.BaseDir = "", ""
+	return a == b
+}
+
+// Exists 实现检查MinIO文件是否存在（已过期的文件视为不存在）
 func (s *MinIOStorage) Exists(ctx context.Context, filePath string) (bool, error) {
 	fullKey := filepath.Join(s.config.BaseDir, filePath)
-	_, err := s.client.StatObject(ctx, s.config.Bucket, fullKey, minio.StatObjectOptions{})
+	objectInfo, err := s.client.StatObject(ctx, s.config.Bucket, fullKey, minio.StatObjectOptions{})
 	if err != nil {
-		errResp := minio.ToErrorResponse(err)
-		if errResp.Code == "NoSuchKey" {
+		if errors.Is(minioError(err), ErrNotExist) {
 			return false, nil
 		}
 		return false, err
 	}
-	return true, nil
+	return !isExpired(parseExpiresAt(lookupMetadata(objectInfo.UserMetadata, ExpiresAtMetaKey))), nil
 }
 
 // CreateDir 实现MinIO目录创建。
@@ -285,11 +337,14 @@ func (s *MinIOStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetad
 
 		// 转换对象信息为FileMeta
 		fileMeta := FileMetadata{
-			Name:     name,
-			Size:     object.Size,
-			ModTime:  object.LastModified,
-			IsDir:    strings.HasSuffix(name, "/"),
-			MIMEType: "application/octet-stream",
+			Name:    name,
+			Size:    object.Size,
+			ModTime: object.LastModified,
+			IsDir:   strings.HasSuffix(name, "/"),
+		}
+		if !fileMeta.IsDir {
+			fileMeta.MIMEType = mimeTypeByName(name)
+			fileMeta.ETag = object.ETag
 		}
 		fileMetas = append(fileMetas, fileMeta)
 	}
@@ -308,7 +363,10 @@ func (s *MinIOStorage) GetMetadata(ctx context.Context, filePath string) (*FileM
 	objectInfo, err := s.client.StatObject(ctx, s.config.Bucket, fullKey, minio.StatObjectOptions{})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "获取MinIO文件信息失败: %v", err)
-		return nil, err
+		return nil, minioError(err)
+	}
+	if isExpired(parseExpiresAt(lookupMetadata(objectInfo.UserMetadata, ExpiresAtMetaKey))) {
+		return nil, errExpired(filePath)
 	}
 
 	// 构建元数据对象
@@ -317,7 +375,8 @@ func (s *MinIOStorage) GetMetadata(ctx context.Context, filePath string) (*FileM
 		Size:     objectInfo.Size,
 		ModTime:  objectInfo.LastModified,
 		IsDir:    objectInfo.Key[len(objectInfo.Key)-1] == '/',
-		MIMEType: "application/octet-stream",
+		MIMEType: contentTypeOrDetect(objectInfo.ContentType, filePath),
+		ETag:     objectInfo.ETag,
 	}
 
 	hlog.CtxInfof(ctx, "成功获取MinIO文件元数据: %s", filePath)
@@ -328,7 +387,85 @@ func (s *MinIOStorage) GetMetadata(ctx context.Context, filePath string) (*FileM
 func (s *MinIOStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
 	hlog.CtxInfof(ctx, "开始更新MinIO文件元数据: %s", filePath)
 	hlog.CtxErrorf(ctx, "MinIO不支持直接更新元数据")
-	return fmt.Errorf("MinIO不支持直接更新元数据")
+	return fmt.Errorf("MinIO不支持直接更新元数据: %w", ErrNotSupported)
+}
+
+// GetExpiration 读取MinIO文件的删除截止时间（不检查是否已过期）
+func (s *MinIOStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	objectInfo, err := s.client.StatObject(ctx, s.config.Bucket, fullKey, minio.StatObjectOptions{})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取MinIO文件信息失败: %v", err)
+		return time.Time{}, minioError(err)
+	}
+	return parseExpiresAt(lookupMetadata(objectInfo.UserMetadata, ExpiresAtMetaKey)), nil
+}
+
+// EnsureExpirationLifecycle 在MinIO存储桶上安装按有效期标签删除对象的生命周期规则
+func (s *MinIOStorage) EnsureExpirationLifecycle(ctx context.Context, days ...int) error {
+	hlog.CtxInfof(ctx, "开始安装MinIO生命周期规则: %v", days)
+
+	days, err := normalizeExpireDays(days)
+	if err != nil {
+		return err
+	}
+
+	// SetBucketLifecycle 会覆盖整个配置，需要保留非本包安装的规则
+	config := lifecycle.NewConfiguration()
+	current, err := s.client.GetBucketLifecycle(ctx, s.config.Bucket)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code != "NoSuchLifecycleConfiguration" {
+			hlog.CtxErrorf(ctx, "获取MinIO生命周期规则失败: %v", err)
+			return err
+		}
+	} else {
+		for _, rule := range current.Rules {
+			if !isExpirationRuleID(rule.ID) {
+				config.Rules = append(config.Rules, rule)
+			}
+		}
+	}
+
+	for _, d := range days {
+		config.Rules = append(config.Rules, lifecycle.Rule{
+			ID:     expirationRuleID(d),
+			Status: "Enabled",
+			RuleFilter: lifecycle.Filter{
+				Tag: lifecycle.Tag{Key: ExpireDaysTagKey, Value: strconv.Itoa(d)},
+			},
+			Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(d)},
+		})
+	}
+
+	if err := s.client.SetBucketLifecycle(ctx, s.config.Bucket, config); err != nil {
+		hlog.CtxErrorf(ctx, "安装MinIO生命周期规则失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "MinIO生命周期规则安装成功: %v", days)
+	return nil
+}
+
+// checkObjectExpired 检查对象是否存在且未过期
+func (s *MinIOStorage) checkObjectExpired(ctx context.Context, fullKey string, filePath string) error {
+	objectInfo, err := s.client.StatObject(ctx, s.config.Bucket, fullKey, minio.StatObjectOptions{})
+	if err != nil {
+		return minioError(err)
+	}
+	if isExpired(parseExpiresAt(lookupMetadata(objectInfo.UserMetadata, ExpiresAtMetaKey))) {
+		return errExpired(filePath)
+	}
+	return nil
+}
+
+// minioError 将对象不存在的响应转换为 ErrNotExist
+func minioError(err error) error {
+	errResp := minio.ToErrorResponse(err)
+	if errResp.Code == "NoSuchKey" || errResp.StatusCode == http.StatusNotFound {
+		return &notExistError{err: err}
+	}
+	return err
 }
 
 // BatchUpload 实现MinIO批量上传
@@ -9,7 +9,8 @@ type UploadOption func(*UploadOptions)
 
 // UploadOptions 上传选项配置
 type UploadOptions struct {
-	Expiration time.Duration // 文件有效期（仅OSS和MinIO支持）
+	Expiration  time.Duration // 文件有效期，到期后读取返回 ErrNotExist 并由 Janitor 清理
+	ContentType string        // 文件 MIME 类型，为空时根据扩展名和内容自动检测
 }
 
 // WithExpiration 设置文件有效期选项
@@ -19,6 +20,13 @@ func WithExpiration(expiration time.Duration) UploadOption {
 	}
 }
 
+// WithContentType 设置文件 MIME 类型选项，覆盖自动检测结果
+func WithContentType(contentType string) UploadOption {
+	return func(opts *UploadOptions) {
+		opts.ContentType = contentType
+	}
+}
+
 // DefaultUploadOptions 默认上传选项
 func DefaultUploadOptions() *UploadOptions {
 	return &UploadOptions{}
@@ -2,9 +2,12 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -58,16 +61,26 @@ func (s *OSSStorage) Upload(ctx context.Context, filePath string, reader io.Read
 	// 应用上传选项
 	options := ApplyUploadOptions(opts...)
 
-	var putOptions []oss.Option
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	putOptions := []oss.Option{oss.ContentType(contentType)}
 
-	// 如果设置了有效期，添加过期时间选项
+	// 如果设置了有效期，记录删除截止时间并打上生命周期标签（Expires 头仅影响缓存）
 	if options.Expiration > 0 {
-		expiration := time.Now().Add(options.Expiration)
-		putOptions = append(putOptions, oss.Expires(expiration))
+		expiration := expirationDeadline(options.Expiration)
+		putOptions = append(putOptions,
+			oss.Expires(expiration),
+			oss.Meta(ExpiresAtMetaKey, formatExpiresAt(expiration)),
+			oss.SetTagging(oss.Tagging{Tags: []oss.Tag{{Key: ExpireDaysTagKey, Value: expireDaysTagValue(options.Expiration)}}}),
+		)
 		hlog.CtxDebugf(ctx, "设置OSS文件过期时间: %v", expiration)
 	}
 
-	err := s.bucket.PutObject(fullKey, reader, putOptions...)
+	err = s.bucket.PutObject(fullKey, reader, putOptions...)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "OSS上传文件失败: %v", err)
 		return err
@@ -83,7 +96,7 @@ func (s *OSSStorage) Download(ctx context.Context, filePath string) (io.Reader,
 
 	fullKey := filepath.Join(s.config.BaseDir, filePath)
 
-	body, err := s.bucket.GetObject(fullKey)
+	body, err := s.getObject(fullKey, filePath)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "OSS获取文件失败: %v", err)
 		return nil, err
@@ -99,7 +112,7 @@ func (s *OSSStorage) DownloadRange(ctx context.Context, filePath string, offset,
 
 	fullKey := filepath.Join(s.config.BaseDir, filePath)
 
-	body, err := s.bucket.GetObject(fullKey, oss.Range(offset, offset+size-1))
+	body, err := s.getObject(fullKey, filePath, oss.Range(offset, offset+size-1))
 	if err != nil {
 		hlog.CtxErrorf(ctx, "OSS获取文件范围失败: %v", err)
 		return nil, err
@@ -136,7 +149,7 @@ func (s *OSSStorage) Rename(ctx context.Context, oldPath string, newPath string)
 	_, err := s.bucket.CopyObject(oldFullKey, newFullKey)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "OSS复制文件失败: %v", err)
-		return err
+		return ossError(err)
 	}
 
 	// 删除旧文件
@@ -164,17 +177,51 @@ func (s *OSSStorage) Copy(ctx context.Context, srcPath string, dstPath string) e
 	_, err := s.bucket.CopyObject(oldFullKey, newFullKey)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "OSS复制文件失败: %v", err)
-		return err
+		return ossError(err)
 	}
 
 	hlog.CtxInfof(ctx, "OSS文件复制成功: %s -> %s", srcPath, dstPath)
 	return nil
 }
 
-// Exists 实现检查OSS文件是否存在
+// CopyFrom 实现 ServerCopier，src 是同一服务、相同凭证的 OSS 存储时在服务端复制（可以跨存储桶）
+func (s *OSSStorage) CopyFrom(ctx context.Context, src Storage, srcPath string, dstPath string) error {
+	other, ok := As[*OSSStorage](src)
+	if !ok || !sameOSSAccount(other.config, s.config) {
+		return fmt.Errorf("OSS无法在服务端从该存储复制: %w", ErrNotSupported)
+	}
+	hlog.CtxInfof(ctx, "开始在OSS服务端复制文件: %s/%s -> %s/%s", other.config.Bucket, srcPath, s.config.Bucket, dstPath)
+
+	srcFullKey := filepath.Join(other.config.BaseDir, srcPath)
+	dstFullKey := filepath.Join(s.config.BaseDir, dstPath)
+
+	if _, err := s.bucket.CopyObjectFrom(other.config.Bucket, srcFullKey, dstFullKey); err != nil {
+		hlog.CtxErrorf(ctx, "OSS服务端复制文件失败: %v", err)
+		return ossError(err)
+	}
+
+	hlog.CtxInfof(ctx, "OSS服务端复制文件成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// sameOSSAccount 判断两个配置是否使用同一服务和凭证（存储桶和基础目录可以不同）
+func sameOSSAccount(a, b OSSStorageConfig) bool {
+	a.Bucket, a.BaseDir = "", ""
+	b.Bucket, b.BaseDir = "", ""
+	return a == b
+}
+
+// Exists 实现检查OSS文件是否存在（已过期的文件视为不存在）
 func (s *OSSStorage) Exists(ctx context.Context, filePath string) (bool, error) {
 	fullKey := filepath.Join(s.config.BaseDir, filePath)
-	return s.bucket.IsObjectExist(fullKey)
+	props, err := s.bucket.GetObjectDetailedMeta(fullKey)
+	if err != nil {
+		if errors.Is(ossError(err), ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !isExpired(ossExpiresAt(props)), nil
 }
 
 // CreateDir 在OSS中创建目录。
@@ -223,8 +270,7 @@ func (s *OSSStorage) DeleteDir(ctx context.Context, dirPath string) error {
 			}
 		}
 
-		// 如果当前批次返回的对象数量少于最大限制，则认为是最后一批
-		if len(objectListing.Objects) < 1000 {
+		if !objectListing.IsTruncated {
 			break
 		}
 
@@ -236,9 +282,8 @@ func (s *OSSStorage) DeleteDir(ctx context.Context, dirPath string) error {
 	return nil
 }
 
-// ListDir 列出OSS目录内容。
-// OSS 默认 ListObjects 不带 Delimiter，会递归返回当前目录下所有对象，
-// 调用方（如 FileManager.walkDir）需自行处理层级关系。
+// ListDir 列出OSS目录内容（仅当前层级）。
+// 使用 Delimiter 将子目录折叠为以 / 结尾的目录项，与 S3、MinIO 的行为一致。
 func (s *OSSStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
 	hlog.CtxInfof(ctx, "开始列出OSS目录内容: %s", dirPath)
 
@@ -253,8 +298,8 @@ func (s *OSSStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadat
 	for {
 		var listOptions []oss.Option
 
-		// 添加Prefix选项
-		listOptions = append(listOptions, oss.Prefix(fullKey))
+		// 添加Prefix和Delimiter选项
+		listOptions = append(listOptions, oss.Prefix(fullKey), oss.Delimiter("/"))
 
 		// 如果有marker，添加Marker选项
 		if marker != "" {
@@ -281,18 +326,23 @@ func (s *OSSStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadat
 					Size:     object.Size,
 					ModTime:  object.LastModified,
 					IsDir:    false,
-					MIMEType: "application/octet-stream",
+					MIMEType: mimeTypeByName(name),
+					ETag:     trimETag(object.ETag),
 				}
 				fileMetas = append(fileMetas, fileMeta)
 			}
 		}
+		for _, prefix := range objectListing.CommonPrefixes {
+			if name := strings.TrimPrefix(prefix, fullKey); name != "" {
+				fileMetas = append(fileMetas, FileMetadata{Name: name, IsDir: true})
+			}
+		}
 
-		// 如果当前批次返回的对象数量少于最大限制，则认为是最后一批
-		if len(objectListing.Objects) < 1000 { // OSS默认最多每次返回1000个对象
+		if !objectListing.IsTruncated {
 			break
 		}
 
-		// 使用最后一个对象的Key作为下次查询的marker
+		// 使用返回的NextMarker作为下次查询的marker
 		marker = objectListing.NextMarker
 	}
 
@@ -310,7 +360,10 @@ func (s *OSSStorage) GetMetadata(ctx context.Context, filePath string) (*FileMet
 	props, err := s.bucket.GetObjectDetailedMeta(fullKey)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "获取OSS文件元数据失败: %v", err)
-		return nil, fmt.Errorf("获取OSS文件元数据失败：%v", err)
+		return nil, fmt.Errorf("获取OSS文件元数据失败：%w", ossError(err))
+	}
+	if isExpired(ossExpiresAt(props)) {
+		return nil, errExpired(filePath)
 	}
 
 	// 从HTTPHeader中解析ContentLength
@@ -341,7 +394,8 @@ func (s *OSSStorage) GetMetadata(ctx context.Context, filePath string) (*FileMet
 		Size:     size,
 		ModTime:  modTime,
 		IsDir:    false,
-		MIMEType: props.Get("Content-Type"),
+		MIMEType: contentTypeOrDetect(props.Get("Content-Type"), filePath),
+		ETag:     trimETag(props.Get("ETag")),
 	}
 
 	hlog.CtxInfof(ctx, "成功获取OSS文件元数据: %s", filePath)
@@ -355,7 +409,90 @@ func (s *OSSStorage) UpdateMetadata(ctx context.Context, filePath string, metada
 	// OSS不支持直接更新元数据，除非重新上传文件
 	// 这里可以选择仅记录日志或抛出错误
 	hlog.CtxErrorf(ctx, "OSS不支持直接更新元数据")
-	return fmt.Errorf("OSS不支持直接更新元数据")
+	return fmt.Errorf("OSS不支持直接更新元数据: %w", ErrNotSupported)
+}
+
+// GetExpiration 读取OSS文件的删除截止时间（不检查是否已过期）
+func (s *OSSStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	props, err := s.bucket.GetObjectDetailedMeta(fullKey)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取OSS文件元数据失败: %v", err)
+		return time.Time{}, ossError(err)
+	}
+	return ossExpiresAt(props), nil
+}
+
+// EnsureExpirationLifecycle 在OSS存储桶上安装按有效期标签删除对象的生命周期规则
+func (s *OSSStorage) EnsureExpirationLifecycle(ctx context.Context, days ...int) error {
+	hlog.CtxInfof(ctx, "开始安装OSS生命周期规则: %v", days)
+
+	days, err := normalizeExpireDays(days)
+	if err != nil {
+		return err
+	}
+
+	// SetBucketLifecycle 会覆盖整个配置，需要保留非本包安装的规则
+	var rules []oss.LifecycleRule
+	current, err := s.client.GetBucketLifecycle(s.config.Bucket)
+	if err != nil {
+		var serviceErr oss.ServiceError
+		if !errors.As(err, &serviceErr) || serviceErr.Code != "NoSuchLifecycle" {
+			hlog.CtxErrorf(ctx, "获取OSS生命周期规则失败: %v", err)
+			return err
+		}
+	} else {
+		for _, rule := range current.Rules {
+			if !isExpirationRuleID(rule.ID) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	for _, d := range days {
+		rules = append(rules, oss.LifecycleRule{
+			ID:         expirationRuleID(d),
+			Status:     "Enabled",
+			Tags:       []oss.Tag{{Key: ExpireDaysTagKey, Value: strconv.Itoa(d)}},
+			Expiration: &oss.LifecycleExpiration{Days: d},
+		})
+	}
+
+	if err := s.client.SetBucketLifecycle(s.config.Bucket, rules); err != nil {
+		hlog.CtxErrorf(ctx, "安装OSS生命周期规则失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "OSS生命周期规则安装成功: %v", days)
+	return nil
+}
+
+// getObject 获取对象内容，已过期的对象返回 ErrNotExist
+func (s *OSSStorage) getObject(fullKey, filePath string, options ...oss.Option) (io.ReadCloser, error) {
+	result, err := s.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: fullKey}, options)
+	if err != nil {
+		return nil, ossError(err)
+	}
+	if isExpired(ossExpiresAt(result.Response.Headers)) {
+		result.Response.Body.Close()
+		return nil, errExpired(filePath)
+	}
+	return result.Response, nil
+}
+
+// ossError 将对象不存在的响应（404）转换为 ErrNotExist
+func ossError(err error) error {
+	var serviceErr oss.ServiceError
+	if errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound {
+		return &notExistError{err: err}
+	}
+	return err
+}
+
+// ossExpiresAt 从OSS响应头中解析删除截止时间
+func ossExpiresAt(header http.Header) time.Time {
+	return parseExpiresAt(header.Get(oss.HTTPHeaderOssMetaPrefix + ExpiresAtMetaKey))
 }
 
 // BatchUpload 实现OSS批量上传
@@ -0,0 +1,62 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/v-mars/storage"
+	"github.com/v-mars/storage/storagetest"
+)
+
+func TestOSSStorage_ListDirAndWalk(t *testing.T) {
+	server := storagetest.NewFakeServer()
+	defer server.Close()
+	server.PageSize = 2
+	server.CreateBucket("photos")
+
+	s := storage.NewOSSStorage(storage.OSSStorageConfig{
+		Endpoint:        server.URL,
+		AccessKeyID:     "test",
+		AccessKeySecret: "test",
+		Bucket:          "photos",
+		BaseDir:         "base",
+	})
+	if s == nil {
+		t.Fatal("NewOSSStorage returned nil")
+	}
+	ctx := context.Background()
+
+	files := []string{"a.txt", "dir/b.txt", "dir/c.txt", "dir/sub/d.txt", "dir/sub/deep/e.txt", "other/f.txt"}
+	for _, filePath := range files {
+		if err := s.Upload(ctx, filePath, strings.NewReader(filePath)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+
+	// ListDir 只返回当前层级，子目录跨越多页时也只出现一次
+	entries, err := s.ListDir(ctx, "dir")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, fmt.Sprintf("%s:%v", entry.Name, entry.IsDir))
+	}
+	if want := "b.txt:false c.txt:false sub/:true"; strings.Join(got, " ") != want {
+		t.Fatalf("Unexpected listing: %v", got)
+	}
+
+	// Walk 逐层遍历，得到所有文件
+	var walked []string
+	if err := storage.Walk(ctx, s, "", func(filePath string, _ storage.FileMetadata) error {
+		walked = append(walked, filePath)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	if strings.Join(walked, " ") != strings.Join(files, " ") {
+		t.Fatalf("Unexpected walk result: %v", walked)
+	}
+}
@@ -0,0 +1,535 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	quotaKindBytes   = "bytes"
+	quotaKindObjects = "objects"
+)
+
+// QuotaRule 配额规则
+type QuotaRule struct {
+	Prefix      string `yaml:"prefix" json:"prefix"`             // 配额前缀，如 "tenants/acme/"，为空表示整个存储
+	PerTenant   bool   `yaml:"per_tenant" json:"per_tenant"`     // 为 Prefix 下的每个一级子目录（租户）单独计算配额
+	MaxBytes    int64  `yaml:"max_bytes" json:"max_bytes"`       // 字节数硬限制，0 表示不限制
+	MaxObjects  int64  `yaml:"max_objects" json:"max_objects"`   // 对象数硬限制，0 表示不限制
+	SoftBytes   int64  `yaml:"soft_bytes" json:"soft_bytes"`     // 字节数软限制，超过时仅告警
+	SoftObjects int64  `yaml:"soft_objects" json:"soft_objects"` // 对象数软限制，超过时仅告警
+}
+
+// QuotaConfig 配额配置
+type QuotaConfig struct {
+	Rules []QuotaRule `yaml:"rules" json:"rules"` // 配额规则，一个文件可以同时受多条规则约束
+
+	// OnSoftLimit 用量超过软限制时回调（可选），默认仅记录告警日志
+	OnSoftLimit func(ctx context.Context, usage QuotaUsage) `yaml:"-" json:"-"`
+}
+
+// QuotaUsage 配额用量
+type QuotaUsage struct {
+	Prefix       string    `json:"prefix"`        // 配额前缀（按租户计算时为租户目录）
+	Bytes        int64     `json:"bytes"`         // 已用字节数
+	Objects      int64     `json:"objects"`       // 已用对象数
+	Rule         QuotaRule `json:"rule"`          // 对应的配额规则
+	SoftExceeded bool      `json:"soft_exceeded"` // 是否超过软限制
+}
+
+// quotaCounter 单个配额前缀的用量计数
+type quotaCounter struct {
+	prefix  string
+	rule    QuotaRule
+	bytes   int64
+	objects int64
+	loaded  bool
+	loadMu  sync.Mutex // 串行化首次加载（遍历存储统计用量）
+}
+
+// QuotaStorage 按前缀统计用量并执行配额限制的存储装饰器。
+// 用量在首次访问某个前缀时通过遍历存储加载，之后随上传、删除、复制、移动增量更新；
+// 其他进程直接修改存储会导致计数偏差，可调用 Recalculate 重新统计。
+type QuotaStorage struct {
+	Storage
+	config QuotaConfig
+
+	mu       sync.Mutex
+	counters map[string]*quotaCounter
+}
+
+// NewQuotaStorage 创建带配额限制的存储
+func NewQuotaStorage(s Storage, config QuotaConfig) *QuotaStorage {
+	// 复制规则列表，规范化前缀时不修改调用方的切片
+	config.Rules = append([]QuotaRule(nil), config.Rules...)
+	for i := range config.Rules {
+		config.Rules[i].Prefix = normalizeQuotaPrefix(config.Rules[i].Prefix)
+	}
+	return &QuotaStorage{
+		Storage:  s,
+		config:   config,
+		counters: make(map[string]*quotaCounter),
+	}
+}
+
+// Unwrap 返回被包装的存储
+func (q *QuotaStorage) Unwrap() Storage {
+	return q.Storage
+}
+
+// Upload 上传文件，上传流在读取过程中计入用量，超过字节数硬限制时立即中止
+func (q *QuotaStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	counters, err := q.countersFor(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	if len(counters) == 0 {
+		return q.Storage.Upload(ctx, filePath, reader, opts...)
+	}
+
+	oldSize, existed, err := q.statFile(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	// 覆盖上传时旧文件的用量先行释放，新增文件预留一个对象数
+	deltas := make(map[*quotaCounter]quotaDelta, len(counters))
+	for _, c := range counters {
+		d := quotaDelta{bytes: -oldSize}
+		if !existed {
+			d.objects = 1
+		}
+		deltas[c] = d
+	}
+	if err := q.reserve(deltas); err != nil {
+		hlog.CtxErrorf(ctx, "上传文件失败: %s, %v", filePath, err)
+		return err
+	}
+
+	var consumed int64
+	limited := newLimitReader(reader, func(delta int64) error {
+		consumed += delta
+		return q.consumeBytes(counters, delta)
+	})
+
+	if err := q.Storage.Upload(ctx, filePath, limited, opts...); err != nil {
+		q.rollback(deltas, counters, consumed)
+		var quotaErr *QuotaExceededError
+		if errors.As(limitReaderErr(limited), &quotaErr) {
+			hlog.CtxErrorf(ctx, "上传文件失败: %s, %v", filePath, quotaErr)
+			return quotaErr
+		}
+		return err
+	}
+
+	q.checkSoftLimits(ctx, counters)
+	return nil
+}
+
+// Delete 删除文件并释放用量
+func (q *QuotaStorage) Delete(ctx context.Context, filePath string) error {
+	counters, err := q.countersFor(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	if len(counters) == 0 {
+		return q.Storage.Delete(ctx, filePath)
+	}
+
+	size, existed, err := q.statFile(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	if err := q.Storage.Delete(ctx, filePath); err != nil {
+		return err
+	}
+	if existed {
+		q.apply(counters, quotaDelta{bytes: -size, objects: -1})
+	}
+	return nil
+}
+
+// Rename 重命名文件，目标前缀超出配额时拒绝
+func (q *QuotaStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	return q.transfer(ctx, oldPath, newPath, true, q.Storage.Rename)
+}
+
+// Move 移动文件，目标前缀超出配额时拒绝
+func (q *QuotaStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return q.transfer(ctx, srcPath, dstPath, true, q.Storage.Move)
+}
+
+// Copy 复制文件，目标前缀超出配额时拒绝
+func (q *QuotaStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	return q.transfer(ctx, srcPath, dstPath, false, q.Storage.Copy)
+}
+
+// DeleteDir 删除目录，涉及的配额前缀会在下次访问时重新统计
+func (q *QuotaStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	err := q.Storage.DeleteDir(ctx, dirPath)
+	q.invalidate(normalizeQuotaPrefix(dirPath))
+	return err
+}
+
+// BatchUpload 批量上传，逐个文件执行配额检查
+func (q *QuotaStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	return BatchUploadHelper(ctx, q, files, opts...)
+}
+
+// BatchDelete 批量删除并释放用量
+func (q *QuotaStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	return BatchDeleteHelper(ctx, q, filePaths)
+}
+
+// Usage 返回所有配额前缀的当前用量（按租户计算的规则会列出全部租户）
+func (q *QuotaStorage) Usage(ctx context.Context) ([]QuotaUsage, error) {
+	var usages []QuotaUsage
+	for _, rule := range q.config.Rules {
+		prefixes := []string{rule.Prefix}
+		if rule.PerTenant {
+			tenants, err := q.listTenants(ctx, rule.Prefix)
+			if err != nil {
+				return nil, err
+			}
+			prefixes = tenants
+		}
+		for _, prefix := range prefixes {
+			c, err := q.counter(ctx, prefix, rule)
+			if err != nil {
+				return nil, err
+			}
+			usages = append(usages, q.snapshot(c))
+		}
+	}
+	sort.SliceStable(usages, func(i, j int) bool { return usages[i].Prefix < usages[j].Prefix })
+	return usages, nil
+}
+
+// UsageOf 返回指定文件或目录所属的配额用量
+func (q *QuotaStorage) UsageOf(ctx context.Context, filePath string) ([]QuotaUsage, error) {
+	counters, err := q.countersFor(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	usages := make([]QuotaUsage, 0, len(counters))
+	for _, c := range counters {
+		usages = append(usages, q.snapshot(c))
+	}
+	return usages, nil
+}
+
+// Recalculate 丢弃缓存的计数并重新遍历存储统计用量，用于修正计数偏差
+func (q *QuotaStorage) Recalculate(ctx context.Context) ([]QuotaUsage, error) {
+	hlog.CtxInfof(ctx, "开始重新统计存储配额用量")
+	q.invalidate("")
+	usages, err := q.Usage(ctx)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "重新统计存储配额用量失败: %v", err)
+		return nil, err
+	}
+	hlog.CtxInfof(ctx, "存储配额用量统计完成: 共 %d 个前缀", len(usages))
+	return usages, nil
+}
+
+// quotaDelta 用量变化量
+type quotaDelta struct {
+	bytes   int64
+	objects int64
+}
+
+// transfer 处理复制与移动的配额变化
+func (q *QuotaStorage) transfer(ctx context.Context, srcPath, dstPath string, removeSrc bool, op func(context.Context, string, string) error) error {
+	srcCounters, err := q.countersFor(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	dstCounters, err := q.countersFor(ctx, dstPath)
+	if err != nil {
+		return err
+	}
+	if len(srcCounters) == 0 && len(dstCounters) == 0 {
+		return op(ctx, srcPath, dstPath)
+	}
+
+	src, err := q.statUsage(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	dst, err := q.statUsage(ctx, dstPath)
+	if err != nil {
+		return err
+	}
+
+	deltas := make(map[*quotaCounter]quotaDelta)
+	for _, c := range dstCounters {
+		deltas[c] = quotaDelta{bytes: src.bytes - dst.bytes, objects: src.objects - dst.objects}
+	}
+	if removeSrc {
+		for _, c := range srcCounters {
+			d := deltas[c]
+			d.bytes -= src.bytes
+			d.objects -= src.objects
+			deltas[c] = d
+		}
+	}
+
+	if err := q.reserve(deltas); err != nil {
+		hlog.CtxErrorf(ctx, "操作文件失败: %s -> %s, %v", srcPath, dstPath, err)
+		return err
+	}
+	if err := op(ctx, srcPath, dstPath); err != nil {
+		q.rollback(deltas, nil, 0)
+		return err
+	}
+
+	q.checkSoftLimits(ctx, dstCounters)
+	return nil
+}
+
+// statFile 获取文件大小，文件不存在时 existed 为 false
+func (q *QuotaStorage) statFile(ctx context.Context, filePath string) (size int64, existed bool, err error) {
+	existed, err = q.Storage.Exists(ctx, filePath)
+	if err != nil || !existed {
+		return 0, false, err
+	}
+	metadata, err := q.Storage.GetMetadata(ctx, filePath)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	return metadata.Size, true, nil
+}
+
+// statUsage 返回文件或目录占用的用量，目录累计其下的所有文件，路径不存在时返回零值
+func (q *QuotaStorage) statUsage(ctx context.Context, filePath string) (quotaDelta, error) {
+	existed, err := q.Storage.Exists(ctx, filePath)
+	if err != nil || !existed {
+		return quotaDelta{}, err
+	}
+	metadata, err := q.Storage.GetMetadata(ctx, filePath)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return quotaDelta{}, nil
+		}
+		return quotaDelta{}, err
+	}
+	if !metadata.IsDir {
+		return quotaDelta{bytes: metadata.Size, objects: 1}, nil
+	}
+
+	var usage quotaDelta
+	err = Walk(ctx, q.Storage, filePath, func(_ string, metadata FileMetadata) error {
+		usage.bytes += metadata.Size
+		usage.objects++
+		return nil
+	})
+	return usage, err
+}
+
+// countersFor 返回约束该路径的所有配额计数（必要时加载）
+func (q *QuotaStorage) countersFor(ctx context.Context, filePath string) ([]*quotaCounter, error) {
+	filePath = strings.TrimPrefix(path.Clean("/"+filePath), "/")
+
+	var counters []*quotaCounter
+	for _, rule := range q.config.Rules {
+		if !strings.HasPrefix(filePath, rule.Prefix) {
+			continue
+		}
+		prefix := rule.Prefix
+		if rule.PerTenant {
+			rest := filePath[len(rule.Prefix):]
+			i := strings.Index(rest, "/")
+			if i <= 0 {
+				// 直接位于规则前缀下的文件不属于任何租户
+				continue
+			}
+			prefix += rest[:i+1]
+		}
+		c, err := q.counter(ctx, prefix, rule)
+		if err != nil {
+			return nil, err
+		}
+		counters = append(counters, c)
+	}
+	return counters, nil
+}
+
+// counter 返回指定前缀的计数，首次访问时遍历存储统计用量
+func (q *QuotaStorage) counter(ctx context.Context, prefix string, rule QuotaRule) (*quotaCounter, error) {
+	q.mu.Lock()
+	c, ok := q.counters[prefix]
+	if !ok {
+		c = &quotaCounter{prefix: prefix, rule: rule}
+		q.counters[prefix] = c
+	}
+	loaded := c.loaded
+	q.mu.Unlock()
+	if loaded {
+		return c, nil
+	}
+
+	c.loadMu.Lock()
+	defer c.loadMu.Unlock()
+
+	q.mu.Lock()
+	loaded = c.loaded
+	q.mu.Unlock()
+	if loaded {
+		return c, nil
+	}
+
+	var bytes, objects int64
+	err := Walk(ctx, q.Storage, prefix, func(_ string, metadata FileMetadata) error {
+		bytes += metadata.Size
+		objects++
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		hlog.CtxErrorf(ctx, "统计配额用量失败: %s, %v", prefix, err)
+		return nil, err
+	}
+
+	q.mu.Lock()
+	c.bytes, c.objects, c.loaded = bytes, objects, true
+	q.mu.Unlock()
+	return c, nil
+}
+
+// listTenants 列出规则前缀下的所有租户目录
+func (q *QuotaStorage) listTenants(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := q.Storage.ListDir(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var tenants []string
+	for _, entry := range entries {
+		// 对象存储的子目录名称带有结尾的 "/"
+		if name := strings.TrimSuffix(entry.Name, "/"); entry.IsDir && name != "" {
+			tenants = append(tenants, prefix+name+"/")
+		}
+	}
+	return tenants, nil
+}
+
+// reserve 检查硬限制并预先计入用量
+func (q *QuotaStorage) reserve(deltas map[*quotaCounter]quotaDelta) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for c, d := range deltas {
+		if d.objects > 0 && c.rule.MaxObjects > 0 && c.objects+d.objects > c.rule.MaxObjects {
+			return &QuotaExceededError{Prefix: c.prefix, Kind: quotaKindObjects, Limit: c.rule.MaxObjects, Usage: c.objects + d.objects}
+		}
+		if d.bytes > 0 && c.rule.MaxBytes > 0 && c.bytes+d.bytes > c.rule.MaxBytes {
+			return &QuotaExceededError{Prefix: c.prefix, Kind: quotaKindBytes, Limit: c.rule.MaxBytes, Usage: c.bytes + d.bytes}
+		}
+	}
+	for c, d := range deltas {
+		c.bytes += d.bytes
+		c.objects += d.objects
+	}
+	return nil
+}
+
+// consumeBytes 计入上传流读取的字节数，超过字节数硬限制时返回错误
+func (q *QuotaStorage) consumeBytes(counters []*quotaCounter, delta int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var exceeded error
+	for _, c := range counters {
+		c.bytes += delta
+		if delta > 0 && exceeded == nil && c.rule.MaxBytes > 0 && c.bytes > c.rule.MaxBytes {
+			exceeded = &QuotaExceededError{Prefix: c.prefix, Kind: quotaKindBytes, Limit: c.rule.MaxBytes, Usage: c.bytes}
+		}
+	}
+	return exceeded
+}
+
+// rollback 撤销预先计入的用量和已读取的字节数
+func (q *QuotaStorage) rollback(deltas map[*quotaCounter]quotaDelta, counters []*quotaCounter, consumed int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for c, d := range deltas {
+		c.bytes -= d.bytes
+		c.objects -= d.objects
+	}
+	for _, c := range counters {
+		c.bytes -= consumed
+	}
+}
+
+// apply 直接计入用量变化
+func (q *QuotaStorage) apply(counters []*quotaCounter, d quotaDelta) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, c := range counters {
+		c.bytes += d.bytes
+		c.objects += d.objects
+	}
+}
+
+// invalidate 使与目录重叠的配额计数失效，下次访问时重新统计
+func (q *QuotaStorage) invalidate(dirPrefix string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for prefix, c := range q.counters {
+		if strings.HasPrefix(prefix, dirPrefix) || strings.HasPrefix(dirPrefix, prefix) {
+			c.loaded = false
+		}
+	}
+}
+
+// snapshot 生成计数的用量快照
+func (q *QuotaStorage) snapshot(c *quotaCounter) QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return QuotaUsage{
+		Prefix:  c.prefix,
+		Bytes:   c.bytes,
+		Objects: c.objects,
+		Rule:    c.rule,
+		SoftExceeded: (c.rule.SoftBytes > 0 && c.bytes > c.rule.SoftBytes) ||
+			(c.rule.SoftObjects > 0 && c.objects > c.rule.SoftObjects),
+	}
+}
+
+// checkSoftLimits 用量超过软限制时记录告警并回调
+func (q *QuotaStorage) checkSoftLimits(ctx context.Context, counters []*quotaCounter) {
+	for _, c := range counters {
+		usage := q.snapshot(c)
+		if !usage.SoftExceeded {
+			continue
+		}
+		hlog.CtxWarnf(ctx, "存储配额超过软限制: 前缀 %s, 已用 %d 字节/%d 个对象", usage.Prefix, usage.Bytes, usage.Objects)
+		if q.config.OnSoftLimit != nil {
+			q.config.OnSoftLimit(ctx, usage)
+		}
+	}
+}
+
+// normalizeQuotaPrefix 统一配额前缀格式：去掉前导斜杠，非空时以斜杠结尾
+func normalizeQuotaPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
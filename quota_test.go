@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSizeLimitedStorage_Upload(t *testing.T) {
+	// 创建临时目录用于测试
+	tempDir, err := os.MkdirTemp("", "storage_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	storage := NewSizeLimitedStorage(NewLocalStorage(LocalStorageConfig{BasePath: tempDir}), 10)
+	ctx := context.Background()
+
+	if err := storage.Upload(ctx, "ok.txt", strings.NewReader("0123456789")); err != nil {
+		t.Fatalf("Upload at limit failed: %v", err)
+	}
+
+	// 使用不可 Seek 的 reader，模拟网络上传流
+	err = storage.Upload(ctx, "big.txt", io.MultiReader(strings.NewReader("0123456789A")))
+	var limitErr *SizeLimitError
+	if !errors.Is(err, ErrTooLarge) || !errors.As(err, &limitErr) || limitErr.Limit != 10 {
+		t.Fatalf("Expected SizeLimitError, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "big.txt")); !os.IsNotExist(err) {
+		t.Fatal("Oversized file should be removed")
+	}
+
+	err = storage.BatchUpload(ctx, map[string]io.Reader{
+		"batch/big.txt": strings.NewReader(strings.Repeat("x", 100)),
+	})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("Expected ErrTooLarge from BatchUpload, got %v", err)
+	}
+
+	// 包装后仍可访问底层存储的可选能力
+	if _, ok := As[ExpirationReader](storage); !ok {
+		t.Fatal("As should find ExpirationReader through wrapper")
+	}
+}
+
+func TestQuotaStorage_Limits(t *testing.T) {
+	// 创建临时目录用于测试
+	tempDir, err := os.MkdirTemp("", "storage_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ctx := context.Background()
+	local := NewLocalStorage(LocalStorageConfig{BasePath: tempDir})
+
+	// 配额生效前已存在的文件会在首次访问时统计
+	if err := local.Upload(ctx, "tenants/acme/existing.txt", strings.NewReader("12345")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	var softHits []string
+	quota := NewQuotaStorage(local, QuotaConfig{
+		Rules: []QuotaRule{
+			{Prefix: "/tenants", PerTenant: true, MaxBytes: 20, MaxObjects: 3, SoftBytes: 12},
+		},
+		OnSoftLimit: func(_ context.Context, usage QuotaUsage) {
+			softHits = append(softHits, usage.Prefix)
+		},
+	})
+
+	if err := quota.Upload(ctx, "tenants/acme/a.txt", strings.NewReader("1234567890")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if len(softHits) != 1 || softHits[0] != "tenants/acme/" {
+		t.Fatalf("Expected soft limit callback, got %v", softHits)
+	}
+
+	// 超过字节数硬限制
+	err = quota.Upload(ctx, "tenants/acme/b.txt", io.MultiReader(strings.NewReader("123456")))
+	var quotaErr *QuotaExceededError
+	if !errors.Is(err, ErrQuotaExceeded) || !errors.As(err, &quotaErr) || quotaErr.Kind != quotaKindBytes {
+		t.Fatalf("Expected bytes QuotaExceededError, got %v", err)
+	}
+
+	// 覆盖上传时旧文件的用量会被释放
+	if err := quota.Upload(ctx, "tenants/acme/a.txt", strings.NewReader("123456789012345")); err != nil {
+		t.Fatalf("Overwrite failed: %v", err)
+	}
+
+	// 其他租户不受影响
+	if err := quota.Upload(ctx, "tenants/other/a.txt", strings.NewReader("1234567890")); err != nil {
+		t.Fatalf("Upload to other tenant failed: %v", err)
+	}
+
+	usage, err := quota.UsageOf(ctx, "tenants/acme/a.txt")
+	if err != nil || len(usage) != 1 {
+		t.Fatalf("UsageOf failed: %v, %v", usage, err)
+	}
+	if usage[0].Bytes != 20 || usage[0].Objects != 2 {
+		t.Fatalf("Unexpected usage: %+v", usage[0])
+	}
+
+	// 删除释放用量
+	if err := quota.Delete(ctx, "tenants/acme/existing.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := quota.Upload(ctx, "tenants/acme/c.txt", strings.NewReader("1")); err != nil {
+		t.Fatalf("Upload after delete failed: %v", err)
+	}
+	if err := quota.Upload(ctx, "tenants/acme/d.txt", strings.NewReader("")); err != nil {
+		t.Fatalf("Upload after delete failed: %v", err)
+	}
+
+	// 超过对象数硬限制
+	err = quota.Upload(ctx, "tenants/acme/e.txt", strings.NewReader(""))
+	if !errors.As(err, &quotaErr) || quotaErr.Kind != quotaKindObjects {
+		t.Fatalf("Expected objects QuotaExceededError, got %v", err)
+	}
+
+	// 复制到已满的租户被拒绝
+	err = quota.Copy(ctx, "tenants/other/a.txt", "tenants/acme/copy.txt")
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded from Copy, got %v", err)
+	}
+
+	// 绕过配额直接写入后重新统计
+	if err := local.Upload(ctx, "tenants/other/external.txt", strings.NewReader("12345")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	usages, err := quota.Recalculate(ctx)
+	if err != nil {
+		t.Fatalf("Recalculate failed: %v", err)
+	}
+	if len(usages) != 2 {
+		t.Fatalf("Expected 2 tenants, got %+v", usages)
+	}
+	for _, u := range usages {
+		switch u.Prefix {
+		case "tenants/acme/":
+			if u.Bytes != 16 || u.Objects != 3 || !u.SoftExceeded {
+				t.Fatalf("Unexpected acme usage: %+v", u)
+			}
+		case "tenants/other/":
+			if u.Bytes != 15 || u.Objects != 2 {
+				t.Fatalf("Unexpected other usage: %+v", u)
+			}
+		default:
+			t.Fatalf("Unexpected prefix: %s", u.Prefix)
+		}
+	}
+}
+
+func TestQuotaStorage_MoveDir(t *testing.T) {
+	ctx := context.Background()
+	local := NewLocalStorage(LocalStorageConfig{BasePath: t.TempDir()})
+
+	// 规范化规则前缀时不修改调用方的切片
+	rules := []QuotaRule{{Prefix: "/tenants", PerTenant: true, MaxBytes: 10}}
+	quota := NewQuotaStorage(local, QuotaConfig{Rules: rules})
+	if rules[0].Prefix != "/tenants" {
+		t.Fatalf("Caller's rules modified: %+v", rules)
+	}
+
+	for filePath, content := range map[string]string{"tenants/acme/dir/a.txt": "123", "tenants/acme/dir/sub/b.txt": "4567"} {
+		if err := quota.Upload(ctx, filePath, strings.NewReader(content)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	if err := quota.Upload(ctx, "tenants/full/a.txt", strings.NewReader("12345")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 移动目录时按目录下所有文件计算用量
+	if err := quota.Rename(ctx, "tenants/acme/dir", "tenants/full/dir"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := quota.Rename(ctx, "tenants/acme/dir", "tenants/other/dir"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	for prefix, want := range map[string][2]int64{"tenants/acme/x": {0, 0}, "tenants/other/x": {7, 2}} {
+		usage, err := quota.UsageOf(ctx, prefix)
+		if err != nil || len(usage) != 1 || usage[0].Bytes != want[0] || usage[0].Objects != want[1] {
+			t.Fatalf("Unexpected usage of %s: %+v, %v", prefix, usage, err)
+		}
+	}
+}
@@ -0,0 +1,475 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// remoteMaxErrorBody 读取错误响应的最大字节数
+const remoteMaxErrorBody = 64 << 10
+
+// RemoteStorageConfig 远程存储（存储网关客户端）配置
+type RemoteStorageConfig struct {
+	Endpoint  string            `json:"endpoint"` // 网关地址（包含路由前缀），如 http://storage-gateway:8080/api/v1
+	Token     string            `json:"token"`    // 访问令牌
+	BaseDir   string            `json:"base_dir"` // 网关存储中的基础目录
+	Timeout   time.Duration     `json:"timeout"`  // 单个请求的超时时间，默认不限制（大文件传输依赖 ctx 控制）
+	Transport http.RoundTripper `json:"-"`        // 自定义传输层，为空时使用 http.DefaultTransport
+}
+
+// RemoteStorage 通过存储网关（Gateway）访问远程存储的客户端。
+// 上传和下载均为流式传输；网关返回的错误码还原为 ErrNotExist、ErrNotSupported、
+// ErrPreconditionFailed、ErrTooLarge 和 ErrQuotaExceeded，可用 errors.Is 判断。
+type RemoteStorage struct {
+	config   RemoteStorageConfig
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewRemoteStorage 创建新的远程存储实例
+func NewRemoteStorage(config RemoteStorageConfig) Storage {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		hlog.Errorf("存储网关地址无效: %q", config.Endpoint)
+		return nil
+	}
+	config.BaseDir = strings.Trim(config.BaseDir, "/")
+
+	return &RemoteStorage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Transport: config.Transport, Timeout: config.Timeout},
+	}
+}
+
+// fullPath 返回网关存储中的完整路径
+func (s *RemoteStorage) fullPath(filePath string) string {
+	return strings.Trim(path.Join(s.config.BaseDir, filePath), "/")
+}
+
+// url 返回接口地址，route 为 files、dirs 等路由，filePath 会拼接在其后
+func (s *RemoteStorage) url(route, filePath string, query url.Values) string {
+	u := *s.endpoint
+	u.Path = strings.TrimSuffix(s.endpoint.Path, "/") + "/" + route
+	if filePath != "" || route == "dirs" {
+		u.Path += "/" + s.fullPath(filePath)
+	}
+	u.RawPath = ""
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// do 发送请求，非 2xx 状态码（除 416 外）转换为对应的错误
+func (s *RemoteStorage) do(ctx context.Context, method, target string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if s.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.config.Token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	return nil, remoteError(method, resp)
+}
+
+// remoteError 将网关的错误响应还原为对应的错误
+func remoteError(method string, resp *http.Response) error {
+	var body gatewayError
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, remoteMaxErrorBody))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = resp.Status
+	}
+	err := fmt.Errorf("存储网关 %s %s 失败: %s", method, resp.Request.URL.Path, body.Error)
+
+	code := body.Code
+	if code == "" {
+		// HEAD 等没有响应体的请求按状态码判断
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			code = gatewayCodeNotExist
+		case http.StatusPreconditionFailed:
+			code = gatewayCodePreconditionFailed
+		}
+	}
+	switch code {
+	case gatewayCodeNotExist:
+		return &notExistError{err: err}
+	case gatewayCodeNotSupported:
+		return fmt.Errorf("%w: %w", err, ErrNotSupported)
+	case gatewayCodePreconditionFailed:
+		return fmt.Errorf("%w: %w", err, ErrPreconditionFailed)
+	case gatewayCodeTooLarge:
+		return fmt.Errorf("%w: %w", err, ErrTooLarge)
+	case gatewayCodeQuotaExceeded:
+		return fmt.Errorf("%w: %w", err, ErrQuotaExceeded)
+	}
+	return err
+}
+
+// doJSON 发送 JSON 请求，out 不为 nil 时解析响应
+func (s *RemoteStorage) doJSON(ctx context.Context, method, target string, in, out interface{}) error {
+	var body io.Reader
+	header := http.Header{}
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+		header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.do(ctx, method, target, body, header)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// uploadHeader 将上传选项转换为请求头
+func uploadHeader(contentTypeHeader string, opts []UploadOption) http.Header {
+	options := ApplyUploadOptions(opts...)
+	header := http.Header{}
+	if options.ContentType != "" {
+		header.Set(contentTypeHeader, options.ContentType)
+	}
+	if options.Expiration > 0 {
+		// 网关以秒为单位，不足一秒的部分向上取整
+		seconds := int64((options.Expiration + time.Second - 1) / time.Second)
+		header.Set(gatewayExpirationHeader, strconv.FormatInt(seconds, 10))
+	}
+	return header
+}
+
+// Upload 实现上传文件到远程存储（流式上传）
+func (s *RemoteStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到远程存储: %s", filePath)
+
+	resp, err := s.do(ctx, http.MethodPut, s.url("files", filePath, nil), reader, uploadHeader("Content-Type", opts))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "远程存储上传文件失败: %v", err)
+		return err
+	}
+	resp.Body.Close()
+
+	hlog.CtxInfof(ctx, "远程存储文件上传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从远程存储下载文件（流式下载）
+func (s *RemoteStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从远程存储下载文件: %s", filePath)
+
+	resp, err := s.do(ctx, http.MethodGet, s.url("files", filePath, nil), nil, nil)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "远程存储获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储文件下载已启动: %s", filePath)
+	return resp.Body, nil
+}
+
+// DownloadRange 实现从远程存储下载文件范围（Range 请求，size <= 0 表示到文件末尾）
+func (s *RemoteStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从远程存储下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	rangeHeader := fmt.Sprintf("bytes=%d-", offset)
+	if size > 0 {
+		rangeHeader += strconv.FormatInt(offset+size-1, 10)
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.url("files", filePath, nil), nil, http.Header{"Range": {rangeHeader}})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "远程存储获取文件范围失败: %v", err)
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// 起始位置超出文件末尾
+		resp.Body.Close()
+		return strings.NewReader(""), nil
+	}
+
+	hlog.CtxInfof(ctx, "远程存储文件断点续传下载已启动: %s", filePath)
+	return resp.Body, nil
+}
+
+// Delete 实现删除远程存储中的文件
+func (s *RemoteStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始删除远程存储文件: %s", filePath)
+
+	if err := s.doJSON(ctx, http.MethodDelete, s.url("files", filePath, nil), nil, nil); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储文件删除成功: %s", filePath)
+	return nil
+}
+
+// transfer 调用重命名、移动或复制接口
+func (s *RemoteStorage) transfer(ctx context.Context, route, srcPath, dstPath string) error {
+	pair := gatewayPathPair{Src: s.fullPath(srcPath), Dst: s.fullPath(dstPath)}
+	return s.doJSON(ctx, http.MethodPost, s.url(route, "", nil), pair, nil)
+}
+
+// Rename 实现重命名远程存储中的文件
+func (s *RemoteStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始重命名远程存储文件: %s -> %s", oldPath, newPath)
+
+	if err := s.transfer(ctx, "rename", oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储重命名文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现移动远程存储中的文件
+func (s *RemoteStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始移动远程存储文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.transfer(ctx, "move", srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储移动文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储文件移动成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Copy 实现复制远程存储中的文件
+func (s *RemoteStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始复制远程存储文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.transfer(ctx, "copy", srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Exists 实现检查远程存储中的文件是否存在
+func (s *RemoteStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	var result struct {
+		Exists bool `json:"exists"`
+	}
+	if err := s.doJSON(ctx, http.MethodGet, s.url("exists", filePath, nil), nil, &result); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储检查文件是否存在失败: %v", err)
+		return false, err
+	}
+	return result.Exists, nil
+}
+
+// CreateDir 实现在远程存储中创建目录
+func (s *RemoteStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始创建远程存储目录: %s", dirPath)
+
+	if err := s.doJSON(ctx, http.MethodPut, s.url("dirs", dirPath, nil), nil, nil); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储创建目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储目录创建成功: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现递归删除远程存储中的目录
+func (s *RemoteStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除远程存储目录: %s", dirPath)
+
+	if err := s.doJSON(ctx, http.MethodDelete, s.url("dirs", dirPath, nil), nil, nil); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储删除目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储目录删除成功: %s", dirPath)
+	return nil
+}
+
+// ListDir 实现列出远程存储目录内容，自动读取所有分页
+func (s *RemoteStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出远程存储目录: %s", dirPath)
+
+	var files []FileMetadata
+	marker := ""
+	for {
+		query := url.Values{}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		var listing gatewayListing
+		if err := s.doJSON(ctx, http.MethodGet, s.url("dirs", dirPath, query), nil, &listing); err != nil {
+			hlog.CtxErrorf(ctx, "远程存储列出目录失败: %v", err)
+			return nil, err
+		}
+		files = append(files, listing.Entries...)
+		if listing.NextMarker == "" {
+			break
+		}
+		marker = listing.NextMarker
+	}
+
+	hlog.CtxInfof(ctx, "远程存储目录列出成功: %s, 共 %d 项", dirPath, len(files))
+	return files, nil
+}
+
+// GetMetadata 实现获取远程存储文件元数据
+func (s *RemoteStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	var metadata FileMetadata
+	if err := s.doJSON(ctx, http.MethodGet, s.url("metadata", filePath, nil), nil, &metadata); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储获取文件元数据失败: %v", err)
+		return nil, err
+	}
+	// 与其他后端一致，名称为传入的文件路径
+	metadata.Name = filePath
+	return &metadata, nil
+}
+
+// UpdateMetadata 实现更新远程存储文件元数据
+func (s *RemoteStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	if err := s.doJSON(ctx, http.MethodPatch, s.url("metadata", filePath, nil), metadata, nil); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储更新文件元数据失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// BatchUpload 实现批量上传文件，所有文件在一个 multipart 请求中流式发送
+func (s *RemoteStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传文件到远程存储: %d 个", len(files))
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		var err error
+		for filePath, reader := range files {
+			var part io.Writer
+			if part, err = mw.CreateFormFile(url.PathEscape(s.fullPath(filePath)), path.Base(filePath)); err != nil {
+				break
+			}
+			if _, err = io.Copy(part, reader); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	header := uploadHeader(gatewayContentTypeHeader, opts)
+	header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := s.do(ctx, http.MethodPost, s.url("batch/upload", "", nil), pr, header)
+	// 请求失败时让写入协程退出
+	pr.CloseWithError(errors.New("请求已结束"))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "远程存储批量上传失败: %v", err)
+		return err
+	}
+	resp.Body.Close()
+
+	hlog.CtxInfof(ctx, "远程存储批量上传成功: %d 个", len(files))
+	return nil
+}
+
+// BatchDownload 实现批量下载文件，返回的内容已全部读入内存
+func (s *RemoteStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从远程存储批量下载文件: %d 个", len(filePaths))
+
+	request := gatewayPaths{Paths: make([]string, 0, len(filePaths))}
+	names := make(map[string]string, len(filePaths))
+	for _, filePath := range filePaths {
+		fullPath := s.fullPath(filePath)
+		request.Paths = append(request.Paths, fullPath)
+		names[fullPath] = filePath
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodPost, s.url("batch/download", "", nil), bytes.NewReader(data), http.Header{"Content-Type": {"application/json"}})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "远程存储批量下载失败: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("存储网关批量下载响应无效: %w", err)
+	}
+	results := make(map[string]io.Reader, len(filePaths))
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取批量下载响应失败: %w", err)
+		}
+		fullPath, err := url.PathUnescape(part.FormName())
+		if err != nil {
+			return nil, fmt.Errorf("批量下载响应中的路径无效: %w", err)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, part); err != nil {
+			return nil, fmt.Errorf("读取批量下载响应失败: %w", err)
+		}
+		if name, ok := names[fullPath]; ok {
+			results[name] = &buf
+		}
+	}
+
+	hlog.CtxInfof(ctx, "远程存储批量下载成功: %d 个", len(results))
+	return results, nil
+}
+
+// BatchDelete 实现批量删除文件
+func (s *RemoteStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除远程存储文件: %d 个", len(filePaths))
+
+	request := gatewayPaths{Paths: make([]string, 0, len(filePaths))}
+	for _, filePath := range filePaths {
+		request.Paths = append(request.Paths, s.fullPath(filePath))
+	}
+	if err := s.doJSON(ctx, http.MethodPost, s.url("batch/delete", "", nil), request, nil); err != nil {
+		hlog.CtxErrorf(ctx, "远程存储批量删除失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "远程存储批量删除成功: %d 个", len(filePaths))
+	return nil
+}
@@ -0,0 +1,1234 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+const (
+	// s3XMLNamespace S3 响应的 XML 命名空间
+	s3XMLNamespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+	// s3DefaultRegion 默认的区域
+	s3DefaultRegion = "us-east-1"
+	// s3DefaultStatePrefix 默认的分片上传状态目录
+	s3DefaultStatePrefix = ".s3-multipart"
+	// s3DefaultExpiration 默认的未完成分片上传有效期
+	s3DefaultExpiration = 24 * time.Hour
+	// s3MaxKeys 单次列举返回的最大条目数
+	s3MaxKeys = 1000
+	// s3MaxPartNumber 最大分片编号
+	s3MaxPartNumber = 10000
+	// s3MaxXMLBody XML 请求体的最大字节数
+	s3MaxXMLBody = 4 << 20
+	// s3ExpiresAtHeader 携带删除截止时间的用户元数据头，与 S3Storage 写入的元数据一致
+	s3ExpiresAtHeader = "X-Amz-Meta-" + ExpiresAtMetaKey
+)
+
+// s3Subresources 不支持的子资源查询参数，带有这些参数的请求返回 NotImplemented
+var s3Subresources = []string{
+	"acl", "tagging", "versioning", "versions", "versionId", "policy", "cors", "lifecycle", "website",
+	"logging", "notification", "replication", "encryption", "object-lock", "retention", "legal-hold",
+	"torrent", "attributes", "restore", "select", "accelerate", "requestPayment", "ownershipControls",
+}
+
+// S3GatewayConfig S3 兼容网关配置
+type S3GatewayConfig struct {
+	Buckets     map[string]Storage `json:"-"`            // 存储桶名称到存储的映射
+	Credentials map[string]string  `json:"credentials"`  // 访问密钥ID到密钥的映射，为空时不校验签名
+	Region      string             `json:"region"`       // GetBucketLocation 返回的区域，默认 us-east-1
+	StatePrefix string             `json:"state_prefix"` // 各存储桶中保存分片上传状态和分片的目录，默认 .s3-multipart
+	Expiration  time.Duration      `json:"expiration"`   // 未完成分片上传的有效期，默认 24 小时
+}
+
+// S3Gateway 将 Storage 以 S3 REST API 的子集对外提供，使 aws-cli、rclone、Spark（s3a）等只支持 S3 的工具
+// 可以访问本地目录、OSS 等任意存储。仅支持路径样式访问（http://host/bucket/key）：
+//
+//	GET    /                                  ListBuckets
+//	HEAD   /bucket                            HeadBucket
+//	GET    /bucket?location                   GetBucketLocation
+//	GET    /bucket[?list-type=2]              ListObjects、ListObjectsV2（prefix、delimiter、分页、encoding-type=url）
+//	POST   /bucket?delete                     DeleteObjects
+//	PUT    /bucket/key                        PutObject，带 x-amz-copy-source 时为 CopyObject
+//	GET    /bucket/key                        GetObject，支持单个 Range 和条件请求
+//	HEAD   /bucket/key                        HeadObject
+//	DELETE /bucket/key                        DeleteObject
+//	POST   /bucket/key?uploads                CreateMultipartUpload
+//	PUT    /bucket/key?partNumber=&uploadId=  UploadPart
+//	POST   /bucket/key?uploadId=              CompleteMultipartUpload
+//	DELETE /bucket/key?uploadId=              AbortMultipartUpload
+//
+// 配置 Credentials 时校验 AWS Signature Version 4 签名（Authorization 头、预签名URL 和 aws-chunked 分块签名）。
+// 分片保存在对应存储桶的 StatePrefix 目录下，完成时按顺序合并写入目标路径；该目录不出现在列举结果中。
+// 以 / 结尾的空对象（目录占位符）映射为 CreateDir。
+type S3Gateway struct {
+	config  S3GatewayConfig
+	created time.Time
+}
+
+// s3APIError S3 错误响应
+type s3APIError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *s3APIError) Error() string {
+	return e.code + ": " + e.message
+}
+
+// s3Request 一次请求解析出的存储桶和对象
+type s3Request struct {
+	bucket    string
+	key       string // 请求中的原始 key
+	filePath  string // 存储路径
+	storage   Storage
+	signature *s3Signature
+}
+
+// s3Upload 保存在状态目录中的分片上传信息
+type s3Upload struct {
+	ID          string    `json:"id"`
+	Key         string    `json:"key"`
+	ContentType string    `json:"content_type,omitempty"`
+	ExpiresAt   string    `json:"expires_at,omitempty"` // 对象的删除截止时间
+	Initiated   time.Time `json:"initiated"`
+}
+
+// s3Part 已上传的分片
+type s3Part struct {
+	number int
+	etag   string
+	size   int64
+	name   string
+}
+
+// NewS3Gateway 创建 S3 兼容网关，通过 Register 注册路由：
+//
+//	gateway := storage.NewS3Gateway(storage.S3GatewayConfig{
+//		Buckets:     map[string]storage.Storage{"data": s},
+//		Credentials: map[string]string{accessKey: secretKey},
+//	})
+//	gateway.Register(h)
+func NewS3Gateway(config S3GatewayConfig) *S3Gateway {
+	if config.Region == "" {
+		config.Region = s3DefaultRegion
+	}
+	if config.StatePrefix == "" {
+		config.StatePrefix = s3DefaultStatePrefix
+	}
+	config.StatePrefix = strings.Trim(config.StatePrefix, "/")
+	if config.Expiration <= 0 {
+		config.Expiration = s3DefaultExpiration
+	}
+	return &S3Gateway{config: config, created: time.Now().UTC()}
+}
+
+// Register 注册 S3 路由，网关需要独占根路径
+func (g *S3Gateway) Register(r route.IRoutes) {
+	for _, method := range []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodPost, http.MethodDelete} {
+		r.Handle(method, "/", g.serve)
+		r.Handle(method, "/:bucket", g.serve)
+		r.Handle(method, "/:bucket/*key", g.serve)
+	}
+}
+
+// Cleanup 删除超过有效期仍未完成的分片上传，返回删除的上传数量。
+// 过期的上传不会自动清理，应定期调用（如与 Janitor 一起）。
+func (g *S3Gateway) Cleanup(ctx context.Context) (int, error) {
+	removed := 0
+	for bucket, s := range g.config.Buckets {
+		entries, err := s.ListDir(ctx, g.config.StatePrefix)
+		if errors.Is(err, ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return removed, err
+		}
+		for _, entry := range entries {
+			id := path.Base(strings.Trim(entry.Name, "/"))
+			upload, err := g.loadUpload(ctx, s, id)
+			if err != nil || time.Since(upload.Initiated) < g.config.Expiration {
+				continue
+			}
+			if err := s.DeleteDir(ctx, g.uploadDir(id)); err != nil {
+				hlog.CtxErrorf(ctx, "清理过期分片上传失败: %s/%s, %v", bucket, id, err)
+				continue
+			}
+			removed++
+		}
+	}
+	if removed > 0 {
+		hlog.CtxInfof(ctx, "清理过期分片上传: %d 个", removed)
+	}
+	return removed, nil
+}
+
+// serve 校验签名并按请求分派操作
+func (g *S3Gateway) serve(ctx context.Context, c *app.RequestContext) {
+	if id, err := randomHex(); err == nil {
+		c.Header("X-Amz-Request-Id", id)
+	}
+	signature, authErr := g.authenticate(c)
+	if authErr != nil {
+		g.abort(ctx, c, authErr)
+		return
+	}
+
+	// 使用原始路径解析存储桶和 key，避免路由对路径的规范化改变 key
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(s3RawPath(c), "/"), "/")
+	if bucket == "" {
+		if string(c.Method()) != http.MethodGet {
+			g.abort(ctx, c, &s3APIError{status: http.StatusMethodNotAllowed, code: "MethodNotAllowed", message: "不支持的操作"})
+			return
+		}
+		g.listBuckets(c)
+		return
+	}
+	req := &s3Request{bucket: bucket, key: key, storage: g.config.Buckets[bucket], signature: signature}
+	if req.storage == nil {
+		if string(c.Method()) == http.MethodPut && key == "" {
+			g.abort(ctx, c, &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "不支持创建存储桶"})
+			return
+		}
+		g.abort(ctx, c, &s3APIError{status: http.StatusNotFound, code: "NoSuchBucket", message: "存储桶不存在: " + bucket})
+		return
+	}
+	for _, name := range s3Subresources {
+		if c.QueryArgs().Has(name) {
+			g.abort(ctx, c, &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "不支持的子资源: " + name})
+			return
+		}
+	}
+	if key == "" {
+		g.serveBucket(ctx, c, req)
+		return
+	}
+
+	req.filePath = strings.Trim(path.Clean("/"+key), "/")
+	if req.filePath == "" || g.reserved(req.filePath) {
+		g.abort(ctx, c, &s3APIError{status: http.StatusForbidden, code: "AccessDenied", message: "不允许访问: " + key})
+		return
+	}
+	g.serveObject(ctx, c, req)
+}
+
+// serveBucket 处理存储桶级别的请求
+func (g *S3Gateway) serveBucket(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	query := c.QueryArgs()
+	switch method := string(c.Method()); {
+	case method == http.MethodHead:
+		c.Header("X-Amz-Bucket-Region", g.config.Region)
+		c.AbortWithStatus(http.StatusOK)
+	case method == http.MethodGet && query.Has("location"):
+		location := g.config.Region
+		if location == s3DefaultRegion {
+			location = ""
+		}
+		g.writeXML(c, http.StatusOK, struct {
+			XMLName  xml.Name `xml:"LocationConstraint"`
+			Xmlns    string   `xml:"xmlns,attr"`
+			Location string   `xml:",chardata"`
+		}{Xmlns: s3XMLNamespace, Location: location})
+	case method == http.MethodGet && query.Has("uploads"):
+		g.abort(ctx, c, &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "不支持列举分片上传"})
+	case method == http.MethodGet:
+		g.listObjects(ctx, c, req)
+	case method == http.MethodPost && query.Has("delete"):
+		g.deleteObjects(ctx, c, req)
+	case method == http.MethodPut:
+		g.abort(ctx, c, &s3APIError{status: http.StatusConflict, code: "BucketAlreadyOwnedByYou", message: "存储桶已存在: " + req.bucket})
+	default:
+		g.abort(ctx, c, &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "不支持的存储桶操作"})
+	}
+}
+
+// serveObject 处理对象级别的请求
+func (g *S3Gateway) serveObject(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	query := c.QueryArgs()
+	uploadID := string(query.Peek("uploadId"))
+	switch method := string(c.Method()); {
+	case method == http.MethodPost && query.Has("uploads"):
+		g.createMultipartUpload(ctx, c, req)
+	case method == http.MethodPut && uploadID != "":
+		g.uploadPart(ctx, c, req, uploadID)
+	case method == http.MethodPost && uploadID != "":
+		g.completeMultipartUpload(ctx, c, req, uploadID)
+	case method == http.MethodDelete && uploadID != "":
+		g.abortMultipartUpload(ctx, c, req, uploadID)
+	case uploadID != "" || query.Has("partNumber"):
+		g.abort(ctx, c, &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "不支持的分片操作"})
+	case method == http.MethodPut && len(c.GetHeader("X-Amz-Copy-Source")) > 0:
+		g.copyObject(ctx, c, req)
+	case method == http.MethodPut:
+		g.putObject(ctx, c, req)
+	case method == http.MethodGet, method == http.MethodHead:
+		g.getObject(ctx, c, req)
+	case method == http.MethodDelete:
+		g.deleteObject(ctx, c, req)
+	default:
+		g.abort(ctx, c, &s3APIError{status: http.StatusMethodNotAllowed, code: "MethodNotAllowed", message: "不支持的对象操作"})
+	}
+}
+
+// abort 返回 XML 错误响应，存储错误转换为对应的 S3 错误码
+func (g *S3Gateway) abort(ctx context.Context, c *app.RequestContext, err error) {
+	var e *s3APIError
+	if !errors.As(err, &e) {
+		e = &s3APIError{status: http.StatusInternalServerError, code: "InternalError", message: err.Error()}
+		switch {
+		case errors.Is(err, ErrNotExist):
+			e.status, e.code = http.StatusNotFound, "NoSuchKey"
+		case errors.Is(err, ErrNotSupported):
+			e.status, e.code = http.StatusNotImplemented, "NotImplemented"
+		case errors.Is(err, ErrPreconditionFailed):
+			e.status, e.code = http.StatusPreconditionFailed, "PreconditionFailed"
+		case errors.Is(err, ErrTooLarge):
+			e.status, e.code = http.StatusBadRequest, "EntityTooLarge"
+		case errors.Is(err, ErrQuotaExceeded):
+			e.status, e.code = http.StatusForbidden, "QuotaExceeded"
+		default:
+			hlog.CtxErrorf(ctx, "S3网关请求失败: %s %s, %v", c.Method(), c.Path(), err)
+		}
+	}
+	if string(c.Method()) == http.MethodHead {
+		c.AbortWithStatus(e.status)
+		return
+	}
+	g.writeXML(c, e.status, struct {
+		XMLName   xml.Name `xml:"Error"`
+		Code      string   `xml:"Code"`
+		Message   string   `xml:"Message"`
+		Resource  string   `xml:"Resource"`
+		RequestID string   `xml:"RequestId"`
+	}{Code: e.code, Message: e.message, Resource: string(c.Path()), RequestID: string(c.Response.Header.Peek("X-Amz-Request-Id"))})
+	c.Abort()
+}
+
+// writeXML 返回 XML 响应
+func (g *S3Gateway) writeXML(c *app.RequestContext, status int, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "application/xml", append([]byte(xml.Header), data...))
+}
+
+// readXML 读取并解析 XML 请求体
+func (g *S3Gateway) readXML(c *app.RequestContext, req *s3Request, v interface{}) error {
+	data, err := io.ReadAll(io.LimitReader(g.payload(c, req.signature), s3MaxXMLBody))
+	if err != nil {
+		return err
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return &s3APIError{status: http.StatusBadRequest, code: "MalformedXML", message: err.Error()}
+	}
+	return nil
+}
+
+// reserved 判断存储路径是否位于分片上传状态目录中
+func (g *S3Gateway) reserved(filePath string) bool {
+	return filePath == g.config.StatePrefix || strings.HasPrefix(filePath, g.config.StatePrefix+"/")
+}
+
+// listBuckets 列出所有存储桶
+func (g *S3Gateway) listBuckets(c *app.RequestContext) {
+	type bucket struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+	result := struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Xmlns   string   `xml:"xmlns,attr"`
+		Owner   struct {
+			ID          string `xml:"ID"`
+			DisplayName string `xml:"DisplayName"`
+		} `xml:"Owner"`
+		Buckets []bucket `xml:"Buckets>Bucket"`
+	}{Xmlns: s3XMLNamespace}
+	result.Owner.ID, result.Owner.DisplayName = "storage", "storage"
+	for name := range g.config.Buckets {
+		result.Buckets = append(result.Buckets, bucket{Name: name, CreationDate: s3FormatTime(g.created)})
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool {
+		return result.Buckets[i].Name < result.Buckets[j].Name
+	})
+	g.writeXML(c, http.StatusOK, result)
+}
+
+// s3ListEntry 列举结果中的一项（对象或公共前缀）
+type s3ListEntry struct {
+	key      string
+	isPrefix bool
+	metadata FileMetadata
+}
+
+// listObjects 实现 ListObjects 和 ListObjectsV2（list-type=2）。
+// delimiter 为 / 时只列出 prefix 所在的一层目录，否则递归遍历后按 delimiter 分组；
+// 结果按 key 排序后从 marker 之后分页，因此每一页都需要重新列举。
+func (g *S3Gateway) listObjects(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	query := c.QueryArgs()
+	v2 := string(query.Peek("list-type")) == "2"
+	prefix := string(query.Peek("prefix"))
+	delimiter := string(query.Peek("delimiter"))
+	marker := string(query.Peek("marker"))
+	if v2 {
+		marker = string(query.Peek("start-after"))
+		if token := string(query.Peek("continuation-token")); token != "" {
+			decoded, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "InvalidArgument", message: "无效的 continuation-token"})
+				return
+			}
+			marker = string(decoded)
+		}
+	}
+	maxKeys := s3MaxKeys
+	if value := string(query.Peek("max-keys")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "InvalidArgument", message: "无效的 max-keys: " + value})
+			return
+		}
+		maxKeys = min(n, s3MaxKeys)
+	}
+
+	candidates, err := g.listCandidates(ctx, req.storage, prefix, delimiter)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	var entries []s3ListEntry
+	truncated := false
+	for _, entry := range candidates {
+		if entry.key <= marker || len(entries) > 0 && entries[len(entries)-1].key == entry.key {
+			continue
+		}
+		if len(entries) == maxKeys {
+			truncated = true
+			break
+		}
+		entries = append(entries, entry)
+	}
+
+	encode := func(s string) string { return s }
+	if string(query.Peek("encoding-type")) == "url" {
+		encode = func(s string) string { return s3URIEncode(s, false) }
+	}
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Xmlns                 string         `xml:"xmlns,attr"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		Marker                *string        `xml:"Marker,omitempty"`
+		StartAfter            string         `xml:"StartAfter,omitempty"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		KeyCount              *int           `xml:"KeyCount,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		EncodingType          string         `xml:"EncodingType,omitempty"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextMarker            string         `xml:"NextMarker,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{
+		Xmlns:        s3XMLNamespace,
+		Name:         req.bucket,
+		Prefix:       encode(prefix),
+		MaxKeys:      maxKeys,
+		Delimiter:    encode(delimiter),
+		EncodingType: string(query.Peek("encoding-type")),
+		IsTruncated:  truncated,
+	}
+	for _, entry := range entries {
+		if entry.isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: encode(entry.key)})
+			continue
+		}
+		result.Contents = append(result.Contents, content{
+			Key:          encode(entry.key),
+			LastModified: s3FormatTime(entry.metadata.ModTime),
+			ETag:         s3ETag(&entry.metadata),
+			Size:         entry.metadata.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+
+	next := ""
+	if truncated {
+		next = entries[len(entries)-1].key
+	}
+	if v2 {
+		count := len(entries)
+		result.KeyCount = &count
+		result.StartAfter = encode(string(query.Peek("start-after")))
+		result.ContinuationToken = string(query.Peek("continuation-token"))
+		if next != "" {
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(next))
+		}
+	} else {
+		m := encode(marker)
+		result.Marker = &m
+		if delimiter != "" {
+			result.NextMarker = encode(next)
+		}
+	}
+	g.writeXML(c, http.StatusOK, result)
+}
+
+// listCandidates 返回 key 以 prefix 开头的对象和按 delimiter 分组后的公共前缀，按 key 排序（可能有重复的公共前缀）
+func (g *S3Gateway) listCandidates(ctx context.Context, s Storage, prefix, delimiter string) ([]s3ListEntry, error) {
+	dir := ""
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i]
+	}
+	join := func(name string) string {
+		if dir == "" {
+			return name
+		}
+		return dir + "/" + name
+	}
+
+	var entries []s3ListEntry
+	add := func(key string, isDir bool, metadata FileMetadata) {
+		if !strings.HasPrefix(key, prefix) || g.reserved(strings.TrimSuffix(key, "/")) {
+			return
+		}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entries = append(entries, s3ListEntry{key: key[:len(prefix)+i+len(delimiter)], isPrefix: true})
+				return
+			}
+		}
+		if !isDir {
+			entries = append(entries, s3ListEntry{key: key, metadata: metadata})
+		}
+	}
+
+	var err error
+	if delimiter == "/" {
+		// 只需要 prefix 所在的一层目录，子目录直接作为公共前缀
+		var files []FileMetadata
+		files, err = s.ListDir(ctx, dir)
+		for _, file := range files {
+			name := strings.TrimSuffix(file.Name, "/")
+			if name == "" {
+				continue
+			}
+			if file.IsDir {
+				add(join(name)+"/", true, file)
+			} else {
+				add(join(name), false, file)
+			}
+		}
+	} else {
+		err = Walk(ctx, s, dir, func(filePath string, metadata FileMetadata) error {
+			add(filePath, false, metadata)
+			return nil
+		})
+	}
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+	return entries, nil
+}
+
+// deleteObjects 批量删除，不存在的对象视为删除成功
+func (g *S3Gateway) deleteObjects(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	var input struct {
+		Quiet   bool `xml:"Quiet"`
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+	if err := g.readXML(c, req, &input); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	if len(input.Objects) > s3MaxKeys {
+		g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "MalformedXML", message: "单次最多删除 1000 个对象"})
+		return
+	}
+
+	type deleted struct {
+		Key string `xml:"Key"`
+	}
+	type deleteError struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	result := struct {
+		XMLName xml.Name      `xml:"DeleteResult"`
+		Xmlns   string        `xml:"xmlns,attr"`
+		Deleted []deleted     `xml:"Deleted"`
+		Errors  []deleteError `xml:"Error"`
+	}{Xmlns: s3XMLNamespace}
+	for _, object := range input.Objects {
+		filePath := strings.Trim(path.Clean("/"+object.Key), "/")
+		var err error
+		switch {
+		case filePath == "" || g.reserved(filePath):
+			err = &s3APIError{code: "AccessDenied", message: "不允许访问: " + object.Key}
+		case strings.HasSuffix(object.Key, "/"):
+			err = g.deleteDirMarker(ctx, req.storage, filePath)
+		default:
+			err = req.storage.Delete(ctx, filePath)
+		}
+		if err != nil && !errors.Is(err, ErrNotExist) {
+			code := "InternalError"
+			var e *s3APIError
+			if errors.As(err, &e) {
+				code = e.code
+			}
+			result.Errors = append(result.Errors, deleteError{Key: object.Key, Code: code, Message: err.Error()})
+			continue
+		}
+		if !input.Quiet {
+			result.Deleted = append(result.Deleted, deleted{Key: object.Key})
+		}
+	}
+	g.writeXML(c, http.StatusOK, result)
+}
+
+// uploadOptions 从请求头读取上传选项：Content-Type 和 x-amz-meta-expires-at
+func (g *S3Gateway) uploadOptions(c *app.RequestContext) ([]UploadOption, error) {
+	var opts []UploadOption
+	if contentType := s3ContentType(c); contentType != "" {
+		opts = append(opts, WithContentType(contentType))
+	}
+	if value := string(c.GetHeader(s3ExpiresAtHeader)); value != "" {
+		ttl, err := s3ExpirationTTL(value)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithExpiration(ttl))
+	}
+	return opts, nil
+}
+
+// s3ContentType 返回请求指定的 MIME 类型，SDK 和 curl 默认发送的通用类型返回空，由存储自动检测
+func s3ContentType(c *app.RequestContext) string {
+	contentType := string(c.ContentType())
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType == "application/x-www-form-urlencoded" || mediaType == defaultMIMEType {
+		return ""
+	}
+	return contentType
+}
+
+// s3ExpirationTTL 将删除截止时间转换为有效期
+func s3ExpirationTTL(expiresAt string) (time.Duration, error) {
+	deadline := parseExpiresAt(expiresAt)
+	ttl := time.Until(deadline)
+	if deadline.IsZero() || ttl <= 0 {
+		return 0, &s3APIError{status: http.StatusBadRequest, code: "InvalidArgument", message: "无效的 " + s3ExpiresAtHeader + ": " + expiresAt}
+	}
+	// WithExpiration 按秒截断，向上取整以保持截止时间不变
+	return ttl.Truncate(time.Second) + time.Second, nil
+}
+
+// putObject 上传对象，支持 If-None-Match: * 和 If-Match 条件写入（需要存储实现 ConditionalUploader）
+func (g *S3Gateway) putObject(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	body := g.payload(c, req.signature)
+	if strings.HasSuffix(req.key, "/") {
+		// 目录占位符
+		if n, err := io.Copy(io.Discard, body); err != nil || n > 0 {
+			g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "InvalidArgument", message: "以 / 结尾的对象必须为空"})
+			return
+		}
+		if err := req.storage.CreateDir(ctx, req.filePath); err != nil {
+			g.abort(ctx, c, err)
+			return
+		}
+		c.Header("ETag", `"`+s3EmptyMD5+`"`)
+		c.AbortWithStatus(http.StatusOK)
+		return
+	}
+
+	opts, err := g.uploadOptions(c)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	ifMatch, ifNoneMatch := string(c.GetHeader("If-Match")), string(c.GetHeader("If-None-Match"))
+	if ifMatch == "" && ifNoneMatch == "" {
+		err = req.storage.Upload(ctx, req.filePath, body, opts...)
+	} else {
+		err = g.uploadIf(ctx, req, body, ifMatch, ifNoneMatch, opts)
+	}
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	g.writeObjectETag(ctx, c, req)
+	c.AbortWithStatus(http.StatusOK)
+}
+
+// uploadIf 条件写入。S3 的 ETag 与存储的 ETag 可能不同（见 s3ETag），If-Match 先比较当前的 S3 ETag
+func (g *S3Gateway) uploadIf(ctx context.Context, req *s3Request, body io.Reader, ifMatch, ifNoneMatch string, opts []UploadOption) error {
+	uploader, ok := As[ConditionalUploader](req.storage)
+	if !ok {
+		return &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "存储不支持条件写入"}
+	}
+	if ifNoneMatch != "" {
+		if strings.TrimSpace(ifNoneMatch) != "*" {
+			return &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "If-None-Match 仅支持 *"}
+		}
+		return uploader.UploadIf(ctx, req.filePath, body, UploadCondition{IfNoneMatch: true}, opts...)
+	}
+	metadata, err := req.storage.GetMetadata(ctx, req.filePath)
+	if errors.Is(err, ErrNotExist) {
+		return &s3APIError{status: http.StatusPreconditionFailed, code: "PreconditionFailed", message: "对象不存在"}
+	}
+	if err != nil {
+		return err
+	}
+	if !etagMatch(ifMatch, s3ETag(metadata), false) || metadata.ETag == "" {
+		return &s3APIError{status: http.StatusPreconditionFailed, code: "PreconditionFailed", message: "ETag 不匹配"}
+	}
+	return uploader.UploadIf(ctx, req.filePath, body, UploadCondition{IfMatch: metadata.ETag}, opts...)
+}
+
+// writeObjectETag 写入后设置对象的 ETag 响应头
+func (g *S3Gateway) writeObjectETag(ctx context.Context, c *app.RequestContext, req *s3Request) *FileMetadata {
+	metadata, err := req.storage.GetMetadata(ctx, req.filePath)
+	if err != nil {
+		hlog.CtxWarnf(ctx, "获取写入后的对象信息失败: %s, %v", req.filePath, err)
+		return nil
+	}
+	c.Header("ETag", s3ETag(metadata))
+	return metadata
+}
+
+// getObject 下载对象或返回对象响应头
+func (g *S3Gateway) getObject(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	metadata, err := req.storage.GetMetadata(ctx, req.filePath)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	if metadata.IsDir != strings.HasSuffix(req.key, "/") {
+		g.abort(ctx, c, ErrNotExist)
+		return
+	}
+	if metadata.IsDir {
+		metadata = &FileMetadata{ETag: s3EmptyMD5, ModTime: metadata.ModTime, MIMEType: "application/x-directory"}
+	}
+
+	etag := s3ETag(metadata)
+	c.Header("ETag", etag)
+	if !metadata.ModTime.IsZero() {
+		c.Header("Last-Modified", metadata.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if reader, ok := As[ExpirationReader](req.storage); ok && !metadata.IsDir {
+		if deadline, err := reader.GetExpiration(ctx, req.filePath); err == nil && !deadline.IsZero() {
+			c.Header(s3ExpiresAtHeader, formatExpiresAt(deadline))
+		}
+	}
+	switch checkPreconditions(c, etag, metadata.ModTime) {
+	case http.StatusNotModified:
+		c.AbortWithStatus(http.StatusNotModified)
+		return
+	case http.StatusPreconditionFailed:
+		g.abort(ctx, c, &s3APIError{status: http.StatusPreconditionFailed, code: "PreconditionFailed", message: "前置条件不满足"})
+		return
+	}
+
+	contentType := metadata.MIMEType
+	if contentType == "" {
+		contentType = mimeTypeByName(req.filePath)
+	}
+	c.Response.Header.SetContentType(contentType)
+	c.Header("Accept-Ranges", "bytes")
+	// 预签名URL可以通过 response-* 查询参数覆盖响应头
+	for param, header := range map[string]string{
+		"response-content-type":        "Content-Type",
+		"response-content-disposition": "Content-Disposition",
+		"response-content-encoding":    "Content-Encoding",
+		"response-content-language":    "Content-Language",
+		"response-cache-control":       "Cache-Control",
+		"response-expires":             "Expires",
+	} {
+		if value := c.QueryArgs().Peek(param); len(value) > 0 {
+			c.Response.Header.Set(header, string(value))
+		}
+	}
+
+	size := metadata.Size
+	ranges, err := parseRange(rangeHeader(c, etag, metadata.ModTime), size)
+	if err != nil {
+		c.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		g.abort(ctx, c, &s3APIError{status: http.StatusRequestedRangeNotSatisfiable, code: "InvalidRange", message: "请求的范围无效"})
+		return
+	}
+	body := func() (io.Reader, error) { return req.storage.Download(ctx, req.filePath) }
+	c.SetStatusCode(http.StatusOK)
+	// S3 不支持多个范围，此时返回完整内容
+	if len(ranges) == 1 {
+		ra := ranges[0]
+		c.SetStatusCode(http.StatusPartialContent)
+		c.Header("Content-Range", ra.contentRange(size))
+		size = ra.length
+		body = func() (io.Reader, error) { return req.storage.DownloadRange(ctx, req.filePath, ra.start, ra.length) }
+	}
+
+	if string(c.Method()) == http.MethodHead {
+		c.Response.Header.SetContentLength(int(size))
+		c.Response.SkipBody = true
+		return
+	}
+	if size == 0 {
+		c.Response.Header.SetContentLength(0)
+		return
+	}
+	reader, err := body()
+	if err != nil {
+		c.Response.Header.Del("Content-Range")
+		g.abort(ctx, c, err)
+		return
+	}
+	c.SetBodyStream(reader, int(size))
+}
+
+// deleteObject 删除对象，不存在时同样返回 204
+func (g *S3Gateway) deleteObject(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	var err error
+	if strings.HasSuffix(req.key, "/") {
+		err = g.deleteDirMarker(ctx, req.storage, req.filePath)
+	} else {
+		err = req.storage.Delete(ctx, req.filePath)
+	}
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// deleteDirMarker 删除目录占位符：只删除空目录，非空目录保持不变
+func (g *S3Gateway) deleteDirMarker(ctx context.Context, s Storage, dirPath string) error {
+	entries, err := s.ListDir(ctx, dirPath)
+	if err != nil || len(entries) > 0 {
+		return err
+	}
+	return s.DeleteDir(ctx, dirPath)
+}
+
+// copyObject 复制对象。x-amz-metadata-directive 为 REPLACE 时使用请求中的 Content-Type，
+// 同一存储桶内保留元数据时使用 Storage.Copy，否则读取源对象后写入目标
+func (g *S3Gateway) copyObject(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	source := string(c.GetHeader("X-Amz-Copy-Source"))
+	source, versionID, _ := strings.Cut(source, "?")
+	if versionID != "" && versionID != "versionId=null" {
+		g.abort(ctx, c, &s3APIError{status: http.StatusNotImplemented, code: "NotImplemented", message: "不支持复制指定版本"})
+		return
+	}
+	if decoded, err := url.PathUnescape(source); err == nil {
+		source = decoded
+	}
+	srcBucket, srcKey, _ := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	srcStorage := g.config.Buckets[srcBucket]
+	srcPath := strings.Trim(path.Clean("/"+srcKey), "/")
+	if srcStorage == nil {
+		g.abort(ctx, c, &s3APIError{status: http.StatusNotFound, code: "NoSuchBucket", message: "存储桶不存在: " + srcBucket})
+		return
+	}
+	if srcPath == "" || g.reserved(srcPath) {
+		g.abort(ctx, c, &s3APIError{status: http.StatusForbidden, code: "AccessDenied", message: "不允许访问: " + srcKey})
+		return
+	}
+
+	metadata, err := srcStorage.GetMetadata(ctx, srcPath)
+	if err == nil && metadata.IsDir {
+		err = ErrNotExist
+	}
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	if !s3CopyPreconditions(c, s3ETag(metadata), metadata.ModTime) {
+		g.abort(ctx, c, &s3APIError{status: http.StatusPreconditionFailed, code: "PreconditionFailed", message: "复制源的前置条件不满足"})
+		return
+	}
+
+	replace := strings.EqualFold(string(c.GetHeader("X-Amz-Metadata-Directive")), "REPLACE")
+	sameObject := srcStorage == req.storage && srcPath == req.filePath
+	switch {
+	case sameObject && !replace:
+		err = &s3APIError{status: http.StatusBadRequest, code: "InvalidRequest", message: "复制到自身时必须替换元数据"}
+	case sameObject:
+		err = req.storage.UpdateMetadata(ctx, req.filePath, &FileMetadata{MIMEType: string(c.ContentType())})
+	case srcStorage == req.storage && !replace:
+		err = req.storage.Copy(ctx, srcPath, req.filePath)
+	default:
+		contentType := metadata.MIMEType
+		if replace {
+			contentType = string(c.ContentType())
+		}
+		var reader io.Reader
+		if reader, err = srcStorage.Download(ctx, srcPath); err == nil {
+			var opts []UploadOption
+			if contentType != "" {
+				opts = append(opts, WithContentType(contentType))
+			}
+			err = req.storage.Upload(ctx, req.filePath, reader, opts...)
+			if closer, ok := reader.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+
+	result := struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		Xmlns        string   `xml:"xmlns,attr"`
+		LastModified string   `xml:"LastModified"`
+		ETag         string   `xml:"ETag"`
+	}{Xmlns: s3XMLNamespace}
+	if copied := g.writeObjectETag(ctx, c, req); copied != nil {
+		result.LastModified, result.ETag = s3FormatTime(copied.ModTime), s3ETag(copied)
+	}
+	g.writeXML(c, http.StatusOK, result)
+}
+
+// s3CopyPreconditions 检查 x-amz-copy-source-if-* 条件
+func s3CopyPreconditions(c *app.RequestContext, etag string, modTime time.Time) bool {
+	if ifMatch := string(c.GetHeader("X-Amz-Copy-Source-If-Match")); ifMatch != "" && !etagMatch(ifMatch, etag, false) {
+		return false
+	}
+	if ifNoneMatch := string(c.GetHeader("X-Amz-Copy-Source-If-None-Match")); ifNoneMatch != "" && etagMatch(ifNoneMatch, etag, true) {
+		return false
+	}
+	if since, err := http.ParseTime(string(c.GetHeader("X-Amz-Copy-Source-If-Modified-Since"))); err == nil && !modTime.Truncate(time.Second).After(since) {
+		return false
+	}
+	if since, err := http.ParseTime(string(c.GetHeader("X-Amz-Copy-Source-If-Unmodified-Since"))); err == nil && modTime.Truncate(time.Second).After(since) {
+		return false
+	}
+	return true
+}
+
+// createMultipartUpload 创建分片上传
+func (g *S3Gateway) createMultipartUpload(ctx context.Context, c *app.RequestContext, req *s3Request) {
+	id, err := randomHex()
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	upload := &s3Upload{ID: id, Key: req.filePath, ContentType: s3ContentType(c), Initiated: time.Now().UTC()}
+	if value := string(c.GetHeader(s3ExpiresAtHeader)); value != "" {
+		if _, err := s3ExpirationTTL(value); err != nil {
+			g.abort(ctx, c, err)
+			return
+		}
+		upload.ExpiresAt = value
+	}
+	data, err := json.Marshal(upload)
+	if err == nil {
+		err = req.storage.Upload(ctx, path.Join(g.uploadDir(id), "upload.json"), bytes.NewReader(data), WithContentType("application/json"))
+	}
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	g.writeXML(c, http.StatusOK, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}{Xmlns: s3XMLNamespace, Bucket: req.bucket, Key: req.key, UploadID: id})
+}
+
+// uploadPart 上传分片，分片以 "<编号>.<MD5>" 为名保存，重复上传同一编号时替换旧分片
+func (g *S3Gateway) uploadPart(ctx context.Context, c *app.RequestContext, req *s3Request, uploadID string) {
+	number, err := strconv.Atoi(string(c.QueryArgs().Peek("partNumber")))
+	if err != nil || number < 1 || number > s3MaxPartNumber {
+		g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "InvalidArgument", message: "分片编号必须在 1 到 10000 之间"})
+		return
+	}
+	if _, err := g.lookupUpload(ctx, req, uploadID); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	parts, err := g.listParts(ctx, req.storage, uploadID)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+
+	// 先写入临时分片，得到 MD5 后再改名
+	tempPath := path.Join(g.uploadDir(uploadID), fmt.Sprintf("%05d.tmp", number))
+	hash := md5.New()
+	if err := req.storage.Upload(ctx, tempPath, io.TeeReader(g.payload(c, req.signature), hash)); err != nil {
+		_ = req.storage.Delete(ctx, tempPath)
+		g.abort(ctx, c, err)
+		return
+	}
+	etag := hex.EncodeToString(hash.Sum(nil))
+	partPath := path.Join(g.uploadDir(uploadID), fmt.Sprintf("%05d.%s", number, etag))
+	if err := req.storage.Rename(ctx, tempPath, partPath); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	for _, part := range parts {
+		if part.number == number && part.etag != etag {
+			_ = req.storage.Delete(ctx, path.Join(g.uploadDir(uploadID), part.name))
+		}
+	}
+	c.Header("ETag", `"`+etag+`"`)
+	c.AbortWithStatus(http.StatusOK)
+}
+
+// completeMultipartUpload 按请求中的分片顺序合并写入目标路径并删除上传状态
+func (g *S3Gateway) completeMultipartUpload(ctx context.Context, c *app.RequestContext, req *s3Request, uploadID string) {
+	upload, err := g.lookupUpload(ctx, req, uploadID)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	var input struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := g.readXML(c, req, &input); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	if len(input.Parts) == 0 {
+		g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "MalformedXML", message: "缺少分片列表"})
+		return
+	}
+	parts, err := g.listParts(ctx, req.storage, uploadID)
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	uploaded := make(map[int]s3Part, len(parts))
+	for _, part := range parts {
+		uploaded[part.number] = part
+	}
+
+	var selected []s3Part
+	for i, p := range input.Parts {
+		if i > 0 && p.PartNumber <= input.Parts[i-1].PartNumber {
+			g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "InvalidPartOrder", message: "分片必须按编号升序排列"})
+			return
+		}
+		part, ok := uploaded[p.PartNumber]
+		if !ok || part.etag != trimETag(p.ETag) {
+			g.abort(ctx, c, &s3APIError{status: http.StatusBadRequest, code: "InvalidPart", message: fmt.Sprintf("分片 %d 不存在或 ETag 不匹配", p.PartNumber)})
+			return
+		}
+		selected = append(selected, part)
+	}
+
+	var opts []UploadOption
+	if upload.ContentType != "" {
+		opts = append(opts, WithContentType(upload.ContentType))
+	}
+	if upload.ExpiresAt != "" {
+		ttl, err := s3ExpirationTTL(upload.ExpiresAt)
+		if err != nil {
+			g.abort(ctx, c, err)
+			return
+		}
+		opts = append(opts, WithExpiration(ttl))
+	}
+	reader := &s3PartsReader{ctx: ctx, storage: req.storage, dir: g.uploadDir(uploadID), parts: selected}
+	err = req.storage.Upload(ctx, req.filePath, reader, opts...)
+	reader.Close()
+	if err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	if err := req.storage.DeleteDir(ctx, g.uploadDir(uploadID)); err != nil {
+		hlog.CtxWarnf(ctx, "删除分片上传状态失败: %s, %v", uploadID, err)
+	}
+	hlog.CtxInfof(ctx, "S3分片上传完成: %s/%s, %d 个分片", req.bucket, req.filePath, len(selected))
+
+	result := struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Xmlns    string   `xml:"xmlns,attr"`
+		Location string   `xml:"Location"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		ETag     string   `xml:"ETag"`
+	}{Xmlns: s3XMLNamespace, Location: "/" + req.bucket + "/" + req.key, Bucket: req.bucket, Key: req.key}
+	if metadata := g.writeObjectETag(ctx, c, req); metadata != nil {
+		result.ETag = s3ETag(metadata)
+	}
+	g.writeXML(c, http.StatusOK, result)
+}
+
+// abortMultipartUpload 中止分片上传并删除已上传的分片
+func (g *S3Gateway) abortMultipartUpload(ctx context.Context, c *app.RequestContext, req *s3Request, uploadID string) {
+	if _, err := g.lookupUpload(ctx, req, uploadID); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	if err := req.storage.DeleteDir(ctx, g.uploadDir(uploadID)); err != nil {
+		g.abort(ctx, c, err)
+		return
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// uploadDir 返回分片上传的状态目录
+func (g *S3Gateway) uploadDir(id string) string {
+	return path.Join(g.config.StatePrefix, id)
+}
+
+// loadUpload 读取分片上传信息
+func (g *S3Gateway) loadUpload(ctx context.Context, s Storage, id string) (*s3Upload, error) {
+	if id == "" || strings.ContainsAny(id, "/\\.") {
+		return nil, ErrNotExist
+	}
+	reader, err := s.Download(ctx, path.Join(g.uploadDir(id), "upload.json"))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	var upload s3Upload
+	if err := json.NewDecoder(reader).Decode(&upload); err != nil {
+		return nil, fmt.Errorf("解析分片上传信息失败: %w", err)
+	}
+	return &upload, nil
+}
+
+// lookupUpload 读取属于请求对象的分片上传，不存在时返回 NoSuchUpload
+func (g *S3Gateway) lookupUpload(ctx context.Context, req *s3Request, id string) (*s3Upload, error) {
+	upload, err := g.loadUpload(ctx, req.storage, id)
+	if errors.Is(err, ErrNotExist) || err == nil && upload.Key != req.filePath {
+		return nil, &s3APIError{status: http.StatusNotFound, code: "NoSuchUpload", message: "分片上传不存在: " + id}
+	}
+	return upload, err
+}
+
+// listParts 列出已上传的分片，按编号排序
+func (g *S3Gateway) listParts(ctx context.Context, s Storage, id string) ([]s3Part, error) {
+	entries, err := s.ListDir(ctx, g.uploadDir(id))
+	if err != nil {
+		return nil, err
+	}
+	var parts []s3Part
+	for _, entry := range entries {
+		numberText, etag, ok := strings.Cut(entry.Name, ".")
+		number, err := strconv.Atoi(numberText)
+		if !ok || err != nil || entry.IsDir || len(etag) != 2*md5.Size {
+			continue
+		}
+		parts = append(parts, s3Part{number: number, etag: etag, size: entry.Size, name: entry.Name})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].number < parts[j].number
+	})
+	return parts, nil
+}
+
+// s3PartsReader 依次读取各分片
+type s3PartsReader struct {
+	ctx     context.Context
+	storage Storage
+	dir     string
+	parts   []s3Part
+	current io.Reader
+}
+
+func (r *s3PartsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.parts) == 0 {
+				return 0, io.EOF
+			}
+			reader, err := r.storage.Download(r.ctx, path.Join(r.dir, r.parts[0].name))
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.parts = reader, r.parts[1:]
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close 关闭正在读取的分片
+func (r *s3PartsReader) Close() error {
+	if closer, ok := r.current.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// s3EmptyMD5 空内容的 MD5
+const s3EmptyMD5 = "d41d8cd98f00b204e9800998ecf8427e"
+
+// s3ETag 返回对象的 S3 ETag。存储的 ETag 是 MD5 时原样返回；
+// 否则（包括存储不提供 ETag）生成一个带 "-1" 后缀的 ETag，与分片上传对象一样，客户端不会将其当作内容的 MD5 校验
+func s3ETag(metadata *FileMetadata) string {
+	if etag := trimETag(metadata.ETag); len(etag) == 2*md5.Size {
+		if _, err := hex.DecodeString(etag); err == nil {
+			return `"` + strings.ToLower(etag) + `"`
+		}
+	}
+	seed := metadata.ETag
+	if seed == "" {
+		seed = fmt.Sprintf("%d-%d", metadata.Size, metadata.ModTime.UnixNano())
+	}
+	sum := md5.Sum([]byte(seed))
+	return `"` + hex.EncodeToString(sum[:]) + `-1"`
+}
+
+// s3FormatTime 格式化 XML 响应中的时间
+func s3FormatTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,473 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+const (
+	// s3SigningAlgorithm 支持的签名算法（AWS Signature Version 4）
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+	// s3TimeFormat X-Amz-Date 的时间格式
+	s3TimeFormat = "20060102T150405Z"
+	// s3MaxClockSkew 允许的客户端时钟偏差
+	s3MaxClockSkew = 15 * time.Minute
+	// s3MaxPresignExpiry 预签名URL的最长有效期
+	s3MaxPresignExpiry = 7 * 24 * time.Hour
+	// s3MaxChunkHeader aws-chunked 分块头的最大长度
+	s3MaxChunkHeader = 4096
+
+	// x-amz-content-sha256 的特殊取值
+	s3UnsignedPayload        = "UNSIGNED-PAYLOAD"
+	s3StreamingPayload       = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	s3StreamingPayloadTrail  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	s3StreamingUnsignedTrail = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	s3EmptySHA256            = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// s3Signature 通过校验的请求签名，用于校验 aws-chunked 分块签名
+type s3Signature struct {
+	signingKey []byte
+	amzDate    string
+	scope      string
+	seed       string // 请求签名，作为第一个分块签名的前一个签名
+}
+
+// authenticate 校验请求的 SigV4 签名（Authorization 头或预签名URL），未配置凭证时返回 nil
+func (g *S3Gateway) authenticate(c *app.RequestContext) (*s3Signature, *s3APIError) {
+	if len(g.config.Credentials) == 0 {
+		return nil, nil
+	}
+	query := c.QueryArgs()
+	if query.Has("X-Amz-Algorithm") {
+		return g.authenticatePresigned(c)
+	}
+
+	authorization := string(c.GetHeader("Authorization"))
+	if authorization == "" {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "AccessDenied", message: "请求未签名"}
+	}
+	fields, ok := strings.CutPrefix(authorization, s3SigningAlgorithm+" ")
+	if !ok {
+		return nil, &s3APIError{status: http.StatusBadRequest, code: "AuthorizationHeaderMalformed", message: "仅支持 AWS Signature Version 4"}
+	}
+	params := make(map[string]string)
+	for _, field := range strings.Split(fields, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		params[key] = value
+	}
+
+	amzDate := string(c.GetHeader("X-Amz-Date"))
+	if amzDate == "" {
+		// 没有 X-Amz-Date 时使用 Date 头
+		if date, err := http.ParseTime(string(c.GetHeader("Date"))); err == nil {
+			amzDate = date.UTC().Format(s3TimeFormat)
+		}
+	}
+	signedTime, err := time.Parse(s3TimeFormat, amzDate)
+	if err != nil {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "AccessDenied", message: "缺少有效的 X-Amz-Date"}
+	}
+	if skew := time.Since(signedTime); skew > s3MaxClockSkew || skew < -s3MaxClockSkew {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "RequestTimeTooSkewed", message: "请求时间与服务器时间相差过大"}
+	}
+
+	payloadHash := string(c.GetHeader("X-Amz-Content-Sha256"))
+	if payloadHash == "" {
+		payloadHash = s3UnsignedPayload
+	}
+	return g.verifySignature(c, params["Credential"], params["SignedHeaders"], params["Signature"], amzDate, payloadHash, false)
+}
+
+// authenticatePresigned 校验预签名URL
+func (g *S3Gateway) authenticatePresigned(c *app.RequestContext) (*s3Signature, *s3APIError) {
+	query := c.QueryArgs()
+	if string(query.Peek("X-Amz-Algorithm")) != s3SigningAlgorithm {
+		return nil, &s3APIError{status: http.StatusBadRequest, code: "AuthorizationQueryParametersError", message: "仅支持 AWS Signature Version 4"}
+	}
+	amzDate := string(query.Peek("X-Amz-Date"))
+	signedTime, err := time.Parse(s3TimeFormat, amzDate)
+	if err != nil {
+		return nil, &s3APIError{status: http.StatusBadRequest, code: "AuthorizationQueryParametersError", message: "无效的 X-Amz-Date"}
+	}
+	seconds, err := strconv.ParseInt(string(query.Peek("X-Amz-Expires")), 10, 64)
+	if err != nil || seconds < 0 || time.Duration(seconds)*time.Second > s3MaxPresignExpiry {
+		return nil, &s3APIError{status: http.StatusBadRequest, code: "AuthorizationQueryParametersError", message: "无效的 X-Amz-Expires"}
+	}
+	if time.Until(signedTime) > s3MaxClockSkew {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "RequestNotReadyYet", message: "预签名URL尚未生效"}
+	}
+	if time.Since(signedTime) > time.Duration(seconds)*time.Second {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "AccessDenied", message: "预签名URL已过期"}
+	}
+
+	payloadHash := string(query.Peek("X-Amz-Content-Sha256"))
+	if payloadHash == "" {
+		payloadHash = s3UnsignedPayload
+	}
+	return g.verifySignature(c, string(query.Peek("X-Amz-Credential")), string(query.Peek("X-Amz-SignedHeaders")),
+		string(query.Peek("X-Amz-Signature")), amzDate, payloadHash, true)
+}
+
+// verifySignature 重新计算签名并与请求中的签名比较。
+// 凭证范围中的区域不做限制，客户端配置任意区域均可访问。
+func (g *S3Gateway) verifySignature(c *app.RequestContext, credential, signedHeaders, signature, amzDate, payloadHash string, presigned bool) (*s3Signature, *s3APIError) {
+	malformed := &s3APIError{status: http.StatusBadRequest, code: "AuthorizationHeaderMalformed", message: "无效的签名参数"}
+	scopeParts := strings.Split(credential, "/")
+	if len(scopeParts) != 5 || signedHeaders == "" || signature == "" {
+		return nil, malformed
+	}
+	accessKey, date, region, service, terminator := scopeParts[0], scopeParts[1], scopeParts[2], scopeParts[3], scopeParts[4]
+	if date != amzDate[:8] || service != "s3" || terminator != "aws4_request" {
+		return nil, malformed
+	}
+	secret, ok := g.config.Credentials[accessKey]
+	if !ok {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "InvalidAccessKeyId", message: "访问密钥不存在"}
+	}
+
+	canonicalRequest := strings.Join([]string{
+		string(c.Method()),
+		s3URIEncode(s3RawPath(c), false),
+		s3CanonicalQuery(c, presigned),
+		s3CanonicalHeaders(c, signedHeaders),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join(scopeParts[1:], "/")
+	stringToSign := strings.Join([]string{s3SigningAlgorithm, amzDate, scope, s3SHA256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := []byte("AWS4" + secret)
+	for _, part := range []string{date, region, service, terminator} {
+		signingKey = s3HMAC(signingKey, part)
+	}
+	expected := hex.EncodeToString(s3HMAC(signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, &s3APIError{status: http.StatusForbidden, code: "SignatureDoesNotMatch", message: "签名不匹配"}
+	}
+	return &s3Signature{signingKey: signingKey, amzDate: amzDate, scope: scope, seed: signature}, nil
+}
+
+// payload 返回解码并校验后的请求体：
+//   - aws-chunked 编码（STREAMING-*）逐块解码，带签名的分块逐块校验签名
+//   - x-amz-content-sha256 为十六进制摘要时在读取结束时校验
+//   - 带 Content-MD5 时在读取结束时校验
+//
+// 校验失败时 Read 返回 *s3APIError，写入存储的 Upload 随之失败。
+func (g *S3Gateway) payload(c *app.RequestContext, signature *s3Signature) io.Reader {
+	body := requestBody(c)
+	payloadHash := string(c.GetHeader("X-Amz-Content-Sha256"))
+	switch payloadHash {
+	case s3StreamingPayload, s3StreamingPayloadTrail, s3StreamingUnsignedTrail:
+		chunked := &s3ChunkedReader{reader: bufio.NewReader(body), decodedLength: -1}
+		if payloadHash != s3StreamingUnsignedTrail && signature != nil {
+			chunked.signature = signature
+			chunked.previous = signature.seed
+		}
+		if value := string(c.GetHeader("X-Amz-Decoded-Content-Length")); value != "" {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				chunked.decodedLength = n
+			}
+		}
+		body = chunked
+	case "", s3UnsignedPayload:
+	default:
+		if expected, err := hex.DecodeString(payloadHash); err == nil && signature != nil {
+			body = &s3VerifyingReader{reader: body, hash: sha256.New(), expected: expected,
+				err: &s3APIError{status: http.StatusBadRequest, code: "XAmzContentSHA256Mismatch", message: "请求体与 x-amz-content-sha256 不一致"}}
+		}
+	}
+
+	if contentMD5 := string(c.GetHeader("Content-MD5")); contentMD5 != "" {
+		expected, err := base64.StdEncoding.DecodeString(contentMD5)
+		if err != nil || len(expected) != md5.Size {
+			expected = nil
+		}
+		body = &s3VerifyingReader{reader: body, hash: md5.New(), expected: expected,
+			err: &s3APIError{status: http.StatusBadRequest, code: "BadDigest", message: "请求体与 Content-MD5 不一致"}}
+	}
+	return body
+}
+
+// s3RawPath 返回未解码的请求路径
+func s3RawPath(c *app.RequestContext) string {
+	raw := string(c.Request.URI().PathOriginal())
+	if decoded, err := s3PathUnescape(raw); err == nil {
+		return decoded
+	}
+	return raw
+}
+
+// s3PathUnescape 解码路径中的百分号编码（不把 + 视为空格）
+func s3PathUnescape(s string) (string, error) {
+	if !strings.Contains(s, "%") {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			b.WriteByte(s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return "", fmt.Errorf("无效的路径编码: %q", s)
+		}
+		v, err := strconv.ParseUint(s[i+1:i+3], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("无效的路径编码: %q", s)
+		}
+		b.WriteByte(byte(v))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// s3URIEncode 按 SigV4 规则编码：保留 A-Z a-z 0-9 - _ . ~，encodeSlash 为 false 时保留 /
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~', ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// s3CanonicalQuery 返回排序后的规范查询字符串，预签名请求排除 X-Amz-Signature
+func s3CanonicalQuery(c *app.RequestContext, presigned bool) string {
+	var pairs []string
+	for _, pair := range strings.Split(string(c.Request.URI().QueryString()), "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		key, value = s3QueryUnescape(key), s3QueryUnescape(value)
+		if presigned && key == "X-Amz-Signature" {
+			continue
+		}
+		pairs = append(pairs, s3URIEncode(key, true)+"="+s3URIEncode(value, true))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3QueryUnescape 解码查询参数，+ 视为空格
+func s3QueryUnescape(s string) string {
+	decoded, err := s3PathUnescape(strings.ReplaceAll(s, "+", " "))
+	if err != nil {
+		return s
+	}
+	return decoded
+}
+
+// s3CanonicalHeaders 返回签名头的规范形式："名称:值\n"，多个值以逗号连接，值中的连续空白压缩为一个空格
+func s3CanonicalHeaders(c *app.RequestContext, signedHeaders string) string {
+	var b strings.Builder
+	for _, name := range strings.Split(signedHeaders, ";") {
+		var values []string
+		switch name {
+		case "host":
+			values = []string{string(c.Request.Host())}
+		case "content-length":
+			values = []string{string(c.Request.Header.ContentLengthBytes())}
+		default:
+			for _, value := range c.Request.Header.PeekAll(name) {
+				values = append(values, strings.Join(strings.Fields(string(value)), " "))
+			}
+		}
+		b.WriteString(name + ":" + strings.Join(values, ",") + "\n")
+	}
+	return b.String()
+}
+
+// s3HMAC 计算 HMAC-SHA256
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3SHA256Hex 返回 SHA-256 的十六进制摘要
+func s3SHA256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// s3VerifyingReader 读取结束时比较摘要，不一致时返回 err
+type s3VerifyingReader struct {
+	reader   io.Reader
+	hash     hash.Hash
+	expected []byte
+	err      *s3APIError
+}
+
+func (r *s3VerifyingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF && !bytes.Equal(r.hash.Sum(nil), r.expected) {
+		return n, r.err
+	}
+	return n, err
+}
+
+// s3ChunkedReader 解码 aws-chunked 请求体：
+//
+//	<十六进制长度>[;chunk-signature=<签名>]\r\n<数据>\r\n ... 0[;chunk-signature=<签名>]\r\n[尾部头\r\n]\r\n
+//
+// signature 不为 nil 时逐块校验签名（每块的签名链接前一块的签名），尾部头（校验和及其签名）被忽略。
+type s3ChunkedReader struct {
+	reader        *bufio.Reader
+	signature     *s3Signature
+	previous      string    // 前一个分块的签名
+	expected      string    // 当前分块的签名
+	hash          hash.Hash // 当前分块数据的摘要
+	remaining     int64     // 当前分块未读取的字节数
+	inChunk       bool
+	total         int64
+	decodedLength int64 // x-amz-decoded-content-length，未提供时为 -1
+	err           error
+}
+
+func (r *s3ChunkedReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.remaining == 0 {
+		if r.inChunk {
+			if r.err = r.finishChunk(); r.err != nil {
+				return 0, r.err
+			}
+		}
+		if r.err = r.nextChunk(); r.err != nil {
+			return 0, r.err
+		}
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	r.total += int64(n)
+	if r.hash != nil {
+		r.hash.Write(p[:n])
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// nextChunk 读取分块头，最后一个分块时校验签名并读取尾部，返回 io.EOF
+func (r *s3ChunkedReader) nextChunk() error {
+	line, err := r.readLine()
+	if err != nil {
+		return err
+	}
+	sizeText, extension, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(strings.TrimSpace(sizeText), 16, 64)
+	if err != nil || size < 0 {
+		return s3ErrIncompleteBody
+	}
+	if r.signature != nil {
+		signature, ok := strings.CutPrefix(strings.TrimSpace(extension), "chunk-signature=")
+		if !ok {
+			return &s3APIError{status: http.StatusForbidden, code: "SignatureDoesNotMatch", message: "分块缺少签名"}
+		}
+		r.expected = signature
+		r.hash = sha256.New()
+	}
+	if size > 0 {
+		r.remaining, r.inChunk = size, true
+		return nil
+	}
+
+	// 最后一个空分块
+	if err := r.verifyChunk(); err != nil {
+		return err
+	}
+	for {
+		line, err := r.readLine()
+		if err == io.EOF || err == nil && line == "" {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if r.decodedLength >= 0 && r.total != r.decodedLength {
+		return s3ErrIncompleteBody
+	}
+	return io.EOF
+}
+
+// finishChunk 读取分块数据后的 \r\n 并校验签名
+func (r *s3ChunkedReader) finishChunk() error {
+	r.inChunk = false
+	line, err := r.readLine()
+	if err != nil || line != "" {
+		return s3ErrIncompleteBody
+	}
+	return r.verifyChunk()
+}
+
+// verifyChunk 校验当前分块的签名
+func (r *s3ChunkedReader) verifyChunk() error {
+	if r.signature == nil {
+		return nil
+	}
+	stringToSign := strings.Join([]string{
+		s3SigningAlgorithm + "-PAYLOAD",
+		r.signature.amzDate,
+		r.signature.scope,
+		r.previous,
+		s3EmptySHA256,
+		hex.EncodeToString(r.hash.Sum(nil)),
+	}, "\n")
+	expected := hex.EncodeToString(s3HMAC(r.signature.signingKey, stringToSign))
+	if !hmac.Equal([]byte(expected), []byte(r.expected)) {
+		return &s3APIError{status: http.StatusForbidden, code: "SignatureDoesNotMatch", message: "分块签名不匹配"}
+	}
+	r.previous = r.expected
+	return nil
+}
+
+// readLine 读取以 \r\n 结尾的一行（不含换行符）
+func (r *s3ChunkedReader) readLine() (string, error) {
+	line, err := r.reader.ReadSlice('\n')
+	if err == bufio.ErrBufferFull || len(line) > s3MaxChunkHeader {
+		return "", s3ErrIncompleteBody
+	}
+	if err == io.EOF && len(line) > 0 {
+		return "", s3ErrIncompleteBody
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// s3ErrIncompleteBody aws-chunked 请求体格式错误或不完整
+var s3ErrIncompleteBody = &s3APIError{status: http.StatusBadRequest, code: "IncompleteBody", message: "请求体不完整"}
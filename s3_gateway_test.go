@@ -0,0 +1,270 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/v-mars/storage"
+)
+
+// startS3Gateway 在随机端口启动 S3 兼容网关，返回服务地址
+func startS3Gateway(t *testing.T, config storage.S3GatewayConfig) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	h := server.New(
+		server.WithHostPorts(addr),
+		server.WithStreamBody(true),
+		server.WithDisablePrintRoute(true),
+		server.WithExitWaitTime(0),
+	)
+	storage.NewS3Gateway(config).Register(h)
+	go h.Spin()
+	t.Cleanup(func() { h.Shutdown(context.Background()) })
+
+	// 等待服务就绪
+	for i := 0; i < 100; i++ {
+		if resp, err := http.Get("http://" + addr + "/"); err == nil {
+			resp.Body.Close()
+			return "http://" + addr
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("S3 gateway did not start")
+	return ""
+}
+
+// newS3Client 创建访问网关的 AWS SDK 客户端
+func newS3Client(endpoint, accessKey, secretKey string) *s3.Client {
+	return s3.New(s3.Options{
+		BaseEndpoint: aws.String(endpoint),
+		Region:       "us-east-1",
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider(accessKey, secretKey, ""),
+	})
+}
+
+func TestS3Gateway(t *testing.T) {
+	data := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	backup := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	endpoint := startS3Gateway(t, storage.S3GatewayConfig{
+		Buckets:     map[string]storage.Storage{"data": data, "backup": backup},
+		Credentials: map[string]string{"access": "secret"},
+	})
+	client := newS3Client(endpoint, "access", "secret")
+	ctx := context.Background()
+
+	// 签名校验
+	_, err := newS3Client(endpoint, "access", "wrong").ListBuckets(ctx, &s3.ListBucketsInput{})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "SignatureDoesNotMatch" {
+		t.Fatalf("Expected SignatureDoesNotMatch, got %v", err)
+	}
+	resp, err := http.Get(endpoint + "/data/a.txt")
+	if err != nil {
+		t.Fatalf("Anonymous request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected 403 for unsigned request, got %d", resp.StatusCode)
+	}
+
+	buckets, err := client.ListBuckets(ctx, &s3.ListBucketsInput{})
+	if err != nil || len(buckets.Buckets) != 2 || aws.ToString(buckets.Buckets[0].Name) != "backup" {
+		t.Fatalf("Unexpected buckets: %+v, %v", buckets, err)
+	}
+
+	// key 中的特殊字符参与签名
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String("data"),
+		Key:    aws.String("special/a b+c&d=中.txt"),
+		Body:   strings.NewReader("special"),
+	})
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	if exists, _ := data.Exists(ctx, "special/a b+c&d=中.txt"); !exists {
+		t.Fatal("Object with special characters should exist")
+	}
+
+	// 列举：分页和公共前缀
+	for _, key := range []string{"logs/2024/a.log", "logs/2024/b.log", "logs/2025/c.log", "logs/d.log", "readme.md"} {
+		if err := data.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	var keys []string
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket:  aws.String("data"),
+		Prefix:  aws.String("logs/"),
+		MaxKeys: aws.Int32(2),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("ListObjectsV2 failed: %v", err)
+		}
+		for _, object := range page.Contents {
+			keys = append(keys, aws.ToString(object.Key))
+		}
+	}
+	if strings.Join(keys, ",") != "logs/2024/a.log,logs/2024/b.log,logs/2025/c.log,logs/d.log" {
+		t.Fatalf("Unexpected keys: %v", keys)
+	}
+	listing, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String("data"),
+		Prefix:    aws.String("logs/20"),
+		Delimiter: aws.String("/"),
+	})
+	if err != nil || len(listing.CommonPrefixes) != 2 || len(listing.Contents) != 0 || aws.ToString(listing.CommonPrefixes[1].Prefix) != "logs/2025/" {
+		t.Fatalf("Unexpected delimiter listing: %+v, %v", listing, err)
+	}
+
+	// 超过 5MiB 的流式上传使用分片上传
+	s := storage.NewS3Storage(storage.S3StorageConfig{Endpoint: endpoint, AccessKeyID: "access", AccessKeySecret: "secret", Region: "us-east-1", Bucket: "data", BaseDir: "big"})
+	large := bytes.Repeat([]byte("0123456789abcdef"), 6<<16)
+	if err := s.Upload(ctx, "large.bin", io.MultiReader(bytes.NewReader(large))); err != nil {
+		t.Fatalf("Multipart upload failed: %v", err)
+	}
+	stored, err := data.Download(ctx, "big/large.bin")
+	if got := readString(t)(stored, err); got != string(large) {
+		t.Fatalf("Unexpected multipart content size: %d", len(got))
+	}
+	if entries, _ := data.ListDir(ctx, ".s3-multipart"); len(entries) != 0 {
+		t.Fatalf("Multipart state should be removed: %+v", entries)
+	}
+
+	// 跨存储桶复制
+	_, err = client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String("backup"),
+		Key:        aws.String("readme-copy.md"),
+		CopySource: aws.String("data/readme.md"),
+	})
+	if err != nil {
+		t.Fatalf("CopyObject failed: %v", err)
+	}
+	copied, err := backup.Download(ctx, "readme-copy.md")
+	if got := readString(t)(copied, err); got != "readme.md" {
+		t.Fatalf("Unexpected copied content: %q", got)
+	}
+
+	// 批量删除，不存在的对象视为成功
+	deleted, err := client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+		Bucket: aws.String("data"),
+		Delete: &types.Delete{Objects: []types.ObjectIdentifier{{Key: aws.String("logs/d.log")}, {Key: aws.String("missing.txt")}}},
+	})
+	if err != nil || len(deleted.Deleted) != 2 || len(deleted.Errors) != 0 {
+		t.Fatalf("Unexpected DeleteObjects result: %+v, %v", deleted, err)
+	}
+	if exists, _ := data.Exists(ctx, "logs/d.log"); exists {
+		t.Fatal("logs/d.log should be deleted")
+	}
+
+	// 预签名URL
+	presigned, err := s3.NewPresignClient(client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String("data"),
+		Key:    aws.String("readme.md"),
+	})
+	if err != nil {
+		t.Fatalf("Presign failed: %v", err)
+	}
+	resp, err = http.Get(presigned.URL)
+	if err != nil {
+		t.Fatalf("Presigned request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "readme.md" {
+		t.Fatalf("Unexpected presigned response: %d %q", resp.StatusCode, body)
+	}
+	resp, err = http.Get(strings.Replace(presigned.URL, "readme.md", "readme.txt", 1))
+	if err != nil {
+		t.Fatalf("Tampered request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Expected 403 for tampered presigned URL, got %d", resp.StatusCode)
+	}
+}
+
+func TestS3Gateway_MultipartCleanup(t *testing.T) {
+	data := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	config := storage.S3GatewayConfig{Buckets: map[string]storage.Storage{"data": data}}
+	endpoint := startS3Gateway(t, config)
+	client := newS3Client(endpoint, "any", "any")
+	ctx := context.Background()
+
+	created, err := client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String("data"),
+		Key:    aws.String("parts.txt"),
+	})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload failed: %v", err)
+	}
+	for _, part := range []int32{1, 2} {
+		_, err := client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String("data"),
+			Key:        aws.String("parts.txt"),
+			UploadId:   created.UploadId,
+			PartNumber: aws.Int32(part),
+			Body:       strings.NewReader(fmt.Sprintf("part%d", part)),
+		})
+		if err != nil {
+			t.Fatalf("UploadPart failed: %v", err)
+		}
+	}
+
+	// 分片的 ETag 不匹配时拒绝完成
+	_, err = client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String("data"),
+		Key:             aws.String("parts.txt"),
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: []types.CompletedPart{{PartNumber: aws.Int32(1), ETag: aws.String(`"0123"`)}}},
+	})
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "InvalidPart" {
+		t.Fatalf("Expected InvalidPart, got %v", err)
+	}
+
+	// 状态目录不出现在列举结果中
+	listing, err := client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("data")})
+	if err != nil || len(listing.Contents) != 0 {
+		t.Fatalf("Multipart state should be hidden: %+v, %v", listing, err)
+	}
+
+	// 未过期的上传不会被清理
+	if removed, err := storage.NewS3Gateway(config).Cleanup(ctx); err != nil || removed != 0 {
+		t.Fatalf("Unexpected cleanup result: %d, %v", removed, err)
+	}
+	config.Expiration = time.Nanosecond
+	if removed, err := storage.NewS3Gateway(config).Cleanup(ctx); err != nil || removed != 1 {
+		t.Fatalf("Unexpected cleanup result: %d, %v", removed, err)
+	}
+	_, err = client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String("data"),
+		Key:      aws.String("parts.txt"),
+		UploadId: created.UploadId,
+	})
+	if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchUpload" {
+		t.Fatalf("Expected NoSuchUpload, got %v", err)
+	}
+}
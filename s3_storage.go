@@ -1,17 +1,23 @@
 package storage
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/cloudwego/hertz/pkg/common/hlog"
 )
 
@@ -88,21 +94,30 @@ func (s *S3Storage) Upload(ctx context.Context, filePath string, reader io.Reade
 	// 应用上传选项
 	options := ApplyUploadOptions(opts...)
 
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
 	input := &s3.PutObjectInput{
-		Bucket: aws.String(s.config.Bucket),
-		Key:    aws.String(fullKey),
-		Body:   reader,
+		Bucket:      aws.String(s.config.Bucket),
+		Key:         aws.String(fullKey),
+		Body:        reader,
+		ContentType: aws.String(contentType),
 	}
 
-	// 如果设置了有效期，添加过期时间
+	// 如果设置了有效期，记录删除截止时间并打上生命周期标签（Expires 头仅影响缓存）
 	if options.Expiration > 0 {
-		expiration := time.Now().Add(options.Expiration)
+		expiration := expirationDeadline(options.Expiration)
 		input.Expires = aws.Time(expiration)
+		input.Metadata = map[string]string{ExpiresAtMetaKey: formatExpiresAt(expiration)}
+		input.Tagging = aws.String(url.Values{ExpireDaysTagKey: {expireDaysTagValue(options.Expiration)}}.Encode())
 		hlog.CtxDebugf(ctx, "设置S3文件过期时间: %v", expiration)
 	}
 
 	// 使用流式上传
-	_, err := s.client.PutObject(ctx, input)
+	err = s.putObject(ctx, input)
 	if err != nil {
 		hlog.CtxErrorf(ctx, "S3上传文件失败: %v", err)
 		return err
@@ -112,6 +127,108 @@ func (s *S3Storage) Upload(ctx context.Context, filePath string, reader io.Reade
 	return nil
 }
 
+// s3PartSize 不可 Seek 的流分片上传时每个分片的大小（S3 要求除最后一个分片外不小于 5MiB）
+const s3PartSize = 5 << 20
+
+// putObject 上传对象。
+// SDK 在非 TLS 连接上无法对不可 Seek 的流计算校验和，因此这类流按分片读入内存：
+// 不超过一个分片时直接 PutObject，否则使用分片上传，内存占用不超过一个分片。
+func (s *S3Storage) putObject(ctx context.Context, input *s3.PutObjectInput) error {
+	if _, ok := input.Body.(io.ReadSeeker); ok {
+		_, err := s.client.PutObject(ctx, input)
+		return err
+	}
+
+	body := input.Body
+	buf := make([]byte, s3PartSize)
+	n, err := io.ReadFull(body, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		input.Body = bytes.NewReader(buf[:n])
+		_, err = s.client.PutObject(ctx, input)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	return s.multipartUpload(ctx, input, body, buf)
+}
+
+// multipartUpload 分片上传 body，buf 中已读入第一个分片，失败时中止上传
+func (s *S3Storage) multipartUpload(ctx context.Context, input *s3.PutObjectInput, body io.Reader, buf []byte) error {
+	created, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      input.Bucket,
+		Key:         input.Key,
+		ContentType: input.ContentType,
+		Expires:     input.Expires,
+		Metadata:    input.Metadata,
+		Tagging:     input.Tagging,
+	})
+	if err != nil {
+		return err
+	}
+
+	abort := func(err error) error {
+		_, abortErr := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+			Bucket:   input.Bucket,
+			Key:      input.Key,
+			UploadId: created.UploadId,
+		})
+		if abortErr != nil {
+			hlog.CtxErrorf(ctx, "中止S3分片上传失败: %v", abortErr)
+		}
+		return err
+	}
+
+	var parts []types.CompletedPart
+	part := buf
+	for partNumber := int32(1); ; partNumber++ {
+		output, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+			Bucket:     input.Bucket,
+			Key:        input.Key,
+			UploadId:   created.UploadId,
+			PartNumber: aws.Int32(partNumber),
+			Body:       bytes.NewReader(part),
+		})
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, types.CompletedPart{
+			ETag:          output.ETag,
+			PartNumber:    aws.Int32(partNumber),
+			ChecksumCRC32: output.ChecksumCRC32,
+		})
+
+		n, err := io.ReadFull(body, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return abort(err)
+		}
+		part = buf[:n]
+	}
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          input.Bucket,
+		Key:             input.Key,
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abort(err)
+	}
+	return nil
+}
+
+// s3Error 将对象不存在的响应（404）转换为 ErrNotExist
+func s3Error(err error) error {
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) && respErr.HTTPStatusCode() == http.StatusNotFound {
+		return &notExistError{err: err}
+	}
+	return err
+}
+
 // Download 实现从S3下载文件（流式下载）
 func (s *S3Storage) Download(ctx context.Context, filePath string) (io.Reader, error) {
 	hlog.CtxInfof(ctx, "开始从S3下载文件: %s", filePath)
@@ -124,7 +241,11 @@ func (s *S3Storage) Download(ctx context.Context, filePath string) (io.Reader, e
 	})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "S3获取文件失败: %v", err)
-		return nil, err
+		return nil, s3Error(err)
+	}
+	if isExpired(parseExpiresAt(lookupMetadata(output.Metadata, ExpiresAtMetaKey))) {
+		output.Body.Close()
+		return nil, errExpired(filePath)
 	}
 
 	hlog.CtxInfof(ctx, "S3文件下载已启动: %s", filePath)
@@ -145,7 +266,11 @@ func (s *S3Storage) DownloadRange(ctx context.Context, filePath string, offset i
 	})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "S3获取文件范围失败: %v", err)
-		return nil, err
+		return nil, s3Error(err)
+	}
+	if isExpired(parseExpiresAt(lookupMetadata(output.Metadata, ExpiresAtMetaKey))) {
+		output.Body.Close()
+		return nil, errExpired(filePath)
 	}
 
 	hlog.CtxInfof(ctx, "S3文件断点续传下载已启动: %s", filePath)
@@ -186,7 +311,7 @@ func (s *S3Storage) Rename(ctx context.Context, oldPath string, newPath string)
 	})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "S3复制文件失败: %v", err)
-		return err
+		return s3Error(err)
 	}
 
 	// 删除旧文件
@@ -220,24 +345,59 @@ func (s *S3Storage) Copy(ctx context.Context, srcPath string, dstPath string) er
 	})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "S3复制文件失败: %v", err)
-		return err
+		return s3Error(err)
 	}
 
 	hlog.CtxInfof(ctx, "S3文件复制成功: %s -> %s", srcPath, dstPath)
 	return nil
 }
 
-// Exists 实现检查S3文件是否存在
+// CopyFrom 实现 ServerCopier，src 是同一服务、相同凭证的 S3 存储时在服务端复制（可以跨存储桶）
+func (s *S3Storage) CopyFrom(ctx context.Context, src Storage, srcPath string, dstPath string) error {
+	other, ok := As[*S3Storage](src)
+	if !ok || !sameS3Account(other.config, s.config) {
+		return fmt.Errorf("S3无法在服务端从该存储复制: %w", ErrNotSupported)
+	}
+	hlog.CtxInfof(ctx, "开始在S3服务端复制文件: %s/%s -> %s/%s", other.config.Bucket, srcPath, s.config.Bucket, dstPath)
+
+	srcFullKey := filepath.Join(other.config.BaseDir, srcPath)
+	dstFullKey := filepath.Join(s.config.BaseDir, dstPath)
+
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.config.Bucket),
+		Key:        aws.String(dstFullKey),
+		CopySource: aws.String(url.PathEscape(other.config.Bucket + "/" + srcFullKey)),
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "S3服务端复制文件失败: %v", err)
+		return s3Error(err)
+	}
+
+	hlog.CtxInfof(ctx, "S3服务端复制文件成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// sameS3Account 判断两个配置是否使用同一服务和凭证（存储桶和基础目录可以不同）
+func sameS3Account(a, b S3StorageConfig) bool {
+	a.Bucket, a.BaseDir = "", ""
+	b.Bucket, b.BaseDir = "", ""
+	return a == b
+}
+
+// Exists 实现检查S3文件是否存在（已过期的文件视为不存在）
 func (s *S3Storage) Exists(ctx context.Context, filePath string) (bool, error) {
 	fullKey := filepath.Join(s.config.BaseDir, filePath)
-	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(s.config.Bucket),
 		Key:    aws.String(fullKey),
 	})
 	if err != nil {
-		return false, nil
+		if errors.Is(s3Error(err), ErrNotExist) {
+			return false, nil
+		}
+		return false, err
 	}
-	return true, nil
+	return !isExpired(parseExpiresAt(lookupMetadata(output.Metadata, ExpiresAtMetaKey))), nil
 }
 
 // CreateDir 实现S3目录创建。
@@ -319,7 +479,8 @@ func (s *S3Storage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata
 				Size:     *object.Size,
 				ModTime:  *object.LastModified,
 				IsDir:    false,
-				MIMEType: "application/octet-stream",
+				MIMEType: mimeTypeByName(name),
+				ETag:     trimETag(aws.ToString(object.ETag)),
 			}
 			fileMetas = append(fileMetas, fileMeta)
 		}
@@ -358,7 +519,10 @@ func (s *S3Storage) GetMetadata(ctx context.Context, filePath string) (*FileMeta
 	})
 	if err != nil {
 		hlog.CtxErrorf(ctx, "获取S3文件信息失败: %v", err)
-		return nil, err
+		return nil, s3Error(err)
+	}
+	if isExpired(parseExpiresAt(lookupMetadata(output.Metadata, ExpiresAtMetaKey))) {
+		return nil, errExpired(filePath)
 	}
 
 	// 构建元数据对象
@@ -367,7 +531,10 @@ func (s *S3Storage) GetMetadata(ctx context.Context, filePath string) (*FileMeta
 		Size:     *output.ContentLength,
 		ModTime:  *output.LastModified,
 		IsDir:    false,
-		MIMEType: aws.ToString(output.ContentType),
+		MIMEType: contentTypeOrDetect(aws.ToString(output.ContentType), filePath),
+		ETag:     trimETag(aws.ToString(output.ETag)),
+		// 使用 SSE-KMS 或 SSE-C 加密的对象，ETag 不是内容的 MD5
+		ETagMD5: output.SSECustomerAlgorithm == nil && output.ServerSideEncryption != types.ServerSideEncryptionAwsKms && output.ServerSideEncryption != types.ServerSideEncryptionAwsKmsDsse,
 	}
 
 	hlog.CtxInfof(ctx, "成功获取S3文件元数据: %s", filePath)
@@ -378,7 +545,74 @@ func (s *S3Storage) GetMetadata(ctx context.Context, filePath string) (*FileMeta
 func (s *S3Storage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
 	hlog.CtxInfof(ctx, "开始更新S3文件元数据: %s", filePath)
 	hlog.CtxErrorf(ctx, "S3不支持直接更新元数据")
-	return fmt.Errorf("S3不支持直接更新元数据")
+	return fmt.Errorf("S3不支持直接更新元数据: %w", ErrNotSupported)
+}
+
+// GetExpiration 读取S3文件的删除截止时间（不检查是否已过期）
+func (s *S3Storage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	fullKey := filepath.Join(s.config.BaseDir, filePath)
+
+	output, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(s.config.Bucket),
+		Key:    aws.String(fullKey),
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取S3文件信息失败: %v", err)
+		return time.Time{}, s3Error(err)
+	}
+	return parseExpiresAt(lookupMetadata(output.Metadata, ExpiresAtMetaKey)), nil
+}
+
+// EnsureExpirationLifecycle 在S3存储桶上安装按有效期标签删除对象的生命周期规则
+func (s *S3Storage) EnsureExpirationLifecycle(ctx context.Context, days ...int) error {
+	hlog.CtxInfof(ctx, "开始安装S3生命周期规则: %v", days)
+
+	days, err := normalizeExpireDays(days)
+	if err != nil {
+		return err
+	}
+
+	// PutBucketLifecycleConfiguration 会覆盖整个配置，需要保留非本包安装的规则
+	var rules []types.LifecycleRule
+	current, err := s.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(s.config.Bucket),
+	})
+	if err != nil {
+		var apiErr smithy.APIError
+		if !errors.As(err, &apiErr) || apiErr.ErrorCode() != "NoSuchLifecycleConfiguration" {
+			hlog.CtxErrorf(ctx, "获取S3生命周期规则失败: %v", err)
+			return err
+		}
+	} else {
+		for _, rule := range current.Rules {
+			if !isExpirationRuleID(aws.ToString(rule.ID)) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	for _, d := range days {
+		rules = append(rules, types.LifecycleRule{
+			ID:     aws.String(expirationRuleID(d)),
+			Status: types.ExpirationStatusEnabled,
+			Filter: &types.LifecycleRuleFilter{
+				Tag: &types.Tag{Key: aws.String(ExpireDaysTagKey), Value: aws.String(strconv.Itoa(d))},
+			},
+			Expiration: &types.LifecycleExpiration{Days: aws.Int32(int32(d))},
+		})
+	}
+
+	_, err = s.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(s.config.Bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "安装S3生命周期规则失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "S3生命周期规则安装成功: %v", days)
+	return nil
 }
 
 // BatchUpload 实现S3批量上传
@@ -0,0 +1,881 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// sftpDefaultPort 默认的SSH端口
+	sftpDefaultPort = 22
+	// sftpDefaultPoolSize 默认的连接池大小
+	sftpDefaultPoolSize = 4
+	// sftpDefaultTimeout 默认的连接超时时间
+	sftpDefaultTimeout = 10 * time.Second
+)
+
+// SFTPStorageConfig SFTP 存储配置。
+// 认证方式可以组合使用：密码、私钥（PrivateKey 或 PrivateKeyFile）和 ssh-agent（UseAgent）。
+// 主机密钥默认按 KnownHostsFile（为空时为 ~/.ssh/known_hosts）校验。
+type SFTPStorageConfig struct {
+	Host                  string        `json:"host"`                     // 服务器地址
+	Port                  int           `json:"port"`                     // 服务器端口，默认 22
+	User                  string        `json:"user"`                     // 用户名
+	Password              string        `json:"password"`                 // 密码（同时用于 keyboard-interactive 认证）
+	PrivateKey            string        `json:"private_key"`              // PEM 格式的私钥内容
+	PrivateKeyFile        string        `json:"private_key_file"`         // 私钥文件路径
+	Passphrase            string        `json:"passphrase"`               // 私钥密码
+	UseAgent              bool          `json:"use_agent"`                // 使用 SSH_AUTH_SOCK 指定的 ssh-agent 中的密钥
+	KnownHostsFile        string        `json:"known_hosts_file"`         // known_hosts 文件路径，默认 ~/.ssh/known_hosts
+	InsecureIgnoreHostKey bool          `json:"insecure_ignore_host_key"` // 不校验主机密钥（仅用于测试环境）
+	BaseDir               string        `json:"base_dir"`                 // 存储基础目录（服务器上的路径）
+	PoolSize              int           `json:"pool_size"`                // 连接池大小，默认 4
+	Timeout               time.Duration `json:"timeout"`                  // 连接超时时间，默认 10 秒
+}
+
+// SFTPStorage SFTP 存储实现。
+// 维护固定数量的SSH连接并轮流使用（每个连接上的SFTP会话可以并发处理多个请求），
+// 连接断开后在下次使用时自动重连。附加元数据（有效期、MIME 类型）与本地存储相同，
+// 保存在 <BaseDir>/.storage-meta/ 下。
+type SFTPStorage struct {
+	config    SFTPStorageConfig
+	sshConfig *ssh.ClientConfig
+	agentConn net.Conn
+
+	mu    sync.Mutex
+	conns []*sftpConn
+	next  int
+}
+
+// sftpConn 连接池中的一个连接
+type sftpConn struct {
+	ssh    *ssh.Client
+	client *sftp.Client
+	closed chan struct{} // SSH 连接断开后关闭
+}
+
+// alive 判断连接是否仍然可用
+func (c *sftpConn) alive() bool {
+	select {
+	case <-c.closed:
+		return false
+	default:
+		return true
+	}
+}
+
+// close 关闭SFTP会话和SSH连接
+func (c *sftpConn) close() {
+	c.client.Close()
+	c.ssh.Close()
+}
+
+// NewSFTPStorage 创建新的SFTP存储实例，创建时建立第一个连接以校验配置
+func NewSFTPStorage(config SFTPStorageConfig) Storage {
+	if config.Port <= 0 {
+		config.Port = sftpDefaultPort
+	}
+	if config.PoolSize <= 0 {
+		config.PoolSize = sftpDefaultPoolSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = sftpDefaultTimeout
+	}
+
+	s := &SFTPStorage{
+		config: config,
+		conns:  make([]*sftpConn, config.PoolSize),
+	}
+
+	auth, err := s.authMethods()
+	if err != nil {
+		hlog.Errorf("加载SFTP认证信息失败: %v", err)
+		s.Close()
+		return nil
+	}
+	hostKeyCallback, err := s.hostKeyCallback()
+	if err != nil {
+		hlog.Errorf("加载SFTP主机密钥失败: %v", err)
+		s.Close()
+		return nil
+	}
+	s.sshConfig = &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         config.Timeout,
+	}
+
+	// 建立第一个连接并确保基础目录存在
+	client, err := s.client()
+	if err != nil {
+		hlog.Errorf("连接SFTP服务器失败: %v", err)
+		s.Close()
+		return nil
+	}
+	if config.BaseDir != "" {
+		if err := client.MkdirAll(config.BaseDir); err != nil {
+			hlog.Errorf("创建SFTP基础目录失败: %v", err)
+			s.Close()
+			return nil
+		}
+	}
+	return s
+}
+
+// authMethods 根据配置组合认证方式
+func (s *SFTPStorage) authMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+
+	keyData := []byte(s.config.PrivateKey)
+	if len(keyData) == 0 && s.config.PrivateKeyFile != "" {
+		data, err := os.ReadFile(s.config.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		keyData = data
+	}
+	if len(keyData) > 0 {
+		var signer ssh.Signer
+		var err error
+		if s.config.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(keyData, []byte(s.config.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(keyData)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析私钥失败: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+
+	if s.config.UseAgent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return nil, errors.New("未设置 SSH_AUTH_SOCK，无法使用 ssh-agent")
+		}
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("连接 ssh-agent 失败: %w", err)
+		}
+		s.agentConn = conn
+		methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+	}
+
+	if s.config.Password != "" {
+		password := s.config.Password
+		methods = append(methods,
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		)
+	}
+
+	if len(methods) == 0 {
+		return nil, errors.New("未配置任何认证方式（密码、私钥或 ssh-agent）")
+	}
+	return methods, nil
+}
+
+// hostKeyCallback 返回主机密钥校验方式
+func (s *SFTPStorage) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if s.config.InsecureIgnoreHostKey {
+		hlog.Warnf("SFTP未校验主机密钥，存在中间人攻击风险: %s", s.config.Host)
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+
+	file := s.config.KnownHostsFile
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	return knownhosts.New(file)
+}
+
+// client 轮流返回连接池中的连接，连接不存在或已断开时重新建立
+func (s *SFTPStorage) client() (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.next
+	s.next = (s.next + 1) % len(s.conns)
+	if conn := s.conns[i]; conn != nil {
+		if conn.alive() {
+			return conn.client, nil
+		}
+		conn.close()
+		s.conns[i] = nil
+	}
+
+	conn, err := s.dial()
+	if err != nil {
+		return nil, err
+	}
+	s.conns[i] = conn
+	return conn.client, nil
+}
+
+// dial 建立新的SSH连接并打开SFTP会话
+func (s *SFTPStorage) dial() (*sftpConn, error) {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	sshClient, err := ssh.Dial("tcp", addr, s.sshConfig)
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+
+	conn := &sftpConn{ssh: sshClient, client: client, closed: make(chan struct{})}
+	go func() {
+		sshClient.Wait()
+		close(conn.closed)
+	}()
+	return conn, nil
+}
+
+// Close 关闭连接池中的所有连接
+func (s *SFTPStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, conn := range s.conns {
+		if conn != nil {
+			conn.close()
+			s.conns[i] = nil
+		}
+	}
+	if s.agentConn != nil {
+		s.agentConn.Close()
+		s.agentConn = nil
+	}
+	return nil
+}
+
+// fullPath 返回服务器上的完整路径（SFTP 路径总是使用正斜杠）
+func (s *SFTPStorage) fullPath(filePath string) string {
+	return path.Join(s.config.BaseDir, filePath)
+}
+
+// Upload 实现SFTP文件上传。
+// 先写入同目录下的临时文件再重命名，读取方不会看到不完整的文件；设置有效期时删除截止时间记录在附加元数据中。
+func (s *SFTPStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到SFTP: %s", filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	if err := s.writeFile(client, s.fullPath(filePath), reader); err != nil {
+		hlog.CtxErrorf(ctx, "SFTP写入文件失败: %v", err)
+		return err
+	}
+
+	// 覆盖上传时同时覆盖旧的附加元数据；能由扩展名推断的类型无需记录
+	meta := &localObjectMeta{}
+	if contentType != MIMETypeByExtension(filePath) {
+		meta.ContentType = contentType
+	}
+	if options.Expiration > 0 {
+		deadline := expirationDeadline(options.Expiration)
+		meta.ExpiresAt = &deadline
+		hlog.CtxDebugf(ctx, "设置SFTP文件过期时间: %v", deadline)
+	}
+	if err := s.writeMeta(client, filePath, meta); err != nil {
+		hlog.CtxErrorf(ctx, "写入文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件上传成功: %s", filePath)
+	return nil
+}
+
+// writeFile 将内容写入临时文件后重命名为目标文件
+func (s *SFTPStorage) writeFile(client *sftp.Client, fullPath string, reader io.Reader) error {
+	dir := path.Dir(fullPath)
+	if err := client.MkdirAll(dir); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tempPath := path.Join(dir, localUploadTempPrefix+hex.EncodeToString(suffix))
+
+	file, err := client.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	_, err = file.ReadFrom(reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.rename(client, tempPath, fullPath)
+	}
+	if err != nil {
+		// 上传中断时删除临时文件，已有文件保持不变
+		client.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// rename 重命名并覆盖已存在的目标文件。
+// 优先使用 posix-rename 扩展（原子覆盖），服务器不支持时先删除目标文件再重命名。
+func (s *SFTPStorage) rename(client *sftp.Client, oldPath, newPath string) error {
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		return client.PosixRename(oldPath, newPath)
+	}
+	// 删除目标文件前确认源文件存在，避免重命名失败时丢失已有的目标文件
+	if _, err := client.Lstat(oldPath); err != nil {
+		return err
+	}
+	if err := client.Remove(newPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return client.Rename(oldPath, newPath)
+}
+
+// UploadAt 实现 ResumableUploader，从 offset 处续传SFTP文件
+// offset 为 0 时文件可以不存在；offset 超过当前文件大小时返回错误。
+func (s *SFTPStorage) UploadAt(ctx context.Context, filePath string, reader io.Reader, offset int64) error {
+	hlog.CtxInfof(ctx, "开始续传文件到SFTP: %s, offset: %d", filePath, offset)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	fullPath := s.fullPath(filePath)
+	if offset == 0 {
+		if err := client.MkdirAll(path.Dir(fullPath)); err != nil {
+			hlog.CtxErrorf(ctx, "创建目录失败: %v", err)
+			return err
+		}
+	}
+
+	file, err := client.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "打开SFTP文件失败: %v", err)
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取SFTP文件信息失败: %v", err)
+		return err
+	}
+	if offset > info.Size() {
+		return fmt.Errorf("续传位置 %d 超过文件 %s 的当前大小 %d", offset, filePath, info.Size())
+	}
+	if err := file.Truncate(offset); err != nil {
+		hlog.CtxErrorf(ctx, "截断SFTP文件失败: %v", err)
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		hlog.CtxErrorf(ctx, "设置文件偏移量失败: %v", err)
+		return err
+	}
+	if _, err := file.ReadFrom(reader); err != nil {
+		hlog.CtxErrorf(ctx, "SFTP续传文件失败: %v", err)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		hlog.CtxErrorf(ctx, "SFTP续传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件续传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从SFTP下载文件（流式下载）
+func (s *SFTPStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从SFTP下载文件: %s", filePath)
+
+	file, err := s.open(filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "SFTP打开文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件下载已启动: %s", filePath)
+	return file, nil // 返回原始的文件，由调用方负责关闭
+}
+
+// DownloadRange 实现从SFTP下载文件（支持断点续传）
+func (s *SFTPStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从SFTP下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	file, err := s.open(filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "SFTP打开文件失败: %v", err)
+		return nil, err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		hlog.CtxErrorf(ctx, "设置文件偏移量失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件断点续传下载已启动: %s", filePath)
+	return &limitedReadCloser{Reader: io.LimitReader(file, size), Closer: file}, nil
+}
+
+// open 打开文件用于读取，已过期的文件返回 ErrNotExist
+func (s *SFTPStorage) open(filePath string) (*sftp.File, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkExpired(client, filePath); err != nil {
+		return nil, err
+	}
+	return client.Open(s.fullPath(filePath))
+}
+
+// limitedReadCloser 限制读取长度，关闭时关闭底层文件
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// Delete 实现SFTP文件删除（删除不存在的文件不报错）
+func (s *SFTPStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从SFTP删除文件: %s", filePath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	if err := client.Remove(s.fullPath(filePath)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		hlog.CtxErrorf(ctx, "SFTP删除文件失败: %v", err)
+		return err
+	}
+	if err := s.removeMeta(client, filePath); err != nil {
+		hlog.CtxErrorf(ctx, "删除文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件删除成功: %s", filePath)
+	return nil
+}
+
+// Rename 实现SFTP文件重命名（覆盖已存在的目标文件）
+func (s *SFTPStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在SFTP中重命名文件: %s -> %s", oldPath, newPath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	newFullPath := s.fullPath(newPath)
+	// 确保目标目录存在
+	if err := client.MkdirAll(path.Dir(newFullPath)); err != nil {
+		hlog.CtxErrorf(ctx, "创建目标目录失败: %v", err)
+		return err
+	}
+	if err := s.rename(client, s.fullPath(oldPath), newFullPath); err != nil {
+		hlog.CtxErrorf(ctx, "SFTP文件重命名失败: %v", err)
+		return err
+	}
+	renameMeta := s.renameMeta
+	if info, err := client.Stat(newFullPath); err == nil && info.IsDir() {
+		renameMeta = s.renameMetaDir
+	}
+	if err := renameMeta(client, oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "移动文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现SFTP文件移动（与重命名相同的操作）
+func (s *SFTPStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现SFTP文件复制。SFTP 协议没有服务端复制，内容经由客户端中转。
+func (s *SFTPStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在SFTP中复制文件: %s -> %s", srcPath, dstPath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	src, err := client.Open(s.fullPath(srcPath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "打开源文件失败: %v", err)
+		return err
+	}
+	defer src.Close()
+
+	if err := s.writeFile(client, s.fullPath(dstPath), src); err != nil {
+		hlog.CtxErrorf(ctx, "复制文件内容失败: %v", err)
+		return err
+	}
+
+	meta, err := s.readMeta(client, srcPath)
+	if err == nil {
+		err = s.writeMeta(client, dstPath, meta)
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "复制文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Exists 实现检查SFTP文件是否存在（已过期的文件视为不存在）
+func (s *SFTPStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	client, err := s.client()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = client.Stat(s.fullPath(filePath))
+	if err == nil {
+		err = s.checkExpired(client, filePath)
+	}
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+// CreateDir 实现SFTP目录创建（递归创建）
+func (s *SFTPStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始创建SFTP目录: %s", dirPath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+	if err := client.MkdirAll(s.fullPath(dirPath)); err != nil {
+		hlog.CtxErrorf(ctx, "创建SFTP目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "SFTP目录创建成功: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现SFTP目录删除（递归删除目录及其附加元数据）
+func (s *SFTPStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除SFTP目录: %s", dirPath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	for _, dir := range []string{s.fullPath(dirPath), s.metaDirPath(dirPath)} {
+		if err := client.RemoveAll(dir); err != nil && !errors.Is(err, os.ErrNotExist) {
+			hlog.CtxErrorf(ctx, "删除SFTP目录失败: %v", err)
+			return err
+		}
+	}
+
+	hlog.CtxInfof(ctx, "SFTP目录删除成功: %s", dirPath)
+	return nil
+}
+
+// ListDir 实现SFTP目录列表（仅列出当前层级，不递归）
+func (s *SFTPStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出SFTP目录内容: %s", dirPath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return nil, err
+	}
+
+	fullPath := s.fullPath(dirPath)
+	entries, err := client.ReadDir(fullPath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出SFTP目录内容失败: %v", err)
+		return nil, err
+	}
+
+	isRoot := fullPath == path.Clean(s.fullPath(""))
+
+	files := make([]FileMetadata, 0, len(entries))
+	for _, entry := range entries {
+		// 跳过存放附加元数据的隐藏目录和上传中的临时文件
+		if isRoot && entry.Name() == localMetaDir || strings.HasPrefix(entry.Name(), localUploadTempPrefix) {
+			continue
+		}
+		metadata := FileMetadata{
+			Name:    entry.Name(),
+			Size:    entry.Size(),
+			ModTime: entry.ModTime(),
+			IsDir:   entry.IsDir(),
+		}
+		if !entry.IsDir() {
+			entryPath := path.Join(dirPath, entry.Name())
+			meta, _ := s.readMeta(client, entryPath)
+			metadata.MIMEType = recordedMIMEType(entryPath, meta)
+		}
+		files = append(files, metadata)
+	}
+
+	hlog.CtxInfof(ctx, "成功列出SFTP目录内容: %s, 共找到 %d 个条目", dirPath, len(files))
+	return files, nil
+}
+
+// GetMetadata 实现获取SFTP文件元数据
+func (s *SFTPStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取SFTP文件元数据: %s", filePath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return nil, err
+	}
+
+	info, err := client.Stat(s.fullPath(filePath))
+	var meta *localObjectMeta
+	if err == nil && !info.IsDir() {
+		if meta, err = s.readMeta(client, filePath); err == nil && meta.ExpiresAt != nil && isExpired(*meta.ExpiresAt) {
+			err = errExpired(filePath)
+		}
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取SFTP文件信息失败: %v", err)
+		return nil, err
+	}
+
+	metadata := &FileMetadata{
+		Name:    filePath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+	}
+	if !info.IsDir() {
+		metadata.MIMEType = recordedMIMEType(filePath, meta)
+	}
+
+	hlog.CtxInfof(ctx, "成功获取SFTP文件元数据: %s", filePath)
+	return metadata, nil
+}
+
+// UpdateMetadata 更新SFTP文件元数据，支持修改修改时间和 MIME 类型
+func (s *SFTPStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新SFTP文件元数据: %s", filePath)
+
+	client, err := s.client()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "连接SFTP服务器失败: %v", err)
+		return err
+	}
+
+	if !metadata.ModTime.IsZero() {
+		if err := client.Chtimes(s.fullPath(filePath), metadata.ModTime, metadata.ModTime); err != nil {
+			hlog.CtxErrorf(ctx, "更新文件时间失败: %v", err)
+			return err
+		}
+	}
+	if metadata.MIMEType != "" {
+		meta, err := s.readMeta(client, filePath)
+		if err == nil {
+			meta.ContentType = metadata.MIMEType
+			err = s.writeMeta(client, filePath, meta)
+		}
+		if err != nil {
+			hlog.CtxErrorf(ctx, "更新文件元数据失败: %v", err)
+			return err
+		}
+	}
+
+	hlog.CtxInfof(ctx, "成功更新SFTP文件元数据: %s", filePath)
+	return nil
+}
+
+// GetExpiration 读取SFTP文件的删除截止时间（不检查是否已过期）
+func (s *SFTPStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	client, err := s.client()
+	if err != nil {
+		return time.Time{}, err
+	}
+	if _, err := client.Stat(s.fullPath(filePath)); err != nil {
+		return time.Time{}, err
+	}
+
+	meta, err := s.readMeta(client, filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取文件元数据失败: %v", err)
+		return time.Time{}, err
+	}
+	if meta.ExpiresAt == nil {
+		return time.Time{}, nil
+	}
+	return *meta.ExpiresAt, nil
+}
+
+// BatchUpload 实现SFTP批量上传
+func (s *SFTPStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到SFTP", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现SFTP批量下载（流式下载）
+func (s *SFTPStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个SFTP文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现SFTP批量删除
+func (s *SFTPStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个SFTP文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
+
+// metaPath 返回文件对应的附加元数据路径
+func (s *SFTPStorage) metaPath(filePath string) string {
+	return path.Join(s.config.BaseDir, localMetaDir, filePath) + ".json"
+}
+
+// metaDirPath 返回目录对应的附加元数据目录
+func (s *SFTPStorage) metaDirPath(dirPath string) string {
+	return path.Join(s.config.BaseDir, localMetaDir, dirPath)
+}
+
+// readMeta 读取文件的附加元数据，不存在时返回空元数据
+func (s *SFTPStorage) readMeta(client *sftp.Client, filePath string) (*localObjectMeta, error) {
+	meta := &localObjectMeta{}
+	file, err := client.Open(s.metaPath(filePath))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return meta, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := json.NewDecoder(file).Decode(meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// writeMeta 写入文件的附加元数据，元数据为空时删除对应文件
+func (s *SFTPStorage) writeMeta(client *sftp.Client, filePath string, meta *localObjectMeta) error {
+	if meta == nil || meta.isEmpty() {
+		return s.removeMeta(client, filePath)
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return s.writeFile(client, s.metaPath(filePath), strings.NewReader(string(data)))
+}
+
+// removeMeta 删除文件的附加元数据
+func (s *SFTPStorage) removeMeta(client *sftp.Client, filePath string) error {
+	err := client.Remove(s.metaPath(filePath))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// renameMeta 随文件一起移动附加元数据
+func (s *SFTPStorage) renameMeta(client *sftp.Client, oldPath, newPath string) error {
+	meta, err := s.readMeta(client, oldPath)
+	if err != nil {
+		return err
+	}
+	if err := s.writeMeta(client, newPath, meta); err != nil {
+		return err
+	}
+	return s.removeMeta(client, oldPath)
+}
+
+// renameMetaDir 随目录一起移动附加元数据目录，先删除目标位置残留的附加元数据
+func (s *SFTPStorage) renameMetaDir(client *sftp.Client, oldPath, newPath string) error {
+	oldMetaDir, newMetaDir := s.metaDirPath(oldPath), s.metaDirPath(newPath)
+	if _, err := client.Stat(oldMetaDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if err := client.RemoveAll(newMetaDir); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := client.MkdirAll(path.Dir(newMetaDir)); err != nil {
+		return err
+	}
+	return client.Rename(oldMetaDir, newMetaDir)
+}
+
+// checkExpired 检查文件是否已过期，过期时返回 ErrNotExist
+func (s *SFTPStorage) checkExpired(client *sftp.Client, filePath string) error {
+	meta, err := s.readMeta(client, filePath)
+	if err != nil {
+		return err
+	}
+	if meta.ExpiresAt != nil && isExpired(*meta.ExpiresAt) {
+		return errExpired(filePath)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package storage_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/v-mars/storage"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sftpTestServer 进程内的SFTP服务器，直接读写本机文件系统
+type sftpTestServer struct {
+	addr       string
+	hostKey    ssh.PublicKey
+	knownHosts string             // 只包含该服务器主机密钥的 known_hosts 文件
+	clientKey  ed25519.PrivateKey // 公钥认证接受的客户端密钥
+	accepted   atomic.Int32
+
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+// newSFTPTestServer 启动接受密码 tester/secret 和 clientKey 公钥认证的SFTP服务器
+func newSFTPTestServer(t *testing.T) *sftpTestServer {
+	t.Helper()
+	server := &sftpTestServer{}
+	hostSigner := newTestSigner(t)
+	server.hostKey = hostSigner.PublicKey()
+	server.clientKey = newTestKey(t)
+	clientSigner, err := ssh.NewSignerFromKey(server.clientKey)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	clientPublicKey := string(clientSigner.PublicKey().Marshal())
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "tester" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) == clientPublicKey {
+				return nil, nil
+			}
+			return nil, os.ErrPermission
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	server.addr = listener.Addr().String()
+	server.knownHosts = filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, server.hostKey)
+	if err := os.WriteFile(server.knownHosts, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write known_hosts: %v", err)
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			server.mu.Lock()
+			server.conns = append(server.conns, conn)
+			server.mu.Unlock()
+			go server.serve(conn, config)
+		}
+	}()
+	t.Cleanup(func() {
+		listener.Close()
+		server.dropConnections()
+	})
+	return server
+}
+
+// serve 处理一个SSH连接，只接受 sftp 子系统
+func (s *sftpTestServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	s.accepted.Add(1)
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					go func() {
+						if server, err := sftp.NewServer(channel); err == nil {
+							server.Serve()
+						}
+						channel.Close()
+					}()
+				}
+			}
+		}()
+	}
+}
+
+// dropConnections 断开所有已建立的连接
+func (s *sftpTestServer) dropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+// config 返回使用密码认证并校验主机密钥的配置
+func (s *sftpTestServer) config(baseDir string) storage.SFTPStorageConfig {
+	host, port, _ := net.SplitHostPort(s.addr)
+	portNum, _ := strconv.Atoi(port)
+	return storage.SFTPStorageConfig{
+		Host:           host,
+		Port:           portNum,
+		User:           "tester",
+		Password:       "secret",
+		KnownHostsFile: s.knownHosts,
+		BaseDir:        baseDir,
+		PoolSize:       2,
+	}
+}
+
+// newTestKey 生成 ed25519 私钥
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
+
+// newTestSigner 生成 ed25519 签名器
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	signer, err := ssh.NewSignerFromKey(newTestKey(t))
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func TestSFTPStorage_Backend(t *testing.T) {
+	server := newSFTPTestServer(t)
+	config := server.config(t.TempDir())
+
+	s := storage.NewSFTPStorage(config)
+	if s == nil {
+		t.Fatal("NewSFTPStorage returned nil")
+	}
+	defer s.(io.Closer).Close()
+	ctx := context.Background()
+
+	// 连接池最多建立 PoolSize 个连接
+	for i := 0; i < 10; i++ {
+		if err := s.Upload(ctx, "pool.txt", strings.NewReader("pooled")); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	if n := server.accepted.Load(); n != 2 {
+		t.Fatalf("Expected 2 pooled connections, got %d", n)
+	}
+
+	// 连接断开后自动重连
+	server.dropConnections()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		ok, err := s.Exists(ctx, "pool.txt")
+		if err == nil && ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Storage did not reconnect: %v, %v", ok, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// 上传的临时文件和附加元数据目录不出现在列表中
+	if err := s.Upload(ctx, "typed", strings.NewReader("{}"), storage.WithContentType("application/json")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	files, err := s.ListDir(ctx, "")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	for _, file := range files {
+		if strings.HasPrefix(file.Name, ".") {
+			t.Fatalf("ListDir should hide %s", file.Name)
+		}
+		if file.Name == "typed" && file.MIMEType != "application/json" {
+			t.Fatalf("Recorded content type not used: %q", file.MIMEType)
+		}
+	}
+
+	// 重命名目录时附加元数据随目录移动
+	if err := s.Upload(ctx, "old/sub/ttl.txt", strings.NewReader("t"), storage.WithExpiration(time.Hour)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Rename(ctx, "old", "new"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	expiration, _ := storage.As[storage.ExpirationReader](s)
+	if deadline, err := expiration.GetExpiration(ctx, "new/sub/ttl.txt"); err != nil || time.Until(deadline) < 59*time.Minute {
+		t.Fatalf("Expiration not moved: %v, %v", deadline, err)
+	}
+
+	// 源文件不存在时重命名失败，已有的目标文件保持不变
+	if err := s.Rename(ctx, "missing.txt", "new/sub/ttl.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "new/sub/ttl.txt")); got != "t" {
+		t.Fatalf("Destination should be kept: %q", got)
+	}
+}
+
+func TestSFTPStorage_HostKeyMismatch(t *testing.T) {
+	server := newSFTPTestServer(t)
+	config := server.config(t.TempDir())
+
+	// known_hosts 中登记的是另一把主机密钥
+	other := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(server.addr)}, newTestSigner(t).PublicKey())
+	if err := os.WriteFile(other, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatalf("Failed to write known_hosts: %v", err)
+	}
+	config.KnownHostsFile = other
+	if s := storage.NewSFTPStorage(config); s != nil {
+		t.Fatal("Expected NewSFTPStorage to fail on host key mismatch")
+	}
+
+	// 明确关闭校验时可以连接
+	config.InsecureIgnoreHostKey = true
+	s := storage.NewSFTPStorage(config)
+	if s == nil {
+		t.Fatal("NewSFTPStorage with InsecureIgnoreHostKey returned nil")
+	}
+	s.(io.Closer).Close()
+}
+
+func TestSFTPStorage_AgentAuth(t *testing.T) {
+	server := newSFTPTestServer(t)
+
+	// 在 unix socket 上提供只包含客户端密钥的 ssh-agent
+	key := server.clientKey
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+		t.Fatalf("Failed to add key to agent: %v", err)
+	}
+	socket := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Failed to listen on agent socket: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	t.Setenv("SSH_AUTH_SOCK", socket)
+
+	config := server.config(t.TempDir())
+	config.Password = ""
+	config.UseAgent = true
+	s := storage.NewSFTPStorage(config)
+	if s == nil {
+		t.Fatal("NewSFTPStorage with agent auth returned nil")
+	}
+	defer s.(io.Closer).Close()
+
+	if err := s.Upload(context.Background(), "agent.txt", strings.NewReader("agent")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 同一把密钥也可以直接以私钥形式配置
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal private key: %v", err)
+	}
+	config.UseAgent = false
+	config.PrivateKey = string(pem.EncodeToMemory(block))
+	keyStorage := storage.NewSFTPStorage(config)
+	if keyStorage == nil {
+		t.Fatal("NewSFTPStorage with private key returned nil")
+	}
+	defer keyStorage.(io.Closer).Close()
+	if got := readString(t)(keyStorage.Download(context.Background(), "agent.txt")); got != "agent" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+}
+
+// readString 读取 Download 返回的全部内容，出错时终止测试
+func readString(t *testing.T) func(io.Reader, error) string {
+	return func(reader io.Reader, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("Download failed: %v", err)
+		}
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Read failed: %v", err)
+		}
+		return string(data)
+	}
+}
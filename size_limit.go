@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// SizeLimitedStorage 限制单个文件上传大小的存储装饰器。
+// 上传流在读取过程中计数，一旦超过限制立即中止上传并返回 *SizeLimitError，不会缓冲数据。
+type SizeLimitedStorage struct {
+	Storage
+	maxSize int64
+}
+
+// NewSizeLimitedStorage 创建限制上传大小的存储，maxSize <= 0 时直接返回原存储
+func NewSizeLimitedStorage(s Storage, maxSize int64) Storage {
+	if s == nil || maxSize <= 0 {
+		return s
+	}
+	return &SizeLimitedStorage{
+		Storage: s,
+		maxSize: maxSize,
+	}
+}
+
+// Unwrap 返回被包装的存储
+func (s *SizeLimitedStorage) Unwrap() Storage {
+	return s.Storage
+}
+
+// MaxSize 返回单个文件的大小限制（字节）
+func (s *SizeLimitedStorage) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload 上传文件，超过大小限制时返回 *SizeLimitError
+func (s *SizeLimitedStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	limited := s.limit(filePath, reader)
+	err := s.Storage.Upload(ctx, filePath, limited, opts...)
+	return s.result(ctx, err, limited)
+}
+
+// BatchUpload 批量上传文件，任一文件超过大小限制时返回 *SizeLimitError
+func (s *SizeLimitedStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	limitedFiles := make(map[string]io.Reader, len(files))
+	readers := make([]io.Reader, 0, len(files))
+	for filePath, reader := range files {
+		limited := s.limit(filePath, reader)
+		limitedFiles[filePath] = limited
+		readers = append(readers, limited)
+	}
+	err := s.Storage.BatchUpload(ctx, limitedFiles, opts...)
+	return s.result(ctx, err, readers...)
+}
+
+// limit 包装上传流
+func (s *SizeLimitedStorage) limit(filePath string, reader io.Reader) io.Reader {
+	var read int64
+	return newLimitReader(reader, func(delta int64) error {
+		read += delta
+		if read > s.maxSize {
+			return &SizeLimitError{Path: filePath, Limit: s.maxSize}
+		}
+		return nil
+	})
+}
+
+// result 后端可能包装或吞掉读取错误，优先返回记录下的大小超限错误
+func (s *SizeLimitedStorage) result(ctx context.Context, err error, readers ...io.Reader) error {
+	if err == nil || errors.Is(err, ErrTooLarge) {
+		return err
+	}
+	for _, r := range readers {
+		var limitErr *SizeLimitError
+		if errors.As(limitReaderErr(r), &limitErr) {
+			hlog.CtxErrorf(ctx, "上传文件失败: %v", limitErr)
+			return limitErr
+		}
+	}
+	return err
+}
+
+// limitReader 在每次读取后调用 consume 计数，consume 返回错误时中止读取。
+// 不缓冲任何数据，适用于在流式上传过程中执行大小限制和配额检查。
+type limitReader struct {
+	reader  io.Reader
+	consume func(delta int64) error
+	pos     int64
+	err     error
+}
+
+// limitReadSeeker 在原 reader 可 Seek 时保留 Seek 能力（SDK 重试上传时需要回退）
+type limitReadSeeker struct {
+	*limitReader
+	seeker io.Seeker
+}
+
+// newLimitReader 包装 reader，原 reader 实现 io.Seeker 时返回值同样实现 io.Seeker
+func newLimitReader(reader io.Reader, consume func(delta int64) error) io.Reader {
+	lr := &limitReader{reader: reader, consume: consume}
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		if pos, err := seeker.Seek(0, io.SeekCurrent); err == nil {
+			lr.pos = pos
+			return &limitReadSeeker{limitReader: lr, seeker: seeker}
+		}
+	}
+	return lr
+}
+
+// limitReaderErr 返回 limitReader 记录的计数错误
+func limitReaderErr(r io.Reader) error {
+	switch lr := r.(type) {
+	case *limitReader:
+		return lr.err
+	case *limitReadSeeker:
+		return lr.err
+	}
+	return nil
+}
+
+func (r *limitReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.pos += int64(n)
+		if cerr := r.consume(int64(n)); cerr != nil {
+			r.err = cerr
+			return n, cerr
+		}
+	}
+	return n, err
+}
+
+func (r *limitReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+	delta := pos - r.pos
+	r.pos = pos
+	if delta != 0 {
+		r.err = r.consume(delta)
+	}
+	return pos, nil
+}
@@ -0,0 +1,760 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	// sqlDefaultDriver 默认的 database/sql 驱动名（modernc.org/sqlite）
+	sqlDefaultDriver = "sqlite"
+	// sqlDefaultTablePrefix 默认的表名前缀
+	sqlDefaultTablePrefix = "storage_"
+	// sqlDefaultChunkSize 默认的数据块大小
+	sqlDefaultChunkSize = 256 << 10
+)
+
+// SQL 方言
+const (
+	SQLDialectSQLite   = "sqlite"
+	SQLDialectPostgres = "postgres"
+)
+
+// sqlTablePrefixPattern 表名前缀只允许字母、数字和下划线，避免拼接 SQL 时注入
+var sqlTablePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_]*$`)
+
+// SQLStorageConfig 数据库存储配置。驱动需要由使用方导入，如 modernc.org/sqlite、github.com/jackc/pgx/v5/stdlib。
+type SQLStorageConfig struct {
+	Driver      string  `json:"driver"`       // database/sql 驱动名，默认 sqlite
+	DSN         string  `json:"dsn"`          // 数据源，如 file:/data/storage.db?_pragma=busy_timeout(5000)
+	DB          *sql.DB `json:"-"`            // 已打开的数据库，设置后忽略 Driver 和 DSN，关闭由调用方负责
+	Dialect     string  `json:"dialect"`      // SQL 方言：sqlite、postgres，为空时根据 Driver 推断
+	TablePrefix string  `json:"table_prefix"` // 表名前缀，默认 storage_
+	ChunkSize   int     `json:"chunk_size"`   // 数据块大小（字节），默认 256KiB
+}
+
+// SQLStorage 数据库存储实现，适合不想单独部署对象存储的小型部署。
+// 文件信息保存在 <prefix>files 表中（按 parent 建索引用于目录列表），内容按块保存在 <prefix>chunks 表中，
+// 上传和下载都逐块进行，不会把整个文件读入内存。数据块以随机的 blob_id 关联，覆盖、重命名和复制
+// 只在事务中切换文件记录，读取方不会看到不完整的文件。目录以 is_dir 记录显式保存，上传时自动创建上级目录。
+type SQLStorage struct {
+	config SQLStorageConfig
+	db     *sql.DB
+	ownDB  bool   // 数据库由本实例打开，Close 时关闭
+	files  string // 文件表名
+	chunks string // 数据块表名
+}
+
+// NewSQLStorage 创建新的数据库存储实例，表不存在时自动创建
+func NewSQLStorage(config SQLStorageConfig) Storage {
+	if config.Driver == "" {
+		config.Driver = sqlDefaultDriver
+	}
+	if config.Dialect == "" {
+		config.Dialect = SQLDialectSQLite
+		if config.Driver == "postgres" || config.Driver == "pgx" {
+			config.Dialect = SQLDialectPostgres
+		}
+	}
+	if config.TablePrefix == "" {
+		config.TablePrefix = sqlDefaultTablePrefix
+	}
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = sqlDefaultChunkSize
+	}
+	if config.Dialect != SQLDialectSQLite && config.Dialect != SQLDialectPostgres {
+		hlog.Errorf("不支持的SQL方言: %s", config.Dialect)
+		return nil
+	}
+	if !sqlTablePrefixPattern.MatchString(config.TablePrefix) {
+		hlog.Errorf("表名前缀只能包含字母、数字和下划线: %q", config.TablePrefix)
+		return nil
+	}
+
+	s := &SQLStorage{
+		config: config,
+		db:     config.DB,
+		files:  config.TablePrefix + "files",
+		chunks: config.TablePrefix + "chunks",
+	}
+	if s.db == nil {
+		db, err := sql.Open(config.Driver, config.DSN)
+		if err != nil {
+			hlog.Errorf("打开数据库失败: %v", err)
+			return nil
+		}
+		if config.Dialect == SQLDialectSQLite {
+			// SQLite 同一时间只允许一个写入方，使用单个连接避免 SQLITE_BUSY（:memory: 数据库也只在单个连接内可见）
+			db.SetMaxOpenConns(1)
+		}
+		s.db, s.ownDB = db, true
+	}
+
+	if err := s.migrate(context.Background()); err != nil {
+		hlog.Errorf("创建数据库表失败: %v", err)
+		s.Close()
+		return nil
+	}
+	return s
+}
+
+// migrate 创建文件表、数据块表和目录索引
+func (s *SQLStorage) migrate(ctx context.Context) error {
+	blobType := "BLOB"
+	if s.config.Dialect == SQLDialectPostgres {
+		blobType = "BYTEA"
+	}
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS ` + s.files + ` (
+			path TEXT PRIMARY KEY,
+			parent TEXT NOT NULL,
+			name TEXT NOT NULL,
+			is_dir INTEGER NOT NULL DEFAULT 0,
+			size BIGINT NOT NULL DEFAULT 0,
+			chunk_size INTEGER NOT NULL DEFAULT 0,
+			blob_id TEXT NOT NULL DEFAULT '',
+			content_type TEXT NOT NULL DEFAULT '',
+			mod_time BIGINT NOT NULL,
+			expires_at BIGINT NOT NULL DEFAULT 0
+		)`,
+		`CREATE INDEX IF NOT EXISTS ` + s.files + `_parent ON ` + s.files + ` (parent, name)`,
+		`CREATE TABLE IF NOT EXISTS ` + s.chunks + ` (
+			blob_id TEXT NOT NULL,
+			seq INTEGER NOT NULL,
+			data ` + blobType + ` NOT NULL,
+			PRIMARY KEY (blob_id, seq)
+		)`,
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DB 返回使用的数据库
+func (s *SQLStorage) DB() *sql.DB {
+	return s.db
+}
+
+// Close 关闭由本实例打开的数据库
+func (s *SQLStorage) Close() error {
+	if s.ownDB {
+		return s.db.Close()
+	}
+	return nil
+}
+
+// sqlQueryer 数据库和事务共有的查询方法
+type sqlQueryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// rebind 将 ? 占位符转换为方言对应的形式（Postgres 使用 $1、$2…）
+func (s *SQLStorage) rebind(query string) string {
+	if s.config.Dialect != SQLDialectPostgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// exec 执行语句
+func (s *SQLStorage) exec(ctx context.Context, q sqlQueryer, query string, args ...any) (sql.Result, error) {
+	return q.ExecContext(ctx, s.rebind(query), args...)
+}
+
+// inTx 在事务中执行 fn，fn 返回错误时回滚
+func (s *SQLStorage) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// sqlKey 规范化文件路径：去掉首尾斜杠，根目录为空字符串
+func sqlKey(filePath string) string {
+	return strings.Trim(path.Clean("/"+filePath), "/")
+}
+
+// sqlParent 返回上级目录的键
+func sqlParent(key string) string {
+	if i := strings.LastIndex(key, "/"); i >= 0 {
+		return key[:i]
+	}
+	return ""
+}
+
+// sqlLikePrefix 返回匹配目录下所有条目的 LIKE 模式（以 \ 转义）
+func sqlLikePrefix(key string) string {
+	if key == "" {
+		return "%"
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(key)
+	return escaped + "/%"
+}
+
+// sqlFile 文件表中的一行
+type sqlFile struct {
+	path        string
+	isDir       bool
+	size        int64
+	chunkSize   int64
+	blobID      string
+	contentType string
+	modTime     time.Time
+	expiresAt   time.Time
+}
+
+// metadata 将文件记录转换为 FileMetadata
+func (f *sqlFile) metadata(name string) FileMetadata {
+	return FileMetadata{
+		Name:     name,
+		Size:     f.size,
+		ModTime:  f.modTime,
+		IsDir:    f.isDir,
+		MIMEType: f.contentType,
+	}
+}
+
+// sqlFileColumns 查询文件记录时的列
+const sqlFileColumns = "path, is_dir, size, chunk_size, blob_id, content_type, mod_time, expires_at"
+
+// scanFile 读取一行文件记录
+func scanFile(scanner interface{ Scan(...any) error }) (*sqlFile, error) {
+	var file sqlFile
+	var isDir int
+	var modTime, expiresAt int64
+	if err := scanner.Scan(&file.path, &isDir, &file.size, &file.chunkSize, &file.blobID, &file.contentType, &modTime, &expiresAt); err != nil {
+		return nil, err
+	}
+	file.isDir = isDir != 0
+	file.modTime = time.Unix(0, modTime)
+	if expiresAt != 0 {
+		file.expiresAt = time.Unix(0, expiresAt)
+	}
+	return &file, nil
+}
+
+// getFile 按路径读取文件记录，不存在时返回 ErrNotExist
+func (s *SQLStorage) getFile(ctx context.Context, q sqlQueryer, key string) (*sqlFile, error) {
+	row := q.QueryRowContext(ctx, s.rebind("SELECT "+sqlFileColumns+" FROM "+s.files+" WHERE path = ?"), key)
+	file, err := scanFile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, &notExistError{err: fmt.Errorf("文件不存在: %s", key)}
+	}
+	return file, err
+}
+
+// getRegularFile 读取未过期的普通文件记录，目录和已过期的文件视为不存在
+func (s *SQLStorage) getRegularFile(ctx context.Context, q sqlQueryer, key string) (*sqlFile, error) {
+	file, err := s.getFile(ctx, q, key)
+	if err != nil {
+		return nil, err
+	}
+	if file.isDir {
+		return nil, &notExistError{err: fmt.Errorf("%s 是目录", key)}
+	}
+	if !file.expiresAt.IsZero() && isExpired(file.expiresAt) {
+		return nil, errExpired(key)
+	}
+	return file, nil
+}
+
+// ensureParents 逐级创建目录记录（已存在时忽略）
+func (s *SQLStorage) ensureParents(ctx context.Context, tx *sql.Tx, dir string) error {
+	now := time.Now().UnixNano()
+	for ; dir != ""; dir = sqlParent(dir) {
+		_, err := s.exec(ctx, tx,
+			"INSERT INTO "+s.files+" (path, parent, name, is_dir, mod_time) VALUES (?, ?, ?, 1, ?) ON CONFLICT (path) DO NOTHING",
+			dir, sqlParent(dir), path.Base(dir), now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceFile 在事务中写入文件记录，覆盖同路径的旧文件并删除其数据块
+func (s *SQLStorage) replaceFile(ctx context.Context, tx *sql.Tx, key string, file *sqlFile) error {
+	old, err := s.getFile(ctx, tx, key)
+	switch {
+	case err == nil && old.isDir:
+		return fmt.Errorf("%s 是目录", key)
+	case err == nil:
+		if _, err := s.exec(ctx, tx, "DELETE FROM "+s.chunks+" WHERE blob_id = ?", old.blobID); err != nil {
+			return err
+		}
+	case !errors.Is(err, ErrNotExist):
+		return err
+	}
+
+	if err := s.ensureParents(ctx, tx, sqlParent(key)); err != nil {
+		return err
+	}
+	var expiresAt int64
+	if !file.expiresAt.IsZero() {
+		expiresAt = file.expiresAt.UnixNano()
+	}
+	_, err = s.exec(ctx, tx,
+		"INSERT INTO "+s.files+" (path, parent, name, is_dir, size, chunk_size, blob_id, content_type, mod_time, expires_at) "+
+			"VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?, ?) ON CONFLICT (path) DO UPDATE SET "+
+			"size = excluded.size, chunk_size = excluded.chunk_size, blob_id = excluded.blob_id, "+
+			"content_type = excluded.content_type, mod_time = excluded.mod_time, expires_at = excluded.expires_at",
+		key, sqlParent(key), path.Base(key), file.size, file.chunkSize, file.blobID, file.contentType, file.modTime.UnixNano(), expiresAt)
+	return err
+}
+
+// newBlobID 生成新的数据块组标识
+func newBlobID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// Upload 实现数据库文件上传。
+// 内容逐块写入新的 blob_id 下，全部写入后在事务中切换文件记录并删除旧的数据块。
+func (s *SQLStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到数据库: %s", filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	key := sqlKey(filePath)
+	if key == "" {
+		return fmt.Errorf("无效的文件路径: %q", filePath)
+	}
+	blobID, err := newBlobID()
+	if err != nil {
+		return err
+	}
+	file := &sqlFile{
+		chunkSize:   int64(s.config.ChunkSize),
+		blobID:      blobID,
+		contentType: contentType,
+		modTime:     time.Now(),
+	}
+	if options.Expiration > 0 {
+		file.expiresAt = expirationDeadline(options.Expiration)
+		hlog.CtxDebugf(ctx, "设置数据库文件过期时间: %v", file.expiresAt)
+	}
+
+	// 每个数据块单独提交，避免长时间占用写事务
+	buf := make([]byte, s.config.ChunkSize)
+	for seq := 0; ; seq++ {
+		n, readErr := io.ReadFull(reader, buf)
+		if n > 0 {
+			if _, err = s.exec(ctx, s.db, "INSERT INTO "+s.chunks+" (blob_id, seq, data) VALUES (?, ?, ?)", blobID, seq, buf[:n]); err != nil {
+				break
+			}
+			file.size += int64(n)
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			err = readErr
+			break
+		}
+	}
+	if err == nil {
+		err = s.inTx(ctx, func(tx *sql.Tx) error {
+			return s.replaceFile(ctx, tx, key, file)
+		})
+	}
+	if err != nil {
+		// 上传失败时删除已写入的数据块，已有文件保持不变
+		s.exec(context.WithoutCancel(ctx), s.db, "DELETE FROM "+s.chunks+" WHERE blob_id = ?", blobID)
+		hlog.CtxErrorf(ctx, "数据库上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "数据库文件上传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从数据库下载文件（逐块读取）
+func (s *SQLStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从数据库下载文件: %s", filePath)
+
+	reader, err := s.download(ctx, filePath, 0, -1)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "数据库获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "数据库文件下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// DownloadRange 实现从数据库下载文件范围（从 offset 所在的数据块开始读取）
+func (s *SQLStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从数据库下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	reader, err := s.download(ctx, filePath, offset, size)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "数据库获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "数据库文件断点续传下载已启动: %s", filePath)
+	return reader, nil
+}
+
+// download 返回从 offset 开始最多 size 个字节的 Reader（size 小于 0 表示到文件末尾）
+func (s *SQLStorage) download(ctx context.Context, filePath string, offset, size int64) (io.Reader, error) {
+	file, err := s.getRegularFile(ctx, s.db, sqlKey(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := max(file.size-offset, 0)
+	if size >= 0 {
+		remaining = min(remaining, size)
+	}
+	reader := &sqlChunkReader{ctx: ctx, storage: s, blobID: file.blobID, remaining: remaining}
+	if remaining > 0 {
+		reader.seq = offset / file.chunkSize
+		reader.skip = offset % file.chunkSize
+	}
+	return reader, nil
+}
+
+// sqlChunkReader 逐块读取文件内容，每次只查询一个数据块，不会长时间占用数据库连接
+type sqlChunkReader struct {
+	ctx       context.Context
+	storage   *SQLStorage
+	blobID    string
+	seq       int64
+	skip      int64 // 当前数据块中需要跳过的字节数
+	remaining int64
+	buf       []byte
+}
+
+func (r *sqlChunkReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if len(r.buf) == 0 {
+		s := r.storage
+		var data []byte
+		err := s.db.QueryRowContext(r.ctx, s.rebind("SELECT data FROM "+s.chunks+" WHERE blob_id = ? AND seq = ?"), r.blobID, r.seq).Scan(&data)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("数据块 %d 不存在，文件可能已被覆盖或删除", r.seq)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if r.skip > int64(len(data)) {
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.buf = data[r.skip:]
+		r.skip = 0
+		r.seq++
+		if len(r.buf) == 0 {
+			return 0, io.ErrUnexpectedEOF
+		}
+	}
+
+	n := copy(p[:min(int64(len(p)), r.remaining)], r.buf)
+	r.buf = r.buf[n:]
+	r.remaining -= int64(n)
+	return n, nil
+}
+
+// Delete 实现数据库文件删除（删除不存在的文件不报错）
+func (s *SQLStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从数据库删除文件: %s", filePath)
+
+	key := sqlKey(filePath)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		file, err := s.getFile(ctx, tx, key)
+		if errors.Is(err, ErrNotExist) || err == nil && file.isDir {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.exec(ctx, tx, "DELETE FROM "+s.files+" WHERE path = ?", key); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, tx, "DELETE FROM "+s.chunks+" WHERE blob_id = ?", file.blobID)
+		return err
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "数据库删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "数据库文件删除成功: %s", filePath)
+	return nil
+}
+
+// Rename 实现数据库文件重命名（在事务中修改文件记录，覆盖已存在的目标文件）
+func (s *SQLStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在数据库中重命名文件: %s -> %s", oldPath, newPath)
+
+	oldKey, newKey := sqlKey(oldPath), sqlKey(newPath)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		file, err := s.getRegularFile(ctx, tx, oldKey)
+		if err != nil || oldKey == newKey {
+			return err
+		}
+		if err := s.replaceFile(ctx, tx, newKey, file); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, tx, "DELETE FROM "+s.files+" WHERE path = ?", oldKey)
+		return err
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "数据库文件重命名失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "数据库文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现数据库文件移动（与重命名相同的操作）
+func (s *SQLStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现数据库文件复制（在事务中由数据库复制数据块，内容不经过客户端）
+func (s *SQLStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在数据库中复制文件: %s -> %s", srcPath, dstPath)
+
+	srcKey, dstKey := sqlKey(srcPath), sqlKey(dstPath)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		file, err := s.getRegularFile(ctx, tx, srcKey)
+		if err != nil || srcKey == dstKey {
+			return err
+		}
+		srcBlobID := file.blobID
+		if file.blobID, err = newBlobID(); err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, tx,
+			"INSERT INTO "+s.chunks+" (blob_id, seq, data) SELECT ?, seq, data FROM "+s.chunks+" WHERE blob_id = ?",
+			file.blobID, srcBlobID)
+		if err != nil {
+			return err
+		}
+		file.modTime = time.Now()
+		return s.replaceFile(ctx, tx, dstKey, file)
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "数据库复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "数据库文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// Exists 实现检查数据库文件或目录是否存在（已过期的文件视为不存在）
+func (s *SQLStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	file, err := s.getFile(ctx, s.db, sqlKey(filePath))
+	if errors.Is(err, ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return file.isDir || file.expiresAt.IsZero() || !isExpired(file.expiresAt), nil
+}
+
+// CreateDir 实现数据库目录创建（逐级创建目录记录）
+func (s *SQLStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始创建数据库目录: %s", dirPath)
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		return s.ensureParents(ctx, tx, sqlKey(dirPath))
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "创建数据库目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "数据库目录创建成功: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现数据库目录删除（在事务中删除目录下的所有文件记录和数据块）
+func (s *SQLStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除数据库目录: %s", dirPath)
+
+	key := sqlKey(dirPath)
+	where := ` WHERE path = ? OR path LIKE ? ESCAPE '\'`
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		_, err := s.exec(ctx, tx, "DELETE FROM "+s.chunks+" WHERE blob_id IN (SELECT blob_id FROM "+s.files+where+")", key, sqlLikePrefix(key))
+		if err != nil {
+			return err
+		}
+		_, err = s.exec(ctx, tx, "DELETE FROM "+s.files+where, key, sqlLikePrefix(key))
+		return err
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "数据库删除目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "数据库目录删除成功: %s", dirPath)
+	return nil
+}
+
+// ListDir 实现数据库目录列表（按 parent 索引查询，仅列出当前层级）
+func (s *SQLStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出数据库目录内容: %s", dirPath)
+
+	files, err := s.listDir(ctx, sqlKey(dirPath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出数据库目录内容失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "成功列出数据库目录内容: %s, 共找到 %d 个条目", dirPath, len(files))
+	return files, nil
+}
+
+// listDir 列出目录下的直接子项，目录不存在时返回 ErrNotExist
+func (s *SQLStorage) listDir(ctx context.Context, key string) ([]FileMetadata, error) {
+	if key != "" {
+		dir, err := s.getFile(ctx, s.db, key)
+		if err != nil {
+			return nil, err
+		}
+		if !dir.isDir {
+			return nil, fmt.Errorf("%s 不是目录", key)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.rebind("SELECT "+sqlFileColumns+" FROM "+s.files+" WHERE parent = ? AND path <> '' ORDER BY name"), key)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []FileMetadata{}
+	for rows.Next() {
+		file, err := scanFile(rows)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file.metadata(path.Base(file.path)))
+	}
+	return files, rows.Err()
+}
+
+// GetMetadata 实现获取数据库文件元数据
+func (s *SQLStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取数据库文件元数据: %s", filePath)
+
+	file, err := s.getFile(ctx, s.db, sqlKey(filePath))
+	if err == nil && !file.isDir && !file.expiresAt.IsZero() && isExpired(file.expiresAt) {
+		err = errExpired(filePath)
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取数据库文件信息失败: %v", err)
+		return nil, err
+	}
+
+	metadata := file.metadata(filePath)
+	hlog.CtxInfof(ctx, "成功获取数据库文件元数据: %s", filePath)
+	return &metadata, nil
+}
+
+// UpdateMetadata 更新数据库文件元数据，支持修改修改时间和 MIME 类型
+func (s *SQLStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新数据库文件元数据: %s", filePath)
+
+	key := sqlKey(filePath)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := s.getFile(ctx, tx, key); err != nil {
+			return err
+		}
+		if !metadata.ModTime.IsZero() {
+			if _, err := s.exec(ctx, tx, "UPDATE "+s.files+" SET mod_time = ? WHERE path = ?", metadata.ModTime.UnixNano(), key); err != nil {
+				return err
+			}
+		}
+		if metadata.MIMEType != "" {
+			if _, err := s.exec(ctx, tx, "UPDATE "+s.files+" SET content_type = ? WHERE path = ? AND is_dir = 0", metadata.MIMEType, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "更新数据库文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "成功更新数据库文件元数据: %s", filePath)
+	return nil
+}
+
+// GetExpiration 读取数据库文件的删除截止时间（不检查是否已过期）
+func (s *SQLStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	file, err := s.getFile(ctx, s.db, sqlKey(filePath))
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取数据库文件信息失败: %v", err)
+		return time.Time{}, err
+	}
+	return file.expiresAt, nil
+}
+
+// BatchUpload 实现数据库批量上传
+func (s *SQLStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到数据库", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现数据库批量下载（逐块读取）
+func (s *SQLStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个数据库文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现数据库批量删除
+func (s *SQLStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个数据库文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
@@ -0,0 +1,142 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/v-mars/storage"
+	_ "modernc.org/sqlite"
+)
+
+// newSQLTestStorage 创建使用临时 SQLite 数据库文件的存储，数据块设置得很小以便测试跨块读写
+func newSQLTestStorage(t *testing.T, chunkSize int) storage.Storage {
+	t.Helper()
+	s := storage.NewSQLStorage(storage.SQLStorageConfig{
+		DSN:       "file:" + filepath.Join(t.TempDir(), "storage.db"),
+		ChunkSize: chunkSize,
+	})
+	if s == nil {
+		t.Fatal("NewSQLStorage returned nil")
+	}
+	t.Cleanup(func() { s.(*storage.SQLStorage).Close() })
+	return s
+}
+
+func TestSQLStorage_Chunks(t *testing.T) {
+	s := newSQLTestStorage(t, 4)
+	ctx := context.Background()
+
+	// 内容跨越多个数据块，范围从块中间开始并在另一个块中间结束
+	content := []byte("0123456789abcdefghij")
+	if err := s.Upload(ctx, "dir/sub/file.bin", bytes.NewReader(content)); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "dir/sub/file.bin")); got != string(content) {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	for _, tc := range []struct {
+		offset, size int64
+		want         string
+	}{
+		{5, 9, "56789abcd"},
+		{8, 4, "89ab"},
+		{18, -1, "ij"},
+		{20, 5, ""},
+	} {
+		if got := readString(t)(s.DownloadRange(ctx, "dir/sub/file.bin", tc.offset, tc.size)); got != tc.want {
+			t.Fatalf("DownloadRange(%d, %d) = %q, want %q", tc.offset, tc.size, got, tc.want)
+		}
+	}
+
+	// 上传时自动创建上级目录记录
+	files, err := s.ListDir(ctx, "dir")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "sub" || !files[0].IsDir {
+		t.Fatalf("Unexpected entries: %+v", files)
+	}
+
+	// 覆盖后旧的数据块被删除
+	if err := s.Upload(ctx, "dir/sub/file.bin", bytes.NewReader([]byte("short"))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Copy(ctx, "dir/sub/file.bin", "copy.bin"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if err := s.Rename(ctx, "copy.bin", "dir/renamed.bin"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "dir/renamed.bin")); got != "short" {
+		t.Fatalf("Unexpected renamed content: %q", got)
+	}
+	if err := s.Rename(ctx, "missing.bin", "other.bin"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist, got %v", err)
+	}
+	if n := countChunks(t, s); n != 4 {
+		t.Fatalf("Expected 4 chunks for two 5-byte files, got %d", n)
+	}
+
+	// 删除目录时同时删除其中文件的数据块；名称中的 LIKE 通配符按字面匹配
+	if err := s.Upload(ctx, "d_r/keep.txt", bytes.NewReader([]byte("keep"))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.DeleteDir(ctx, "dir"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if err := s.DeleteDir(ctx, "d%"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if ok, _ := s.Exists(ctx, "d_r/keep.txt"); !ok {
+		t.Fatal("DeleteDir removed an unrelated directory")
+	}
+	if n := countChunks(t, s); n != 1 {
+		t.Fatalf("Expected 1 remaining chunk, got %d", n)
+	}
+}
+
+// countChunks 统计默认数据块表中的行数
+func countChunks(t *testing.T, s storage.Storage) int {
+	t.Helper()
+	db := s.(*storage.SQLStorage).DB()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM storage_chunks").Scan(&n); err != nil {
+		t.Fatalf("Failed to count chunks: %v", err)
+	}
+	return n
+}
+
+func TestSQLStorage_SharedDB(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+	ctx := context.Background()
+
+	// 不同前缀的实例共用一个数据库而互不影响，Close 不关闭传入的数据库
+	a := storage.NewSQLStorage(storage.SQLStorageConfig{DB: db, TablePrefix: "a_"})
+	b := storage.NewSQLStorage(storage.SQLStorageConfig{DB: db, TablePrefix: "b_"})
+	if a == nil || b == nil {
+		t.Fatal("NewSQLStorage returned nil")
+	}
+	if err := a.Upload(ctx, "file.txt", bytes.NewReader([]byte("a"))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if ok, _ := b.Exists(ctx, "file.txt"); ok {
+		t.Fatal("Table prefixes should isolate instances")
+	}
+	a.(*storage.SQLStorage).Close()
+	if err := db.Ping(); err != nil {
+		t.Fatalf("Shared database was closed: %v", err)
+	}
+
+	if s := storage.NewSQLStorage(storage.SQLStorageConfig{DB: db, TablePrefix: "x; DROP"}); s != nil {
+		t.Fatal("Expected NewSQLStorage to reject invalid table prefix")
+	}
+}
@@ -0,0 +1,566 @@
+package storagetest
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FakeAzureAccount 假 Azure Blob 服务的存储账户名称（与 Azurite 相同）
+	FakeAzureAccount = "devstoreaccount1"
+	// FakeAzureAccountKey 假 Azure Blob 服务的账户密钥（Azurite 的公开密钥，服务不校验签名）
+	FakeAzureAccountKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+)
+
+// azureContentHeaders 请求中的 Blob HTTP 头与响应头的对应关系
+var azureContentHeaders = map[string]string{
+	"X-Ms-Blob-Content-Type":        "Content-Type",
+	"X-Ms-Blob-Cache-Control":       "Cache-Control",
+	"X-Ms-Blob-Content-Encoding":    "Content-Encoding",
+	"X-Ms-Blob-Content-Language":    "Content-Language",
+	"X-Ms-Blob-Content-Disposition": "Content-Disposition",
+	"X-Ms-Blob-Content-Md5":         "Content-Md5",
+}
+
+// FakeAzureServer 基于 httptest 的假 Azure Blob 服务，实现了 Blob REST API 的常用子集
+// （创建容器、Put Blob、Put Block/Put Block List、Get/Head/Delete Blob、Copy Blob、
+// Set Blob Properties 和分层列举），足以让 azblob SDK 客户端正常工作。
+//
+// 服务使用路径样式访问（/account/container/blob），不校验签名：
+//
+//	server := storagetest.NewFakeAzureServer()
+//	defer server.Close()
+//	s := storage.NewAzureBlobStorage(storage.AzureBlobStorageConfig{ConnectionString: server.ConnectionString(), Container: "test"})
+type FakeAzureServer struct {
+	*httptest.Server
+
+	// PageSize 列举 Blob 时每页返回的最大条目数（客户端请求的 maxresults 更小时以客户端为准），默认 5000
+	PageSize int
+	// PendingCopyPolls 复制 Blob 后，读取目标 Blob 属性时报告复制未完成（pending）的次数，用于测试异步复制的轮询
+	PendingCopyPolls int
+
+	mu         sync.Mutex
+	containers map[string]*fakeContainer
+	seq        int64
+}
+
+// fakeContainer 容器
+type fakeContainer struct {
+	blobs  map[string]*fakeBlob
+	blocks map[string]map[string][]byte // 尚未提交的块，按 Blob 名称和块 ID 保存
+}
+
+// fakeBlob 块 Blob
+type fakeBlob struct {
+	data       []byte
+	headers    http.Header       // Content-Type、Cache-Control 等 HTTP 头
+	meta       map[string]string // 用户元数据
+	etag       string
+	modTime    time.Time
+	blocks     int // 提交的块数，Put Blob 上传时为 0
+	copyID     string
+	copyStatus string
+	copyPolls  int
+}
+
+// NewFakeAzureServer 创建并启动假 Azure Blob 服务，使用完毕后需调用 Close
+func NewFakeAzureServer() *FakeAzureServer {
+	s := &FakeAzureServer{
+		PageSize:   5000,
+		containers: make(map[string]*fakeContainer),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint 返回账户的 Blob 服务地址
+func (s *FakeAzureServer) Endpoint() string {
+	return s.URL + "/" + FakeAzureAccount
+}
+
+// ConnectionString 返回连接假服务的连接字符串
+func (s *FakeAzureServer) ConnectionString() string {
+	return fmt.Sprintf("DefaultEndpointsProtocol=http;AccountName=%s;AccountKey=%s;BlobEndpoint=%s;",
+		FakeAzureAccount, FakeAzureAccountKey, s.Endpoint())
+}
+
+// Blob 返回 Blob 的内容和提交的块数，用于在测试中直接检查服务端状态
+func (s *FakeAzureServer) Blob(container, name string) ([]byte, int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.containers[container]
+	if !ok {
+		return nil, 0, false
+	}
+	blob, ok := c.blobs[name]
+	if !ok {
+		return nil, 0, false
+	}
+	return blob.data, blob.blocks, true
+}
+
+// fakeAzureRequest 单个请求的上下文
+type fakeAzureRequest struct {
+	w         http.ResponseWriter
+	r         *http.Request
+	query     url.Values
+	container string
+	blob      string
+}
+
+func (s *FakeAzureServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &fakeAzureRequest{w: w, r: r, query: r.URL.Query()}
+	// 路径的第一段是账户名称
+	_, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	req.container, req.blob, _ = strings.Cut(rest, "/")
+	w.Header().Set("x-ms-request-id", strconv.FormatInt(time.Now().UnixNano(), 36))
+	w.Header().Set("x-ms-version", r.Header.Get("x-ms-version"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if req.container == "" {
+		req.error(http.StatusBadRequest, "InvalidUri", "missing container")
+		return
+	}
+	if req.blob == "" {
+		s.serveContainer(req)
+		return
+	}
+	c, ok := s.containers[req.container]
+	if !ok {
+		req.error(http.StatusNotFound, "ContainerNotFound", "the specified container does not exist")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		switch req.query.Get("comp") {
+		case "block":
+			s.putBlock(req, c)
+		case "blocklist":
+			s.putBlockList(req, c)
+		case "properties":
+			s.setProperties(req, c)
+		case "":
+			if r.Header.Get("x-ms-copy-source") != "" {
+				s.copyBlob(req, c)
+			} else {
+				s.putBlob(req, c)
+			}
+		default:
+			req.error(http.StatusBadRequest, "UnsupportedQueryParameter", "unsupported comp: "+req.query.Get("comp"))
+		}
+	case http.MethodGet, http.MethodHead:
+		s.getBlob(req, c)
+	case http.MethodDelete:
+		if _, ok := c.blobs[req.blob]; !ok {
+			req.error(http.StatusNotFound, "BlobNotFound", "the specified blob does not exist")
+			return
+		}
+		delete(c.blobs, req.blob)
+		delete(c.blocks, req.blob)
+		w.WriteHeader(http.StatusAccepted)
+	default:
+		req.error(http.StatusMethodNotAllowed, "UnsupportedHttpVerb", "unsupported method: "+r.Method)
+	}
+}
+
+// error 返回 Azure 格式的错误响应，错误码同时写入 x-ms-error-code 头（HEAD 请求没有响应体）
+func (req *fakeAzureRequest) error(status int, code, message string) {
+	io.Copy(io.Discard, req.r.Body)
+	req.w.Header().Set("x-ms-error-code", code)
+	if req.r.Method == http.MethodHead {
+		req.w.WriteHeader(status)
+		return
+	}
+	req.writeXML(status, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
+
+// writeXML 写入 XML 响应
+func (req *fakeAzureRequest) writeXML(status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(req.w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.w.Header().Set("Content-Type", "application/xml")
+	req.w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(data)))
+	req.w.WriteHeader(status)
+	io.WriteString(req.w, xml.Header)
+	req.w.Write(data)
+}
+
+// serveContainer 处理容器级别的请求
+func (s *FakeAzureServer) serveContainer(req *fakeAzureRequest) {
+	if req.query.Get("restype") != "container" {
+		req.error(http.StatusBadRequest, "InvalidQueryParameterValue", "restype must be container")
+		return
+	}
+	c, ok := s.containers[req.container]
+	switch {
+	case req.r.Method == http.MethodPut && req.query.Get("comp") == "":
+		if ok {
+			req.error(http.StatusConflict, "ContainerAlreadyExists", "the specified container already exists")
+			return
+		}
+		s.containers[req.container] = &fakeContainer{
+			blobs:  make(map[string]*fakeBlob),
+			blocks: make(map[string]map[string][]byte),
+		}
+		req.w.WriteHeader(http.StatusCreated)
+	case !ok:
+		req.error(http.StatusNotFound, "ContainerNotFound", "the specified container does not exist")
+	case req.r.Method == http.MethodGet && req.query.Get("comp") == "list":
+		s.listBlobs(req, c)
+	default:
+		req.error(http.StatusBadRequest, "UnsupportedQueryParameter", "unsupported container operation")
+	}
+}
+
+// checkConditions 检查写入请求的 If-Match / If-None-Match 头，不满足时写入错误响应并返回 false
+func (req *fakeAzureRequest) checkConditions(current *fakeBlob) bool {
+	if ifMatch := req.r.Header.Get("If-Match"); ifMatch != "" {
+		if current == nil || ifMatch != "*" && ifMatch != current.etag {
+			req.error(http.StatusPreconditionFailed, "ConditionNotMet", "the condition specified using HTTP conditional header(s) is not met")
+			return false
+		}
+	}
+	if ifNoneMatch := req.r.Header.Get("If-None-Match"); ifNoneMatch != "" && current != nil {
+		if ifNoneMatch == "*" {
+			req.error(http.StatusConflict, "BlobAlreadyExists", "the specified blob already exists")
+			return false
+		}
+		if ifNoneMatch == current.etag {
+			req.error(http.StatusPreconditionFailed, "ConditionNotMet", "the condition specified using HTTP conditional header(s) is not met")
+			return false
+		}
+	}
+	return true
+}
+
+// newBlob 根据请求头构造 Blob 的 HTTP 头和用户元数据
+func (req *fakeAzureRequest) newBlob(data []byte) *fakeBlob {
+	blob := &fakeBlob{data: data, headers: make(http.Header), meta: make(map[string]string)}
+	blob.setHeaders(req.r.Header)
+	for name, values := range req.r.Header {
+		if key, ok := strings.CutPrefix(name, "X-Ms-Meta-"); ok {
+			blob.meta[key] = values[0]
+		}
+	}
+	return blob
+}
+
+// setHeaders 以请求中的 x-ms-blob-* 头替换 Blob 的全部 HTTP 头
+func (b *fakeBlob) setHeaders(h http.Header) {
+	b.headers = make(http.Header)
+	for requestHeader, responseHeader := range azureContentHeaders {
+		if value := h.Get(requestHeader); value != "" {
+			b.headers.Set(responseHeader, value)
+		}
+	}
+	if b.headers.Get("Content-Type") == "" {
+		b.headers.Set("Content-Type", "application/octet-stream")
+	}
+}
+
+// store 保存 Blob 并写入包含 ETag 的响应头
+func (s *FakeAzureServer) store(req *fakeAzureRequest, c *fakeContainer, blob *fakeBlob, status int) {
+	s.seq++
+	blob.etag = fmt.Sprintf(`"0x8D%013X"`, s.seq)
+	blob.modTime = time.Now().UTC().Truncate(time.Second)
+	c.blobs[req.blob] = blob
+	req.w.Header().Set("ETag", blob.etag)
+	req.w.Header().Set("Last-Modified", blob.modTime.Format(http.TimeFormat))
+	req.w.WriteHeader(status)
+}
+
+// putBlob 实现 Put Blob，未指定 Content-MD5 时由服务计算
+func (s *FakeAzureServer) putBlob(req *fakeAzureRequest, c *fakeContainer) {
+	if req.r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+		req.error(http.StatusBadRequest, "InvalidBlobType", "only block blobs are supported")
+		return
+	}
+	data, err := io.ReadAll(req.r.Body)
+	if err != nil {
+		req.error(http.StatusBadRequest, "InvalidInput", err.Error())
+		return
+	}
+	if !req.checkConditions(c.blobs[req.blob]) {
+		return
+	}
+	blob := req.newBlob(data)
+	if blob.headers.Get("Content-Md5") == "" {
+		sum := md5.Sum(data)
+		blob.headers.Set("Content-Md5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	delete(c.blocks, req.blob)
+	s.store(req, c, blob, http.StatusCreated)
+}
+
+// putBlock 实现 Put Block，暂存未提交的块
+func (s *FakeAzureServer) putBlock(req *fakeAzureRequest, c *fakeContainer) {
+	blockID := req.query.Get("blockid")
+	if blockID == "" {
+		req.error(http.StatusBadRequest, "InvalidQueryParameterValue", "missing blockid")
+		return
+	}
+	data, err := io.ReadAll(req.r.Body)
+	if err != nil {
+		req.error(http.StatusBadRequest, "InvalidInput", err.Error())
+		return
+	}
+	if c.blocks[req.blob] == nil {
+		c.blocks[req.blob] = make(map[string][]byte)
+	}
+	c.blocks[req.blob][blockID] = data
+	req.w.WriteHeader(http.StatusCreated)
+}
+
+// putBlockList 实现 Put Block List，按请求中的顺序拼接未提交的块
+func (s *FakeAzureServer) putBlockList(req *fakeAzureRequest, c *fakeContainer) {
+	var list struct {
+		Blocks []struct {
+			XMLName xml.Name
+			ID      string `xml:",chardata"`
+		} `xml:",any"`
+	}
+	if err := xml.NewDecoder(req.r.Body).Decode(&list); err != nil {
+		req.error(http.StatusBadRequest, "InvalidXmlDocument", err.Error())
+		return
+	}
+	if !req.checkConditions(c.blobs[req.blob]) {
+		return
+	}
+
+	var data []byte
+	for _, block := range list.Blocks {
+		chunk, ok := c.blocks[req.blob][block.ID]
+		if !ok || block.XMLName.Local == "Committed" {
+			req.error(http.StatusBadRequest, "InvalidBlockList", "the specified block list is invalid")
+			return
+		}
+		data = append(data, chunk...)
+	}
+	blob := req.newBlob(data)
+	blob.blocks = len(list.Blocks)
+	delete(c.blocks, req.blob)
+	s.store(req, c, blob, http.StatusCreated)
+}
+
+// setProperties 实现 Set Blob Properties：与服务端行为一致，请求中没有的 HTTP 头会被清空
+func (s *FakeAzureServer) setProperties(req *fakeAzureRequest, c *fakeContainer) {
+	current, ok := c.blobs[req.blob]
+	if !ok {
+		req.error(http.StatusNotFound, "BlobNotFound", "the specified blob does not exist")
+		return
+	}
+	if !req.checkConditions(current) {
+		return
+	}
+	blob := *current
+	blob.setHeaders(req.r.Header)
+	s.store(req, c, &blob, http.StatusOK)
+}
+
+// copyBlob 实现 Copy Blob。复制立即完成，但在 PendingCopyPolls 次读取属性前报告 pending
+func (s *FakeAzureServer) copyBlob(req *fakeAzureRequest, c *fakeContainer) {
+	source, err := url.Parse(req.r.Header.Get("x-ms-copy-source"))
+	if err != nil {
+		req.error(http.StatusBadRequest, "InvalidHeaderValue", "invalid copy source")
+		return
+	}
+	_, rest, _ := strings.Cut(strings.TrimPrefix(source.Path, "/"), "/")
+	srcContainer, srcName, _ := strings.Cut(rest, "/")
+	var src *fakeBlob
+	if sc, ok := s.containers[srcContainer]; ok {
+		src = sc.blobs[srcName]
+	}
+	if src == nil {
+		req.error(http.StatusNotFound, "CannotVerifyCopySource", "the specified blob does not exist")
+		return
+	}
+	if !req.checkConditions(c.blobs[req.blob]) {
+		return
+	}
+
+	blob := &fakeBlob{data: src.data, headers: src.headers.Clone(), meta: src.meta, blocks: src.blocks}
+	if copied := req.newBlob(nil).meta; len(copied) > 0 {
+		blob.meta = copied
+	}
+	blob.copyID = strconv.FormatInt(time.Now().UnixNano(), 36)
+	blob.copyStatus, blob.copyPolls = "success", s.PendingCopyPolls
+	if blob.copyPolls > 0 {
+		blob.copyStatus = "pending"
+	}
+	req.w.Header().Set("x-ms-copy-id", blob.copyID)
+	req.w.Header().Set("x-ms-copy-status", blob.copyStatus)
+	s.store(req, c, blob, http.StatusAccepted)
+}
+
+// getBlob 实现 Get Blob 和 Get Blob Properties，支持 Range
+func (s *FakeAzureServer) getBlob(req *fakeAzureRequest, c *fakeContainer) {
+	blob, ok := c.blobs[req.blob]
+	if !ok {
+		req.error(http.StatusNotFound, "BlobNotFound", "the specified blob does not exist")
+		return
+	}
+
+	h := req.w.Header()
+	for name, values := range blob.headers {
+		h[name] = values
+	}
+	h.Set("ETag", blob.etag)
+	h.Set("Last-Modified", blob.modTime.Format(http.TimeFormat))
+	h.Set("Accept-Ranges", "bytes")
+	h.Set("x-ms-blob-type", "BlockBlob")
+	if blob.blocks > 0 {
+		h.Set("x-ms-blob-committed-block-count", strconv.Itoa(blob.blocks))
+	}
+	for key, value := range blob.meta {
+		h.Set("x-ms-meta-"+key, value)
+	}
+	if blob.copyID != "" {
+		if blob.copyPolls > 0 {
+			blob.copyPolls--
+		} else {
+			blob.copyStatus = "success"
+		}
+		h.Set("x-ms-copy-id", blob.copyID)
+		h.Set("x-ms-copy-status", blob.copyStatus)
+	}
+
+	data := blob.data
+	status := http.StatusOK
+	rangeHeader := req.r.Header.Get("x-ms-range")
+	if rangeHeader == "" {
+		rangeHeader = req.r.Header.Get("Range")
+	}
+	if rangeHeader != "" {
+		start, end, ok := parseRange(rangeHeader, int64(len(data)))
+		if !ok {
+			h.Del("Content-Type")
+			h.Set("Content-Range", fmt.Sprintf("bytes */%d", len(data)))
+			req.error(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "the range specified is invalid for the current size of the resource")
+			return
+		}
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	}
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	req.w.WriteHeader(status)
+	if req.r.Method == http.MethodGet {
+		req.w.Write(data)
+	}
+}
+
+// listBlobs 实现 List Blobs，指定 delimiter 时按分层方式返回 BlobPrefix
+func (s *FakeAzureServer) listBlobs(req *fakeAzureRequest, c *fakeContainer) {
+	q := req.query
+	prefix, delimiter, marker := q.Get("prefix"), q.Get("delimiter"), q.Get("marker")
+	maxResults := s.PageSize
+	if v := q.Get("maxresults"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n < maxResults {
+			maxResults = n
+		}
+	}
+
+	names := make([]string, 0, len(c.blobs))
+	for name := range c.blobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var entries []fakeListEntry
+	truncated := false
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) || name <= marker {
+			continue
+		}
+		entry := fakeListEntry{key: name}
+		if delimiter != "" {
+			if i := strings.Index(name[len(prefix):], delimiter); i >= 0 {
+				blobPrefix := name[:len(prefix)+i+len(delimiter)]
+				// BlobPrefix 在翻页时作为 marker 返回，之后的同前缀 Blob 需要跳过
+				if blobPrefix == marker || len(entries) > 0 && entries[len(entries)-1].key == blobPrefix {
+					continue
+				}
+				entry = fakeListEntry{key: blobPrefix, isPrefix: true}
+			}
+		}
+		if len(entries) == maxResults {
+			truncated = true
+			break
+		}
+		entries = append(entries, entry)
+	}
+
+	type properties struct {
+		LastModified  string `xml:"Last-Modified"`
+		ETag          string `xml:"Etag"`
+		ContentLength int64  `xml:"Content-Length"`
+		ContentType   string `xml:"Content-Type"`
+		BlobType      string `xml:"BlobType"`
+	}
+	type blobItem struct {
+		XMLName    xml.Name
+		Name       string      `xml:"Name"`
+		Properties *properties `xml:"Properties,omitempty"`
+	}
+	result := struct {
+		XMLName         xml.Name   `xml:"EnumerationResults"`
+		ServiceEndpoint string     `xml:"ServiceEndpoint,attr"`
+		ContainerName   string     `xml:"ContainerName,attr"`
+		Prefix          string     `xml:"Prefix"`
+		Marker          string     `xml:"Marker"`
+		MaxResults      int        `xml:"MaxResults"`
+		Delimiter       string     `xml:"Delimiter,omitempty"`
+		Blobs           []blobItem `xml:"Blobs>Blob"`
+		NextMarker      string     `xml:"NextMarker"`
+	}{
+		ServiceEndpoint: s.Endpoint() + "/",
+		ContainerName:   req.container,
+		Prefix:          prefix,
+		Marker:          marker,
+		MaxResults:      maxResults,
+		Delimiter:       delimiter,
+	}
+	for _, entry := range entries {
+		if entry.isPrefix {
+			result.Blobs = append(result.Blobs, blobItem{XMLName: xml.Name{Local: "BlobPrefix"}, Name: entry.key})
+			continue
+		}
+		blob := c.blobs[entry.key]
+		result.Blobs = append(result.Blobs, blobItem{
+			XMLName: xml.Name{Local: "Blob"},
+			Name:    entry.key,
+			Properties: &properties{
+				LastModified:  blob.modTime.Format(http.TimeFormat),
+				ETag:          blob.etag,
+				ContentLength: int64(len(blob.data)),
+				ContentType:   blob.headers.Get("Content-Type"),
+				BlobType:      "BlockBlob",
+			},
+		})
+	}
+	if truncated && len(entries) > 0 {
+		result.NextMarker = entries[len(entries)-1].key
+	}
+	req.writeXML(http.StatusOK, result)
+}
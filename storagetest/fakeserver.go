@@ -0,0 +1,1063 @@
+package storagetest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeServer 基于 httptest 的假对象存储服务，实现了 S3 REST API 的常用子集
+// （Put/Get/Head/Delete/Copy/ListObjects(V2)/分片上传/DeleteObjects/对象标签），
+// 并能识别阿里云 OSS（x-oss-* 头）和腾讯云 COS（x-cos-* 头）的请求方言，
+// 足以让 S3、MinIO、OSS 和 COS 的 SDK 客户端正常工作。
+//
+// 服务默认使用路径样式访问（/bucket/key）；请求的 Host 为域名时按虚拟主机样式处理，
+// 取域名的第一段作为存储桶名（配合 Transport 使用）。服务不校验签名。
+// 可以通过 InjectFault 注入错误响应或延迟，用于测试后端在服务异常时的行为：
+//
+//	server := storagetest.NewFakeServer()
+//	defer server.Close()
+//	server.CreateBucket("test")
+//	s := storage.NewS3Storage(storage.S3StorageConfig{Endpoint: server.URL, Bucket: "test", ...})
+type FakeServer struct {
+	*httptest.Server
+
+	// PageSize 列举对象时每页返回的最大条目数（客户端请求的 max-keys 更小时以客户端为准），默认 1000
+	PageSize int
+
+	mu      sync.Mutex
+	buckets map[string]*fakeBucket
+	faults  []*Fault
+	seq     int64
+}
+
+// Fault 注入的故障。请求匹配 Method 和 Path 时，先等待 Delay，
+// 再以 Status 返回错误（Status 为 0 时只注入延迟，请求继续正常处理）。
+type Fault struct {
+	Method string        // 匹配的 HTTP 方法，为空时匹配所有方法
+	Path   string        // 匹配的路径前缀（如 /bucket/key），为空时匹配所有路径
+	Status int           // 返回的 HTTP 状态码
+	Code   string        // 错误码，为空时根据状态码推断
+	Delay  time.Duration // 响应前的延迟
+	Times  int           // 生效次数，0 表示一直生效
+
+	hits int
+}
+
+// fakeBucket 存储桶
+type fakeBucket struct {
+	objects   map[string]*fakeObject
+	uploads   map[string]*fakeUpload
+	lifecycle []byte
+}
+
+// fakeObject 对象
+type fakeObject struct {
+	data        []byte
+	contentType string
+	expires     string
+	meta        map[string]string // 用户元数据，键为小写且不带前缀
+	tags        url.Values
+	etag        string
+	modTime     time.Time
+}
+
+// fakeUpload 进行中的分片上传
+type fakeUpload struct {
+	key    string
+	object *fakeObject // 上传完成后使用的元数据
+	parts  map[int][]byte
+}
+
+// NewFakeServer 创建并启动假对象存储服务，使用完毕后需调用 Close
+func NewFakeServer() *FakeServer {
+	s := &FakeServer{
+		PageSize: 1000,
+		buckets:  make(map[string]*fakeBucket),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Endpoint 返回不带协议的服务地址（host:port），用于 MinIO 等需要 host 的客户端
+func (s *FakeServer) Endpoint() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Transport 返回把所有连接都拨到假服务的 http.RoundTripper，
+// 用于只支持虚拟主机样式域名（如 bucket-appid.cos.region.myqcloud.com）的客户端
+func (s *FakeServer) Transport() http.RoundTripper {
+	addr := s.Listener.Addr().String()
+	return &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// CreateBucket 创建存储桶，已存在时不做任何操作
+func (s *FakeServer) CreateBucket(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.createBucket(name)
+}
+
+func (s *FakeServer) createBucket(name string) {
+	if _, ok := s.buckets[name]; !ok {
+		s.buckets[name] = &fakeBucket{
+			objects: make(map[string]*fakeObject),
+			uploads: make(map[string]*fakeUpload),
+		}
+	}
+}
+
+// Object 返回对象的内容，用于在测试中直接检查服务端状态
+func (s *FakeServer) Object(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil, false
+	}
+	object, ok := b.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return object.data, true
+}
+
+// Keys 返回存储桶中所有对象的 key（已排序）
+func (s *FakeServer) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucket]
+	if !ok {
+		return nil
+	}
+	return b.sortedKeys()
+}
+
+// InjectFault 注入故障，按注入顺序匹配
+func (s *FakeServer) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults 清除所有注入的故障
+func (s *FakeServer) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// matchFault 返回匹配请求的故障并记录命中次数
+func (s *FakeServer) matchFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, fault.Path) {
+			continue
+		}
+		if fault.Times > 0 && fault.hits >= fault.Times {
+			continue
+		}
+		fault.hits++
+		copied := *fault
+		return &copied
+	}
+	return nil
+}
+
+// fakeDialect 请求的 API 方言
+type fakeDialect int
+
+const (
+	dialectS3 fakeDialect = iota
+	dialectOSS
+	dialectCOS
+)
+
+// fakeRequest 单个请求的上下文
+type fakeRequest struct {
+	w       http.ResponseWriter
+	r       *http.Request
+	dialect fakeDialect
+	bucket  string
+	key     string
+	query   url.Values
+}
+
+// headerPrefix 返回当前方言的自定义头前缀
+func (req *fakeRequest) headerPrefix() string {
+	switch req.dialect {
+	case dialectOSS:
+		return "X-Oss-"
+	case dialectCOS:
+		return "X-Cos-"
+	}
+	return "X-Amz-"
+}
+
+// header 读取当前方言的自定义头
+func (req *fakeRequest) header(name string) string {
+	return req.r.Header.Get(req.headerPrefix() + name)
+}
+
+// requestDialect 根据签名和请求头判断请求的方言
+func requestDialect(r *http.Request) fakeDialect {
+	auth := r.Header.Get("Authorization")
+	switch {
+	case strings.HasPrefix(auth, "OSS"):
+		return dialectOSS
+	case strings.HasPrefix(auth, "q-sign-algorithm="):
+		return dialectCOS
+	}
+	for name := range r.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-oss-") {
+			return dialectOSS
+		}
+		if strings.HasPrefix(lower, "x-cos-") {
+			return dialectCOS
+		}
+	}
+	return dialectS3
+}
+
+// hostBucket 从虚拟主机样式的 Host 中解析存储桶名，Host 为 IP 或 localhost 时返回空
+func hostBucket(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" || net.ParseIP(host) != nil {
+		return ""
+	}
+	bucket, _, _ := strings.Cut(host, ".")
+	return bucket
+}
+
+func (s *FakeServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	req := &fakeRequest{w: w, r: r, dialect: requestDialect(r), query: r.URL.Query()}
+	if bucket := hostBucket(r.Host); bucket != "" {
+		req.bucket, req.key = bucket, strings.TrimPrefix(r.URL.Path, "/")
+	} else {
+		req.bucket, req.key, _ = strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	}
+
+	if fault := s.matchFault(r); fault != nil {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.Status != 0 {
+			io.Copy(io.Discard, r.Body)
+			code := fault.Code
+			if code == "" {
+				code = faultCode(fault.Status)
+			}
+			req.error(fault.Status, code, "injected fault")
+			return
+		}
+	}
+
+	switch {
+	case req.bucket == "":
+		s.listBuckets(req)
+	case req.key == "":
+		s.serveBucket(req)
+	default:
+		s.serveObject(req)
+	}
+}
+
+// faultCode 根据状态码推断错误码
+func faultCode(status int) string {
+	switch status {
+	case http.StatusForbidden:
+		return "AccessDenied"
+	case http.StatusNotFound:
+		return "NoSuchKey"
+	case http.StatusServiceUnavailable:
+		return "SlowDown"
+	case http.StatusPreconditionFailed:
+		return "PreconditionFailed"
+	}
+	if status >= 500 {
+		return "InternalError"
+	}
+	return "InvalidRequest"
+}
+
+// error 返回 S3/OSS 格式的错误响应（HEAD 请求没有响应体）
+func (req *fakeRequest) error(status int, code, message string) {
+	requestID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	req.w.Header().Set(req.headerPrefix()+"Request-Id", requestID)
+	if req.r.Method == http.MethodHead {
+		req.w.WriteHeader(status)
+		return
+	}
+	req.writeXML(status, struct {
+		XMLName    xml.Name `xml:"Error"`
+		Code       string   `xml:"Code"`
+		Message    string   `xml:"Message"`
+		BucketName string   `xml:"BucketName,omitempty"`
+		Key        string   `xml:"Key,omitempty"`
+		RequestID  string   `xml:"RequestId"`
+		HostID     string   `xml:"HostId"`
+	}{Code: code, Message: message, BucketName: req.bucket, Key: req.key, RequestID: requestID, HostID: "fake"})
+}
+
+// writeXML 写入 XML 响应
+func (req *fakeRequest) writeXML(status int, v any) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		http.Error(req.w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.w.Header().Set("Content-Type", "application/xml")
+	req.w.Header().Set("Content-Length", strconv.Itoa(len(xml.Header)+len(data)))
+	req.w.WriteHeader(status)
+	io.WriteString(req.w, xml.Header)
+	req.w.Write(data)
+}
+
+// readBody 读取请求体，自动解码 aws-chunked 编码（SDK 在非 TLS 连接上的流式签名上传）
+func (req *fakeRequest) readBody() ([]byte, error) {
+	body := req.r.Body
+	if strings.HasPrefix(req.r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") ||
+		strings.Contains(req.r.Header.Get("Content-Encoding"), "aws-chunked") {
+		return decodeAWSChunked(body)
+	}
+	return io.ReadAll(body)
+}
+
+// decodeAWSChunked 解码 aws-chunked 编码的请求体，忽略分块签名和尾部校验和
+func decodeAWSChunked(r io.Reader) ([]byte, error) {
+	reader := bufio.NewReader(r)
+	var data bytes.Buffer
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked body: %w", err)
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked size %q: %w", sizeHex, err)
+		}
+		if size == 0 {
+			io.Copy(io.Discard, reader)
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, reader, size); err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked body: %w", err)
+		}
+		if _, err := reader.ReadString('\n'); err != nil {
+			return nil, fmt.Errorf("invalid aws-chunked body: %w", err)
+		}
+	}
+}
+
+// listBuckets 实现 ListBuckets
+func (s *FakeServer) listBuckets(req *fakeRequest) {
+	if req.r.Method != http.MethodGet {
+		req.error(http.StatusMethodNotAllowed, "MethodNotAllowed", "method not allowed")
+		return
+	}
+	s.mu.Lock()
+	names := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+
+	type bucket struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+	result := struct {
+		XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+		Buckets []bucket `xml:"Buckets>Bucket"`
+	}{}
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, bucket{Name: name, CreationDate: formatXMLTime(time.Now())})
+	}
+	req.writeXML(http.StatusOK, result)
+}
+
+// serveBucket 处理存储桶级别的请求
+func (s *FakeServer) serveBucket(req *fakeRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, exists := s.buckets[req.bucket]
+	r := req.r
+	switch {
+	case r.Method == http.MethodPut && !req.has("lifecycle"):
+		io.Copy(io.Discard, r.Body)
+		if exists {
+			req.error(http.StatusConflict, "BucketAlreadyOwnedByYou", "bucket already exists")
+			return
+		}
+		s.createBucket(req.bucket)
+		req.w.WriteHeader(http.StatusOK)
+		return
+	case !exists:
+		io.Copy(io.Discard, r.Body)
+		req.error(http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodHead:
+		req.w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodGet && req.has("location"):
+		req.writeXML(http.StatusOK, struct {
+			XMLName xml.Name `xml:"LocationConstraint"`
+			Value   string   `xml:",chardata"`
+		}{Value: "us-east-1"})
+	case req.has("lifecycle"):
+		s.serveLifecycle(req, b)
+	case r.Method == http.MethodGet:
+		s.listObjects(req, b)
+	case r.Method == http.MethodPost && req.has("delete"):
+		s.deleteObjects(req, b)
+	case r.Method == http.MethodDelete:
+		if len(b.objects) > 0 {
+			req.error(http.StatusConflict, "BucketNotEmpty", "the bucket is not empty")
+			return
+		}
+		delete(s.buckets, req.bucket)
+		req.w.WriteHeader(http.StatusNoContent)
+	default:
+		req.error(http.StatusNotImplemented, "NotImplemented", "bucket operation not implemented")
+	}
+}
+
+// has 判断查询参数是否存在（包括无值参数，如 ?uploads）
+func (req *fakeRequest) has(name string) bool {
+	_, ok := req.query[name]
+	return ok
+}
+
+// serveLifecycle 原样保存和返回生命周期配置
+func (s *FakeServer) serveLifecycle(req *fakeRequest, b *fakeBucket) {
+	switch req.r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(req.r.Body)
+		if err != nil {
+			req.error(http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		b.lifecycle = data
+		req.w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		if b.lifecycle == nil {
+			code := "NoSuchLifecycleConfiguration"
+			if req.dialect == dialectOSS {
+				code = "NoSuchLifecycle"
+			}
+			req.error(http.StatusNotFound, code, "the lifecycle configuration does not exist")
+			return
+		}
+		req.w.Header().Set("Content-Type", "application/xml")
+		req.w.WriteHeader(http.StatusOK)
+		req.w.Write(b.lifecycle)
+	case http.MethodDelete:
+		b.lifecycle = nil
+		req.w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// sortedKeys 返回排序后的全部 key
+func (b *fakeBucket) sortedKeys() []string {
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fakeListEntry 列举结果中的一项（对象或公共前缀）
+type fakeListEntry struct {
+	key      string
+	isPrefix bool
+}
+
+// listObjects 实现 ListObjects 和 ListObjectsV2（list-type=2）
+func (s *FakeServer) listObjects(req *fakeRequest, b *fakeBucket) {
+	q := req.query
+	v2 := q.Get("list-type") == "2"
+	prefix := q.Get("prefix")
+	delimiter := q.Get("delimiter")
+	marker := q.Get("marker")
+	if v2 {
+		marker = q.Get("start-after")
+		if token := q.Get("continuation-token"); token != "" {
+			marker = token
+		}
+	}
+	maxKeys := s.PageSize
+	if v := q.Get("max-keys"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 && n < maxKeys {
+			maxKeys = n
+		}
+	}
+
+	var entries []fakeListEntry
+	truncated := false
+	for _, key := range b.sortedKeys() {
+		if !strings.HasPrefix(key, prefix) || key <= marker {
+			continue
+		}
+		entry := fakeListEntry{key: key}
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				commonPrefix := key[:len(prefix)+i+len(delimiter)]
+				// 公共前缀在翻页时作为 marker 返回，之后的同前缀 key 需要跳过
+				if commonPrefix == marker || len(entries) > 0 && entries[len(entries)-1].key == commonPrefix {
+					continue
+				}
+				entry = fakeListEntry{key: commonPrefix, isPrefix: true}
+			}
+		}
+		if len(entries) == maxKeys {
+			truncated = true
+			break
+		}
+		entries = append(entries, entry)
+	}
+
+	encode := func(s string) string { return s }
+	if q.Get("encoding-type") == "url" {
+		encode = url.QueryEscape
+	}
+
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int64  `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+		Type         string `xml:"Type,omitempty"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Xmlns                 string         `xml:"xmlns,attr,omitempty"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		Marker                *string        `xml:"Marker,omitempty"`
+		StartAfter            string         `xml:"StartAfter,omitempty"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		EncodingType          string         `xml:"EncodingType,omitempty"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextMarker            string         `xml:"NextMarker,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		KeyCount              *int           `xml:"KeyCount,omitempty"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{
+		Name:         req.bucket,
+		Prefix:       encode(prefix),
+		MaxKeys:      maxKeys,
+		Delimiter:    encode(delimiter),
+		EncodingType: q.Get("encoding-type"),
+		IsTruncated:  truncated,
+	}
+	if req.dialect == dialectS3 {
+		result.Xmlns = "http://s3.amazonaws.com/doc/2006-03-01/"
+	}
+
+	for _, entry := range entries {
+		if entry.isPrefix {
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: encode(entry.key)})
+			continue
+		}
+		object := b.objects[entry.key]
+		c := content{
+			Key:          encode(entry.key),
+			LastModified: formatXMLTime(object.modTime),
+			ETag:         `"` + object.etag + `"`,
+			Size:         int64(len(object.data)),
+			StorageClass: "STANDARD",
+		}
+		if req.dialect == dialectOSS {
+			c.Type = "Normal"
+			c.StorageClass = "Standard"
+		}
+		result.Contents = append(result.Contents, c)
+	}
+
+	next := ""
+	if truncated && len(entries) > 0 {
+		next = entries[len(entries)-1].key
+	}
+	if v2 {
+		count := len(entries)
+		result.KeyCount = &count
+		result.StartAfter = encode(q.Get("start-after"))
+		result.ContinuationToken = q.Get("continuation-token")
+		result.NextContinuationToken = next
+	} else {
+		m := encode(marker)
+		result.Marker = &m
+		result.NextMarker = encode(next)
+	}
+	req.writeXML(http.StatusOK, result)
+}
+
+// deleteObjects 实现批量删除（POST ?delete）
+func (s *FakeServer) deleteObjects(req *fakeRequest, b *fakeBucket) {
+	var input struct {
+		Quiet   bool `xml:"Quiet"`
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+	data, err := io.ReadAll(req.r.Body)
+	if err == nil {
+		err = xml.Unmarshal(data, &input)
+	}
+	if err != nil {
+		req.error(http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	type deleted struct {
+		Key string `xml:"Key"`
+	}
+	result := struct {
+		XMLName xml.Name  `xml:"DeleteResult"`
+		Deleted []deleted `xml:"Deleted"`
+	}{}
+	for _, object := range input.Objects {
+		key := object.Key
+		if req.query.Get("encoding-type") == "url" {
+			if decoded, err := url.QueryUnescape(key); err == nil {
+				key = decoded
+			}
+		}
+		delete(b.objects, key)
+		if !input.Quiet {
+			result.Deleted = append(result.Deleted, deleted{Key: object.Key})
+		}
+	}
+	req.writeXML(http.StatusOK, result)
+}
+
+// serveObject 处理对象级别的请求
+func (s *FakeServer) serveObject(req *fakeRequest) {
+	// 读取请求体不持有锁，避免慢客户端阻塞其他请求
+	var body []byte
+	if req.r.Method == http.MethodPut || req.r.Method == http.MethodPost {
+		var err error
+		if body, err = req.readBody(); err != nil {
+			req.error(http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[req.bucket]
+	if !ok {
+		req.error(http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+
+	r := req.r
+	switch {
+	case r.Method == http.MethodPost && req.has("uploads"):
+		s.createMultipartUpload(req, b)
+	case r.Method == http.MethodPut && req.has("uploadId"):
+		s.uploadPart(req, b, body)
+	case r.Method == http.MethodPost && req.has("uploadId"):
+		s.completeMultipartUpload(req, b, body)
+	case r.Method == http.MethodDelete && req.has("uploadId"):
+		delete(b.uploads, req.query.Get("uploadId"))
+		req.w.WriteHeader(http.StatusNoContent)
+	case req.has("tagging"):
+		s.serveTagging(req, b, body)
+	case r.Method == http.MethodPut && req.header("Copy-Source") != "":
+		s.copyObject(req, b)
+	case r.Method == http.MethodPut:
+		s.putObject(req, b, body)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		s.getObject(req, b)
+	case r.Method == http.MethodDelete:
+		delete(b.objects, req.key)
+		req.w.WriteHeader(http.StatusNoContent)
+	default:
+		req.error(http.StatusNotImplemented, "NotImplemented", "object operation not implemented")
+	}
+}
+
+// objectFromHeaders 根据请求头构造对象的元数据
+func (req *fakeRequest) objectFromHeaders() *fakeObject {
+	object := &fakeObject{
+		contentType: req.r.Header.Get("Content-Type"),
+		expires:     req.r.Header.Get("Expires"),
+		meta:        make(map[string]string),
+	}
+	if object.contentType == "" {
+		object.contentType = "application/octet-stream"
+	}
+	metaPrefix := strings.ToLower(req.headerPrefix()) + "meta-"
+	for name, values := range req.r.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, metaPrefix) && len(values) > 0 {
+			object.meta[strings.TrimPrefix(lower, metaPrefix)] = values[0]
+		}
+	}
+	if tagging := req.header("Tagging"); tagging != "" {
+		object.tags, _ = url.ParseQuery(tagging)
+	}
+	return object
+}
+
+// checkPreconditions 检查条件写入头（If-Match / If-None-Match），不满足时返回 false 并写入 412
+func (req *fakeRequest) checkPreconditions(current *fakeObject) bool {
+	if match := req.r.Header.Get("If-None-Match"); match != "" && current != nil {
+		if match == "*" || strings.Trim(match, `"`) == current.etag {
+			req.error(http.StatusPreconditionFailed, "PreconditionFailed", "at least one of the pre-conditions you specified did not hold")
+			return false
+		}
+	}
+	if match := req.r.Header.Get("If-Match"); match != "" {
+		if current == nil || match != "*" && strings.Trim(match, `"`) != current.etag {
+			req.error(http.StatusPreconditionFailed, "PreconditionFailed", "at least one of the pre-conditions you specified did not hold")
+			return false
+		}
+	}
+	return true
+}
+
+// store 保存对象并写入包含 ETag 的响应头
+func (s *FakeServer) store(req *fakeRequest, b *fakeBucket, key string, object *fakeObject) {
+	object.modTime = time.Now().UTC()
+	if object.etag == "" {
+		sum := md5.Sum(object.data)
+		object.etag = hex.EncodeToString(sum[:])
+	}
+	b.objects[key] = object
+	req.w.Header().Set("ETag", `"`+object.etag+`"`)
+	if req.dialect != dialectS3 {
+		req.w.Header().Set(req.headerPrefix()+"Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum(object.data, crc64Table), 10))
+	}
+}
+
+var crc64Table = crc64.MakeTable(crc64.ECMA)
+
+// putObject 实现 PutObject
+func (s *FakeServer) putObject(req *fakeRequest, b *fakeBucket, body []byte) {
+	if !req.checkPreconditions(b.objects[req.key]) {
+		return
+	}
+	object := req.objectFromHeaders()
+	object.data = body
+	s.store(req, b, req.key, object)
+	req.w.WriteHeader(http.StatusOK)
+}
+
+// copyObject 实现 CopyObject
+func (s *FakeServer) copyObject(req *fakeRequest, b *fakeBucket) {
+	source := req.header("Copy-Source")
+	source, _, _ = strings.Cut(source, "?")
+	// OSS 使用 QueryEscape 编码源路径，S3 和 COS 使用路径编码
+	unescape := url.PathUnescape
+	if req.dialect == dialectOSS {
+		unescape = url.QueryUnescape
+	}
+	decoded, err := unescape(source)
+	if err != nil {
+		req.error(http.StatusBadRequest, "InvalidArgument", "invalid copy source")
+		return
+	}
+	srcBucketName, srcKey, _ := strings.Cut(strings.TrimPrefix(decoded, "/"), "/")
+	// COS 的源路径为 <bucket>-<appid>.cos.<region>.myqcloud.com/key
+	if req.dialect == dialectCOS {
+		srcBucketName, _, _ = strings.Cut(srcBucketName, ".")
+	}
+	srcBucket, ok := s.buckets[srcBucketName]
+	if !ok {
+		req.error(http.StatusNotFound, "NoSuchBucket", "the specified bucket does not exist")
+		return
+	}
+	src, ok := srcBucket.objects[srcKey]
+	if !ok {
+		req.error(http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	object := &fakeObject{
+		data:        src.data,
+		contentType: src.contentType,
+		expires:     src.expires,
+		meta:        src.meta,
+		tags:        src.tags,
+		etag:        src.etag,
+	}
+	if strings.EqualFold(req.header("Metadata-Directive"), "REPLACE") {
+		replaced := req.objectFromHeaders()
+		object.contentType, object.expires, object.meta = replaced.contentType, replaced.expires, replaced.meta
+	}
+	if strings.EqualFold(req.header("Tagging-Directive"), "REPLACE") {
+		object.tags, _ = url.ParseQuery(req.header("Tagging"))
+	}
+	s.store(req, b, req.key, object)
+
+	req.writeXML(http.StatusOK, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		LastModified string   `xml:"LastModified"`
+		ETag         string   `xml:"ETag"`
+	}{LastModified: formatXMLTime(object.modTime), ETag: `"` + object.etag + `"`})
+}
+
+// getObject 实现 GetObject 和 HeadObject，支持 Range
+func (s *FakeServer) getObject(req *fakeRequest, b *fakeBucket) {
+	object, ok := b.objects[req.key]
+	if !ok {
+		req.error(http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	h := req.w.Header()
+	h.Set("Content-Type", object.contentType)
+	h.Set("ETag", `"`+object.etag+`"`)
+	h.Set("Last-Modified", object.modTime.Format(http.TimeFormat))
+	h.Set("Accept-Ranges", "bytes")
+	if object.expires != "" {
+		h.Set("Expires", object.expires)
+	}
+	for k, v := range object.meta {
+		h.Set(req.headerPrefix()+"Meta-"+k, v)
+	}
+	if len(object.tags) > 0 {
+		h.Set(req.headerPrefix()+"Tagging-Count", strconv.Itoa(len(object.tags)))
+	}
+
+	data := object.data
+	status := http.StatusOK
+	if rangeHeader := req.r.Header.Get("Range"); rangeHeader != "" {
+		start, end, ok := parseRange(rangeHeader, int64(len(data)))
+		if !ok {
+			h.Del("Content-Type")
+			h.Set("Content-Range", fmt.Sprintf("bytes */%d", len(data)))
+			req.error(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "the requested range is not satisfiable")
+			return
+		}
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		data = data[start : end+1]
+		status = http.StatusPartialContent
+	} else if req.dialect != dialectS3 {
+		h.Set(req.headerPrefix()+"Hash-Crc64ecma", strconv.FormatUint(crc64.Checksum(data, crc64Table), 10))
+	}
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	req.w.WriteHeader(status)
+	if req.r.Method == http.MethodGet {
+		req.w.Write(data)
+	}
+}
+
+// parseRange 解析单个字节范围（bytes=a-b、bytes=a-、bytes=-n），返回闭区间
+func parseRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	if startStr == "" {
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil || n <= 0 || size == 0 {
+			return 0, 0, false
+		}
+		return max(size-n, 0), size - 1, true
+	}
+	start, err := strconv.ParseInt(startStr, 10, 64)
+	if err != nil || start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if endStr != "" {
+		if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+			return 0, 0, false
+		}
+		end = min(end, size-1)
+	}
+	return start, end, true
+}
+
+// serveTagging 实现对象标签的读取、设置和删除
+func (s *FakeServer) serveTagging(req *fakeRequest, b *fakeBucket, body []byte) {
+	object, ok := b.objects[req.key]
+	if !ok {
+		req.error(http.StatusNotFound, "NoSuchKey", "the specified key does not exist")
+		return
+	}
+
+	type tag struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	}
+	type tagging struct {
+		XMLName xml.Name `xml:"Tagging"`
+		Tags    []tag    `xml:"TagSet>Tag"`
+	}
+
+	switch req.r.Method {
+	case http.MethodGet:
+		result := tagging{}
+		keys := make([]string, 0, len(object.tags))
+		for k := range object.tags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Tags = append(result.Tags, tag{Key: k, Value: object.tags.Get(k)})
+		}
+		req.writeXML(http.StatusOK, result)
+	case http.MethodPut:
+		var input tagging
+		if err := xml.Unmarshal(body, &input); err != nil {
+			req.error(http.StatusBadRequest, "MalformedXML", err.Error())
+			return
+		}
+		updated := *object
+		updated.tags = url.Values{}
+		for _, t := range input.Tags {
+			updated.tags.Set(t.Key, t.Value)
+		}
+		b.objects[req.key] = &updated
+		req.w.WriteHeader(http.StatusOK)
+	case http.MethodDelete:
+		updated := *object
+		updated.tags = nil
+		b.objects[req.key] = &updated
+		req.w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// createMultipartUpload 实现 CreateMultipartUpload（POST ?uploads）
+func (s *FakeServer) createMultipartUpload(req *fakeRequest, b *fakeBucket) {
+	s.seq++
+	uploadID := fmt.Sprintf("upload-%d", s.seq)
+	b.uploads[uploadID] = &fakeUpload{
+		key:    req.key,
+		object: req.objectFromHeaders(),
+		parts:  make(map[int][]byte),
+	}
+	req.writeXML(http.StatusOK, struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}{Bucket: req.bucket, Key: req.key, UploadID: uploadID})
+}
+
+// uploadPart 实现 UploadPart（PUT ?partNumber&uploadId）
+func (s *FakeServer) uploadPart(req *fakeRequest, b *fakeBucket, body []byte) {
+	upload, ok := b.uploads[req.query.Get("uploadId")]
+	if !ok || upload.key != req.key {
+		req.error(http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	partNumber, err := strconv.Atoi(req.query.Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > 10000 {
+		req.error(http.StatusBadRequest, "InvalidArgument", "invalid part number")
+		return
+	}
+	upload.parts[partNumber] = body
+	sum := md5.Sum(body)
+	req.w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	req.w.WriteHeader(http.StatusOK)
+}
+
+// completeMultipartUpload 实现 CompleteMultipartUpload（POST ?uploadId），按请求中的顺序拼接分片
+func (s *FakeServer) completeMultipartUpload(req *fakeRequest, b *fakeBucket, body []byte) {
+	uploadID := req.query.Get("uploadId")
+	upload, ok := b.uploads[uploadID]
+	if !ok || upload.key != req.key {
+		req.error(http.StatusNotFound, "NoSuchUpload", "the specified upload does not exist")
+		return
+	}
+	var input struct {
+		Parts []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &input); err != nil {
+		req.error(http.StatusBadRequest, "MalformedXML", err.Error())
+		return
+	}
+
+	var data bytes.Buffer
+	var sums []byte
+	for _, part := range input.Parts {
+		partData, ok := upload.parts[part.PartNumber]
+		sum := md5.Sum(partData)
+		if !ok || strings.Trim(part.ETag, `"`) != hex.EncodeToString(sum[:]) {
+			req.error(http.StatusBadRequest, "InvalidPart", "one or more of the specified parts could not be found")
+			return
+		}
+		data.Write(partData)
+		sums = append(sums, sum[:]...)
+	}
+	if !req.checkPreconditions(b.objects[req.key]) {
+		return
+	}
+
+	object := upload.object
+	object.data = data.Bytes()
+	total := md5.Sum(sums)
+	object.etag = fmt.Sprintf("%s-%d", hex.EncodeToString(total[:]), len(input.Parts))
+	s.store(req, b, req.key, object)
+	delete(b.uploads, uploadID)
+
+	req.writeXML(http.StatusOK, struct {
+		XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+		Location string   `xml:"Location"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		ETag     string   `xml:"ETag"`
+	}{Location: s.URL + "/" + req.bucket + "/" + req.key, Bucket: req.bucket, Key: req.key, ETag: `"` + object.etag + `"`})
+}
+
+// formatXMLTime 格式化 XML 响应中的时间
+func formatXMLTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
@@ -0,0 +1,741 @@
+// Package storagetest 提供 storage.Storage 实现的一致性测试套件。
+//
+// 新的存储后端只需在测试中调用 RunConformance 即可验证其行为与其他后端一致：
+//
+//	func TestConformance(t *testing.T) {
+//		storagetest.RunConformance(t, func(t *testing.T) storage.Storage {
+//			return storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: t.TempDir()})
+//		})
+//	}
+//
+// 套件只断言各后端都应满足的约定。对于文件系统与对象存储之间本就存在的差异
+// （如删除不存在的文件是否报错、ListDir 中目录名是否以 / 结尾、空目录是否可见），
+// 套件接受两种行为，但要求错误可以用 errors.Is(err, storage.ErrNotExist) 判断。
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+)
+
+// Factory 为每个子测试创建一个空的存储实例，实例之间不能共享数据
+type Factory func(t *testing.T) storage.Storage
+
+// RunConformance 对 factory 创建的存储运行全部一致性测试
+func RunConformance(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, s storage.Storage)
+	}{
+		{"UploadDownload", testUploadDownload},
+		{"EmptyFile", testEmptyFile},
+		{"Overwrite", testOverwrite},
+		{"UnicodeKeys", testUnicodeKeys},
+		{"DownloadRange", testDownloadRange},
+		{"NotExist", testNotExist},
+		{"Delete", testDelete},
+		{"RenameMove", testRenameMove},
+		{"Copy", testCopy},
+		{"ListDir", testListDir},
+		{"DeleteDir", testDeleteDir},
+		{"Metadata", testMetadata},
+		{"Batch", testBatch},
+		{"ConcurrentWriters", testConcurrentWriters},
+		{"Expiration", testExpiration},
+		{"ConditionalUpload", testConditionalUpload},
+		{"Tags", testTags},
+		{"Versions", testVersions},
+		{"Presign", testPresign},
+		{"ResumableUpload", testResumableUpload},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := factory(t)
+			if s == nil {
+				t.Fatal("factory returned nil storage")
+			}
+			tt.fn(t, s)
+		})
+	}
+}
+
+// upload 上传字符串内容，失败时终止测试
+func upload(t *testing.T, s storage.Storage, filePath, content string, opts ...storage.UploadOption) {
+	t.Helper()
+	if err := s.Upload(context.Background(), filePath, strings.NewReader(content), opts...); err != nil {
+		t.Fatalf("Upload %q failed: %v", filePath, err)
+	}
+}
+
+// readAll 读取 reader 的全部内容并在可关闭时关闭
+func readAll(reader io.Reader, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	data, err := io.ReadAll(reader)
+	return string(data), err
+}
+
+// download 下载文件内容。部分后端（如本地存储）在读取时才返回错误，因此一并读取完毕
+func download(s storage.Storage, filePath string) (string, error) {
+	return readAll(s.Download(context.Background(), filePath))
+}
+
+// mustDownload 下载文件内容，失败时终止测试
+func mustDownload(t *testing.T, s storage.Storage, filePath string) string {
+	t.Helper()
+	content, err := download(s, filePath)
+	if err != nil {
+		t.Fatalf("Download %q failed: %v", filePath, err)
+	}
+	return content
+}
+
+// exists 检查文件是否存在，失败时终止测试
+func exists(t *testing.T, s storage.Storage, filePath string) bool {
+	t.Helper()
+	ok, err := s.Exists(context.Background(), filePath)
+	if err != nil {
+		t.Fatalf("Exists %q failed: %v", filePath, err)
+	}
+	return ok
+}
+
+// listNames 列出目录并返回规范化（去掉尾斜杠）后排序的名称，目录名以 / 结尾以便断言
+func listNames(t *testing.T, s storage.Storage, dirPath string) []string {
+	t.Helper()
+	files, err := s.ListDir(context.Background(), dirPath)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return nil
+		}
+		t.Fatalf("ListDir %q failed: %v", dirPath, err)
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name, "/")
+		if file.IsDir {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isNotExistOrNil 删除等幂等操作在文件不存在时可以成功，也可以返回 ErrNotExist
+func isNotExistOrNil(err error) bool {
+	return err == nil || errors.Is(err, storage.ErrNotExist)
+}
+
+func testUploadDownload(t *testing.T, s storage.Storage) {
+	upload(t, s, "hello.txt", "hello world")
+	upload(t, s, "a/b/c/nested.txt", "nested")
+
+	if got := mustDownload(t, s, "hello.txt"); got != "hello world" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if got := mustDownload(t, s, "a/b/c/nested.txt"); got != "nested" {
+		t.Fatalf("Unexpected nested content: %q", got)
+	}
+	if !exists(t, s, "hello.txt") || !exists(t, s, "a/b/c/nested.txt") {
+		t.Fatal("Uploaded files should exist")
+	}
+
+	// 非 Seek 的流式 reader 同样可以上传
+	data := bytes.Repeat([]byte("0123456789"), 10000)
+	if err := s.Upload(context.Background(), "stream.bin", io.MultiReader(bytes.NewReader(data))); err != nil {
+		t.Fatalf("Upload stream failed: %v", err)
+	}
+	if got := mustDownload(t, s, "stream.bin"); got != string(data) {
+		t.Fatalf("Unexpected stream content length: %d", len(got))
+	}
+}
+
+func testEmptyFile(t *testing.T, s storage.Storage) {
+	upload(t, s, "empty.txt", "")
+
+	if !exists(t, s, "empty.txt") {
+		t.Fatal("Empty file should exist")
+	}
+	if got := mustDownload(t, s, "empty.txt"); got != "" {
+		t.Fatalf("Expected empty content, got %q", got)
+	}
+	metadata, err := s.GetMetadata(context.Background(), "empty.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Size != 0 || metadata.IsDir {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+}
+
+func testOverwrite(t *testing.T, s storage.Storage) {
+	upload(t, s, "file.txt", "a much longer original content")
+	upload(t, s, "file.txt", "short")
+
+	if got := mustDownload(t, s, "file.txt"); got != "short" {
+		t.Fatalf("Unexpected content after overwrite: %q", got)
+	}
+	metadata, err := s.GetMetadata(context.Background(), "file.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.Size != 5 {
+		t.Fatalf("Expected size 5 after overwrite, got %d", metadata.Size)
+	}
+}
+
+func testUnicodeKeys(t *testing.T, s storage.Storage) {
+	keys := []string{"中文目录/文件 名.txt", "emoji/😀.txt", "special/a+b=c&d.txt"}
+	for _, key := range keys {
+		upload(t, s, key, key)
+	}
+	for _, key := range keys {
+		if got := mustDownload(t, s, key); got != key {
+			t.Fatalf("Unexpected content for %q: %q", key, got)
+		}
+	}
+	if names := listNames(t, s, "中文目录"); len(names) != 1 || names[0] != "文件 名.txt" {
+		t.Fatalf("Unexpected listing: %v", names)
+	}
+}
+
+func testDownloadRange(t *testing.T, s storage.Storage) {
+	upload(t, s, "range.txt", "0123456789")
+	ctx := context.Background()
+
+	cases := []struct {
+		offset, size int64
+		want         string
+	}{
+		{0, 4, "0123"},
+		{3, 3, "345"},
+		{8, 10, "89"}, // 超出文件末尾的部分被截断
+		{9, 1, "9"},
+	}
+	for _, c := range cases {
+		got, err := readAll(s.DownloadRange(ctx, "range.txt", c.offset, c.size))
+		if err != nil {
+			t.Fatalf("DownloadRange(%d, %d) failed: %v", c.offset, c.size, err)
+		}
+		if got != c.want {
+			t.Fatalf("DownloadRange(%d, %d) = %q, want %q", c.offset, c.size, got, c.want)
+		}
+	}
+
+	// 起始位置超出文件末尾时，返回空内容或错误均可，但不能返回数据
+	if got, err := readAll(s.DownloadRange(ctx, "range.txt", 20, 5)); err == nil && got != "" {
+		t.Fatalf("Expected no data beyond EOF, got %q", got)
+	}
+}
+
+func testNotExist(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+
+	if exists(t, s, "missing.txt") {
+		t.Fatal("Missing file should not exist")
+	}
+	if _, err := download(s, "missing.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from Download, got %v", err)
+	}
+	if _, err := readAll(s.DownloadRange(ctx, "missing.txt", 0, 10)); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from DownloadRange, got %v", err)
+	}
+	if _, err := s.GetMetadata(ctx, "missing.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from GetMetadata, got %v", err)
+	}
+	if err := s.Rename(ctx, "missing.txt", "renamed.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from Rename, got %v", err)
+	}
+	if err := s.Copy(ctx, "missing.txt", "copied.txt"); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from Copy, got %v", err)
+	}
+	if exists(t, s, "renamed.txt") || exists(t, s, "copied.txt") {
+		t.Fatal("Failed Rename/Copy should not create the destination")
+	}
+}
+
+func testDelete(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	upload(t, s, "dir/delete.txt", "data")
+	upload(t, s, "dir/keep.txt", "data")
+
+	if err := s.Delete(ctx, "dir/delete.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if exists(t, s, "dir/delete.txt") {
+		t.Fatal("Deleted file should not exist")
+	}
+	if !exists(t, s, "dir/keep.txt") {
+		t.Fatal("Delete should not affect sibling files")
+	}
+	if err := s.Delete(ctx, "dir/delete.txt"); !isNotExistOrNil(err) {
+		t.Fatalf("Delete of missing file should succeed or return ErrNotExist, got %v", err)
+	}
+}
+
+func testRenameMove(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	upload(t, s, "old.txt", "rename me")
+	upload(t, s, "src/move.txt", "move me")
+
+	if err := s.Rename(ctx, "old.txt", "renamed/new.txt"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if exists(t, s, "old.txt") {
+		t.Fatal("Source should not exist after Rename")
+	}
+	if got := mustDownload(t, s, "renamed/new.txt"); got != "rename me" {
+		t.Fatalf("Unexpected content after Rename: %q", got)
+	}
+
+	// 目标已存在时覆盖
+	upload(t, s, "dst/move.txt", "old content")
+	if err := s.Move(ctx, "src/move.txt", "dst/move.txt"); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if exists(t, s, "src/move.txt") {
+		t.Fatal("Source should not exist after Move")
+	}
+	if got := mustDownload(t, s, "dst/move.txt"); got != "move me" {
+		t.Fatalf("Unexpected content after Move: %q", got)
+	}
+}
+
+func testCopy(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	upload(t, s, "original.txt", "copy me")
+
+	if err := s.Copy(ctx, "original.txt", "copies/copy.txt"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if got := mustDownload(t, s, "original.txt"); got != "copy me" {
+		t.Fatalf("Source changed after Copy: %q", got)
+	}
+	if got := mustDownload(t, s, "copies/copy.txt"); got != "copy me" {
+		t.Fatalf("Unexpected content after Copy: %q", got)
+	}
+
+	// 副本独立于源文件
+	upload(t, s, "original.txt", "changed")
+	if got := mustDownload(t, s, "copies/copy.txt"); got != "copy me" {
+		t.Fatalf("Copy should not follow source changes: %q", got)
+	}
+}
+
+func testListDir(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	if err := s.CreateDir(ctx, "list"); err != nil {
+		t.Fatalf("CreateDir failed: %v", err)
+	}
+	upload(t, s, "list/a.txt", "a")
+	upload(t, s, "list/b.txt", "bb")
+	upload(t, s, "list/sub/c.txt", "ccc")
+	upload(t, s, "list/sub/deeper/d.txt", "dddd")
+	upload(t, s, "listing.txt", "not in list/")
+
+	// 只列出当前层级，子目录以目录条目出现
+	want := []string{"a.txt", "b.txt", "sub/"}
+	if got := listNames(t, s, "list"); !equal(got, want) {
+		t.Fatalf("ListDir(list) = %v, want %v", got, want)
+	}
+	// 带尾斜杠的目录路径结果相同
+	if got := listNames(t, s, "list/"); !equal(got, want) {
+		t.Fatalf("ListDir(list/) = %v, want %v", got, want)
+	}
+	if got := listNames(t, s, "list/sub"); !equal(got, []string{"c.txt", "deeper/"}) {
+		t.Fatalf("ListDir(list/sub) = %v", got)
+	}
+
+	files, err := s.ListDir(ctx, "list")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	for _, file := range files {
+		if file.Name == "b.txt" && (file.Size != 2 || file.IsDir) {
+			t.Fatalf("Unexpected file entry: %+v", file)
+		}
+	}
+
+	// 不存在的目录返回空列表或 ErrNotExist
+	if got := listNames(t, s, "no-such-dir"); len(got) != 0 {
+		t.Fatalf("Expected empty listing, got %v", got)
+	}
+
+	// Walk 可以递归遍历所有后端
+	var walked []string
+	err = storage.Walk(ctx, s, "list", func(filePath string, metadata storage.FileMetadata) error {
+		if !metadata.IsDir {
+			walked = append(walked, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+	sort.Strings(walked)
+	if !equal(walked, []string{"list/a.txt", "list/b.txt", "list/sub/c.txt", "list/sub/deeper/d.txt"}) {
+		t.Fatalf("Unexpected walk result: %v", walked)
+	}
+}
+
+func testDeleteDir(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	upload(t, s, "tree/a.txt", "a")
+	upload(t, s, "tree/sub/b.txt", "b")
+	upload(t, s, "tree/sub/deeper/c.txt", "c")
+	upload(t, s, "treehouse.txt", "sibling with common prefix")
+
+	if err := s.DeleteDir(ctx, "tree"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	for _, filePath := range []string{"tree/a.txt", "tree/sub/b.txt", "tree/sub/deeper/c.txt"} {
+		if exists(t, s, filePath) {
+			t.Fatalf("%s should be deleted recursively", filePath)
+		}
+	}
+	if got := listNames(t, s, "tree"); len(got) != 0 {
+		t.Fatalf("Expected empty directory after DeleteDir, got %v", got)
+	}
+	if !exists(t, s, "treehouse.txt") {
+		t.Fatal("DeleteDir should not delete files sharing the prefix")
+	}
+	if err := s.DeleteDir(ctx, "tree"); !isNotExistOrNil(err) {
+		t.Fatalf("DeleteDir of missing directory should succeed or return ErrNotExist, got %v", err)
+	}
+}
+
+func testMetadata(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	before := time.Now().Add(-time.Minute)
+	upload(t, s, "meta/info.txt", "metadata")
+
+	metadata, err := s.GetMetadata(ctx, "meta/info.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	// 与其他后端一致，GetMetadata 返回的名称为传入的文件路径
+	if metadata.Name != "meta/info.txt" || metadata.Size != 8 || metadata.IsDir {
+		t.Fatalf("Unexpected metadata: %+v", metadata)
+	}
+	if metadata.ModTime.Before(before) {
+		t.Fatalf("Unexpected ModTime: %v", metadata.ModTime)
+	}
+	if !strings.HasPrefix(metadata.MIMEType, "text/plain") {
+		t.Fatalf("Unexpected MIMEType: %q", metadata.MIMEType)
+	}
+
+	upload(t, s, "meta/data.json", `{"k":"v"}`, storage.WithContentType("application/vnd.test+json"))
+	metadata, err = s.GetMetadata(ctx, "meta/data.json")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.MIMEType != "application/vnd.test+json" {
+		t.Fatalf("Explicit content type not preserved: %q", metadata.MIMEType)
+	}
+
+	// 不支持更新元数据的后端必须返回 ErrNotSupported
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	err = s.UpdateMetadata(ctx, "meta/info.txt", &storage.FileMetadata{ModTime: modTime})
+	switch {
+	case errors.Is(err, storage.ErrNotSupported):
+	case err != nil:
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	default:
+		metadata, err = s.GetMetadata(ctx, "meta/info.txt")
+		if err != nil {
+			t.Fatalf("GetMetadata failed: %v", err)
+		}
+		if !metadata.ModTime.Equal(modTime) {
+			t.Fatalf("ModTime not updated: %v", metadata.ModTime)
+		}
+	}
+}
+
+func testBatch(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	files := map[string]io.Reader{
+		"batch/1.txt":     strings.NewReader("one"),
+		"batch/2.txt":     strings.NewReader("two"),
+		"batch/sub/3.txt": strings.NewReader("three"),
+	}
+	if err := s.BatchUpload(ctx, files); err != nil {
+		t.Fatalf("BatchUpload failed: %v", err)
+	}
+
+	paths := []string{"batch/1.txt", "batch/2.txt", "batch/sub/3.txt"}
+	readers, err := s.BatchDownload(ctx, paths)
+	if err != nil {
+		t.Fatalf("BatchDownload failed: %v", err)
+	}
+	want := map[string]string{"batch/1.txt": "one", "batch/2.txt": "two", "batch/sub/3.txt": "three"}
+	for filePath, content := range want {
+		got, err := readAll(readers[filePath], nil)
+		if err != nil || got != content {
+			t.Fatalf("BatchDownload %s = %q, %v", filePath, got, err)
+		}
+	}
+
+	if _, err := readAllBatch(s.BatchDownload(ctx, []string{"batch/1.txt", "batch/missing.txt"})); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from BatchDownload, got %v", err)
+	}
+
+	if err := s.BatchDelete(ctx, paths); err != nil {
+		t.Fatalf("BatchDelete failed: %v", err)
+	}
+	for _, filePath := range paths {
+		if exists(t, s, filePath) {
+			t.Fatalf("%s should be deleted", filePath)
+		}
+	}
+}
+
+// readAllBatch 读取批量下载的全部内容，返回第一个错误
+func readAllBatch(readers map[string]io.Reader, err error) (map[string]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string]string, len(readers))
+	var firstErr error
+	for filePath, reader := range readers {
+		content, err := readAll(reader, nil)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		contents[filePath] = content
+	}
+	return contents, firstErr
+}
+
+func testConcurrentWriters(t *testing.T, s storage.Storage) {
+	const writers = 8
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers*2)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			content := fmt.Sprintf("writer-%d-%s", i, strings.Repeat("x", i*1000))
+			if err := s.Upload(ctx, fmt.Sprintf("concurrent/%d.txt", i), strings.NewReader(content)); err != nil {
+				errs <- err
+			}
+			// 多个写入方覆盖同一个文件
+			if err := s.Upload(ctx, "concurrent/shared.txt", strings.NewReader(content)); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Concurrent Upload failed: %v", err)
+	}
+
+	for i := 0; i < writers; i++ {
+		if !exists(t, s, fmt.Sprintf("concurrent/%d.txt", i)) {
+			t.Fatalf("concurrent/%d.txt should exist", i)
+		}
+	}
+
+	// 最终内容必须完整地来自某一个写入方，不能是多个写入的混合
+	shared := mustDownload(t, s, "concurrent/shared.txt")
+	match := regexp.MustCompile(`^writer-(\d+)-(x*)$`).FindStringSubmatch(shared)
+	if match == nil {
+		t.Fatalf("Shared file content is corrupted: %.40q", shared)
+	}
+	var i int
+	fmt.Sscanf(match[1], "%d", &i)
+	if len(match[2]) != i*1000 {
+		t.Fatalf("Shared file content is mixed: writer %d with %d bytes of padding", i, len(match[2]))
+	}
+}
+
+func testExpiration(t *testing.T, s storage.Storage) {
+	reader, ok := storage.As[storage.ExpirationReader](s)
+	if !ok {
+		t.Skip("storage does not implement ExpirationReader")
+	}
+	ctx := context.Background()
+	start := time.Now().Truncate(time.Second)
+	upload(t, s, "expire/temp.txt", "temp", storage.WithExpiration(time.Hour))
+	upload(t, s, "expire/keep.txt", "keep")
+
+	deadline, err := reader.GetExpiration(ctx, "expire/temp.txt")
+	if err != nil {
+		t.Fatalf("GetExpiration failed: %v", err)
+	}
+	if deadline.Before(start.Add(time.Hour)) || deadline.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("Unexpected deadline: %v", deadline)
+	}
+	if deadline, err := reader.GetExpiration(ctx, "expire/keep.txt"); err != nil || !deadline.IsZero() {
+		t.Fatalf("Expected zero deadline, got %v, %v", deadline, err)
+	}
+	if got := mustDownload(t, s, "expire/temp.txt"); got != "temp" {
+		t.Fatalf("Unexpired file should be readable: %q", got)
+	}
+}
+
+func testConditionalUpload(t *testing.T, s storage.Storage) {
+	cu, ok := storage.As[storage.ConditionalUploader](s)
+	if !ok {
+		t.Skip("storage does not implement ConditionalUploader")
+	}
+	ctx := context.Background()
+
+	if err := cu.UploadIf(ctx, "cond.txt", strings.NewReader("v1"), storage.UploadCondition{IfNoneMatch: true}); err != nil {
+		t.Fatalf("UploadIf on missing file failed: %v", err)
+	}
+	err := cu.UploadIf(ctx, "cond.txt", strings.NewReader("v2"), storage.UploadCondition{IfNoneMatch: true})
+	if !errors.Is(err, storage.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed, got %v", err)
+	}
+	if got := mustDownload(t, s, "cond.txt"); got != "v1" {
+		t.Fatalf("Failed UploadIf should not modify the file: %q", got)
+	}
+
+	metadata, err := s.GetMetadata(ctx, "cond.txt")
+	if err != nil {
+		t.Fatalf("GetMetadata failed: %v", err)
+	}
+	if metadata.ETag == "" {
+		t.Fatal("ConditionalUploader should report ETag in metadata")
+	}
+	if err := cu.UploadIf(ctx, "cond.txt", strings.NewReader("v2"), storage.UploadCondition{IfMatch: metadata.ETag}); err != nil {
+		t.Fatalf("UploadIf with current ETag failed: %v", err)
+	}
+	err = cu.UploadIf(ctx, "cond.txt", strings.NewReader("v3"), storage.UploadCondition{IfMatch: metadata.ETag})
+	if !errors.Is(err, storage.ErrPreconditionFailed) {
+		t.Fatalf("Expected ErrPreconditionFailed for stale ETag, got %v", err)
+	}
+	if got := mustDownload(t, s, "cond.txt"); got != "v2" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+}
+
+func testTags(t *testing.T, s storage.Storage) {
+	tagger, ok := storage.As[storage.Tagger](s)
+	if !ok {
+		t.Skip("storage does not implement Tagger")
+	}
+	ctx := context.Background()
+	upload(t, s, "tagged.txt", "tagged")
+
+	tags := map[string]string{"env": "test", "owner": "storage"}
+	if err := tagger.SetTags(ctx, "tagged.txt", tags); err != nil {
+		t.Fatalf("SetTags failed: %v", err)
+	}
+	got, err := tagger.GetTags(ctx, "tagged.txt")
+	if err != nil {
+		t.Fatalf("GetTags failed: %v", err)
+	}
+	if len(got) != len(tags) || got["env"] != "test" || got["owner"] != "storage" {
+		t.Fatalf("Unexpected tags: %v", got)
+	}
+	if err := tagger.SetTags(ctx, "missing.txt", tags); !errors.Is(err, storage.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist from SetTags, got %v", err)
+	}
+}
+
+func testVersions(t *testing.T, s storage.Storage) {
+	versioner, ok := storage.As[storage.Versioner](s)
+	if !ok {
+		t.Skip("storage does not implement Versioner")
+	}
+	ctx := context.Background()
+	upload(t, s, "versioned.txt", "v1")
+	upload(t, s, "versioned.txt", "v2")
+
+	versions, err := versioner.ListVersions(ctx, "versioned.txt")
+	if err != nil {
+		t.Fatalf("ListVersions failed: %v", err)
+	}
+	if len(versions) == 0 || !versions[0].IsLatest || versions[0].Size != 2 {
+		t.Fatalf("Unexpected versions: %+v", versions)
+	}
+	for _, version := range versions[1:] {
+		if version.IsLatest {
+			t.Fatalf("Only the first version should be latest: %+v", versions)
+		}
+	}
+	if got, err := readAll(versioner.DownloadVersion(ctx, "versioned.txt", versions[0].VersionID)); err != nil || got != "v2" {
+		t.Fatalf("DownloadVersion latest = %q, %v", got, err)
+	}
+	if _, err := readAll(versioner.DownloadVersion(ctx, "versioned.txt", "no-such-version")); err == nil {
+		t.Fatal("Expected error for unknown version")
+	}
+}
+
+func testPresign(t *testing.T, s storage.Storage) {
+	presigner, ok := storage.As[storage.Presigner](s)
+	if !ok {
+		t.Skip("storage does not implement Presigner")
+	}
+	ctx := context.Background()
+	upload(t, s, "presigned.txt", "presigned")
+
+	for _, method := range []string{http.MethodGet, http.MethodPut} {
+		url, err := presigner.Presign(ctx, method, "presigned.txt", time.Minute)
+		if err != nil {
+			t.Fatalf("Presign %s failed: %v", method, err)
+		}
+		if url == "" {
+			t.Fatalf("Presign %s returned empty URL", method)
+		}
+	}
+}
+
+func testResumableUpload(t *testing.T, s storage.Storage) {
+	resumer, ok := storage.As[storage.ResumableUploader](s)
+	if !ok {
+		t.Skip("storage does not implement ResumableUploader")
+	}
+	ctx := context.Background()
+
+	if err := resumer.UploadAt(ctx, "resume/data.bin", strings.NewReader("hello, wor"), 0); err != nil {
+		t.Fatalf("UploadAt(0) failed: %v", err)
+	}
+	// 保留前 offset 个字节，丢弃其后的内容
+	if err := resumer.UploadAt(ctx, "resume/data.bin", strings.NewReader("world!"), 7); err != nil {
+		t.Fatalf("UploadAt(7) failed: %v", err)
+	}
+	if got := mustDownload(t, s, "resume/data.bin"); got != "hello, world!" {
+		t.Fatalf("Unexpected resumed content: %q", got)
+	}
+	if err := resumer.UploadAt(ctx, "resume/data.bin", strings.NewReader("x"), 100); err == nil {
+		t.Fatal("Expected error for offset beyond file size")
+	}
+	if got := mustDownload(t, s, "resume/data.bin"); got != "hello, world!" {
+		t.Fatalf("Failed UploadAt should not modify the file: %q", got)
+	}
+}
+
+// equal 比较两个字符串切片
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
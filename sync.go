@@ -0,0 +1,549 @@
+package storage
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/time/rate"
+)
+
+// defaultModifyWindow 按修改时间比较时默认允许的误差，兼容只保存到秒的后端（如 FTP、zip）
+const defaultModifyWindow = time.Second
+
+// SyncCompareMode 判断目标文件是否需要更新的比较方式
+type SyncCompareMode string
+
+const (
+	// SyncCompareSizeModTime 大小不同，或源文件的修改时间晚于目标文件时更新（默认）。
+	// 目标存储不支持设置修改时间时，上传时间总是晚于源文件的修改时间，未修改的文件不会重复复制。
+	SyncCompareSizeModTime SyncCompareMode = "size_modtime"
+	// SyncCompareChecksum 大小不同或 MD5 不同时更新。后端声明 ETag 为 MD5 时直接比较，否则下载文件计算
+	SyncCompareChecksum SyncCompareMode = "checksum"
+)
+
+// SyncOp 同步操作类型
+type SyncOp string
+
+const (
+	SyncCreate SyncOp = "create" // 目标中不存在，复制
+	SyncUpdate SyncOp = "update" // 目标中已存在但内容不同，覆盖
+	SyncDelete SyncOp = "delete" // 源中不存在，从目标中删除（DeleteExtraneous）
+)
+
+// SyncConfig 同步配置
+type SyncConfig struct {
+	Compare          SyncCompareMode `yaml:"compare" json:"compare"`                     // 比较方式，默认 size_modtime
+	Include          []string        `yaml:"include" json:"include"`                     // 只同步匹配的文件（glob），为空时同步所有文件
+	Exclude          []string        `yaml:"exclude" json:"exclude"`                     // 不同步匹配的文件（glob），目标中匹配的文件也不会被删除
+	DryRun           bool            `yaml:"dry_run" json:"dry_run"`                     // 只生成计划，不实际复制或删除
+	DeleteExtraneous bool            `yaml:"delete_extraneous" json:"delete_extraneous"` // 删除目标中源不存在的文件
+	Concurrency      int             `yaml:"concurrency" json:"concurrency"`             // 同时复制或比较的文件数，默认 4
+	BandwidthLimit   int64           `yaml:"bandwidth_limit" json:"bandwidth_limit"`     // 总带宽限制（字节/秒），0 表示不限制
+	ModifyWindow     time.Duration   `yaml:"modify_window" json:"modify_window"`         // 比较修改时间时允许的误差，默认 1 秒
+	Verify           VerifyMode      `yaml:"verify" json:"verify"`                       // 复制后的校验方式，默认 VerifyChecksum
+	StatePath        string          `yaml:"state_path" json:"state_path"`               // 本地状态文件，中断后重新同步时跳过已完成的文件
+}
+
+// SyncAction 同步计划中的一项操作
+type SyncAction struct {
+	Op    SyncOp `json:"op"`              // 操作类型
+	Path  string `json:"path"`            // 相对同步目录的路径
+	Size  int64  `json:"size"`            // 文件大小（删除时为目标文件大小）
+	Error string `json:"error,omitempty"` // 执行失败的原因
+}
+
+// SyncReport 同步结果
+type SyncReport struct {
+	Scanned   int64         `json:"scanned"`   // 参与同步的源文件数（过滤后）
+	Created   int64         `json:"created"`   // 复制的新文件数（DryRun 时为计划数，下同）
+	Updated   int64         `json:"updated"`   // 更新的文件数
+	Deleted   int64         `json:"deleted"`   // 删除的目标文件数
+	Unchanged int64         `json:"unchanged"` // 无需更新的文件数
+	Resumed   int64         `json:"resumed"`   // 根据状态文件跳过比较的文件数（计入 Unchanged）
+	Failed    int64         `json:"failed"`    // 失败的操作数
+	Bytes     int64         `json:"bytes"`     // 复制的字节数
+	DryRun    bool          `json:"dry_run"`   // 是否为演练
+	Actions   []SyncAction  `json:"actions"`   // 计划的操作及执行结果
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Sync 将 src 中 srcDir 目录单向同步到 dst 中的 dstDir 目录（类似 rsync）。
+//
+// 先遍历两侧并按 Compare 比较，生成创建、更新和删除的计划；DryRun 时只返回计划。
+// 复制使用 CopyBetween 的方式（服务端复制或流式复制、保留元数据、校验），失败的文件记录在报告中，其余文件继续同步。
+// 有文件复制失败时不执行删除，避免在目标不完整时删除数据。
+// Include 和 Exclude 中不含 / 的模式匹配路径中任一层级的名称（如 *.tmp），含 / 的模式从同步目录开始匹配路径或其上级目录（如 cache/*）。
+// 只同步文件，空目录不会被创建或删除。
+//
+// 设置 StatePath 时，每复制完成一个文件就追加记录到该本地文件；中断后重新同步，源文件未变化的记录直接视为已同步，
+// 不再比较校验和。同步全部成功后删除状态文件。
+func Sync(ctx context.Context, src Storage, srcDir string, dst Storage, dstDir string, config SyncConfig) (*SyncReport, error) {
+	report := &SyncReport{DryRun: config.DryRun, StartedAt: time.Now()}
+	defer func() { report.Duration = time.Since(report.StartedAt) }()
+	hlog.CtxInfof(ctx, "开始同步: %s -> %s", srcDir, dstDir)
+
+	if err := validateSyncConfig(&config); err != nil {
+		return report, err
+	}
+	srcDir, dstDir = strings.Trim(srcDir, "/"), strings.Trim(dstDir, "/")
+
+	srcFiles, err := syncList(ctx, src, srcDir, config)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "同步时遍历源目录失败: %v", err)
+		return report, err
+	}
+	dstFiles, err := syncList(ctx, dst, dstDir, config)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "同步时遍历目标目录失败: %v", err)
+		return report, err
+	}
+	report.Scanned = int64(len(srcFiles))
+
+	state, err := openSyncState(config.StatePath, srcDir, dstDir, !config.DryRun)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "打开同步状态文件失败: %v", err)
+		return report, err
+	}
+	defer state.Close()
+
+	var limiter *rate.Limiter
+	if config.BandwidthLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.BandwidthLimit), int(min(config.BandwidthLimit, maxRateBurst)))
+	}
+	s := &syncer{
+		src: src, srcDir: srcDir, dst: dst, dstDir: dstDir,
+		config: config, state: state, report: report,
+		options: &CopyOptions{Concurrency: 1, Verify: config.Verify, ServerCopy: true, limiter: limiter},
+	}
+
+	transfers, err := s.plan(ctx, srcFiles, dstFiles)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "生成同步计划失败: %v", err)
+		return report, err
+	}
+	var deletes []SyncAction
+	if config.DeleteExtraneous {
+		for _, rel := range sortedKeys(dstFiles) {
+			if _, ok := srcFiles[rel]; !ok {
+				deletes = append(deletes, SyncAction{Op: SyncDelete, Path: rel, Size: dstFiles[rel].Size})
+			}
+		}
+	}
+
+	if config.DryRun {
+		for _, action := range transfers {
+			report.count(action)
+		}
+		for _, action := range deletes {
+			report.count(action)
+		}
+		report.Actions = append(append([]SyncAction{}, transfers...), deletes...)
+		hlog.CtxInfof(ctx, "同步演练完成: 创建 %d, 更新 %d, 删除 %d, 未变化 %d", report.Created, report.Updated, report.Deleted, report.Unchanged)
+		return report, nil
+	}
+
+	firstErr := s.execute(ctx, transfers, func(ctx context.Context, action SyncAction) error {
+		metadata := srcFiles[action.Path]
+		if err := copyFileBetween(ctx, src, path.Join(srcDir, action.Path), dst, path.Join(dstDir, action.Path), nil, s.options); err != nil {
+			return err
+		}
+		return state.Record(action.Path, metadata)
+	})
+	if firstErr == nil {
+		firstErr = s.execute(ctx, deletes, func(ctx context.Context, action SyncAction) error {
+			return dst.Delete(ctx, path.Join(dstDir, action.Path))
+		})
+	} else if len(deletes) > 0 {
+		hlog.CtxErrorf(ctx, "有文件复制失败，跳过删除 %d 个目标文件", len(deletes))
+	}
+	report.Actions = append(append([]SyncAction{}, transfers...), deletes...)
+
+	if firstErr != nil {
+		hlog.CtxErrorf(ctx, "同步完成，%d 个操作失败: %v", report.Failed, firstErr)
+		return report, fmt.Errorf("同步失败 %d 个操作: %w", report.Failed, firstErr)
+	}
+	if err := state.Remove(); err != nil {
+		hlog.CtxErrorf(ctx, "删除同步状态文件失败: %v", err)
+	}
+	hlog.CtxInfof(ctx, "同步完成: 创建 %d, 更新 %d, 删除 %d, 未变化 %d, 复制 %d 字节", report.Created, report.Updated, report.Deleted, report.Unchanged, report.Bytes)
+	return report, nil
+}
+
+// validateSyncConfig 检查配置并填充默认值
+func validateSyncConfig(config *SyncConfig) error {
+	switch config.Compare {
+	case "":
+		config.Compare = SyncCompareSizeModTime
+	case SyncCompareSizeModTime, SyncCompareChecksum:
+	default:
+		return fmt.Errorf("不支持的同步比较方式: %s", config.Compare)
+	}
+	for _, pattern := range append(append([]string{}, config.Include...), config.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("无效的同步过滤模式 %q: %w", pattern, err)
+		}
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaultCopyConcurrency
+	}
+	if config.ModifyWindow <= 0 {
+		config.ModifyWindow = defaultModifyWindow
+	}
+	return nil
+}
+
+// syncList 遍历目录，返回通过过滤的文件（相对路径到元数据），目录不存在时返回空
+func syncList(ctx context.Context, s Storage, dir string, config SyncConfig) (map[string]FileMetadata, error) {
+	files := make(map[string]FileMetadata)
+	err := Walk(ctx, s, dir, func(filePath string, metadata FileMetadata) error {
+		rel := strings.TrimPrefix(strings.TrimPrefix(filePath, dir), "/")
+		if syncIncluded(rel, config) {
+			files[rel] = metadata
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return nil, err
+	}
+	return files, nil
+}
+
+// syncIncluded 判断相对路径是否通过 Include 和 Exclude 过滤
+func syncIncluded(rel string, config SyncConfig) bool {
+	if len(config.Include) > 0 && !syncMatchAny(config.Include, rel) {
+		return false
+	}
+	return !syncMatchAny(config.Exclude, rel)
+}
+
+// syncMatchAny 判断相对路径是否匹配任一模式
+func syncMatchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if syncMatch(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// syncMatch 不含 / 的模式匹配任一层级的名称，含 / 的模式匹配完整路径或其上级目录
+func syncMatch(pattern, rel string) bool {
+	if !strings.Contains(strings.Trim(pattern, "/"), "/") {
+		pattern = strings.Trim(pattern, "/")
+		for _, name := range strings.Split(rel, "/") {
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	pattern = strings.Trim(pattern, "/")
+	for p := rel; p != "." && p != "/"; p = path.Dir(p) {
+		if ok, _ := path.Match(pattern, p); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// syncer 一次同步的执行状态
+type syncer struct {
+	src     Storage
+	srcDir  string
+	dst     Storage
+	dstDir  string
+	config  SyncConfig
+	options *CopyOptions
+	state   *syncState
+	report  *SyncReport
+	mu      sync.Mutex // 保护 report
+}
+
+// plan 比较两侧的文件，返回需要创建和更新的文件，未变化的文件只计数
+func (s *syncer) plan(ctx context.Context, srcFiles, dstFiles map[string]FileMetadata) ([]SyncAction, error) {
+	var (
+		transfers []SyncAction
+		compare   []string
+	)
+	for _, rel := range sortedKeys(srcFiles) {
+		srcMeta := srcFiles[rel]
+		dstMeta, exists := dstFiles[rel]
+		switch {
+		case !exists:
+			transfers = append(transfers, SyncAction{Op: SyncCreate, Path: rel, Size: srcMeta.Size})
+		case srcMeta.Size != dstMeta.Size:
+			transfers = append(transfers, SyncAction{Op: SyncUpdate, Path: rel, Size: srcMeta.Size})
+		case s.state.Done(rel, srcMeta):
+			s.report.Unchanged++
+			s.report.Resumed++
+		case s.config.Compare == SyncCompareChecksum:
+			compare = append(compare, rel)
+		case srcMeta.ModTime.After(dstMeta.ModTime.Add(s.config.ModifyWindow)):
+			transfers = append(transfers, SyncAction{Op: SyncUpdate, Path: rel, Size: srcMeta.Size})
+		default:
+			s.report.Unchanged++
+		}
+	}
+	if len(compare) == 0 {
+		return transfers, nil
+	}
+
+	// 并发比较校验和
+	changed := make([]bool, len(compare))
+	var firstErr error
+	var errOnce sync.Once
+	s.parallel(ctx, len(compare), func(ctx context.Context, i int) {
+		rel := compare[i]
+		srcSum, err := fileMD5(ctx, s.src, path.Join(s.srcDir, rel), srcFiles[rel], s.options.limiter)
+		if err == nil {
+			var dstSum string
+			dstSum, err = fileMD5(ctx, s.dst, path.Join(s.dstDir, rel), dstFiles[rel], s.options.limiter)
+			changed[i] = srcSum != dstSum
+		}
+		if err != nil {
+			errOnce.Do(func() { firstErr = fmt.Errorf("比较文件 %s 失败: %w", rel, err) })
+		}
+	})
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for i, rel := range compare {
+		if changed[i] {
+			transfers = append(transfers, SyncAction{Op: SyncUpdate, Path: rel, Size: srcFiles[rel].Size})
+			continue
+		}
+		s.report.Unchanged++
+		// 校验和一致的文件同样记录，中断后重新同步时不再比较
+		if err := s.state.Record(rel, srcFiles[rel]); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].Path < transfers[j].Path })
+	return transfers, nil
+}
+
+// execute 并发执行操作，失败的操作记录到报告中并继续执行其余操作，返回第一个错误
+func (s *syncer) execute(ctx context.Context, actions []SyncAction, fn func(ctx context.Context, action SyncAction) error) error {
+	var firstErr error
+	s.parallel(ctx, len(actions), func(ctx context.Context, i int) {
+		err := fn(ctx, actions[i])
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if err != nil {
+			actions[i].Error = err.Error()
+			s.report.Failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			hlog.CtxErrorf(ctx, "同步操作失败: %s %s, %v", actions[i].Op, actions[i].Path, err)
+			return
+		}
+		s.report.count(actions[i])
+	})
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return firstErr
+}
+
+// parallel 以 Concurrency 个并发执行 fn，ctx 结束后不再启动新的任务
+func (s *syncer) parallel(ctx context.Context, n int, fn func(ctx context.Context, i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.config.Concurrency)
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(ctx, i)
+		}()
+	}
+	wg.Wait()
+}
+
+// count 将成功（或 DryRun 中计划）的操作计入报告
+func (r *SyncReport) count(action SyncAction) {
+	switch action.Op {
+	case SyncCreate:
+		r.Created++
+		r.Bytes += action.Size
+	case SyncUpdate:
+		r.Updated++
+		r.Bytes += action.Size
+	case SyncDelete:
+		r.Deleted++
+	}
+}
+
+// fileMD5 返回文件内容的 MD5，后端声明 ETag 为 MD5 时直接使用，否则下载计算
+func fileMD5(ctx context.Context, s Storage, filePath string, metadata FileMetadata, limiter *rate.Limiter) (string, error) {
+	if sum := md5ETag(&metadata); sum != "" {
+		return sum, nil
+	}
+	reader, err := s.Download(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	h := md5.New()
+	if _, err := io.Copy(h, rateLimit(ctx, reader, limiter)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// sortedKeys 返回排序后的文件路径，使计划和报告的顺序稳定
+func sortedKeys(files map[string]FileMetadata) []string {
+	keys := make([]string, 0, len(files))
+	for key := range files {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// syncStateHeader 状态文件第一行，记录同步的目录，目录不同时忽略已有状态
+type syncStateHeader struct {
+	SrcDir string `json:"src_dir"`
+	DstDir string `json:"dst_dir"`
+}
+
+// syncStateEntry 状态文件中已复制完成的文件，记录复制时源文件的大小、修改时间和 ETag
+type syncStateEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mod_time"`
+	ETag    string `json:"etag,omitempty"`
+}
+
+// syncState 同步状态文件（JSON Lines，每复制完成一个文件追加一行，进程中断时最多丢失最后一行）
+type syncState struct {
+	path string
+	done map[string]syncStateEntry
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openSyncState 读取状态文件，write 为 true 时打开文件用于追加记录；statePath 为空时返回不记录状态的空实现
+func openSyncState(statePath, srcDir, dstDir string, write bool) (*syncState, error) {
+	state := &syncState{path: statePath, done: make(map[string]syncStateEntry)}
+	if statePath == "" {
+		return state, nil
+	}
+
+	header := syncStateHeader{SrcDir: srcDir, DstDir: dstDir}
+	valid, err := state.load(header)
+	if err != nil {
+		return nil, err
+	}
+	if !write {
+		return state, nil
+	}
+	if valid {
+		state.file, err = os.OpenFile(statePath, os.O_WRONLY|os.O_APPEND, 0o644)
+		return state, err
+	}
+	if state.file, err = os.Create(statePath); err != nil {
+		return nil, err
+	}
+	line, _ := json.Marshal(header)
+	if _, err := state.file.Write(append(line, '\n')); err != nil {
+		state.file.Close()
+		return nil, err
+	}
+	return state, nil
+}
+
+// load 读取状态文件中已完成的记录，返回文件是否存在且属于同一同步任务
+func (s *syncState) load(header syncStateHeader) (bool, error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	if !scanner.Scan() {
+		return false, scanner.Err()
+	}
+	var got syncStateHeader
+	if err := json.Unmarshal(scanner.Bytes(), &got); err != nil || got != header {
+		return false, nil
+	}
+	for scanner.Scan() {
+		var entry syncStateEntry
+		// 进程中断时最后一行可能不完整，忽略无法解析的行
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			s.done[entry.Path] = entry
+		}
+	}
+	return true, scanner.Err()
+}
+
+// Done 判断文件是否已在之前的同步中复制完成，且源文件没有变化
+func (s *syncState) Done(rel string, metadata FileMetadata) bool {
+	entry, ok := s.done[rel]
+	return ok && entry.Size == metadata.Size && entry.ModTime == metadata.ModTime.UnixNano() && entry.ETag == metadata.ETag
+}
+
+// Record 记录复制完成的文件
+func (s *syncState) Record(rel string, metadata FileMetadata) error {
+	if s.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(syncStateEntry{Path: rel, Size: metadata.Size, ModTime: metadata.ModTime.UnixNano(), ETag: metadata.ETag})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close 关闭状态文件
+func (s *syncState) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// Remove 同步全部成功后删除状态文件
+func (s *syncState) Remove() error {
+	if s.path == "" || s.file == nil {
+		return nil
+	}
+	s.Close()
+	return os.Remove(s.path)
+}
@@ -0,0 +1,213 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v-mars/storage"
+)
+
+// failingUploadStorage 上传指定文件时返回错误
+type failingUploadStorage struct {
+	storage.Storage
+	path string
+}
+
+func (s *failingUploadStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...storage.UploadOption) error {
+	if filePath == s.path {
+		return errors.New("upload failed")
+	}
+	return s.Storage.Upload(ctx, filePath, reader, opts...)
+}
+
+// uploadFiles 上传测试文件
+func uploadFiles(t *testing.T, s storage.Storage, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		if err := s.Upload(context.Background(), name, strings.NewReader(content)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	src := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	dst := storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: t.TempDir()})
+	ctx := context.Background()
+	uploadFiles(t, src, map[string]string{
+		"site/index.html":     "<html></html>",
+		"site/css/main.css":   "body{}",
+		"site/tmp/build.log":  "log",
+		"site/img/logo.png":   "png",
+		"other/untouched.txt": "other",
+	})
+	config := storage.SyncConfig{Exclude: []string{"tmp"}, DeleteExtraneous: true}
+
+	report, err := storage.Sync(ctx, src, "site", dst, "www", config)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if report.Scanned != 3 || report.Created != 3 || report.Bytes != int64(len("<html></html>body{}png")) {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if got := readString(t)(dst.Download(ctx, "www/css/main.css")); got != "body{}" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if exists, _ := dst.Exists(ctx, "www/tmp/build.log"); exists {
+		t.Fatal("Excluded file should not be synced")
+	}
+
+	// 再次同步时没有变化
+	if report, err = storage.Sync(ctx, src, "site", dst, "www", config); err != nil || report.Unchanged != 3 || len(report.Actions) != 0 {
+		t.Fatalf("Expected no changes, got %+v, %v", report, err)
+	}
+
+	// 修改、新增和删除源文件；目标中被排除的文件不会被删除
+	uploadFiles(t, src, map[string]string{"site/index.html": "<html>v2</html>", "site/new.txt": "new"})
+	uploadFiles(t, dst, map[string]string{"www/tmp/keep.log": "keep"})
+	if err := src.Delete(ctx, "site/img/logo.png"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	report, err = storage.Sync(ctx, src, "site", dst, "www", config)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if report.Created != 1 || report.Updated != 1 || report.Deleted != 1 || report.Unchanged != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if exists, _ := dst.Exists(ctx, "www/img/logo.png"); exists {
+		t.Fatal("Extraneous file should be deleted")
+	}
+	if exists, _ := dst.Exists(ctx, "www/tmp/keep.log"); !exists {
+		t.Fatal("Excluded file should not be deleted")
+	}
+}
+
+func TestSync_DryRunAndFilters(t *testing.T) {
+	src := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	dst := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	uploadFiles(t, src, map[string]string{
+		"a.jpg":            "a",
+		"photos/b.jpg":     "bb",
+		"photos/c.txt":     "c",
+		"cache/thumbs.jpg": "t",
+	})
+	uploadFiles(t, dst, map[string]string{"old.jpg": "old"})
+
+	report, err := storage.Sync(ctx, src, "", dst, "", storage.SyncConfig{
+		Include:          []string{"*.jpg"},
+		Exclude:          []string{"cache/*"},
+		DeleteExtraneous: true,
+		DryRun:           true,
+	})
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	want := []storage.SyncAction{
+		{Op: storage.SyncCreate, Path: "a.jpg", Size: 1},
+		{Op: storage.SyncCreate, Path: "photos/b.jpg", Size: 2},
+		{Op: storage.SyncDelete, Path: "old.jpg", Size: 3},
+	}
+	if len(report.Actions) != len(want) || !report.DryRun {
+		t.Fatalf("Unexpected plan: %+v", report)
+	}
+	for i := range want {
+		if report.Actions[i] != want[i] {
+			t.Fatalf("Unexpected action %d: %+v", i, report.Actions[i])
+		}
+	}
+	// 演练不修改目标
+	if files, _ := dst.ListDir(ctx, ""); len(files) != 1 {
+		t.Fatalf("Dry run should not modify destination: %+v", files)
+	}
+
+	if _, err := storage.Sync(ctx, src, "", dst, "", storage.SyncConfig{Exclude: []string{"[a-"}}); err == nil {
+		t.Fatal("Expected invalid pattern error")
+	}
+}
+
+func TestSync_Checksum(t *testing.T) {
+	src := storage.NewLocalStorage(storage.LocalStorageConfig{BasePath: t.TempDir()})
+	dst := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	uploadFiles(t, src, map[string]string{"a.txt": "aaaa", "b.txt": "bbbb"})
+	if _, err := storage.Sync(ctx, src, "", dst, "", storage.SyncConfig{}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	// 大小相同、目标修改时间更新的文件按修改时间比较时视为未变化，按校验和比较时更新
+	uploadFiles(t, src, map[string]string{"a.txt": "AAAA"})
+	if err := src.UpdateMetadata(ctx, "a.txt", &storage.FileMetadata{ModTime: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("UpdateMetadata failed: %v", err)
+	}
+	report, err := storage.Sync(ctx, src, "", dst, "", storage.SyncConfig{})
+	if err != nil || report.Updated != 0 {
+		t.Fatalf("Expected no update by size and mod time, got %+v, %v", report, err)
+	}
+	report, err = storage.Sync(ctx, src, "", dst, "", storage.SyncConfig{Compare: storage.SyncCompareChecksum})
+	if err != nil || report.Updated != 1 || report.Unchanged != 1 {
+		t.Fatalf("Expected one update by checksum, got %+v, %v", report, err)
+	}
+	if got := readString(t)(dst.Download(ctx, "a.txt")); got != "AAAA" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+
+	// 后端没有声明 ETag 为 MD5 时下载计算，形似 MD5 的 ETag 不会导致误判
+	report, err = storage.Sync(ctx, &corruptETagStorage{Storage: src, notMD5: true}, "", dst, "", storage.SyncConfig{Compare: storage.SyncCompareChecksum})
+	if err != nil || report.Updated != 0 || report.Unchanged != 2 {
+		t.Fatalf("Expected no update by checksum, got %+v, %v", report, err)
+	}
+}
+
+func TestSync_Resume(t *testing.T) {
+	src := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	uploadFiles(t, src, map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"})
+	uploadFiles(t, backend, map[string]string{"extra.txt": "extra"})
+	statePath := filepath.Join(t.TempDir(), "sync.state")
+	config := storage.SyncConfig{Compare: storage.SyncCompareChecksum, StatePath: statePath, DeleteExtraneous: true}
+
+	// 部分文件失败时继续同步其余文件，不执行删除，并保留状态文件
+	report, err := storage.Sync(ctx, src, "", &failingUploadStorage{Storage: backend, path: "b.txt"}, "", config)
+	if err == nil || report.Failed != 1 || report.Created != 2 || report.Deleted != 0 {
+		t.Fatalf("Expected partial failure, got %+v, %v", report, err)
+	}
+	if exists, _ := backend.Exists(ctx, "extra.txt"); !exists {
+		t.Fatal("Deletions should be skipped after failures")
+	}
+	if _, err := os.Stat(statePath); err != nil {
+		t.Fatalf("State file should be kept: %v", err)
+	}
+
+	// 重新同步时跳过已完成的文件，成功后删除状态文件
+	report, err = storage.Sync(ctx, src, "", backend, "", config)
+	if err != nil || report.Resumed != 2 || report.Created != 1 || report.Deleted != 1 {
+		t.Fatalf("Unexpected resumed report: %+v, %v", report, err)
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Fatalf("State file should be removed, got %v", err)
+	}
+}
+
+func TestSync_BandwidthLimit(t *testing.T) {
+	src := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	dst := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	uploadFiles(t, src, map[string]string{"a.bin": strings.Repeat("a", 96<<10), "b.bin": strings.Repeat("b", 96<<10)})
+
+	// 192KiB 以 128KiB/s 限速（突发 128KiB），至少需要约 0.5 秒
+	start := time.Now()
+	if _, err := storage.Sync(context.Background(), src, "", dst, "", storage.SyncConfig{BandwidthLimit: 128 << 10}); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Fatalf("Bandwidth limit not applied, took %v", elapsed)
+	}
+}
@@ -0,0 +1,684 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+const (
+	// tusVersion 支持的 tus 协议版本
+	tusVersion = "1.0.0"
+	// tusExtensions 支持的 tus 扩展
+	tusExtensions = "creation,termination,checksum,expiration"
+	// tusChecksumAlgorithms 支持的校验算法
+	tusChecksumAlgorithms = "sha1,md5,sha256,sha512"
+	// tusContentType PATCH 请求体的内容类型
+	tusContentType = "application/offset+octet-stream"
+	// tusDefaultStatePrefix 默认的上传状态目录
+	tusDefaultStatePrefix = ".tus"
+	// tusDefaultExpiration 默认的未完成上传有效期
+	tusDefaultExpiration = 24 * time.Hour
+	// statusChecksumMismatch tus checksum 扩展定义的校验失败状态码
+	statusChecksumMismatch = 460
+)
+
+// TusHandlerConfig tus 断点续传服务配置
+type TusHandlerConfig struct {
+	Storage      Storage        `json:"-"`            // 上传完成后文件的目标存储
+	StateStorage Storage        `json:"-"`            // 保存上传状态和已接收数据的存储，默认与 Storage 相同
+	StatePrefix  string         `json:"state_prefix"` // 上传状态目录，默认 .tus
+	BasePath     string         `json:"base_path"`    // 路由路径（相对于注册时传入的路由组），默认 /files
+	Prefix       string         `json:"prefix"`       // 目标存储目录，生成的路径拼接在其后
+	MaxSize      int64          `json:"max_size"`     // 单个上传的大小限制（字节），0 时沿用存储的限制（如 Types.MaxSize）
+	Expiration   time.Duration  `json:"expiration"`   // 未完成上传的有效期，每次 PATCH 后重新计算，默认 24 小时
+	Naming       NamingPolicy   `json:"-"`            // 命名策略，文件名取自 Upload-Metadata 中的 filename，默认 RandomName
+	Options      []UploadOption `json:"-"`            // 写入目标存储时的附加选项（如有效期）
+}
+
+// TusHandler 实现 tus 1.0 断点续传协议（core、creation、termination、checksum、expiration 扩展）。
+// 上传状态和已接收的数据保存在 StateStorage 中：存储实现 ResumableUploader 时追加写入同一个数据文件，
+// 否则每个 PATCH 请求的数据保存为一个分段；全部数据到达后以 Storage.Upload 写入目标路径。
+// 同一上传的并发 PATCH 只在单个进程内互斥，多实例部署时需要按上传ID做会话保持。
+type TusHandler struct {
+	config    TusHandlerConfig
+	storage   Storage
+	resumable ResumableUploader // StateStorage 支持续传时不为 nil
+	maxSize   int64
+	locks     sync.Map // 正在处理请求的上传ID
+}
+
+// tusInfo 保存在 StateStorage 中的上传状态
+type tusInfo struct {
+	ID       string    `json:"id"`
+	Path     string    `json:"path"`               // 目标路径
+	Size     int64     `json:"size"`               // 上传总大小
+	Offset   int64     `json:"offset"`             // 已接收的字节数
+	Metadata string    `json:"metadata,omitempty"` // 原始 Upload-Metadata
+	Chunked  bool      `json:"chunked"`            // 数据是否按分段保存
+	Parts    []int64   `json:"parts,omitempty"`    // 各分段的大小
+	Expires  time.Time `json:"expires"`            // 过期时间
+	Done     bool      `json:"done"`               // 是否已写入目标路径
+}
+
+// NewTusHandler 创建 tus 断点续传服务，通过 Register 注册路由：
+//
+//	tus := storage.NewTusHandler(storage.TusHandlerConfig{Storage: s, Prefix: "uploads"})
+//	tus.Register(h) // POST /files、HEAD/PATCH/DELETE /files/:id
+func NewTusHandler(config TusHandlerConfig) *TusHandler {
+	if config.StateStorage == nil {
+		config.StateStorage = config.Storage
+	}
+	if config.StatePrefix == "" {
+		config.StatePrefix = tusDefaultStatePrefix
+	}
+	config.StatePrefix = strings.Trim(config.StatePrefix, "/")
+	if config.BasePath == "" {
+		config.BasePath = "/files"
+	}
+	config.BasePath = "/" + strings.Trim(config.BasePath, "/")
+	if config.Expiration <= 0 {
+		config.Expiration = tusDefaultExpiration
+	}
+	if config.Naming == nil {
+		config.Naming = RandomName
+	}
+
+	h := &TusHandler{
+		config:  config,
+		storage: NewSizeLimitedStorage(config.Storage, config.MaxSize),
+		maxSize: config.MaxSize,
+	}
+	if h.maxSize <= 0 {
+		if limited, ok := As[*SizeLimitedStorage](config.Storage); ok {
+			h.maxSize = limited.MaxSize()
+		}
+	}
+	h.resumable, _ = As[ResumableUploader](config.StateStorage)
+	return h
+}
+
+// Register 在路由组上注册 tus 路由
+func (h *TusHandler) Register(r route.IRoutes) {
+	base := strings.TrimSuffix(h.config.BasePath, "/")
+	r.OPTIONS(h.config.BasePath, h.options)
+	r.OPTIONS(base+"/:id", h.options)
+	r.POST(h.config.BasePath, h.create)
+	r.HEAD(base+"/:id", h.head)
+	r.PATCH(base+"/:id", h.patch)
+	r.DELETE(base+"/:id", h.terminate)
+	// 不支持 PATCH、DELETE 的客户端通过 X-HTTP-Method-Override 发送 POST
+	r.POST(base+"/:id", h.override)
+}
+
+// Cleanup 删除已过期的上传状态和数据，返回删除的上传数量。
+// 过期的上传不会自动清理，应定期调用（如与 Janitor 一起）。
+func (h *TusHandler) Cleanup(ctx context.Context) (int, error) {
+	entries, err := h.config.StateStorage.ListDir(ctx, h.config.StatePrefix)
+	if errors.Is(err, ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	removed := 0
+	for _, entry := range entries {
+		id := path.Base(strings.Trim(entry.Name, "/"))
+		info, err := h.loadInfo(ctx, id)
+		if err != nil || now.Before(info.Expires) {
+			continue
+		}
+		if err := h.config.StateStorage.DeleteDir(ctx, h.stateDir(id)); err != nil {
+			hlog.CtxErrorf(ctx, "清理过期上传失败: %s, %v", id, err)
+			continue
+		}
+		removed++
+	}
+	if removed > 0 {
+		hlog.CtxInfof(ctx, "清理过期上传: %d 个", removed)
+	}
+	return removed, nil
+}
+
+// options 返回服务支持的版本和扩展
+func (h *TusHandler) options(ctx context.Context, c *app.RequestContext) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Checksum-Algorithm", tusChecksumAlgorithms)
+	if h.maxSize > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(h.maxSize, 10))
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// checkVersion 检查 Tus-Resumable 请求头，不支持时返回 412
+func (h *TusHandler) checkVersion(c *app.RequestContext) bool {
+	c.Header("Tus-Resumable", tusVersion)
+	if string(c.GetHeader("Tus-Resumable")) != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.AbortWithStatus(http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// create 创建上传（creation 扩展）
+func (h *TusHandler) create(ctx context.Context, c *app.RequestContext) {
+	if !h.checkVersion(c) {
+		return
+	}
+	size, err := strconv.ParseInt(string(c.GetHeader("Upload-Length")), 10, 64)
+	if err != nil || size < 0 {
+		// 不支持 creation-defer-length 扩展，必须提供 Upload-Length
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	if h.maxSize > 0 && size > h.maxSize {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return
+	}
+	rawMetadata := string(c.GetHeader("Upload-Metadata"))
+	metadata, err := parseTusMetadata(rawMetadata)
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	id, err := randomHex()
+	if err != nil {
+		hlog.CtxErrorf(ctx, "生成上传ID失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	filename := path.Base(strings.ReplaceAll(metadata["filename"], "\\", "/"))
+	if filename == "." || filename == "/" {
+		filename = ""
+	}
+	name, err := h.config.Naming(ctx, UploadFile{Filename: filename, ContentType: MIMETypeByExtension(filename)})
+	if err != nil {
+		hlog.CtxErrorf(ctx, "生成上传路径失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	filePath, ok := uploadTargetPath(h.config.Prefix, name)
+	if !ok {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	info := &tusInfo{
+		ID:       id,
+		Path:     filePath,
+		Size:     size,
+		Metadata: rawMetadata,
+		Chunked:  h.resumable == nil,
+		Expires:  time.Now().Add(h.config.Expiration),
+	}
+	if err := h.saveInfo(ctx, info); err != nil {
+		hlog.CtxErrorf(ctx, "保存上传状态失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	if size == 0 {
+		if err := h.finish(ctx, info); err != nil {
+			h.abortWithError(ctx, c, err)
+			return
+		}
+	}
+	hlog.CtxInfof(ctx, "创建断点续传上传: %s, 目标路径: %s, 大小: %d", id, filePath, size)
+
+	c.Header("Location", strings.TrimSuffix(string(c.Request.URI().Path()), "/")+"/"+id)
+	c.Header("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	c.AbortWithStatus(http.StatusCreated)
+}
+
+// head 返回上传进度
+func (h *TusHandler) head(ctx context.Context, c *app.RequestContext) {
+	if !h.checkVersion(c) {
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	info, ok := h.lookup(ctx, c)
+	if !ok {
+		return
+	}
+	c.Header("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(info.Size, 10))
+	if info.Metadata != "" {
+		c.Header("Upload-Metadata", info.Metadata)
+	}
+	if !info.Done {
+		c.Header("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	}
+	c.AbortWithStatus(http.StatusOK)
+}
+
+// patch 从 Upload-Offset 处写入请求体
+func (h *TusHandler) patch(ctx context.Context, c *app.RequestContext) {
+	if !h.checkVersion(c) {
+		return
+	}
+	if string(c.ContentType()) != tusContentType {
+		c.AbortWithStatus(http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(string(c.GetHeader("Upload-Offset")), 10, 64)
+	if err != nil || offset < 0 {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+	checksum, err := parseTusChecksum(string(c.GetHeader("Upload-Checksum")))
+	if err != nil {
+		c.AbortWithStatus(http.StatusBadRequest)
+		return
+	}
+
+	unlock, ok := h.lock(c.Param("id"))
+	if !ok {
+		c.AbortWithStatus(http.StatusLocked)
+		return
+	}
+	defer unlock()
+
+	info, ok := h.lookup(ctx, c)
+	if !ok {
+		return
+	}
+	if offset != info.Offset {
+		c.AbortWithStatus(http.StatusConflict)
+		return
+	}
+	remaining := info.Size - info.Offset
+	if int64(c.Request.Header.ContentLength()) > remaining {
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if remaining > 0 {
+		n, err := h.write(ctx, info, io.LimitReader(requestBody(c), remaining), checksum)
+		if err != nil {
+			h.abortWithError(ctx, c, err)
+			return
+		}
+		info.Offset += n
+		if info.Chunked && n > 0 {
+			info.Parts = append(info.Parts, n)
+		}
+		info.Expires = time.Now().Add(h.config.Expiration)
+		if err := h.saveInfo(ctx, info); err != nil {
+			hlog.CtxErrorf(ctx, "保存上传状态失败: %v", err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+	}
+	// 写入目标路径失败时保留状态，客户端在 Upload-Offset 等于 Upload-Length 时重发空 PATCH 即可重试
+	if info.Offset == info.Size && !info.Done {
+		if err := h.finish(ctx, info); err != nil {
+			h.abortWithError(ctx, c, err)
+			return
+		}
+	}
+
+	c.Header("Upload-Offset", strconv.FormatInt(info.Offset, 10))
+	if !info.Done {
+		c.Header("Upload-Expires", info.Expires.UTC().Format(http.TimeFormat))
+	}
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// terminate 删除上传（termination 扩展）
+func (h *TusHandler) terminate(ctx context.Context, c *app.RequestContext) {
+	if !h.checkVersion(c) {
+		return
+	}
+	unlock, ok := h.lock(c.Param("id"))
+	if !ok {
+		c.AbortWithStatus(http.StatusLocked)
+		return
+	}
+	defer unlock()
+
+	info, err := h.loadInfo(ctx, c.Param("id"))
+	if errors.Is(err, ErrNotExist) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if err == nil {
+		err = h.config.StateStorage.DeleteDir(ctx, h.stateDir(info.ID))
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "删除上传失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+	hlog.CtxInfof(ctx, "删除断点续传上传: %s", info.ID)
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+// override 处理带 X-HTTP-Method-Override 的 POST 请求
+func (h *TusHandler) override(ctx context.Context, c *app.RequestContext) {
+	switch strings.ToUpper(string(c.GetHeader("X-HTTP-Method-Override"))) {
+	case http.MethodPatch:
+		h.patch(ctx, c)
+	case http.MethodDelete:
+		h.terminate(ctx, c)
+	case http.MethodHead:
+		h.head(ctx, c)
+	default:
+		c.Header("Allow", "HEAD, PATCH, DELETE, OPTIONS")
+		c.AbortWithStatus(http.StatusMethodNotAllowed)
+	}
+}
+
+// lookup 读取路由参数对应的上传状态，不存在返回 404，过期返回 410
+func (h *TusHandler) lookup(ctx context.Context, c *app.RequestContext) (*tusInfo, bool) {
+	info, err := h.loadInfo(ctx, c.Param("id"))
+	if errors.Is(err, ErrNotExist) {
+		c.AbortWithStatus(http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		hlog.CtxErrorf(ctx, "读取上传状态失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return nil, false
+	}
+	if !info.Done && time.Now().After(info.Expires) {
+		c.AbortWithStatus(http.StatusGone)
+		return nil, false
+	}
+	return info, true
+}
+
+// write 从 info.Offset 处写入数据，返回写入的字节数。
+// 未指定校验值时读取中断视为数据结束，保留已收到的部分；校验失败时丢弃本次数据。
+func (h *TusHandler) write(ctx context.Context, info *tusInfo, body io.Reader, checksum *tusChecksum) (int64, error) {
+	counter := &tusBodyReader{reader: body, tolerant: checksum == nil}
+	var reader io.Reader = counter
+	if checksum != nil {
+		reader = io.TeeReader(counter, checksum.hash)
+	}
+
+	var dataPath string
+	var err error
+	if info.Chunked {
+		dataPath = h.partPath(info.ID, info.Offset)
+		err = h.config.StateStorage.Upload(ctx, dataPath, reader)
+	} else {
+		if h.resumable == nil {
+			return 0, fmt.Errorf("状态存储不支持续传，无法继续上传 %s: %w", info.ID, ErrNotSupported)
+		}
+		dataPath = h.dataPath(info.ID)
+		err = h.resumable.UploadAt(ctx, dataPath, reader, info.Offset)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if counter.err != nil {
+		hlog.CtxWarnf(ctx, "上传数据读取中断，保留已接收的 %d 字节: %s, %v", counter.n, info.ID, counter.err)
+	}
+	if checksum != nil && !checksum.match() {
+		// 追加写入的多余数据会在下次从 info.Offset 续传时被截断
+		if info.Chunked {
+			_ = h.config.StateStorage.Delete(ctx, dataPath)
+		}
+		return 0, errTusChecksumMismatch
+	}
+	if info.Chunked && counter.n == 0 {
+		_ = h.config.StateStorage.Delete(ctx, dataPath)
+	}
+	return counter.n, nil
+}
+
+// finish 将已接收的数据写入目标路径并删除数据文件
+func (h *TusHandler) finish(ctx context.Context, info *tusInfo) error {
+	var reader io.Reader = strings.NewReader("")
+	if info.Size > 0 {
+		if info.Chunked {
+			reader = &tusPartsReader{ctx: ctx, handler: h, info: info}
+		} else {
+			var err error
+			reader, err = h.config.StateStorage.DownloadRange(ctx, h.dataPath(info.ID), 0, info.Size)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err := h.storage.Upload(ctx, info.Path, reader, h.config.Options...)
+	if closer, ok := reader.(io.Closer); ok {
+		closer.Close()
+	}
+	if err != nil {
+		return err
+	}
+	hlog.CtxInfof(ctx, "断点续传上传完成: %s, 目标路径: %s", info.ID, info.Path)
+
+	// 保留状态以便客户端确认完成，数据文件不再需要
+	info.Done = true
+	if err := h.saveInfo(ctx, info); err != nil {
+		return err
+	}
+	if info.Chunked {
+		var offset int64
+		for _, size := range info.Parts {
+			_ = h.config.StateStorage.Delete(ctx, h.partPath(info.ID, offset))
+			offset += size
+		}
+	} else if info.Size > 0 {
+		_ = h.config.StateStorage.Delete(ctx, h.dataPath(info.ID))
+	}
+	return nil
+}
+
+// abortWithError 按错误类型返回状态码
+func (h *TusHandler) abortWithError(ctx context.Context, c *app.RequestContext, err error) {
+	switch {
+	case errors.Is(err, errTusChecksumMismatch):
+		c.AbortWithStatus(statusChecksumMismatch)
+	case errors.Is(err, ErrTooLarge), errors.Is(err, ErrQuotaExceeded):
+		c.AbortWithStatus(http.StatusRequestEntityTooLarge)
+	default:
+		hlog.CtxErrorf(ctx, "断点续传上传失败: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
+
+// lock 获取上传的进程内锁，已被占用时返回 false。
+// 锁只在持有期间登记在 locks 中，释放时删除，已完成或已删除的上传不会留下条目
+func (h *TusHandler) lock(id string) (func(), bool) {
+	if _, locked := h.locks.LoadOrStore(id, struct{}{}); locked {
+		return nil, false
+	}
+	return func() { h.locks.Delete(id) }, true
+}
+
+// stateDir 返回上传状态目录
+func (h *TusHandler) stateDir(id string) string {
+	return path.Join(h.config.StatePrefix, id)
+}
+
+// dataPath 返回追加写入的数据文件路径
+func (h *TusHandler) dataPath(id string) string {
+	return path.Join(h.stateDir(id), "data")
+}
+
+// partPath 返回从 offset 开始的分段路径
+func (h *TusHandler) partPath(id string, offset int64) string {
+	return path.Join(h.stateDir(id), fmt.Sprintf("part-%020d", offset))
+}
+
+// loadInfo 读取上传状态
+func (h *TusHandler) loadInfo(ctx context.Context, id string) (*tusInfo, error) {
+	if id == "" || strings.ContainsAny(id, "/\\.") {
+		return nil, ErrNotExist
+	}
+	reader, err := h.config.StateStorage.Download(ctx, path.Join(h.stateDir(id), "info.json"))
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := reader.(io.Closer); ok {
+		defer closer.Close()
+	}
+	var info tusInfo
+	if err := json.NewDecoder(reader).Decode(&info); err != nil {
+		return nil, fmt.Errorf("解析上传状态失败: %w", err)
+	}
+	return &info, nil
+}
+
+// saveInfo 保存上传状态
+func (h *TusHandler) saveInfo(ctx context.Context, info *tusInfo) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return h.config.StateStorage.Upload(ctx, path.Join(h.stateDir(info.ID), "info.json"), bytes.NewReader(data), WithContentType("application/json"))
+}
+
+// parseTusMetadata 解析 Upload-Metadata：逗号分隔的 "键 base64值"，值可以省略
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, fmt.Errorf("无效的 Upload-Metadata: %q", header)
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("无效的 Upload-Metadata: %q", header)
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// errTusChecksumMismatch 上传数据与 Upload-Checksum 不一致
+var errTusChecksumMismatch = errors.New("上传数据校验失败")
+
+// tusChecksum Upload-Checksum 指定的算法和期望值
+type tusChecksum struct {
+	hash     hash.Hash
+	expected []byte
+}
+
+// match 比较计算结果与期望值
+func (c *tusChecksum) match() bool {
+	return bytes.Equal(c.hash.Sum(nil), c.expected)
+}
+
+// parseTusChecksum 解析 Upload-Checksum："算法 base64值"，请求头为空时返回 nil
+func parseTusChecksum(header string) (*tusChecksum, error) {
+	if header == "" {
+		return nil, nil
+	}
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, fmt.Errorf("无效的 Upload-Checksum: %q", header)
+	}
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("无效的 Upload-Checksum: %q", header)
+	}
+	var h hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		h = sha1.New()
+	case "md5":
+		h = md5.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("不支持的校验算法: %s", algorithm)
+	}
+	return &tusChecksum{hash: h, expected: expected}, nil
+}
+
+// tusBodyReader 统计读取的字节数；tolerant 时将读取错误视为数据结束并记录下来
+type tusBodyReader struct {
+	reader   io.Reader
+	tolerant bool
+	n        int64
+	err      error
+}
+
+func (r *tusBodyReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	if err != nil && err != io.EOF && r.tolerant {
+		r.err = err
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// tusPartsReader 依次读取各分段
+type tusPartsReader struct {
+	ctx     context.Context
+	handler *TusHandler
+	info    *tusInfo
+	index   int
+	offset  int64
+	current io.Reader
+}
+
+func (r *tusPartsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.index >= len(r.info.Parts) {
+				return 0, io.EOF
+			}
+			reader, err := r.handler.config.StateStorage.Download(r.ctx, r.handler.partPath(r.info.ID, r.offset))
+			if err != nil {
+				return 0, err
+			}
+			r.current = reader
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			if closer, ok := r.current.(io.Closer); ok {
+				closer.Close()
+			}
+			r.current = nil
+			r.offset += r.info.Parts[r.index]
+			r.index++
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Close 关闭正在读取的分段
+func (r *tusPartsReader) Close() error {
+	if closer, ok := r.current.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
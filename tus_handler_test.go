@@ -0,0 +1,216 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/v-mars/storage"
+)
+
+// resumableMemoryStorage 为内存存储补充 UploadAt，用于测试追加写入模式
+type resumableMemoryStorage struct {
+	storage.Storage
+}
+
+func (s *resumableMemoryStorage) UploadAt(ctx context.Context, filePath string, reader io.Reader, offset int64) error {
+	var head []byte
+	if offset > 0 {
+		current, err := s.Download(ctx, filePath)
+		if err != nil {
+			return err
+		}
+		if head, err = io.ReadAll(io.LimitReader(current, offset)); err != nil {
+			return err
+		}
+		if int64(len(head)) < offset {
+			return fmt.Errorf("offset %d beyond file size %d", offset, len(head))
+		}
+	}
+	return s.Upload(ctx, filePath, io.MultiReader(bytes.NewReader(head), reader))
+}
+
+// tusRequest 发送 tus 请求，headers 为成对的请求头名称和值，自动带上 Tus-Resumable
+func tusRequest(engine *route.Engine, method, url, body string, headers ...string) *protocol.Response {
+	utHeaders := []ut.Header{{Key: "Tus-Resumable", Value: "1.0.0"}}
+	for i := 0; i+1 < len(headers); i += 2 {
+		utHeaders = append(utHeaders, ut.Header{Key: headers[i], Value: headers[i+1]})
+	}
+	var utBody *ut.Body
+	if body != "" {
+		utBody = &ut.Body{Body: strings.NewReader(body), Len: len(body)}
+	}
+	return ut.PerformRequest(engine, method, url, utBody, utHeaders...).Result()
+}
+
+// tusPatch 从 offset 处上传数据
+func tusPatch(engine *route.Engine, location string, offset int, data string, headers ...string) *protocol.Response {
+	headers = append([]string{"Content-Type", "application/offset+octet-stream", "Upload-Offset", fmt.Sprint(offset)}, headers...)
+	return tusRequest(engine, http.MethodPatch, location, data, headers...)
+}
+
+// tusCreate 创建上传并返回 Location
+func tusCreate(t *testing.T, engine *route.Engine, length int, filename string) string {
+	t.Helper()
+	resp := tusRequest(engine, http.MethodPost, "/files", "",
+		"Upload-Length", fmt.Sprint(length),
+		"Upload-Metadata", "filename "+base64.StdEncoding.EncodeToString([]byte(filename))+",private")
+	location := resp.Header.Get("Location")
+	if resp.StatusCode() != http.StatusCreated || !strings.HasPrefix(location, "/files/") || resp.Header.Get("Upload-Expires") == "" {
+		t.Fatalf("Unexpected create response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+	return location
+}
+
+func testTusUpload(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	engine := route.NewEngine(config.NewOptions(nil))
+	tus := storage.NewTusHandler(storage.TusHandlerConfig{Storage: s, Prefix: "uploads", MaxSize: 100, Naming: storage.OriginalName})
+	tus.Register(engine)
+
+	resp := ut.PerformRequest(engine, http.MethodOptions, "/files", nil).Result()
+	if resp.StatusCode() != http.StatusNoContent || resp.Header.Get("Tus-Version") != "1.0.0" ||
+		!strings.Contains(resp.Header.Get("Tus-Extension"), "checksum") || resp.Header.Get("Tus-Max-Size") != "100" {
+		t.Fatalf("Unexpected OPTIONS response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+
+	// 协议版本和大小限制
+	resp = ut.PerformRequest(engine, http.MethodPost, "/files", nil, ut.Header{Key: "Upload-Length", Value: "5"}).Result()
+	if resp.StatusCode() != http.StatusPreconditionFailed {
+		t.Fatalf("Expected 412 without Tus-Resumable, got %d", resp.StatusCode())
+	}
+	if resp = tusRequest(engine, http.MethodPost, "/files", "", "Upload-Length", "101"); resp.StatusCode() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d", resp.StatusCode())
+	}
+	if resp = tusRequest(engine, http.MethodPost, "/files", ""); resp.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without Upload-Length, got %d", resp.StatusCode())
+	}
+
+	location := tusCreate(t, engine, 11, "hello.txt")
+	resp = tusRequest(engine, http.MethodHead, location, "")
+	if resp.StatusCode() != http.StatusOK || resp.Header.Get("Upload-Offset") != "0" || resp.Header.Get("Upload-Length") != "11" ||
+		!strings.HasPrefix(resp.Header.Get("Upload-Metadata"), "filename ") {
+		t.Fatalf("Unexpected HEAD response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+
+	resp = tusPatch(engine, location, 0, "hello ")
+	if resp.StatusCode() != http.StatusNoContent || resp.Header.Get("Upload-Offset") != "6" {
+		t.Fatalf("Unexpected PATCH response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+	if resp = tusPatch(engine, location, 3, "lo "); resp.StatusCode() != http.StatusConflict {
+		t.Fatalf("Expected 409 for wrong offset, got %d", resp.StatusCode())
+	}
+	if resp = tusRequest(engine, http.MethodPatch, location, "world", "Upload-Offset", "6"); resp.StatusCode() != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected 415 without content type, got %d", resp.StatusCode())
+	}
+	if resp = tusPatch(engine, location, 6, "world!"); resp.StatusCode() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413 for data beyond Upload-Length, got %d", resp.StatusCode())
+	}
+
+	// 校验失败时丢弃本次数据
+	sum := sha1.Sum([]byte("world"))
+	checksum := "sha1 " + base64.StdEncoding.EncodeToString(sum[:])
+	if resp = tusPatch(engine, location, 6, "w0rld", "Upload-Checksum", checksum); resp.StatusCode() != 460 {
+		t.Fatalf("Expected 460 for checksum mismatch, got %d", resp.StatusCode())
+	}
+	if resp = tusPatch(engine, location, 6, "world", "Upload-Checksum", "crc32 AAAA"); resp.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for unsupported checksum, got %d", resp.StatusCode())
+	}
+	if exists, _ := s.Exists(ctx, "uploads/hello.txt"); exists {
+		t.Fatal("Incomplete upload should not be visible at the target path")
+	}
+
+	resp = tusPatch(engine, location, 6, "world", "Upload-Checksum", checksum)
+	if resp.StatusCode() != http.StatusNoContent || resp.Header.Get("Upload-Offset") != "11" {
+		t.Fatalf("Unexpected final PATCH response: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+	reader, err := s.Download(ctx, "uploads/hello.txt")
+	if got := readString(t)(reader, err); got != "hello world" {
+		t.Fatalf("Unexpected uploaded content: %q", got)
+	}
+
+	// 完成后仍可查询进度，数据文件已删除
+	resp = tusRequest(engine, http.MethodHead, location, "")
+	if resp.StatusCode() != http.StatusOK || resp.Header.Get("Upload-Offset") != "11" {
+		t.Fatalf("Unexpected HEAD response after completion: %d %s", resp.StatusCode(), resp.Header.Header())
+	}
+	id := strings.TrimPrefix(location, "/files/")
+	files, err := s.ListDir(ctx, ".tus/"+id)
+	if err != nil || len(files) != 1 || files[0].Name != "info.json" {
+		t.Fatalf("Unexpected state files: %+v, %v", files, err)
+	}
+
+	// 空文件在创建时直接完成
+	location = tusCreate(t, engine, 0, "empty.txt")
+	if exists, _ := s.Exists(ctx, "uploads/empty.txt"); !exists {
+		t.Fatal("Empty upload should be finished on creation")
+	}
+
+	// 终止上传，通过 X-HTTP-Method-Override
+	location = tusCreate(t, engine, 5, "gone.txt")
+	tusPatch(engine, location, 0, "ab")
+	resp = tusRequest(engine, http.MethodPost, location, "", "X-HTTP-Method-Override", "DELETE")
+	if resp.StatusCode() != http.StatusNoContent {
+		t.Fatalf("Unexpected DELETE response: %d", resp.StatusCode())
+	}
+	if resp = tusRequest(engine, http.MethodHead, location, ""); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("Expected 404 after termination, got %d", resp.StatusCode())
+	}
+	if resp = tusRequest(engine, http.MethodHead, "/files/..", ""); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("Expected 404 for invalid id, got %d", resp.StatusCode())
+	}
+}
+
+func TestTusHandler(t *testing.T) {
+	// 内存存储不支持续传，每个 PATCH 保存为一个分段
+	testTusUpload(t, storage.NewMemoryStorage(storage.MemoryStorageConfig{}))
+}
+
+func TestTusHandler_Resumable(t *testing.T) {
+	// 支持 UploadAt 的存储追加写入同一个数据文件
+	testTusUpload(t, &resumableMemoryStorage{Storage: storage.NewMemoryStorage(storage.MemoryStorageConfig{})})
+}
+
+func TestTusHandler_Expiration(t *testing.T) {
+	s := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	engine := route.NewEngine(config.NewOptions(nil))
+	tus := storage.NewTusHandler(storage.TusHandlerConfig{Storage: s, Expiration: 50 * time.Millisecond})
+	tus.Register(engine)
+
+	expired := tusCreate(t, engine, 10, "a.txt")
+	tusPatch(engine, expired, 0, "abc")
+	finished := tusCreate(t, engine, 1, "b.txt")
+	tusPatch(engine, finished, 0, "b")
+	time.Sleep(100 * time.Millisecond)
+	active := tusCreate(t, engine, 10, "c.txt")
+
+	if resp := tusRequest(engine, http.MethodHead, expired, ""); resp.StatusCode() != http.StatusGone {
+		t.Fatalf("Expected 410 for expired upload, got %d", resp.StatusCode())
+	}
+	if resp := tusPatch(engine, expired, 3, "def"); resp.StatusCode() != http.StatusGone {
+		t.Fatalf("Expected 410 for PATCH on expired upload, got %d", resp.StatusCode())
+	}
+
+	removed, err := tus.Cleanup(ctx)
+	if err != nil || removed != 2 {
+		t.Fatalf("Cleanup removed %d uploads, err: %v", removed, err)
+	}
+	if resp := tusRequest(engine, http.MethodHead, expired, ""); resp.StatusCode() != http.StatusNotFound {
+		t.Fatalf("Expected 404 after cleanup, got %d", resp.StatusCode())
+	}
+	if resp := tusRequest(engine, http.MethodHead, active, ""); resp.StatusCode() != http.StatusOK {
+		t.Fatalf("Active upload should survive cleanup, got %d", resp.StatusCode())
+	}
+}
@@ -10,10 +10,22 @@ import (
 type StorageType string
 
 const (
-	Local StorageType = "local" // 本地存储类型
-	OSS   StorageType = "oss"   // 阿里云OSS存储类型
-	MinIO StorageType = "minio" // MinIO存储类型
-	S3    StorageType = "s3"    // 标准S3存储类型
+	Local   StorageType = "local"   // 本地存储类型
+	OSS     StorageType = "oss"     // 阿里云OSS存储类型
+	MinIO   StorageType = "minio"   // MinIO存储类型
+	S3      StorageType = "s3"      // 标准S3存储类型
+	COS     StorageType = "cos"     // 腾讯云COS存储类型
+	Azure   StorageType = "azure"   // Azure Blob存储类型
+	GCS     StorageType = "gcs"     // Google Cloud Storage存储类型
+	SFTP    StorageType = "sftp"    // SFTP存储类型
+	WebDAV  StorageType = "webdav"  // WebDAV存储类型
+	FTP     StorageType = "ftp"     // FTP/FTPS存储类型
+	SQL     StorageType = "sql"     // 数据库存储类型（SQLite/Postgres）
+	Archive StorageType = "archive" // 只读的 zip/tar 归档存储类型
+	HTTP    StorageType = "http"    // 只读的 HTTP 存储类型（静态文件服务器、CDN）
+	Remote  StorageType = "remote"  // 远程存储类型（通过存储网关访问）
+	GRPC    StorageType = "grpc"    // gRPC 存储类型（通过 gRPC 存储服务访问）
+	Mem     StorageType = "mem"     // 内存存储类型（用于测试）
 )
 
 // FileMetadata 文件元数据
@@ -23,6 +35,8 @@ type FileMetadata struct {
 	ModTime  time.Time `json:"mod_time"`  // 修改时间
 	IsDir    bool      `json:"is_dir"`    // 是否为目录
 	MIMEType string    `json:"mime_type"` // MIME 类型
+	ETag     string    `json:"etag"`      // 内容标识（后端提供时填充）
+	ETagMD5  bool      `json:"etag_md5"`  // ETag 是否为内容的 MD5，仅由能够确认这一点的后端设置
 }
 
 // Storage 接口定义了统一的存储操作
@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+// UploadResultsKey 上传中间件将 []UploadResult 保存在请求上下文中的键
+const UploadResultsKey = "storage.upload_results"
+
+// UploadFile 表单中的一个待上传文件，用于命名策略
+type UploadFile struct {
+	Field       string // 表单字段名
+	Filename    string // 客户端提交的文件名（已去除目录部分）
+	ContentType string // 检测得到的 MIME 类型
+}
+
+// NamingPolicy 根据上传文件生成存储路径（相对于 UploadHandlerConfig.Prefix）
+type NamingPolicy func(ctx context.Context, file UploadFile) (string, error)
+
+// OriginalName 使用客户端提交的文件名，同名文件会被覆盖
+func OriginalName(ctx context.Context, file UploadFile) (string, error) {
+	return file.Filename, nil
+}
+
+// RandomName 使用随机名称并保留原扩展名，如 3f2a...9c.png
+func RandomName(ctx context.Context, file UploadFile) (string, error) {
+	id, err := randomHex()
+	if err != nil {
+		return "", err
+	}
+	return id + strings.ToLower(path.Ext(file.Filename)), nil
+}
+
+// DateName 按上传日期分目录并使用随机名称，如 2024/05/01/3f2a...9c.png
+func DateName(ctx context.Context, file UploadFile) (string, error) {
+	name, err := RandomName(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(time.Now().Format("2006/01/02"), name), nil
+}
+
+// randomHex 生成 16 字节的随机十六进制串
+func randomHex() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// UploadHandlerConfig 表单上传处理器配置
+type UploadHandlerConfig struct {
+	Storage      Storage        `json:"-"`             // 上传目标存储
+	Prefix       string         `json:"prefix"`        // 存储目录，生成的路径拼接在其后
+	MaxSize      int64          `json:"max_size"`      // 单个文件大小限制（字节），0 时沿用存储的限制（如 Types.MaxSize）
+	MaxFiles     int            `json:"max_files"`     // 单次请求的最大文件数，0 表示不限制
+	AllowedTypes []string       `json:"allowed_types"` // 允许的 MIME 类型，支持 image/* 形式，为空时不限制
+	AllowedExts  []string       `json:"allowed_exts"`  // 允许的扩展名（如 .png），为空时不限制
+	Fields       []string       `json:"fields"`        // 接收文件的表单字段，为空时接收所有字段
+	Naming       NamingPolicy   `json:"-"`             // 命名策略，默认 RandomName
+	Options      []UploadOption `json:"-"`             // 传给 Upload 的附加选项（如有效期）
+}
+
+// UploadResult 单个文件的上传结果
+type UploadResult struct {
+	Field       string    `json:"field"`
+	Filename    string    `json:"filename"`
+	Path        string    `json:"path,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+	ContentType string    `json:"content_type,omitempty"`
+	ETag        string    `json:"etag,omitempty"`
+	ModTime     time.Time `json:"mod_time,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Status      int       `json:"-"` // 出错时对应的 HTTP 状态码
+}
+
+// NewUploadHandler 创建接收 multipart/form-data 的 Hertz 处理器。
+// 表单中的每个文件边读取边写入存储，不在内存或临时文件中缓冲；返回每个文件的结果：
+//
+//	{"files": [{"field": "file", "filename": "a.png", "path": "uploads/3f2a...9c.png", "size": 1024, ...}]}
+//
+// 全部成功返回 200，部分失败返回 207，全部失败返回第一个失败的状态码（413、415、400 或 500）。
+// Hertz 默认会先读完整个请求体，需要开启 server.WithStreamBody(true) 才能真正流式上传。
+func NewUploadHandler(config UploadHandlerConfig) app.HandlerFunc {
+	handler := newUploadHandler(config)
+	return func(ctx context.Context, c *app.RequestContext) {
+		results, status := handler.handle(ctx, c)
+		if status != http.StatusOK && len(results) == 0 {
+			c.AbortWithStatusJSON(status, map[string]string{"error": http.StatusText(status)})
+			return
+		}
+		c.JSON(status, map[string][]UploadResult{"files": results})
+	}
+}
+
+// NewUploadMiddleware 与 NewUploadHandler 相同地处理上传，但不写响应：
+// 结果以 []UploadResult 保存在 UploadResultsKey 中，由后续处理器决定如何响应（如写入业务数据库）。
+// 请求不是合法的 multipart 表单时直接中止并返回 400。
+func NewUploadMiddleware(config UploadHandlerConfig) app.HandlerFunc {
+	handler := newUploadHandler(config)
+	return func(ctx context.Context, c *app.RequestContext) {
+		results, status := handler.handle(ctx, c)
+		if status != http.StatusOK && len(results) == 0 {
+			c.AbortWithStatus(status)
+			return
+		}
+		c.Set(UploadResultsKey, results)
+		c.Next(ctx)
+	}
+}
+
+// uploadHandler 表单上传处理器
+type uploadHandler struct {
+	config       UploadHandlerConfig
+	storage      Storage
+	allowedTypes []string
+	allowedExts  map[string]bool
+	fields       map[string]bool
+}
+
+// newUploadHandler 规范化配置
+func newUploadHandler(config UploadHandlerConfig) *uploadHandler {
+	if config.Naming == nil {
+		config.Naming = RandomName
+	}
+	h := &uploadHandler{
+		config:  config,
+		storage: NewSizeLimitedStorage(config.Storage, config.MaxSize),
+	}
+	for _, t := range config.AllowedTypes {
+		h.allowedTypes = append(h.allowedTypes, strings.ToLower(strings.TrimSpace(t)))
+	}
+	if len(config.AllowedExts) > 0 {
+		h.allowedExts = make(map[string]bool, len(config.AllowedExts))
+		for _, ext := range config.AllowedExts {
+			h.allowedExts[normalizeExt(ext)] = true
+		}
+	}
+	if len(config.Fields) > 0 {
+		h.fields = make(map[string]bool, len(config.Fields))
+		for _, field := range config.Fields {
+			h.fields[field] = true
+		}
+	}
+	return h
+}
+
+// uploadError 带 HTTP 状态码的上传错误
+type uploadError struct {
+	status int
+	msg    string
+}
+
+func (e *uploadError) Error() string {
+	return e.msg
+}
+
+// handle 逐个读取表单分段并上传，返回每个文件的结果和整体状态码
+func (h *uploadHandler) handle(ctx context.Context, c *app.RequestContext) ([]UploadResult, int) {
+	if string(c.Method()) != http.MethodPost && string(c.Method()) != http.MethodPut {
+		c.Header("Allow", "POST, PUT")
+		return nil, http.StatusMethodNotAllowed
+	}
+	mediaType, params, err := mime.ParseMediaType(string(c.ContentType()))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		return nil, http.StatusBadRequest
+	}
+
+	reader := multipart.NewReader(requestBody(c), params["boundary"])
+
+	var results []UploadResult
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			hlog.CtxErrorf(ctx, "读取上传表单失败: %v", err)
+			if len(results) == 0 {
+				return nil, http.StatusBadRequest
+			}
+			results = append(results, UploadResult{Error: "读取上传表单失败", Status: http.StatusBadRequest})
+			break
+		}
+		if part.FileName() == "" || (h.fields != nil && !h.fields[part.FormName()]) {
+			// 普通字段和未接收的文件字段直接跳过
+			part.Close()
+			continue
+		}
+		if h.config.MaxFiles > 0 && len(results) >= h.config.MaxFiles {
+			part.Close()
+			results = append(results, UploadResult{
+				Field:    part.FormName(),
+				Filename: path.Base(part.FileName()),
+				Error:    "文件数量超过限制",
+				Status:   http.StatusRequestEntityTooLarge,
+			})
+			continue
+		}
+		results = append(results, h.upload(ctx, part))
+		part.Close()
+	}
+
+	if len(results) == 0 {
+		return nil, http.StatusBadRequest
+	}
+	return results, uploadStatus(results)
+}
+
+// upload 校验并上传单个文件分段
+func (h *uploadHandler) upload(ctx context.Context, part *multipart.Part) UploadResult {
+	// 浏览器在 Windows 上可能提交完整路径
+	filename := path.Base(strings.ReplaceAll(part.FileName(), "\\", "/"))
+	result := UploadResult{Field: part.FormName(), Filename: filename}
+	fail := func(err error) UploadResult {
+		var uploadErr *uploadError
+		switch {
+		case errors.As(err, &uploadErr):
+			result.Status = uploadErr.status
+		case errors.Is(err, ErrTooLarge), errors.Is(err, ErrQuotaExceeded):
+			result.Status = http.StatusRequestEntityTooLarge
+		default:
+			hlog.CtxErrorf(ctx, "上传文件失败: %s, %v", filename, err)
+			result.Status = http.StatusInternalServerError
+		}
+		result.Path = ""
+		result.Error = err.Error()
+		return result
+	}
+
+	if h.allowedExts != nil && !h.allowedExts[normalizeExt(path.Ext(filename))] {
+		return fail(&uploadError{status: http.StatusUnsupportedMediaType, msg: "不允许的文件扩展名: " + path.Ext(filename)})
+	}
+
+	head, err := readHead(part)
+	if err != nil {
+		return fail(&uploadError{status: http.StatusBadRequest, msg: "读取上传文件失败: " + err.Error()})
+	}
+	sniffed := http.DetectContentType(head)
+	contentType := MIMETypeByExtension(filename)
+	if contentType == "" {
+		contentType = sniffed
+	}
+	if !h.typeAllowed(contentType, sniffed) {
+		return fail(&uploadError{status: http.StatusUnsupportedMediaType, msg: "不允许的文件类型: " + contentType})
+	}
+	result.ContentType = contentType
+
+	name, err := h.config.Naming(ctx, UploadFile{Field: result.Field, Filename: filename, ContentType: contentType})
+	if err != nil {
+		return fail(err)
+	}
+	filePath, ok := uploadTargetPath(h.config.Prefix, name)
+	if !ok {
+		return fail(&uploadError{status: http.StatusBadRequest, msg: "无效的文件名"})
+	}
+	result.Path = filePath
+
+	counter := &countingReader{reader: io.MultiReader(bytes.NewReader(head), part)}
+	opts := append([]UploadOption{WithContentType(contentType)}, h.config.Options...)
+	if err := h.storage.Upload(ctx, filePath, counter, opts...); err != nil {
+		return fail(err)
+	}
+	result.Size = counter.n
+	hlog.CtxInfof(ctx, "表单上传文件成功: %s, 大小: %d", filePath, counter.n)
+
+	// 元数据获取失败不影响上传结果
+	if metadata, err := h.storage.GetMetadata(ctx, filePath); err == nil {
+		result.Size = metadata.Size
+		result.ETag = metadata.ETag
+		result.ModTime = metadata.ModTime
+		if metadata.MIMEType != "" {
+			result.ContentType = metadata.MIMEType
+		}
+	}
+	return result
+}
+
+// requestBody 返回请求体：服务开启流式请求体时直接读取连接，否则读取已缓冲的内容
+func requestBody(c *app.RequestContext) io.Reader {
+	if c.Request.IsBodyStream() {
+		return c.RequestBodyStream()
+	}
+	return bytes.NewReader(c.Request.Body())
+}
+
+// uploadTargetPath 将命名策略生成的名称拼接到前缀目录下，名称中的 .. 不能逃出前缀目录
+func uploadTargetPath(prefix, name string) (string, bool) {
+	filePath := strings.Trim(path.Join(prefix, path.Clean("/"+name)), "/")
+	if filePath == "" || filePath == strings.Trim(prefix, "/") {
+		return "", false
+	}
+	return filePath, true
+}
+
+// typeAllowed 检查文件类型是否在允许列表中。
+// 内容嗅探得到明确类型时以嗅探结果为准，防止通过修改扩展名绕过限制；
+// 嗅探结果为通用类型（二进制流或纯文本）时使用按扩展名得到的类型。
+func (h *uploadHandler) typeAllowed(contentType, sniffed string) bool {
+	if len(h.allowedTypes) == 0 {
+		return true
+	}
+	candidate := sniffed
+	if candidate == defaultMIMEType || strings.HasPrefix(candidate, "text/plain") {
+		candidate = contentType
+	}
+	candidate, _, _ = strings.Cut(strings.ToLower(candidate), ";")
+	candidate = strings.TrimSpace(candidate)
+	for _, allowed := range h.allowedTypes {
+		if allowed == candidate || allowed == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(candidate, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadStatus 根据各文件结果确定响应状态码
+func uploadStatus(results []UploadResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Status != 0 {
+			failed++
+		}
+	}
+	switch failed {
+	case 0:
+		return http.StatusOK
+	case len(results):
+		return results[0].Status
+	default:
+		return http.StatusMultiStatus
+	}
+}
@@ -0,0 +1,185 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/v-mars/storage"
+)
+
+// pngHeader PNG 文件头，用于内容嗅探
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// postForm 发送 multipart 表单，files 为成对的文件名和内容，均使用 file 字段
+func postForm(engine *route.Engine, url string, fields map[string]string, files ...string) *protocol.Response {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, value := range fields {
+		mw.WriteField(name, value)
+	}
+	for i := 0; i+1 < len(files); i += 2 {
+		part, _ := mw.CreateFormFile("file", files[i])
+		part.Write([]byte(files[i+1]))
+	}
+	mw.Close()
+	return ut.PerformRequest(engine, http.MethodPost, url, &ut.Body{Body: &body, Len: body.Len()},
+		ut.Header{Key: "Content-Type", Value: mw.FormDataContentType()}).Result()
+}
+
+// uploadResponse 解析上传处理器的响应
+func uploadResponse(t *testing.T, resp *protocol.Response) []storage.UploadResult {
+	t.Helper()
+	var result struct {
+		Files []storage.UploadResult `json:"files"`
+	}
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		t.Fatalf("Unexpected response body %q: %v", resp.Body(), err)
+	}
+	return result.Files
+}
+
+func TestUploadHandler(t *testing.T) {
+	s := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.POST("/upload", storage.NewUploadHandler(storage.UploadHandlerConfig{
+		Storage:      s,
+		Prefix:       "uploads",
+		MaxSize:      64,
+		AllowedTypes: []string{"image/*"},
+		AllowedExts:  []string{"png", ".JPG"},
+		Naming:       storage.OriginalName,
+	}))
+
+	// 多个文件逐个返回结果，普通字段被忽略
+	resp := postForm(engine, "/upload", map[string]string{"title": "t"},
+		"logo.png", pngHeader+"data",
+		`C:\Users\me\photo.jpg`, "\xff\xd8\xff\xe0jpeg",
+	)
+	files := uploadResponse(t, resp)
+	if resp.StatusCode() != http.StatusOK || len(files) != 2 {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode(), resp.Body())
+	}
+	if files[0].Path != "uploads/logo.png" || files[0].Size != int64(len(pngHeader)+4) || files[0].ContentType != "image/png" || files[0].Error != "" {
+		t.Fatalf("Unexpected result: %+v", files[0])
+	}
+	if files[1].Filename != "photo.jpg" || files[1].Path != "uploads/photo.jpg" {
+		t.Fatalf("Unexpected result: %+v", files[1])
+	}
+	reader, err := s.Download(ctx, "uploads/logo.png")
+	if got := readString(t)(reader, err); got != pngHeader+"data" {
+		t.Fatalf("Unexpected stored content: %q", got)
+	}
+
+	// 部分失败返回 207：扩展名不允许、内容与扩展名不符、超过大小限制
+	resp = postForm(engine, "/upload", nil,
+		"notes.txt", "hello",
+		"fake.png", "<html><body>x</body></html>",
+		"big.png", pngHeader+strings.Repeat("x", 100),
+		"ok.png", pngHeader,
+	)
+	files = uploadResponse(t, resp)
+	if resp.StatusCode() != http.StatusMultiStatus || len(files) != 4 {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode(), resp.Body())
+	}
+	for i, want := range []bool{false, false, false, true} {
+		if (files[i].Error == "") != want || (files[i].Path != "") != want {
+			t.Fatalf("Unexpected result %d: %+v", i, files[i])
+		}
+	}
+	for _, name := range []string{"uploads/notes.txt", "uploads/fake.png", "uploads/big.png"} {
+		if exists, _ := s.Exists(ctx, name); exists {
+			t.Fatalf("Rejected file %s should not be stored", name)
+		}
+	}
+
+	// 全部失败时返回对应的状态码
+	resp = postForm(engine, "/upload", nil, "big.png", pngHeader+strings.Repeat("x", 100))
+	if resp.StatusCode() != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected 413, got %d %s", resp.StatusCode(), resp.Body())
+	}
+	resp = postForm(engine, "/upload", nil, "notes.txt", "hello")
+	if resp.StatusCode() != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected 415, got %d %s", resp.StatusCode(), resp.Body())
+	}
+
+	// 没有文件或不是 multipart 表单
+	resp = postForm(engine, "/upload", map[string]string{"title": "t"})
+	if resp.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("Expected 400 without files, got %d", resp.StatusCode())
+	}
+	resp = ut.PerformRequest(engine, http.MethodPost, "/upload", &ut.Body{Body: strings.NewReader("x"), Len: 1},
+		ut.Header{Key: "Content-Type", Value: "text/plain"}).Result()
+	if resp.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("Expected 400 for non-multipart body, got %d", resp.StatusCode())
+	}
+}
+
+func TestUploadHandler_NamingAndLimits(t *testing.T) {
+	// 存储自身的大小限制（如 Types.MaxSize）同样生效
+	s := storage.NewSizeLimitedStorage(storage.NewMemoryStorage(storage.MemoryStorageConfig{}), 8)
+	ctx := context.Background()
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.POST("/upload", storage.NewUploadHandler(storage.UploadHandlerConfig{
+		Storage:  s,
+		Prefix:   "u",
+		MaxFiles: 2,
+		Naming:   storage.DateName,
+	}))
+
+	resp := postForm(engine, "/upload", nil, "a.TXT", "a", "b.txt", "toolarge!", "c.txt", "c")
+	files := uploadResponse(t, resp)
+	if resp.StatusCode() != http.StatusMultiStatus || len(files) != 3 {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode(), resp.Body())
+	}
+	if !strings.HasPrefix(files[0].Path, "u/") || !strings.HasSuffix(files[0].Path, ".txt") || strings.Count(files[0].Path, "/") != 4 {
+		t.Fatalf("Unexpected generated path: %q", files[0].Path)
+	}
+	if exists, _ := s.Exists(ctx, files[0].Path); !exists {
+		t.Fatalf("Uploaded file %s not found", files[0].Path)
+	}
+	if files[1].Error == "" || files[2].Error == "" {
+		t.Fatalf("Expected size and file count errors: %+v", files)
+	}
+
+	// 命名策略不能逃出前缀目录
+	engine = route.NewEngine(config.NewOptions(nil))
+	engine.POST("/upload", storage.NewUploadHandler(storage.UploadHandlerConfig{
+		Storage: s,
+		Prefix:  "u",
+		Naming: func(ctx context.Context, file storage.UploadFile) (string, error) {
+			return "../../etc/" + file.Filename, nil
+		},
+	}))
+	files = uploadResponse(t, postForm(engine, "/upload", nil, "x.txt", "x"))
+	if len(files) != 1 || files[0].Path != "u/etc/x.txt" {
+		t.Fatalf("Unexpected path: %+v", files)
+	}
+}
+
+func TestUploadMiddleware(t *testing.T) {
+	s := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	engine := route.NewEngine(config.NewOptions(nil))
+	engine.POST("/upload", storage.NewUploadMiddleware(storage.UploadHandlerConfig{Storage: s}), func(ctx context.Context, c *app.RequestContext) {
+		results := c.MustGet(storage.UploadResultsKey).([]storage.UploadResult)
+		c.String(http.StatusCreated, results[0].Path)
+	})
+
+	resp := postForm(engine, "/upload", nil, "a.txt", "hello")
+	if resp.StatusCode() != http.StatusCreated {
+		t.Fatalf("Unexpected response: %d %s", resp.StatusCode(), resp.Body())
+	}
+	if exists, _ := s.Exists(context.Background(), string(resp.Body())); !exists {
+		t.Fatalf("Uploaded file %s not found", resp.Body())
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"path"
+	"strings"
+)
+
+// WalkFunc 遍历时对每个文件调用的函数，filePath 为相对存储根的路径
+type WalkFunc func(filePath string, metadata FileMetadata) error
+
+// Walk 递归遍历目录下的所有文件（不包含目录本身）。
+// ListDir 只返回当前层级，对象存储（S3/MinIO/OSS 等）的子目录名称带有结尾的 "/"，这里统一去掉后拼接为文件路径。
+func Walk(ctx context.Context, s Storage, root string, fn WalkFunc) error {
+	entries, err := s.ListDir(ctx, root)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		name := strings.TrimSuffix(entry.Name, "/")
+		if name == "" {
+			continue
+		}
+		entryPath := path.Join(root, name)
+
+		if entry.IsDir {
+			if err := Walk(ctx, s, entryPath, fn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := fn(entryPath, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
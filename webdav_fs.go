@@ -0,0 +1,637 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"golang.org/x/net/webdav"
+)
+
+// WebDAVFSConfig WebDAV 文件系统配置
+type WebDAVFSConfig struct {
+	ReadOnly bool   `json:"read_only"` // 只读模式，写操作返回 os.ErrPermission
+	TempDir  string `json:"temp_dir"`  // 写入缓冲临时文件所在目录，默认为系统临时目录
+}
+
+// WebDAVFS 将任意存储适配为 webdav.FileSystem，配合 webdav.Handler 即可作为网络驱动器挂载。
+// 读取按需通过 Download/DownloadRange 进行；写入先缓冲到本地临时文件，Close 时一次性 Upload。
+// 对象存储没有真正的目录，通过 Mkdir 创建的空目录记录在内存中，直到其中写入文件。
+type WebDAVFS struct {
+	storage Storage
+	config  WebDAVFSConfig
+
+	mu   sync.Mutex
+	dirs map[string]bool // Mkdir 创建、后端可能无法体现的空目录
+}
+
+var _ webdav.FileSystem = (*WebDAVFS)(nil)
+
+// NewWebDAVFS 创建存储的 WebDAV 文件系统适配器
+func NewWebDAVFS(s Storage, config WebDAVFSConfig) *WebDAVFS {
+	return &WebDAVFS{storage: s, config: config, dirs: make(map[string]bool)}
+}
+
+// davPath 将 WebDAV 路径转换为存储路径（去掉首尾斜杠，根目录为空字符串）
+func davPath(name string) string {
+	return strings.Trim(path.Clean("/"+name), "/")
+}
+
+// readOnlyError 只读模式下写操作返回的错误
+func (f *WebDAVFS) readOnlyError(op, name string) error {
+	if f.config.ReadOnly {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// Mkdir 创建目录，父目录不存在时返回 os.ErrNotExist
+func (f *WebDAVFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if err := f.readOnlyError("mkdir", name); err != nil {
+		return err
+	}
+	dirPath := davPath(name)
+	if dirPath == "" {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	if _, err := f.stat(ctx, "mkdir", dirPath); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := f.checkParent(ctx, "mkdir", dirPath); err != nil {
+		return err
+	}
+	if err := f.storage.CreateDir(ctx, dirPath); err != nil {
+		return pathError("mkdir", name, err)
+	}
+
+	f.mu.Lock()
+	f.dirs[dirPath] = true
+	f.mu.Unlock()
+	return nil
+}
+
+// OpenFile 打开文件或目录。只读打开时按需下载；带写标志打开时写入临时文件，Close 时上传
+func (f *WebDAVFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	filePath := davPath(name)
+	write := flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0
+	info, err := f.stat(ctx, "open", filePath)
+	if !write {
+		if err != nil {
+			return nil, err
+		}
+		if info.IsDir() {
+			return &webdavDir{fsys: f, ctx: ctx, name: name, info: info}, nil
+		}
+		return &webdavReadFile{storageFile: &storageFile{fsys: NewFS(ctx, f.storage), name: name, info: info.storageFileInfo}, fsys: f, ctx: ctx, info: info}, nil
+	}
+
+	if err := f.readOnlyError("open", name); err != nil {
+		return nil, err
+	}
+	exists := err == nil
+	switch {
+	case err != nil && (!errors.Is(err, fs.ErrNotExist) || flag&os.O_CREATE == 0):
+		return nil, err
+	case exists && info.IsDir():
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("是目录")}
+	case exists && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case !exists:
+		if err := f.checkParent(ctx, "open", filePath); err != nil {
+			return nil, err
+		}
+	}
+
+	file := &webdavWriteFile{fsys: f, ctx: ctx, name: name, path: filePath, flag: flag}
+	if exists && flag&os.O_TRUNC == 0 {
+		// 保留原内容打开（如 PROPPATCH）时，首次读写才下载原内容，未修改时 Close 不会重新上传
+		file.info = info
+	}
+	return file, nil
+}
+
+// RemoveAll 删除文件或递归删除目录，路径不存在时不返回错误
+func (f *WebDAVFS) RemoveAll(ctx context.Context, name string) error {
+	if err := f.readOnlyError("removeall", name); err != nil {
+		return err
+	}
+	filePath := davPath(name)
+	if filePath == "" {
+		return &fs.PathError{Op: "removeall", Path: name, Err: fs.ErrPermission}
+	}
+	info, err := f.stat(ctx, "removeall", filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = f.storage.DeleteDir(ctx, filePath)
+		f.forgetDirs(filePath)
+	} else {
+		err = f.storage.Delete(ctx, filePath)
+	}
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		return pathError("removeall", name, err)
+	}
+	return nil
+}
+
+// Rename 重命名文件或目录。目录逐个重命名其中的文件，以兼容没有目录概念的对象存储
+func (f *WebDAVFS) Rename(ctx context.Context, oldName, newName string) error {
+	if err := f.readOnlyError("rename", oldName); err != nil {
+		return err
+	}
+	oldPath, newPath := davPath(oldName), davPath(newName)
+	if oldPath == "" || newPath == "" || newPath == oldPath || strings.HasPrefix(newPath, oldPath+"/") {
+		return &fs.PathError{Op: "rename", Path: oldName, Err: fs.ErrInvalid}
+	}
+	info, err := f.stat(ctx, "rename", oldPath)
+	if err != nil {
+		return err
+	}
+	if err := f.checkParent(ctx, "rename", newPath); err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		if err := f.storage.Rename(ctx, oldPath, newPath); err != nil {
+			return pathError("rename", oldName, err)
+		}
+		return nil
+	}
+
+	if err := f.storage.CreateDir(ctx, newPath); err != nil {
+		return pathError("rename", newName, err)
+	}
+	err = Walk(ctx, f.storage, oldPath, func(filePath string, _ FileMetadata) error {
+		return f.storage.Rename(ctx, filePath, newPath+strings.TrimPrefix(filePath, oldPath))
+	})
+	if err == nil {
+		err = f.storage.DeleteDir(ctx, oldPath)
+	}
+	if err != nil {
+		return pathError("rename", oldName, err)
+	}
+
+	// 空目录一并迁移
+	f.mu.Lock()
+	for dir := range f.dirs {
+		if dir == oldPath || strings.HasPrefix(dir, oldPath+"/") {
+			delete(f.dirs, dir)
+			f.dirs[newPath+strings.TrimPrefix(dir, oldPath)] = true
+		}
+	}
+	f.dirs[newPath] = true
+	f.mu.Unlock()
+	return nil
+}
+
+// Stat 返回文件或目录的信息
+func (f *WebDAVFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	return f.stat(ctx, "stat", davPath(name))
+}
+
+// stat 读取文件信息，找不到文件时检查是否为 Mkdir 创建的目录或非空目录
+func (f *WebDAVFS) stat(ctx context.Context, op, filePath string) (*webdavFileInfo, error) {
+	dirInfo := &webdavFileInfo{storageFileInfo: &storageFileInfo{name: path.Base("/" + filePath), metadata: FileMetadata{Name: filePath, IsDir: true}}}
+	if filePath == "" {
+		return dirInfo, nil
+	}
+
+	metadata, err := f.storage.GetMetadata(ctx, filePath)
+	if err == nil {
+		info := *metadata
+		info.Name = filePath
+		return &webdavFileInfo{storageFileInfo: &storageFileInfo{name: path.Base(filePath), metadata: info}}, nil
+	}
+	if !errors.Is(err, ErrNotExist) {
+		return nil, pathError(op, filePath, err)
+	}
+
+	f.mu.Lock()
+	created := f.dirs[filePath]
+	f.mu.Unlock()
+	if created {
+		return dirInfo, nil
+	}
+	entries, listErr := f.storage.ListDir(ctx, filePath)
+	if listErr != nil || len(entries) == 0 {
+		return nil, pathError(op, filePath, err)
+	}
+	return dirInfo, nil
+}
+
+// checkParent 检查父目录是否存在，不存在时返回 os.ErrNotExist（WebDAV 对应 409 Conflict）
+func (f *WebDAVFS) checkParent(ctx context.Context, op, filePath string) error {
+	parent := path.Dir(filePath)
+	if parent == "." {
+		return nil
+	}
+	info, err := f.stat(ctx, op, parent)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return &fs.PathError{Op: op, Path: parent, Err: fs.ErrNotExist}
+	}
+	return nil
+}
+
+// forgetDirs 移除目录及其子目录的空目录记录
+func (f *WebDAVFS) forgetDirs(dirPath string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for dir := range f.dirs {
+		if dir == dirPath || strings.HasPrefix(dir, dirPath+"/") {
+			delete(f.dirs, dir)
+		}
+	}
+}
+
+// childDirs 返回目录下通过 Mkdir 创建的直接子目录名
+func (f *WebDAVFS) childDirs(dirPath string) []string {
+	prefix := ""
+	if dirPath != "" {
+		prefix = dirPath + "/"
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for dir := range f.dirs {
+		if name, ok := strings.CutPrefix(dir, prefix); ok && name != "" && !strings.Contains(name, "/") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// webdavFileInfo 在 storageFileInfo 的基础上提供内容类型和 ETag，避免 webdav.Handler 读取文件内容来推断
+type webdavFileInfo struct {
+	*storageFileInfo
+}
+
+// ContentType 返回存储记录的内容类型，未记录时按扩展名推断
+func (i *webdavFileInfo) ContentType(ctx context.Context) (string, error) {
+	return contentTypeOrDetect(i.metadata.MIMEType, i.name), nil
+}
+
+// ETag 返回后端提供的 ETag，未提供时根据大小和修改时间生成
+func (i *webdavFileInfo) ETag(ctx context.Context) (string, error) {
+	return fileETag(&i.metadata), nil
+}
+
+// webdavReadFile 只读打开的文件，读取复用 io/fs 适配器的按需下载实现
+type webdavReadFile struct {
+	*storageFile
+	fsys *WebDAVFS
+	ctx  context.Context
+	info *webdavFileInfo
+}
+
+func (f *webdavReadFile) Stat() (fs.FileInfo, error) {
+	if _, err := f.storageFile.Stat(); err != nil {
+		return nil, err
+	}
+	return f.info, nil
+}
+
+func (f *webdavReadFile) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("不是目录")}
+}
+
+func (f *webdavReadFile) Write([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+}
+
+func (f *webdavReadFile) DeadProps() (map[xml.Name]webdav.Property, error) {
+	return f.fsys.deadProps(f.ctx, f.info)
+}
+
+// Patch 只读打开的文件不能修改属性，webdav.Handler 修改属性时总是以读写方式打开
+func (f *webdavReadFile) Patch([]webdav.Proppatch) ([]webdav.Propstat, error) {
+	return nil, &fs.PathError{Op: "proppatch", Path: f.name, Err: fs.ErrPermission}
+}
+
+// webdavDir 打开的目录，首次 Readdir 时列出内容
+type webdavDir struct {
+	fsys    *WebDAVFS
+	ctx     context.Context
+	name    string
+	info    *webdavFileInfo
+	entries []fs.FileInfo
+	loaded  bool
+}
+
+func (d *webdavDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+
+func (d *webdavDir) Close() error { return nil }
+
+func (d *webdavDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("是目录")}
+}
+
+func (d *webdavDir) Seek(int64, int) (int64, error) {
+	return 0, &fs.PathError{Op: "seek", Path: d.name, Err: errors.New("是目录")}
+}
+
+func (d *webdavDir) Write([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "write", Path: d.name, Err: errors.New("是目录")}
+}
+
+// Readdir 按 http.File 的约定分批返回目录项
+func (d *webdavDir) Readdir(count int) ([]fs.FileInfo, error) {
+	if !d.loaded {
+		if err := d.load(); err != nil {
+			return nil, err
+		}
+	}
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	count = min(count, len(d.entries))
+	entries := d.entries[:count]
+	d.entries = d.entries[count:]
+	return entries, nil
+}
+
+// load 列出目录内容并合并 Mkdir 创建的空子目录
+func (d *webdavDir) load() error {
+	dirPath := d.info.metadata.Name
+	entries, err := NewFS(d.ctx, d.fsys.storage).readDir(d.name, dirPath)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		seen[entry.Name()] = true
+		d.entries = append(d.entries, &webdavFileInfo{storageFileInfo: info.(*storageFileInfo)})
+	}
+	for _, name := range d.fsys.childDirs(dirPath) {
+		if !seen[name] {
+			d.entries = append(d.entries, &webdavFileInfo{storageFileInfo: &storageFileInfo{name: name, metadata: FileMetadata{Name: path.Join(dirPath, name), IsDir: true}}})
+		}
+	}
+	sort.Slice(d.entries, func(i, j int) bool { return d.entries[i].Name() < d.entries[j].Name() })
+	d.loaded = true
+	return nil
+}
+
+// webdavWriteFile 带写标志打开的文件，内容缓冲在本地临时文件中，Close 时上传到存储
+type webdavWriteFile struct {
+	fsys     *WebDAVFS
+	ctx      context.Context
+	name     string
+	path     string
+	flag     int
+	info     *webdavFileInfo // 保留原内容打开时为原文件信息
+	tmp      *os.File
+	modified bool
+	opts     []UploadOption // PROPPATCH 设置的上传选项
+}
+
+// buffer 返回缓冲临时文件，首次调用时创建并下载原内容
+func (f *webdavWriteFile) buffer() (*os.File, error) {
+	if f.tmp != nil {
+		return f.tmp, nil
+	}
+	tmp, err := os.CreateTemp(f.fsys.config.TempDir, "webdav-*")
+	if err != nil {
+		return nil, err
+	}
+	f.tmp = tmp
+	if f.info == nil {
+		return tmp, nil
+	}
+
+	reader, err := f.fsys.storage.Download(f.ctx, f.path)
+	if err == nil {
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+		_, err = io.Copy(tmp, reader)
+	}
+	if err == nil && f.flag&os.O_APPEND == 0 {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.discard()
+		return nil, pathError("read", f.name, err)
+	}
+	return tmp, nil
+}
+
+func (f *webdavWriteFile) Read(p []byte) (int, error) {
+	tmp, err := f.buffer()
+	if err != nil {
+		return 0, err
+	}
+	return tmp.Read(p)
+}
+
+func (f *webdavWriteFile) Write(p []byte) (int, error) {
+	tmp, err := f.buffer()
+	if err != nil {
+		return 0, err
+	}
+	f.modified = true
+	return tmp.Write(p)
+}
+
+func (f *webdavWriteFile) Seek(offset int64, whence int) (int64, error) {
+	tmp, err := f.buffer()
+	if err != nil {
+		return 0, err
+	}
+	return tmp.Seek(offset, whence)
+}
+
+func (f *webdavWriteFile) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: errors.New("不是目录")}
+}
+
+// Stat 返回缓冲内容的大小，ETag 由 webdav.Handler 根据大小和修改时间生成
+func (f *webdavWriteFile) Stat() (fs.FileInfo, error) {
+	if f.tmp == nil && f.info != nil {
+		return f.info, nil
+	}
+	tmp, err := f.buffer()
+	if err != nil {
+		return nil, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &storageFileInfo{name: path.Base(f.path), metadata: FileMetadata{Name: f.path, Size: info.Size(), ModTime: time.Now()}}, nil
+}
+
+// Close 将缓冲内容上传到存储并删除临时文件，保留原内容打开且未修改时不上传
+func (f *webdavWriteFile) Close() error {
+	if f.info != nil && !f.modified {
+		if f.tmp != nil {
+			f.discard()
+		}
+		return nil
+	}
+	tmp, err := f.buffer()
+	if err != nil {
+		return err
+	}
+	defer f.discard()
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := f.fsys.storage.Upload(f.ctx, f.path, tmp, f.opts...); err != nil {
+		hlog.CtxErrorf(f.ctx, "WebDAV上传文件失败: %s, %v", f.path, err)
+		return pathError("close", f.name, err)
+	}
+	return nil
+}
+
+// discard 关闭并删除临时文件
+func (f *webdavWriteFile) discard() {
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+	f.tmp = nil
+}
+
+// DeadProps 返回本包命名空间下的自定义属性
+func (f *webdavWriteFile) DeadProps() (map[xml.Name]webdav.Property, error) {
+	if f.info == nil {
+		return nil, nil
+	}
+	return f.fsys.deadProps(f.ctx, f.info)
+}
+
+// Patch 设置 WebDAVStorage 使用的 content-type 和 expires-at 属性，不支持其他自定义属性。
+// content-type 通过 UpdateMetadata 修改；expires-at 需要携带有效期重新上传，在 Close 时进行
+func (f *webdavWriteFile) Patch(patches []webdav.Proppatch) ([]webdav.Propstat, error) {
+	var names []xml.Name
+	var contentType string
+	var expiration *time.Duration
+	status := http.StatusOK
+	for _, patch := range patches {
+		for _, prop := range patch.Props {
+			names = append(names, prop.XMLName)
+			switch prop.XMLName {
+			case davContentTypeProp:
+				if !patch.Remove {
+					contentType = davPropText(prop)
+				}
+			case davExpiresAtProp:
+				var ttl time.Duration
+				if !patch.Remove {
+					deadline := parseExpiresAt(davPropText(prop))
+					// WithExpiration 按秒截断，向上取整以保持截止时间不变
+					if ttl = time.Until(deadline).Truncate(time.Second) + time.Second; deadline.IsZero() || ttl <= time.Second {
+						status = http.StatusConflict
+					}
+				}
+				expiration = &ttl
+			default:
+				status = http.StatusForbidden
+			}
+		}
+	}
+	if f.info == nil {
+		status = http.StatusForbidden
+	}
+	if status != http.StatusOK {
+		return []webdav.Propstat{{Props: davProps(names), Status: status}}, nil
+	}
+
+	if expiration == nil {
+		if contentType != "" {
+			if err := f.fsys.storage.UpdateMetadata(f.ctx, f.path, &FileMetadata{MIMEType: contentType}); err != nil {
+				return nil, pathError("proppatch", f.name, err)
+			}
+		}
+		return []webdav.Propstat{{Props: davProps(names), Status: http.StatusOK}}, nil
+	}
+
+	if contentType == "" {
+		contentType = f.info.metadata.MIMEType
+	}
+	f.opts = []UploadOption{WithContentType(contentType)}
+	if *expiration > 0 {
+		f.opts = append(f.opts, WithExpiration(*expiration))
+	}
+	f.modified = true
+	return []webdav.Propstat{{Props: davProps(names), Status: http.StatusOK}}, nil
+}
+
+var (
+	davContentTypeProp = xml.Name{Space: webdavNamespace, Local: "content-type"}
+	davExpiresAtProp   = xml.Name{Space: webdavNamespace, Local: "expires-at"}
+)
+
+// deadProps 返回文件的 content-type 和 expires-at 属性，与 WebDAVStorage 保存的属性一致
+func (f *WebDAVFS) deadProps(ctx context.Context, info *webdavFileInfo) (map[xml.Name]webdav.Property, error) {
+	props := make(map[xml.Name]webdav.Property)
+	if info.IsDir() {
+		return props, nil
+	}
+	if info.metadata.MIMEType != "" {
+		props[davContentTypeProp] = davProperty(davContentTypeProp, info.metadata.MIMEType)
+	}
+	if reader, ok := As[ExpirationReader](f.storage); ok {
+		deadline, err := reader.GetExpiration(ctx, info.metadata.Name)
+		if err != nil {
+			return nil, pathError("propfind", info.metadata.Name, err)
+		}
+		if !deadline.IsZero() {
+			props[davExpiresAtProp] = davProperty(davExpiresAtProp, formatExpiresAt(deadline))
+		}
+	}
+	return props, nil
+}
+
+// davProperty 构造文本值的属性
+func davProperty(name xml.Name, value string) webdav.Property {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(value))
+	return webdav.Property{XMLName: name, InnerXML: buf.Bytes()}
+}
+
+// davPropText 读取属性的文本值
+func davPropText(prop webdav.Property) string {
+	var text struct {
+		Value string `xml:",chardata"`
+	}
+	xml.Unmarshal(append(append([]byte("<v>"), prop.InnerXML...), "</v>"...), &text)
+	return strings.TrimSpace(text.Value)
+}
+
+// davProps 返回只包含名称的属性列表，用于 PROPPATCH 的结果
+func davProps(names []xml.Name) []webdav.Property {
+	props := make([]webdav.Property, len(names))
+	for i, name := range names {
+		props[i] = webdav.Property{XMLName: name}
+	}
+	return props
+}
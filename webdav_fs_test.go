@@ -0,0 +1,147 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/v-mars/storage"
+	"golang.org/x/net/webdav"
+)
+
+// startWebDAVFS 在 httptest 服务器上挂载存储的 WebDAV 文件系统，返回访问它的 WebDAV 客户端存储
+func startWebDAVFS(t *testing.T, backend storage.Storage, config storage.WebDAVFSConfig) (string, storage.Storage) {
+	t.Helper()
+	server := httptest.NewServer(&webdav.Handler{
+		FileSystem: storage.NewWebDAVFS(backend, config),
+		LockSystem: webdav.NewMemLS(),
+	})
+	t.Cleanup(server.Close)
+	return server.URL, storage.NewWebDAVStorage(storage.WebDAVStorageConfig{Endpoint: server.URL + "/"})
+}
+
+func TestWebDAVFS(t *testing.T) {
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	_, client := startWebDAVFS(t, backend, storage.WebDAVFSConfig{})
+	ctx := context.Background()
+
+	// 内存存储没有空目录，MKCOL 创建的目录仍然可以列出
+	if err := client.CreateDir(ctx, "designs/empty"); err != nil {
+		t.Fatalf("CreateDir failed: %v", err)
+	}
+	files, err := client.ListDir(ctx, "designs")
+	if err != nil || len(files) != 1 || !files[0].IsDir {
+		t.Fatalf("Unexpected listing: %+v, %v", files, err)
+	}
+
+	// 重命名目录时移动其中所有文件
+	for _, name := range []string{"designs/v1/logo.svg", "designs/v1/icons/a.png"} {
+		if err := client.Upload(ctx, name, strings.NewReader(name)); err != nil {
+			t.Fatalf("Upload failed: %v", err)
+		}
+	}
+	if err := client.Rename(ctx, "designs/v1", "designs/v2"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if got := readString(t)(backend.Download(ctx, "designs/v2/icons/a.png")); got != "designs/v1/icons/a.png" {
+		t.Fatalf("Unexpected renamed content: %q", got)
+	}
+	if exists, _ := backend.Exists(ctx, "designs/v1/logo.svg"); exists {
+		t.Fatal("Old file should be removed after rename")
+	}
+
+	// 删除目录
+	if err := client.DeleteDir(ctx, "designs"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if files, _ := backend.ListDir(ctx, ""); len(files) != 0 {
+		t.Fatalf("Expected empty storage, got %+v", files)
+	}
+}
+
+func TestWebDAVFS_OpenFile(t *testing.T) {
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	fsys := storage.NewWebDAVFS(backend, storage.WebDAVFSConfig{})
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "doc.txt", strings.NewReader("hello world")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	// 不截断地打开时保留原内容，Close 时上传修改后的内容
+	f, err := fsys.OpenFile(ctx, "/doc.txt", os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	if _, err := f.Seek(6, io.SeekStart); err != nil {
+		t.Fatalf("Seek failed: %v", err)
+	}
+	if _, err := f.Write([]byte("WORLD")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if got := readString(t)(backend.Download(ctx, "doc.txt")); got != "hello world" {
+		t.Fatalf("Content should not change before Close: %q", got)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := readString(t)(backend.Download(ctx, "doc.txt")); got != "hello WORLD" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+
+	// 追加写入
+	f, err = fsys.OpenFile(ctx, "/doc.txt", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("OpenFile failed: %v", err)
+	}
+	f.Write([]byte("!"))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := readString(t)(backend.Download(ctx, "doc.txt")); got != "hello WORLD!" {
+		t.Fatalf("Unexpected appended content: %q", got)
+	}
+
+	// 父目录不存在和排他创建
+	if _, err := fsys.OpenFile(ctx, "/missing/new.txt", os.O_RDWR|os.O_CREATE, 0); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Expected ErrNotExist for missing parent, got %v", err)
+	}
+	if _, err := fsys.OpenFile(ctx, "/doc.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("Expected ErrExist, got %v", err)
+	}
+}
+
+func TestWebDAVFS_ReadOnly(t *testing.T) {
+	backend := storage.NewMemoryStorage(storage.MemoryStorageConfig{})
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "asset.txt", strings.NewReader("asset")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	endpoint, client := startWebDAVFS(t, backend, storage.WebDAVFSConfig{ReadOnly: true})
+
+	if got := readString(t)(client.Download(ctx, "asset.txt")); got != "asset" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+	if err := client.Upload(ctx, "new.txt", strings.NewReader("new")); err == nil {
+		t.Fatal("Expected upload to fail in read-only mode")
+	}
+	if err := client.Delete(ctx, "asset.txt"); err == nil {
+		t.Fatal("Expected delete to fail in read-only mode")
+	}
+	req, _ := http.NewRequest("MKCOL", endpoint+"/dir", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("MKCOL request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusCreated {
+		t.Fatal("Expected MKCOL to fail in read-only mode")
+	}
+	if exists, _ := backend.Exists(ctx, "asset.txt"); !exists {
+		t.Fatal("asset.txt should not be deleted")
+	}
+}
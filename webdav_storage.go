@@ -0,0 +1,886 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+)
+
+const (
+	// webdavNamespace 本包写入的自定义（dead）属性的命名空间
+	webdavNamespace = "urn:v-mars:storage"
+	// webdavDefaultTimeout 默认的请求超时时间
+	webdavDefaultTimeout = 60 * time.Second
+	// webdavLockedRetries 资源被锁定（423）时的重试次数
+	webdavLockedRetries = 10
+	// webdavLockedBackoff 资源被锁定时的重试间隔（按重试次数递增）
+	webdavLockedBackoff = 20 * time.Millisecond
+)
+
+// webdavPropfindBody PROPFIND 请求的属性列表
+const webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:s="` + webdavNamespace + `"><d:prop>
+<d:resourcetype/><d:getcontentlength/><d:getlastmodified/><d:getcontenttype/><d:getetag/>
+<s:content-type/><s:expires-at/>
+</d:prop></d:propfind>`
+
+// WebDAVStorageConfig WebDAV 存储配置
+type WebDAVStorageConfig struct {
+	Endpoint  string            `json:"endpoint"`  // WebDAV 根地址，如 https://cloud.example.com/remote.php/dav/files/user/
+	Username  string            `json:"username"`  // 用户名
+	Password  string            `json:"password"`  // 密码
+	AuthType  string            `json:"auth_type"` // 认证方式：basic、digest，为空时根据服务器的质询自动选择
+	BaseDir   string            `json:"base_dir"`  // 存储基础目录（相对 Endpoint）
+	Timeout   time.Duration     `json:"timeout"`   // 请求超时时间，默认 60 秒
+	Transport http.RoundTripper `json:"-"`         // 自定义传输层，为空时使用 http.DefaultTransport
+}
+
+// WebDAVStorage WebDAV 存储实现（兼容 Nextcloud、ownCloud 等服务）。
+// 有效期和显式指定的 MIME 类型以自定义属性（PROPPATCH）保存在文件上。
+type WebDAVStorage struct {
+	config   WebDAVStorageConfig
+	endpoint *url.URL
+	client   *http.Client
+	auth     *webdavAuth
+	dirs     sync.Map // 已确认存在的目录（服务器路径），避免每次上传都发送 MKCOL
+}
+
+// NewWebDAVStorage 创建新的WebDAV存储实例，创建时检查连接并确保基础目录存在
+func NewWebDAVStorage(config WebDAVStorageConfig) Storage {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		hlog.Errorf("WebDAV地址无效: %q", config.Endpoint)
+		return nil
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = webdavDefaultTimeout
+	}
+
+	s := &WebDAVStorage{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Transport: config.Transport, Timeout: config.Timeout},
+		auth:     &webdavAuth{username: config.Username, password: config.Password, scheme: strings.ToLower(config.AuthType)},
+	}
+
+	// 确保基础目录存在（同时完成认证方式的协商）
+	if err := s.ensureDir(context.Background(), s.serverPath("")); err != nil {
+		hlog.Errorf("创建WebDAV基础目录失败: %v", err)
+		return nil
+	}
+	return s
+}
+
+// serverPath 返回文件在服务器上的路径（未转义）
+func (s *WebDAVStorage) serverPath(filePath string) string {
+	return path.Join("/", s.endpoint.Path, s.config.BaseDir, filePath)
+}
+
+// url 返回服务器路径对应的完整地址，isDir 为 true 时以 / 结尾
+func (s *WebDAVStorage) url(serverPath string, isDir bool) string {
+	u := *s.endpoint
+	if isDir && !strings.HasSuffix(serverPath, "/") {
+		serverPath += "/"
+	}
+	u.Path = serverPath
+	u.RawPath = ""
+	return u.String()
+}
+
+// do 发送请求并处理认证质询（401）和资源锁定（423）。
+// 请求体不可重放（非 bytes.Reader、strings.Reader 等）时不会重试。
+func (s *WebDAVStorage) do(ctx context.Context, method, target string, body io.Reader, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	authRetried := false
+	for attempt := 0; ; attempt++ {
+		s.auth.authorize(req)
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		retry := false
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized && !authRetried:
+			authRetried = true
+			retry = s.auth.challenge(resp.Header.Values("WWW-Authenticate"))
+		case resp.StatusCode == http.StatusLocked && attempt < webdavLockedRetries:
+			retry = true
+		}
+		if !retry || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+			return resp, nil
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusLocked {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt+1) * webdavLockedBackoff):
+			}
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// expect 发送请求，状态码不在 expected 中时返回错误（404 转换为 ErrNotExist）
+func (s *WebDAVStorage) expect(ctx context.Context, method, target string, body io.Reader, header http.Header, expected ...int) (*http.Response, error) {
+	resp, err := s.do(ctx, method, target, body, header)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	return nil, webdavError(method, target, resp)
+}
+
+// ensureDir 逐级创建目录（MKCOL），已存在的目录会被缓存
+func (s *WebDAVStorage) ensureDir(ctx context.Context, serverPath string) error {
+	if serverPath == "/" || serverPath == "." {
+		return nil
+	}
+	if _, ok := s.dirs.Load(serverPath); ok {
+		return nil
+	}
+	if err := s.ensureDir(ctx, path.Dir(serverPath)); err != nil {
+		return err
+	}
+
+	// 405 表示目录已存在
+	resp, err := s.expect(ctx, "MKCOL", s.url(serverPath, true), nil, nil, http.StatusCreated, http.StatusMethodNotAllowed)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	s.dirs.Store(serverPath, struct{}{})
+	return nil
+}
+
+// Upload 实现WebDAV文件上传。
+// 先上传到同目录下的临时文件并写入自定义属性，再 MOVE 覆盖目标文件，读取方不会看到不完整的文件。
+func (s *WebDAVStorage) Upload(ctx context.Context, filePath string, reader io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始上传文件到WebDAV: %s", filePath)
+
+	// 应用上传选项
+	options := ApplyUploadOptions(opts...)
+
+	contentType, reader, err := resolveContentType(filePath, reader, options)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "检测文件类型失败: %v", err)
+		return err
+	}
+
+	target := s.serverPath(filePath)
+	if err := s.ensureDir(ctx, path.Dir(target)); err != nil {
+		hlog.CtxErrorf(ctx, "创建WebDAV目录失败: %v", err)
+		return err
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	tempPath := path.Join(path.Dir(target), localUploadTempPrefix+hex.EncodeToString(suffix))
+
+	header := http.Header{"Content-Type": {contentType}}
+	resp, err := s.expect(ctx, http.MethodPut, s.url(tempPath, false), reader, header, http.StatusCreated, http.StatusNoContent, http.StatusOK)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV上传文件失败: %v", err)
+		return err
+	}
+	resp.Body.Close()
+
+	// 能由扩展名推断的类型无需记录；设置有效期时记录删除截止时间
+	props := map[string]string{}
+	if contentType != MIMETypeByExtension(filePath) {
+		props["content-type"] = contentType
+	}
+	if options.Expiration > 0 {
+		expiration := expirationDeadline(options.Expiration)
+		props["expires-at"] = formatExpiresAt(expiration)
+		hlog.CtxDebugf(ctx, "设置WebDAV文件过期时间: %v", expiration)
+	}
+	if len(props) > 0 {
+		err = s.proppatch(ctx, tempPath, props)
+	}
+	if err == nil {
+		err = s.move(ctx, "MOVE", tempPath, target)
+	}
+	if err != nil {
+		// 失败时删除临时文件，已有文件保持不变
+		s.delete(context.WithoutCancel(ctx), tempPath, false)
+		hlog.CtxErrorf(ctx, "WebDAV上传文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV文件上传成功: %s", filePath)
+	return nil
+}
+
+// Download 实现从WebDAV下载文件（流式下载）
+func (s *WebDAVStorage) Download(ctx context.Context, filePath string) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从WebDAV下载文件: %s", filePath)
+
+	body, err := s.download(ctx, filePath, 0, -1)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV获取文件失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV文件下载已启动: %s", filePath)
+	return body, nil // 返回原始的Reader，由调用方负责关闭
+}
+
+// DownloadRange 实现从WebDAV下载文件（支持断点续传）
+func (s *WebDAVStorage) DownloadRange(ctx context.Context, filePath string, offset int64, size int64) (io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始从WebDAV下载文件范围: %s, offset: %d, size: %d", filePath, offset, size)
+
+	body, err := s.download(ctx, filePath, offset, size)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV获取文件范围失败: %v", err)
+		return nil, err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV文件断点续传下载已启动: %s", filePath)
+	return body, nil
+}
+
+// download 使用 Range 请求下载指定范围（size 小于 0 表示到文件末尾），已过期的文件返回 ErrNotExist。
+// 服务器忽略 Range 返回完整内容时在客户端跳过和截断。
+func (s *WebDAVStorage) download(ctx context.Context, filePath string, offset, size int64) (io.ReadCloser, error) {
+	entry, err := s.propfindOne(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if isExpired(parseExpiresAt(entry.prop.ExpiresAt)) {
+		return nil, errExpired(filePath)
+	}
+	if size == 0 {
+		return io.NopCloser(strings.NewReader("")), nil
+	}
+
+	header := http.Header{}
+	if offset > 0 || size > 0 {
+		rangeHeader := fmt.Sprintf("bytes=%d-", offset)
+		if size > 0 {
+			rangeHeader += strconv.FormatInt(offset+size-1, 10)
+		}
+		header.Set("Range", rangeHeader)
+	}
+
+	resp, err := s.expect(ctx, http.MethodGet, s.url(s.serverPath(filePath), false), nil, header,
+		http.StatusOK, http.StatusPartialContent, http.StatusRequestedRangeNotSatisfiable)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusRequestedRangeNotSatisfiable:
+		// 起始位置超出文件末尾
+		resp.Body.Close()
+		return io.NopCloser(strings.NewReader("")), nil
+	case http.StatusOK:
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil && !errors.Is(err, io.EOF) {
+				resp.Body.Close()
+				return nil, err
+			}
+		}
+	}
+	if size > 0 {
+		return &limitedReadCloser{Reader: io.LimitReader(resp.Body, size), Closer: resp.Body}, nil
+	}
+	return resp.Body, nil
+}
+
+// Delete 实现WebDAV文件删除（删除不存在的文件不报错）
+func (s *WebDAVStorage) Delete(ctx context.Context, filePath string) error {
+	hlog.CtxInfof(ctx, "开始从WebDAV删除文件: %s", filePath)
+
+	if err := s.delete(ctx, s.serverPath(filePath), false); err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV删除文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV文件删除成功: %s", filePath)
+	return nil
+}
+
+// delete 删除文件或目录，不存在时忽略
+func (s *WebDAVStorage) delete(ctx context.Context, serverPath string, isDir bool) error {
+	resp, err := s.expect(ctx, http.MethodDelete, s.url(serverPath, isDir), nil, nil, http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Rename 实现WebDAV文件重命名（MOVE，覆盖已存在的目标文件）
+func (s *WebDAVStorage) Rename(ctx context.Context, oldPath string, newPath string) error {
+	hlog.CtxInfof(ctx, "开始在WebDAV中重命名文件: %s -> %s", oldPath, newPath)
+
+	if err := s.transfer(ctx, "MOVE", oldPath, newPath); err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV文件重命名失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV文件重命名成功: %s -> %s", oldPath, newPath)
+	return nil
+}
+
+// Move 实现WebDAV文件移动（与重命名相同的操作）
+func (s *WebDAVStorage) Move(ctx context.Context, srcPath string, dstPath string) error {
+	return s.Rename(ctx, srcPath, dstPath)
+}
+
+// Copy 实现WebDAV文件复制（COPY，服务端复制，同时复制自定义属性）
+func (s *WebDAVStorage) Copy(ctx context.Context, srcPath string, dstPath string) error {
+	hlog.CtxInfof(ctx, "开始在WebDAV中复制文件: %s -> %s", srcPath, dstPath)
+
+	if err := s.transfer(ctx, "COPY", srcPath, dstPath); err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV复制文件失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV文件复制成功: %s -> %s", srcPath, dstPath)
+	return nil
+}
+
+// transfer 确保目标目录存在后执行 MOVE 或 COPY
+func (s *WebDAVStorage) transfer(ctx context.Context, method, srcPath, dstPath string) error {
+	dst := s.serverPath(dstPath)
+	if err := s.ensureDir(ctx, path.Dir(dst)); err != nil {
+		return err
+	}
+	src := s.serverPath(srcPath)
+	err := s.move(ctx, method, src, dst)
+	if err != nil && !errors.Is(err, ErrNotExist) {
+		// 部分服务器源文件不存在时返回 403 或 409，此时统一返回 ErrNotExist
+		if _, statErr := s.propfindOne(ctx, srcPath); errors.Is(statErr, ErrNotExist) {
+			return statErr
+		}
+	}
+	if err == nil {
+		// 目标位置原有的目录被覆盖，MOVE 后源目录不再存在
+		s.forgetDirs(dst)
+		if method == "MOVE" {
+			s.forgetDirs(src)
+		}
+	}
+	return err
+}
+
+// move 执行 MOVE 或 COPY，覆盖已存在的目标
+func (s *WebDAVStorage) move(ctx context.Context, method, src, dst string) error {
+	header := http.Header{
+		"Destination": {s.url(dst, false)},
+		"Overwrite":   {"T"},
+	}
+	if method == "COPY" {
+		header.Set("Depth", "0")
+	}
+	resp, err := s.expect(ctx, method, s.url(src, false), nil, header, http.StatusCreated, http.StatusNoContent)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Exists 实现检查WebDAV文件是否存在（已过期的文件视为不存在）
+func (s *WebDAVStorage) Exists(ctx context.Context, filePath string) (bool, error) {
+	entry, err := s.propfindOne(ctx, filePath)
+	if err != nil {
+		if errors.Is(err, ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return !isExpired(parseExpiresAt(entry.prop.ExpiresAt)), nil
+}
+
+// CreateDir 实现WebDAV目录创建（MKCOL，逐级创建）
+func (s *WebDAVStorage) CreateDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始创建WebDAV目录: %s", dirPath)
+
+	if err := s.ensureDir(ctx, s.serverPath(dirPath)); err != nil {
+		hlog.CtxErrorf(ctx, "创建WebDAV目录失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "WebDAV目录创建成功: %s", dirPath)
+	return nil
+}
+
+// DeleteDir 实现WebDAV目录删除（DELETE 集合会递归删除其中所有内容）
+func (s *WebDAVStorage) DeleteDir(ctx context.Context, dirPath string) error {
+	hlog.CtxInfof(ctx, "开始删除WebDAV目录: %s", dirPath)
+
+	serverPath := s.serverPath(dirPath)
+	if err := s.delete(ctx, serverPath, true); err != nil {
+		hlog.CtxErrorf(ctx, "WebDAV删除目录失败: %v", err)
+		return err
+	}
+	s.forgetDirs(serverPath)
+
+	hlog.CtxInfof(ctx, "WebDAV目录删除成功: %s", dirPath)
+	return nil
+}
+
+// forgetDirs 清除目录及其子目录的缓存
+func (s *WebDAVStorage) forgetDirs(serverPath string) {
+	s.dirs.Range(func(key, _ any) bool {
+		if dir := key.(string); dir == serverPath || strings.HasPrefix(dir, serverPath+"/") {
+			s.dirs.Delete(key)
+		}
+		return true
+	})
+}
+
+// ListDir 实现WebDAV目录列表（PROPFIND Depth: 1，仅当前层级）
+func (s *WebDAVStorage) ListDir(ctx context.Context, dirPath string) ([]FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始列出WebDAV目录内容: %s", dirPath)
+
+	serverPath := s.serverPath(dirPath)
+	entries, err := s.propfind(ctx, serverPath, true, "1")
+	if err != nil {
+		hlog.CtxErrorf(ctx, "列出WebDAV目录内容失败: %v", err)
+		return nil, err
+	}
+
+	files := make([]FileMetadata, 0, len(entries))
+	for _, entry := range entries {
+		// 跳过目录自身和上传中的临时文件
+		if entry.path == serverPath {
+			continue
+		}
+		name := path.Base(entry.path)
+		if strings.HasPrefix(name, localUploadTempPrefix) {
+			continue
+		}
+		files = append(files, entry.metadata(name))
+	}
+
+	hlog.CtxInfof(ctx, "成功列出WebDAV目录内容: %s, 共找到 %d 个条目", dirPath, len(files))
+	return files, nil
+}
+
+// GetMetadata 实现获取WebDAV文件元数据（PROPFIND Depth: 0）
+func (s *WebDAVStorage) GetMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
+	hlog.CtxInfof(ctx, "开始获取WebDAV文件元数据: %s", filePath)
+
+	entry, err := s.propfindOne(ctx, filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取WebDAV文件信息失败: %v", err)
+		return nil, err
+	}
+	if isExpired(parseExpiresAt(entry.prop.ExpiresAt)) {
+		return nil, errExpired(filePath)
+	}
+
+	metadata := entry.metadata(filePath)
+	hlog.CtxInfof(ctx, "成功获取WebDAV文件元数据: %s", filePath)
+	return &metadata, nil
+}
+
+// UpdateMetadata 更新WebDAV文件元数据（PROPPATCH），仅支持修改 MIME 类型。
+// WebDAV 没有修改 getlastmodified 的标准方式，指定修改时间时返回 ErrNotSupported。
+func (s *WebDAVStorage) UpdateMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	hlog.CtxInfof(ctx, "开始更新WebDAV文件元数据: %s", filePath)
+
+	if !metadata.ModTime.IsZero() {
+		return fmt.Errorf("WebDAV不支持修改文件修改时间: %w", ErrNotSupported)
+	}
+	if metadata.MIMEType == "" {
+		return nil
+	}
+
+	if err := s.proppatch(ctx, s.serverPath(filePath), map[string]string{"content-type": metadata.MIMEType}); err != nil {
+		hlog.CtxErrorf(ctx, "更新WebDAV文件元数据失败: %v", err)
+		return err
+	}
+
+	hlog.CtxInfof(ctx, "成功更新WebDAV文件元数据: %s", filePath)
+	return nil
+}
+
+// GetExpiration 读取WebDAV文件的删除截止时间（不检查是否已过期）
+func (s *WebDAVStorage) GetExpiration(ctx context.Context, filePath string) (time.Time, error) {
+	entry, err := s.propfindOne(ctx, filePath)
+	if err != nil {
+		hlog.CtxErrorf(ctx, "获取WebDAV文件信息失败: %v", err)
+		return time.Time{}, err
+	}
+	return parseExpiresAt(entry.prop.ExpiresAt), nil
+}
+
+// BatchUpload 实现WebDAV批量上传
+func (s *WebDAVStorage) BatchUpload(ctx context.Context, files map[string]io.Reader, opts ...UploadOption) error {
+	hlog.CtxInfof(ctx, "开始批量上传 %d 个文件到WebDAV", len(files))
+	return BatchUploadHelper(ctx, s, files, opts...)
+}
+
+// BatchDownload 实现WebDAV批量下载（流式下载）
+func (s *WebDAVStorage) BatchDownload(ctx context.Context, filePaths []string) (map[string]io.Reader, error) {
+	hlog.CtxInfof(ctx, "开始批量下载 %d 个WebDAV文件", len(filePaths))
+	return BatchDownloadHelper(ctx, s, filePaths)
+}
+
+// BatchDelete 实现WebDAV批量删除
+func (s *WebDAVStorage) BatchDelete(ctx context.Context, filePaths []string) error {
+	hlog.CtxInfof(ctx, "开始批量删除 %d 个WebDAV文件", len(filePaths))
+	return BatchDeleteHelper(ctx, s, filePaths)
+}
+
+// webdavMultistatus PROPFIND 和 PROPPATCH 的 207 响应
+type webdavMultistatus struct {
+	Responses []struct {
+		Href      string `xml:"DAV: href"`
+		Propstats []struct {
+			Prop   webdavProp `xml:"DAV: prop"`
+			Status string     `xml:"DAV: status"`
+		} `xml:"DAV: propstat"`
+	} `xml:"DAV: response"`
+}
+
+// webdavProp PROPFIND 返回的属性
+type webdavProp struct {
+	ResourceType struct {
+		Collection *struct{} `xml:"DAV: collection"`
+	} `xml:"DAV: resourcetype"`
+	ContentLength string `xml:"DAV: getcontentlength"`
+	LastModified  string `xml:"DAV: getlastmodified"`
+	ContentType   string `xml:"DAV: getcontenttype"`
+	ETag          string `xml:"DAV: getetag"`
+	// 本包写入的自定义属性
+	StorageContentType string `xml:"urn:v-mars:storage content-type"`
+	ExpiresAt          string `xml:"urn:v-mars:storage expires-at"`
+}
+
+// webdavEntry PROPFIND 返回的一个资源
+type webdavEntry struct {
+	path string // 服务器路径（已解码，不带尾斜杠）
+	prop webdavProp
+}
+
+// metadata 将资源属性转换为 FileMetadata
+func (e *webdavEntry) metadata(name string) FileMetadata {
+	size, _ := strconv.ParseInt(e.prop.ContentLength, 10, 64)
+	modTime, _ := http.ParseTime(e.prop.LastModified)
+	metadata := FileMetadata{
+		Name:    name,
+		Size:    size,
+		ModTime: modTime,
+		IsDir:   e.prop.ResourceType.Collection != nil,
+		ETag:    trimETag(strings.TrimPrefix(e.prop.ETag, "W/")),
+	}
+	if !metadata.IsDir {
+		metadata.MIMEType = e.prop.StorageContentType
+		if metadata.MIMEType == "" {
+			metadata.MIMEType = contentTypeOrDetect(e.prop.ContentType, name)
+		}
+	}
+	return metadata
+}
+
+// propfindOne 获取单个资源的属性
+func (s *WebDAVStorage) propfindOne(ctx context.Context, filePath string) (*webdavEntry, error) {
+	entries, err := s.propfind(ctx, s.serverPath(filePath), false, "0")
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, &notExistError{err: fmt.Errorf("WebDAV资源不存在: %s", filePath)}
+	}
+	return &entries[0], nil
+}
+
+// propfind 发送 PROPFIND 请求并解析返回的资源列表
+func (s *WebDAVStorage) propfind(ctx context.Context, serverPath string, isDir bool, depth string) ([]webdavEntry, error) {
+	header := http.Header{
+		"Depth":        {depth},
+		"Content-Type": {"application/xml; charset=utf-8"},
+	}
+	resp, err := s.expect(ctx, "PROPFIND", s.url(serverPath, isDir), strings.NewReader(webdavPropfindBody), header, http.StatusMultiStatus)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var multistatus webdavMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		return nil, fmt.Errorf("解析PROPFIND响应失败: %w", err)
+	}
+
+	entries := make([]webdavEntry, 0, len(multistatus.Responses))
+	for _, response := range multistatus.Responses {
+		href, err := url.Parse(response.Href)
+		if err != nil {
+			continue
+		}
+		entry := webdavEntry{path: path.Clean("/" + href.Path)}
+		// 只采用状态为 200 的属性，不存在的属性以 404 的 propstat 返回
+		for _, propstat := range response.Propstats {
+			if webdavStatusOK(propstat.Status) {
+				mergeWebDAVProp(&entry.prop, propstat.Prop)
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// mergeWebDAVProp 合并多个 propstat 中的属性
+func mergeWebDAVProp(dst *webdavProp, src webdavProp) {
+	if src.ResourceType.Collection != nil {
+		dst.ResourceType.Collection = src.ResourceType.Collection
+	}
+	for _, field := range []struct {
+		dst *string
+		src string
+	}{
+		{&dst.ContentLength, src.ContentLength},
+		{&dst.LastModified, src.LastModified},
+		{&dst.ContentType, src.ContentType},
+		{&dst.ETag, src.ETag},
+		{&dst.StorageContentType, src.StorageContentType},
+		{&dst.ExpiresAt, src.ExpiresAt},
+	} {
+		if field.src != "" {
+			*field.dst = field.src
+		}
+	}
+}
+
+// proppatch 设置本包命名空间下的自定义属性，任一属性设置失败时返回错误
+func (s *WebDAVStorage) proppatch(ctx context.Context, serverPath string, props map[string]string) error {
+	var body bytes.Buffer
+	body.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	body.WriteString(`<d:propertyupdate xmlns:d="DAV:" xmlns:s="` + webdavNamespace + `"><d:set><d:prop>`)
+	for name, value := range props {
+		body.WriteString("<s:" + name + ">")
+		xml.EscapeText(&body, []byte(value))
+		body.WriteString("</s:" + name + ">")
+	}
+	body.WriteString(`</d:prop></d:set></d:propertyupdate>`)
+
+	header := http.Header{"Content-Type": {"application/xml; charset=utf-8"}}
+	resp, err := s.expect(ctx, "PROPPATCH", s.url(serverPath, false), bytes.NewReader(body.Bytes()), header, http.StatusMultiStatus, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusMultiStatus {
+		return nil
+	}
+
+	var multistatus webdavMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&multistatus); err != nil {
+		return fmt.Errorf("解析PROPPATCH响应失败: %w", err)
+	}
+	for _, response := range multistatus.Responses {
+		for _, propstat := range response.Propstats {
+			if !webdavStatusOK(propstat.Status) {
+				return fmt.Errorf("WebDAV设置属性失败: %s", propstat.Status)
+			}
+		}
+	}
+	return nil
+}
+
+// webdavStatusOK 判断 multistatus 中的状态行（如 "HTTP/1.1 200 OK"）是否表示成功
+func webdavStatusOK(status string) bool {
+	fields := strings.Fields(status)
+	return len(fields) >= 2 && strings.HasPrefix(fields[1], "2")
+}
+
+// webdavError 根据响应构造错误，404 转换为 ErrNotExist
+func webdavError(method, target string, resp *http.Response) error {
+	err := fmt.Errorf("WebDAV %s %s 失败: %s", method, target, resp.Status)
+	if resp.StatusCode == http.StatusNotFound {
+		return &notExistError{err: err}
+	}
+	return err
+}
+
+// webdavAuth 处理 Basic 和 Digest 认证。
+// 收到 401 质询后记录认证方式和 Digest 参数，后续请求直接携带认证信息。
+type webdavAuth struct {
+	username string
+	password string
+
+	mu     sync.Mutex
+	scheme string            // basic、digest，为空时尚未收到质询
+	params map[string]string // Digest 质询参数（realm、nonce、qop、algorithm、opaque）
+	nc     uint32            // Digest 请求计数
+}
+
+// authorize 为请求添加认证头
+func (a *webdavAuth) authorize(req *http.Request) {
+	if a.username == "" && a.password == "" {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch a.scheme {
+	case "basic":
+		req.SetBasicAuth(a.username, a.password)
+	case "digest":
+		if a.params != nil {
+			a.nc++
+			req.Header.Set("Authorization", a.digest(req.Method, req.URL.RequestURI()))
+		}
+	}
+}
+
+// challenge 解析 WWW-Authenticate 质询（Digest 优先），返回是否应当携带认证信息重试
+func (a *webdavAuth) challenge(headers []string) bool {
+	if a.username == "" && a.password == "" {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var basic bool
+	for _, header := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+		switch strings.ToLower(scheme) {
+		case "digest":
+			if a.scheme != "" && a.scheme != "digest" {
+				continue
+			}
+			a.scheme = "digest"
+			a.params = parseAuthParams(rest)
+			a.nc = 0
+			return true
+		case "basic":
+			basic = true
+		}
+	}
+	if basic && (a.scheme == "" || a.scheme == "basic") {
+		a.scheme = "basic"
+		return true
+	}
+	return false
+}
+
+// digest 按 RFC 7616 计算 Digest 认证头（支持 MD5、SHA-256 和 qop=auth）
+func (a *webdavAuth) digest(method, uri string) string {
+	algorithm := a.params["algorithm"]
+	var newHash func() hash.Hash
+	switch strings.ToUpper(algorithm) {
+	case "SHA-256":
+		newHash = sha256.New
+	default:
+		newHash = md5.New
+	}
+	h := func(s string) string {
+		sum := newHash()
+		sum.Write([]byte(s))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	realm, nonce := a.params["realm"], a.params["nonce"]
+	ha1 := h(a.username + ":" + realm + ":" + a.password)
+	ha2 := h(method + ":" + uri)
+
+	fields := []string{
+		fmt.Sprintf(`username=%q`, a.username),
+		fmt.Sprintf(`realm=%q`, realm),
+		fmt.Sprintf(`nonce=%q`, nonce),
+		fmt.Sprintf(`uri=%q`, uri),
+	}
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+
+	qop := ""
+	for _, q := range strings.Split(a.params["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			qop = "auth"
+		}
+	}
+	if qop != "" {
+		cnonceBytes := make([]byte, 8)
+		rand.Read(cnonceBytes)
+		cnonce := hex.EncodeToString(cnonceBytes)
+		nc := fmt.Sprintf("%08x", a.nc)
+		fields = append(fields,
+			fmt.Sprintf(`response=%q`, h(ha1+":"+nonce+":"+nc+":"+cnonce+":"+qop+":"+ha2)),
+			"qop="+qop, "nc="+nc, fmt.Sprintf(`cnonce=%q`, cnonce))
+	} else {
+		fields = append(fields, fmt.Sprintf(`response=%q`, h(ha1+":"+nonce+":"+ha2)))
+	}
+	if opaque, ok := a.params["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf(`opaque=%q`, opaque))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+// parseAuthParams 解析认证头中逗号分隔的 key=value 参数（值可以带引号）
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimSpace(rest)
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			// 带引号的值，支持反斜杠转义
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, rest = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+			rest = "," + rest
+		}
+		params[key] = value
+		_, s, _ = strings.Cut(rest, ",")
+	}
+	return params
+}
@@ -0,0 +1,206 @@
+package storage_test
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/v-mars/storage"
+	"golang.org/x/net/webdav"
+)
+
+// webdavTestServer 基于 x/net/webdav 的内存WebDAV服务器，挂载在 /dav/ 下
+type webdavTestServer struct {
+	*httptest.Server
+	unauthorized atomic.Int32 // 返回 401 的次数
+
+	mu      sync.Mutex
+	methods map[string]int // 按方法统计的请求次数
+}
+
+// newWebDAVTestServer 启动要求认证的WebDAV服务器，auth 为 basic 或 digest，用户名密码为 tester/secret
+func newWebDAVTestServer(t *testing.T, auth string) *webdavTestServer {
+	t.Helper()
+	server := &webdavTestServer{methods: map[string]int{}}
+	handler := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok := false
+		switch auth {
+		case "basic":
+			user, password, _ := r.BasicAuth()
+			ok = user == "tester" && password == "secret"
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="dav"`)
+			}
+		case "digest":
+			ok = checkDigest(r, "tester", "secret")
+			if !ok {
+				w.Header().Add("WWW-Authenticate", `Basic realm="dav"`)
+				w.Header().Add("WWW-Authenticate", `Digest realm="dav", nonce="abc123", qop="auth", opaque="xyz"`)
+			}
+		}
+		if !ok {
+			server.unauthorized.Add(1)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		server.mu.Lock()
+		server.methods[r.Method]++
+		server.mu.Unlock()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// count 返回某个方法的请求次数
+func (s *webdavTestServer) count(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.methods[method]
+}
+
+// config 返回访问该服务器的配置
+func (s *webdavTestServer) config(baseDir string) storage.WebDAVStorageConfig {
+	return storage.WebDAVStorageConfig{
+		Endpoint: s.URL + "/dav/",
+		Username: "tester",
+		Password: "secret",
+		BaseDir:  baseDir,
+	}
+}
+
+// checkDigest 校验 MD5、qop=auth 的 Digest 认证头
+func checkDigest(r *http.Request, user, password string) bool {
+	header, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Digest ")
+	if !ok {
+		return false
+	}
+	params := map[string]string{}
+	for _, field := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+		params[key] = strings.Trim(value, `"`)
+	}
+	h := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := h(user + ":dav:" + password)
+	ha2 := h(r.Method + ":" + params["uri"])
+	expected := h(ha1 + ":abc123:" + params["nc"] + ":" + params["cnonce"] + ":auth:" + ha2)
+	return params["username"] == user && params["uri"] == r.URL.RequestURI() &&
+		params["opaque"] == "xyz" && params["response"] == expected
+}
+
+func TestWebDAVStorage_Backend(t *testing.T) {
+	server := newWebDAVTestServer(t, "basic")
+	s := storage.NewWebDAVStorage(server.config("base"))
+	if s == nil {
+		t.Fatal("NewWebDAVStorage returned nil")
+	}
+	ctx := context.Background()
+
+	// 认证方式协商后不再收到 401
+	unauthorized := server.unauthorized.Load()
+	if err := s.Upload(ctx, "a/b/file.txt", strings.NewReader("hello")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if err := s.Upload(ctx, "a/b/other.txt", strings.NewReader("world")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if n := server.unauthorized.Load(); n != unauthorized {
+		t.Fatalf("Expected no further 401 responses, got %d", n-unauthorized)
+	}
+
+	// 已确认存在的目录不重复发送 MKCOL
+	mkcol := server.count("MKCOL")
+	if err := s.Upload(ctx, "a/b/third.txt", strings.NewReader("!")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if n := server.count("MKCOL"); n != mkcol {
+		t.Fatalf("Expected cached directories, got %d extra MKCOL", n-mkcol)
+	}
+
+	// 删除目录后重新创建
+	if err := s.DeleteDir(ctx, "a"); err != nil {
+		t.Fatalf("DeleteDir failed: %v", err)
+	}
+	if err := s.Upload(ctx, "a/b/file.txt", strings.NewReader("again")); err != nil {
+		t.Fatalf("Upload after DeleteDir failed: %v", err)
+	}
+
+	// 显式指定的类型以自定义属性保存，临时文件不出现在列表中
+	if err := s.Upload(ctx, "a/b/typed", strings.NewReader("{}"), storage.WithContentType("application/json")); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	files, err := s.ListDir(ctx, "a/b")
+	if err != nil {
+		t.Fatalf("ListDir failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 entries, got %+v", files)
+	}
+	for _, file := range files {
+		if file.Name == "typed" && file.MIMEType != "application/json" {
+			t.Fatalf("Recorded content type not used: %q", file.MIMEType)
+		}
+	}
+
+	// 服务器以 Range 请求返回部分内容
+	if got := readString(t)(s.DownloadRange(ctx, "a/b/file.txt", 1, 3)); got != "gai" {
+		t.Fatalf("Unexpected range content: %q", got)
+	}
+
+	// 移动目录后在原路径重新上传
+	if err := s.Rename(ctx, "a", "moved"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := s.Upload(ctx, "a/b/file.txt", strings.NewReader("new")); err != nil {
+		t.Fatalf("Upload after Rename failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "moved/b/file.txt")); got != "again" {
+		t.Fatalf("Unexpected moved content: %q", got)
+	}
+}
+
+func TestWebDAVStorage_DigestAuth(t *testing.T) {
+	server := newWebDAVTestServer(t, "digest")
+	s := storage.NewWebDAVStorage(server.config("digest"))
+	if s == nil {
+		t.Fatal("NewWebDAVStorage returned nil")
+	}
+	ctx := context.Background()
+
+	// 流式上传的请求体不可重放，依赖创建时协商好的认证方式
+	if err := s.Upload(ctx, "file.txt", io.MultiReader(strings.NewReader("digest "), strings.NewReader("auth"))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if got := readString(t)(s.Download(ctx, "file.txt")); got != "digest auth" {
+		t.Fatalf("Unexpected content: %q", got)
+	}
+
+	// 密码错误时创建失败
+	config := server.config("digest")
+	config.Password = "wrong"
+	if s := storage.NewWebDAVStorage(config); s != nil {
+		t.Fatal("Expected NewWebDAVStorage to fail with wrong password")
+	}
+
+	// 指定 basic 时不接受 Digest 质询
+	config = server.config("digest")
+	config.AuthType = "basic"
+	if s := storage.NewWebDAVStorage(config); s != nil {
+		t.Fatal("Expected NewWebDAVStorage to fail with forced basic auth")
+	}
+}
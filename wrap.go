@@ -0,0 +1,24 @@
+package storage
+
+// Wrapper 由包装其他存储的装饰器实现（如大小限制、配额），用于访问被包装的存储
+type Wrapper interface {
+	Unwrap() Storage
+}
+
+// As 沿包装链查找实现了 T 的存储，类似 errors.As。
+// 装饰器不会转发可选能力接口（如 ExpirationReader、LifecycleManager），
+// 判断存储是否支持某项能力时应使用 As 而不是直接类型断言。
+func As[T any](s Storage) (T, bool) {
+	for s != nil {
+		if t, ok := s.(T); ok {
+			return t, true
+		}
+		w, ok := s.(Wrapper)
+		if !ok {
+			break
+		}
+		s = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}